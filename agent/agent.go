@@ -20,16 +20,22 @@ import (
 type Agent struct {
 	logger        hclog.Logger
 	config        *config.Agent
+	configFunc    ConfigFunc
 	nomadClient   *api.Client
 	pluginManager *manager.PluginManager
 	policyManager *policy.Manager
 	healthServer  *healthServer
 }
 
-func NewAgent(c *config.Agent, logger hclog.Logger) *Agent {
+// ConfigFunc is used by the agent to load an updated configuration when it
+// is reloaded.
+type ConfigFunc func() (*config.Agent, error)
+
+func NewAgent(c *config.Agent, configFunc ConfigFunc, logger hclog.Logger) *Agent {
 	return &Agent{
-		logger: logger,
-		config: c,
+		logger:     logger,
+		config:     c,
+		configFunc: configFunc,
 	}
 }
 
@@ -50,8 +56,10 @@ func (a *Agent) Run() error {
 		return fmt.Errorf("failed to setup plugins: %v", err)
 	}
 
+	policyEvalCh := a.setupPolicyManager()
+
 	// Setup and start the HTTP health server.
-	healthServer, err := newHealthServer(a.config.HTTP, a.logger)
+	healthServer, err := newHealthServer(a.config.HTTP, a.pluginManager, a.policyManager, a.logger)
 	if err != nil {
 		return fmt.Errorf("failed to setup HTTP getHealth server: %v", err)
 	}
@@ -59,7 +67,6 @@ func (a *Agent) Run() error {
 	a.healthServer = healthServer
 	go a.healthServer.run()
 
-	policyEvalCh := a.setupPolicyManager()
 	go a.policyManager.Run(ctx, policyEvalCh)
 
 	// Launch the eval handler.
@@ -87,6 +94,8 @@ func (a *Agent) setupPolicyManager() chan *policy.Evaluation {
 	sourceConfig := &policy.ConfigDefaults{
 		DefaultCooldown:           a.config.Policy.DefaultCooldown,
 		DefaultEvaluationInterval: a.config.Policy.DefaultEvaluationInterval,
+		DefaultEvaluationTimeout:  a.config.Policy.DefaultEvaluationTimeout,
+		DefaultScaleTimeout:       a.config.Policy.DefaultScaleTimeout,
 	}
 
 	// Setup our initial default policy source which is Nomad.
@@ -176,10 +185,36 @@ func (a *Agent) generateNomadClient() error {
 
 // reload triggers the reload of sub-routines based on the operator sending a
 // SIGHUP signal to the agent.
-func (a Agent) reload() {
+func (a *Agent) reload() {
+	a.reloadPlugins()
 	a.policyManager.ReloadSources()
 }
 
+// reloadPlugins reads the agent configuration and updates the plugins to
+// match the configured plugin blocks. Other configuration blocks are not
+// reloaded.
+func (a *Agent) reloadPlugins() {
+	if a.configFunc == nil {
+		return
+	}
+
+	cfg, err := a.configFunc()
+	if err != nil {
+		a.logger.Error("failed to reload agent configuration", "error", err)
+		return
+	}
+
+	a.config.APMs = cfg.APMs
+	a.config.Strategies = cfg.Strategies
+	a.config.Targets = cfg.Targets
+
+	if err := a.pluginManager.Reload(a.setupPluginsConfig()); err != nil {
+		a.logger.Error("failed to reload plugins", "error", err)
+		return
+	}
+	a.logger.Info("successfully reloaded plugins")
+}
+
 // handleSignals blocks until the agent receives an exit signal.
 func (a *Agent) handleSignals() {
 
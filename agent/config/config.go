@@ -127,6 +127,18 @@ type Policy struct {
 	// `evaluation_interval` is not defined in a policy.
 	DefaultEvaluationInterval    time.Duration
 	DefaultEvaluationIntervalHCL string `hcl:"default_evaluation_interval,optional" json:"-"`
+
+	// DefaultEvaluationTimeout is the maximum time duration a policy
+	// evaluation, including target status, APM queries and strategy runs, is
+	// allowed to take when `evaluation_timeout` is not defined in a policy.
+	DefaultEvaluationTimeout    time.Duration
+	DefaultEvaluationTimeoutHCL string `hcl:"default_evaluation_timeout,optional" json:"-"`
+
+	// DefaultScaleTimeout is the maximum time duration a target scaling
+	// action is allowed to take when `scale_timeout` is not defined in a
+	// policy.
+	DefaultScaleTimeout    time.Duration
+	DefaultScaleTimeoutHCL string `hcl:"default_scale_timeout,optional" json:"-"`
 }
 
 const (
@@ -159,6 +171,15 @@ const (
 	// defaultPolicyCooldown is the default time duration applied to policies
 	// which do not explicitly configure a cooldown.
 	defaultPolicyCooldown = 5 * time.Minute
+
+	// defaultPolicyEvaluationTimeout is the default time duration applied to
+	// policies which do not explicitly configure an evaluation timeout.
+	defaultPolicyEvaluationTimeout = 5 * time.Minute
+
+	// defaultPolicyScaleTimeout is the default time duration applied to
+	// policies which do not explicitly configure a scale timeout. This is
+	// purposely long, as scaling actions may include draining nodes.
+	defaultPolicyScaleTimeout = 1 * time.Hour
 )
 
 // Default is used to generate a new default agent configuration.
@@ -185,6 +206,8 @@ func Default() (*Agent, error) {
 		Policy: &Policy{
 			DefaultCooldown:           defaultPolicyCooldown,
 			DefaultEvaluationInterval: defaultEvaluationInterval,
+			DefaultEvaluationTimeout:  defaultPolicyEvaluationTimeout,
+			DefaultScaleTimeout:       defaultPolicyScaleTimeout,
 		},
 		APMs:       []*Plugin{{Name: plugins.InternalAPMNomad, Driver: plugins.InternalAPMNomad}},
 		Strategies: []*Plugin{{Name: plugins.InternalStrategyTargetValue, Driver: plugins.InternalStrategyTargetValue}},
@@ -342,6 +365,12 @@ func (p *Policy) merge(b *Policy) *Policy {
 	if b.DefaultEvaluationInterval != 0 {
 		result.DefaultEvaluationInterval = b.DefaultEvaluationInterval
 	}
+	if b.DefaultEvaluationTimeout != 0 {
+		result.DefaultEvaluationTimeout = b.DefaultEvaluationTimeout
+	}
+	if b.DefaultScaleTimeout != 0 {
+		result.DefaultScaleTimeout = b.DefaultScaleTimeout
+	}
 	return &result
 }
 
@@ -409,6 +438,22 @@ func parseFile(file string, cfg *Agent) error {
 		cfg.Policy.DefaultEvaluationInterval = d
 	}
 
+	if cfg.Policy.DefaultEvaluationTimeoutHCL != "" {
+		d, err := time.ParseDuration(cfg.Policy.DefaultEvaluationTimeoutHCL)
+		if err != nil {
+			return err
+		}
+		cfg.Policy.DefaultEvaluationTimeout = d
+	}
+
+	if cfg.Policy.DefaultScaleTimeoutHCL != "" {
+		d, err := time.ParseDuration(cfg.Policy.DefaultScaleTimeoutHCL)
+		if err != nil {
+			return err
+		}
+		cfg.Policy.DefaultScaleTimeout = d
+	}
+
 	return nil
 }
 
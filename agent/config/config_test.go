@@ -23,8 +23,10 @@ func Test_Default(t *testing.T) {
 	assert.Equal(t, "127.0.0.1", def.HTTP.BindAddress)
 	assert.Equal(t, 8080, def.HTTP.BindPort)
 	assert.Equal(t, def.Policy.DefaultCooldown, 5*time.Minute)
+	assert.Equal(t, 5*time.Minute, def.Policy.DefaultEvaluationTimeout)
+	assert.Equal(t, 1*time.Hour, def.Policy.DefaultScaleTimeout)
 	assert.Len(t, def.APMs, 1)
-	assert.Len(t, def.Targets, 1)
+	assert.Len(t, def.Targets, 2)
 	assert.Len(t, def.Strategies, 1)
 }
 
@@ -73,6 +75,8 @@ func TestAgent_Merge(t *testing.T) {
 			Dir:                       "/etc/scaling/policies",
 			DefaultCooldown:           20 * time.Minute,
 			DefaultEvaluationInterval: 10 * time.Second,
+			DefaultEvaluationTimeout:  2 * time.Minute,
+			DefaultScaleTimeout:       30 * time.Minute,
 		},
 		APMs: []*Plugin{
 			{
@@ -119,6 +123,8 @@ func TestAgent_Merge(t *testing.T) {
 			Dir:                       "/etc/scaling/policies",
 			DefaultCooldown:           20 * time.Minute,
 			DefaultEvaluationInterval: 10 * time.Second,
+			DefaultEvaluationTimeout:  2 * time.Minute,
+			DefaultScaleTimeout:       30 * time.Minute,
 		},
 		APMs: []*Plugin{
 			{
@@ -141,6 +147,10 @@ func TestAgent_Merge(t *testing.T) {
 				Name:   "nomad-target",
 				Driver: "nomad-target",
 			},
+			{
+				Name:   "stateful",
+				Driver: "stateful",
+			},
 		},
 		Strategies: []*Plugin{
 			{
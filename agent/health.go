@@ -2,14 +2,18 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"sync/atomic"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+	"github.com/hashicorp/nomad-autoscaler/policy"
 )
 
 const (
@@ -17,6 +21,10 @@ const (
 	// to register the health server endpoint.
 	healthRoutePattern = "/v1/health"
 
+	// healthQueryParamStrict is the query parameter which opts in to the
+	// health endpoint responding with a 503 when the agent is degraded.
+	healthQueryParamStrict = "strict"
+
 	// healthAliveness is used to define the health of the Autoscaler agent. It
 	// currently can only be in two states; ready or unavailable and depends
 	// entirely on whether the server is serving or not.
@@ -24,26 +32,62 @@ const (
 	healthAlivenessUnavailable
 )
 
+// pluginHealthProvider is the interface used by the health server to obtain
+// the most recent health check results of the running plugins.
+type pluginHealthProvider interface {
+	Health() []manager.PluginHealth
+}
+
+// policyHealthProvider is the interface used by the health server to obtain
+// the policies whose most recent evaluation was skipped.
+type policyHealthProvider interface {
+	SkippedPolicies() []policy.SkippedPolicy
+}
+
+// healthResponse is the body returned by the health endpoint.
+type healthResponse struct {
+	Status          string                 `json:"status"`
+	Plugins         []manager.PluginHealth `json:"plugins"`
+	SkippedPolicies []policy.SkippedPolicy `json:"skipped_policies"`
+}
+
+// healthStatus* are the statuses reported in the health response. An agent
+// which is serving but has unhealthy plugins or skipped policies is degraded,
+// not unhealthy.
+const (
+	healthStatusHealthy   = "healthy"
+	healthStatusDegraded  = "degraded"
+	healthStatusUnhealthy = "unhealthy"
+)
+
 type healthServer struct {
 
 	// aliveness is used to describe the health response and should be set
 	// atomically using healthAliveness* const declarations.
 	aliveness int32
 
+	// plugins provides the plugin health check results included in the
+	// health response. It may be nil.
+	plugins pluginHealthProvider
+
+	// policies provides the skipped policies included in the health
+	// response. It may be nil.
+	policies policyHealthProvider
+
 	log hclog.Logger
 	srv *http.Server
 	ln  net.Listener
 }
 
 // newHealthServer creates a new basic HTTP health server with a single route
-// for responding to requests.
-//
-// TODO(jrasell) enhance this endpoint to provide more than just aliveness
-//  checks.
-func newHealthServer(cfg *config.HTTP, log hclog.Logger) (*healthServer, error) {
+// for responding to requests. The health of the plugins and the skipped
+// policies returned by the providers are detailed in the response body.
+func newHealthServer(cfg *config.HTTP, plugins pluginHealthProvider, policies policyHealthProvider, log hclog.Logger) (*healthServer, error) {
 
 	srv := &healthServer{
-		log: log.Named("health_server"),
+		plugins:  plugins,
+		policies: policies,
+		log:      log.Named("health_server"),
 	}
 
 	// Setup our router and single health check route.
@@ -112,8 +156,15 @@ func (hs *healthServer) stop() {
 }
 
 // getHealth is the HTTP handler used to respond when a request is made to the
-// health endpoint. The response is based on the aliveness parameter within the
-// healthServer struct.
+// health endpoint. The body details the health of each plugin and the policies
+// skipped because of them.
+//
+// By default the response code is based solely on the aliveness parameter
+// within the healthServer struct, so that the endpoint can be used as a
+// liveness check without unreachable plugin dependencies causing the agent to
+// be restarted. Requests with the strict query parameter set to true also
+// receive a 503 when the agent is degraded, so that the endpoint can be used
+// as a readiness check which fails while policies cannot be evaluated.
 func (hs *healthServer) getHealth() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
@@ -123,10 +174,51 @@ func (hs *healthServer) getHealth() http.Handler {
 			return
 		}
 
-		if atomic.LoadInt32(&hs.aliveness) == healthAlivenessReady {
-			w.WriteHeader(http.StatusOK)
-			return
+		var strict bool
+		if v := r.URL.Query().Get(healthQueryParamStrict); v != "" {
+			var err error
+			if strict, err = strconv.ParseBool(v); err != nil {
+				http.Error(w, fmt.Sprintf("failed to parse %q query parameter as bool: %v",
+					healthQueryParamStrict, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		resp := healthResponse{
+			Status:          healthStatusHealthy,
+			Plugins:         []manager.PluginHealth{},
+			SkippedPolicies: []policy.SkippedPolicy{},
+		}
+		if hs.plugins != nil {
+			resp.Plugins = hs.plugins.Health()
+		}
+		if hs.policies != nil {
+			resp.SkippedPolicies = hs.policies.SkippedPolicies()
+		}
+
+		if len(resp.SkippedPolicies) > 0 {
+			resp.Status = healthStatusDegraded
+		}
+		for _, p := range resp.Plugins {
+			if !p.Healthy {
+				resp.Status = healthStatusDegraded
+				break
+			}
+		}
+
+		code := http.StatusOK
+		if strict && resp.Status == healthStatusDegraded {
+			code = http.StatusServiceUnavailable
+		}
+		if atomic.LoadInt32(&hs.aliveness) != healthAlivenessReady {
+			code = http.StatusServiceUnavailable
+			resp.Status = healthStatusUnhealthy
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			hs.log.Error("failed to encode health response", "error", err)
 		}
-		w.WriteHeader(http.StatusServiceUnavailable)
 	})
 }
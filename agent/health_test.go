@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"sync/atomic"
@@ -8,42 +9,167 @@ import (
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins/manager"
+	"github.com/hashicorp/nomad-autoscaler/policy"
 	"github.com/stretchr/testify/assert"
 )
 
+type testPluginHealthProvider []manager.PluginHealth
+
+func (t testPluginHealthProvider) Health() []manager.PluginHealth { return t }
+
+type testPolicyHealthProvider []policy.SkippedPolicy
+
+func (t testPolicyHealthProvider) SkippedPolicies() []policy.SkippedPolicy { return t }
+
 func Test_healthServer_health(t *testing.T) {
 	testCases := []struct {
-		inputReq          *http.Request
-		inputWriter       *httptest.ResponseRecorder
-		inputSetAliveness int32
-		expectedRespCode  int
+		name               string
+		inputReq           *http.Request
+		inputWriter        *httptest.ResponseRecorder
+		inputSetAliveness  int32
+		inputPlugins       testPluginHealthProvider
+		inputPolicies      testPolicyHealthProvider
+		expectedRespCode   int
+		expectedRespStatus string
 	}{
 		{
+			name:               "ready",
+			inputReq:           httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth", nil),
+			inputWriter:        httptest.NewRecorder(),
+			inputSetAliveness:  healthAlivenessReady,
+			expectedRespCode:   200,
+			expectedRespStatus: healthStatusHealthy,
+		},
+		{
+			name:               "unavailable",
+			inputReq:           httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth", nil),
+			inputWriter:        httptest.NewRecorder(),
+			inputSetAliveness:  healthAlivenessUnavailable,
+			expectedRespCode:   503,
+			expectedRespStatus: healthStatusUnhealthy,
+		},
+		{
+			name:              "method not allowed",
+			inputReq:          httptest.NewRequest("PUT", "http://localhost:8080/v1/getHealth", nil),
+			inputWriter:       httptest.NewRecorder(),
+			inputSetAliveness: healthAlivenessReady,
+			expectedRespCode:  405,
+		},
+		{
+			name:              "healthy plugins",
 			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth", nil),
 			inputWriter:       httptest.NewRecorder(),
 			inputSetAliveness: healthAlivenessReady,
-			expectedRespCode:  200,
+			inputPlugins: testPluginHealthProvider{
+				{Name: "nomad-apm", PluginType: "apm", Healthy: true},
+				{Name: "nomad-target", PluginType: "target", Healthy: true},
+			},
+			expectedRespCode:   200,
+			expectedRespStatus: healthStatusHealthy,
 		},
 		{
+			name:              "unhealthy plugin",
 			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth", nil),
 			inputWriter:       httptest.NewRecorder(),
-			inputSetAliveness: healthAlivenessUnavailable,
-			expectedRespCode:  503,
+			inputSetAliveness: healthAlivenessReady,
+			inputPlugins: testPluginHealthProvider{
+				{Name: "nomad-apm", PluginType: "apm", Healthy: true},
+				{Name: "nomad-target", PluginType: "target", Healthy: false, Error: "connection refused"},
+			},
+			inputPolicies: testPolicyHealthProvider{
+				{ID: "policy1", Target: "nomad-target", Reason: "plugin unhealthy"},
+			},
+			expectedRespCode:   200,
+			expectedRespStatus: healthStatusDegraded,
 		},
 		{
-			inputReq:          httptest.NewRequest("PUT", "http://localhost:8080/v1/getHealth", nil),
+			name:              "unhealthy plugin strict",
+			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth?strict=true", nil),
 			inputWriter:       httptest.NewRecorder(),
 			inputSetAliveness: healthAlivenessReady,
-			expectedRespCode:  405,
+			inputPlugins: testPluginHealthProvider{
+				{Name: "nomad-apm", PluginType: "apm", Healthy: true},
+				{Name: "nomad-target", PluginType: "target", Healthy: false, Error: "connection refused"},
+			},
+			inputPolicies: testPolicyHealthProvider{
+				{ID: "policy1", Target: "nomad-target", Reason: "plugin unhealthy"},
+			},
+			expectedRespCode:   503,
+			expectedRespStatus: healthStatusDegraded,
+		},
+		{
+			name:              "skipped policy strict",
+			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth?strict=true", nil),
+			inputWriter:       httptest.NewRecorder(),
+			inputSetAliveness: healthAlivenessReady,
+			inputPlugins: testPluginHealthProvider{
+				{Name: "nomad-target", PluginType: "target", Healthy: true},
+			},
+			inputPolicies: testPolicyHealthProvider{
+				{ID: "policy1", Target: "nomad-target", Reason: "plugin unhealthy"},
+			},
+			expectedRespCode:   503,
+			expectedRespStatus: healthStatusDegraded,
+		},
+		{
+			name:              "healthy plugins strict",
+			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth?strict=true", nil),
+			inputWriter:       httptest.NewRecorder(),
+			inputSetAliveness: healthAlivenessReady,
+			inputPlugins: testPluginHealthProvider{
+				{Name: "nomad-target", PluginType: "target", Healthy: true},
+			},
+			expectedRespCode:   200,
+			expectedRespStatus: healthStatusHealthy,
+		},
+		{
+			name:              "invalid strict",
+			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth?strict=maybe", nil),
+			inputWriter:       httptest.NewRecorder(),
+			inputSetAliveness: healthAlivenessReady,
+			expectedRespCode:  400,
+		},
+		{
+			name:              "unavailable with unhealthy plugin",
+			inputReq:          httptest.NewRequest("GET", "http://localhost:8080/v1/getHealth", nil),
+			inputWriter:       httptest.NewRecorder(),
+			inputSetAliveness: healthAlivenessUnavailable,
+			inputPlugins: testPluginHealthProvider{
+				{Name: "nomad-target", PluginType: "target", Healthy: false, Error: "connection refused"},
+			},
+			expectedRespCode:   503,
+			expectedRespStatus: healthStatusUnhealthy,
 		},
 	}
 
-	svr, err := newHealthServer(&config.HTTP{BindAddress: "localhost", BindPort: 8080}, hclog.NewNullLogger())
+	svr, err := newHealthServer(&config.HTTP{BindAddress: "localhost", BindPort: 8080}, nil, nil, hclog.NewNullLogger())
 	assert.Nil(t, err)
+	defer svr.ln.Close()
 
 	for _, tc := range testCases {
-		atomic.StoreInt32(&svr.aliveness, tc.inputSetAliveness)
-		svr.getHealth().ServeHTTP(tc.inputWriter, tc.inputReq)
-		assert.Equal(t, tc.expectedRespCode, tc.inputWriter.Code)
+		t.Run(tc.name, func(t *testing.T) {
+			svr.plugins = nil
+			if tc.inputPlugins != nil {
+				svr.plugins = tc.inputPlugins
+			}
+			svr.policies = nil
+			if tc.inputPolicies != nil {
+				svr.policies = tc.inputPolicies
+			}
+			atomic.StoreInt32(&svr.aliveness, tc.inputSetAliveness)
+			svr.getHealth().ServeHTTP(tc.inputWriter, tc.inputReq)
+			assert.Equal(t, tc.expectedRespCode, tc.inputWriter.Code)
+
+			if tc.expectedRespStatus == "" {
+				return
+			}
+
+			var resp healthResponse
+			assert.Nil(t, json.NewDecoder(tc.inputWriter.Body).Decode(&resp))
+			assert.Equal(t, tc.expectedRespStatus, resp.Status)
+			assert.Len(t, resp.Plugins, len(tc.inputPlugins))
+			assert.Len(t, resp.SkippedPolicies, len(tc.inputPolicies))
+		})
 	}
 }
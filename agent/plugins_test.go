@@ -151,3 +151,12 @@ func TestAgent_setupPluginConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestAgent_setupPlugins_default(t *testing.T) {
+	cfg, err := config.Default()
+	assert.Nil(t, err)
+
+	a := &Agent{logger: hclog.NewNullLogger(), config: cfg}
+	assert.Nil(t, a.setupPlugins())
+	a.pluginManager.KillPlugins()
+}
@@ -14,6 +14,12 @@ import (
 
 type AgentCommand struct {
 	args []string
+
+	// configPaths and cmdConfig are the config file paths and the config
+	// built from CLI flags. They are stored so the agent config can be
+	// reloaded.
+	configPaths []string
+	cmdConfig   *config.Agent
 }
 
 // Help should return long-form help text that includes the command-line
@@ -114,6 +120,14 @@ Policy Options:
   -policy-default-evaluation-interval=<dur>
     The default evaluation interval that will be applied to all scaling policies
     which do not specify an evaluation interval.
+
+  -policy-default-evaluation-timeout=<dur>
+    The default evaluation timeout that will be applied to all scaling policies
+    which do not specify an evaluation timeout.
+
+  -policy-default-scale-timeout=<dur>
+    The default scale timeout that will be applied to all scaling policies
+    which do not specify a scale timeout.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -148,7 +162,7 @@ func (c *AgentCommand) Run(args []string) int {
 	})
 
 	// create and run agent
-	a := agent.NewAgent(parsedConfig, logger)
+	a := agent.NewAgent(parsedConfig, c.loadConfig, logger)
 	if err := a.Run(); err != nil {
 		logger.Error("failed to start agent", "error", err)
 		return 1
@@ -202,23 +216,44 @@ func (c *AgentCommand) readConfig() *config.Agent {
 		cmdConfig.Policy.DefaultEvaluationInterval = d
 		return nil
 	}), "policy-default-evaluation-interval", "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Policy.DefaultEvaluationTimeout = d
+		return nil
+	}), "policy-default-evaluation-timeout", "")
+	flags.Var((flaghelper.FuncDurationVar)(func(d time.Duration) error {
+		cmdConfig.Policy.DefaultScaleTimeout = d
+		return nil
+	}), "policy-default-scale-timeout", "")
 
 	if err := flags.Parse(c.args); err != nil {
 		return nil
 	}
 
+	c.configPaths = configPath
+	c.cmdConfig = cmdConfig
+
+	cfg, err := c.loadConfig()
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return nil
+	}
+	return cfg
+}
+
+// loadConfig builds the agent config from the defaults, config files and CLI
+// flags. It is called on startup and whenever the agent reloads its config.
+func (c *AgentCommand) loadConfig() (*config.Agent, error) {
+
 	// Grab a default config as the base.
 	cfg, err := config.Default()
 	if err != nil {
-		fmt.Printf("Error generating default agent config: %v\n", err)
-		return nil
+		return nil, fmt.Errorf("Error generating default agent config: %v", err)
 	}
 
-	for _, path := range configPath {
+	for _, path := range c.configPaths {
 		current, err := config.Load(path)
 		if err != nil {
-			fmt.Printf("Error loading configuration from %s: %s\n", path, err)
-			return nil
+			return nil, fmt.Errorf("Error loading configuration from %s: %s", path, err)
 		}
 
 		if cfg == nil {
@@ -229,7 +264,7 @@ func (c *AgentCommand) readConfig() *config.Agent {
 	}
 
 	// Merge the read file based configuration with the passed CLI args.
-	cfg = cfg.Merge(cmdConfig)
+	cfg = cfg.Merge(c.cmdConfig)
 
-	return cfg
+	return cfg, nil
 }
@@ -3,10 +3,17 @@ module github.com/hashicorp/nomad-autoscaler
 go 1.13
 
 require (
+	github.com/Azure/azure-sdk-for-go v44.2.0+incompatible
+	github.com/Azure/go-autorest/autorest v0.11.2
+	github.com/Azure/go-autorest/autorest/azure/auth v0.5.0
+	github.com/Azure/go-autorest/autorest/date v0.3.0
+	github.com/Azure/go-autorest/autorest/to v0.4.1 // indirect
+	github.com/Azure/go-autorest/autorest/validation v0.3.1 // indirect
 	github.com/agext/levenshtein v1.2.3 // indirect
 	github.com/aws/aws-sdk-go-v2 v0.23.0
 	github.com/docker/go-units v0.4.0 // indirect
 	github.com/fatih/color v1.9.0 // indirect
+	github.com/golang/protobuf v1.3.5
 	github.com/gomodule/redigo v1.8.2
 	github.com/google/go-cmp v0.4.0
 	github.com/gorilla/websocket v1.4.2
@@ -26,5 +33,6 @@ require (
 	github.com/prometheus/common v0.9.1
 	github.com/stretchr/testify v1.5.1
 	github.com/zclconf/go-cty v1.3.1 // indirect
-	golang.org/x/sys v0.0.0-20200124204421-9fbb57f87de9 // indirect
+	google.golang.org/api v0.29.0
+	google.golang.org/grpc v1.28.0
 )
@@ -0,0 +1,124 @@
+// Package awsclient provides interfaces over the AWS AutoScaling and EC2
+// operations used by the AWS target plugins, along with an in-memory fake
+// which allows the plugins to be tested without AWS.
+package awsclient
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// AutoScaling is the subset of the AWS AutoScaling API used by the target
+// plugins.
+type AutoScaling interface {
+	CompleteLifecycleAction(context.Context, *autoscaling.CompleteLifecycleActionInput) (*autoscaling.CompleteLifecycleActionOutput, error)
+	CreateOrUpdateTags(context.Context, *autoscaling.CreateOrUpdateTagsInput) (*autoscaling.CreateOrUpdateTagsOutput, error)
+	DescribeAccountLimits(context.Context, *autoscaling.DescribeAccountLimitsInput) (*autoscaling.DescribeAccountLimitsOutput, error)
+	DescribeAutoScalingGroups(context.Context, *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+	DescribeScalingActivities(context.Context, *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error)
+	DetachInstances(context.Context, *autoscaling.DetachInstancesInput) (*autoscaling.DetachInstancesOutput, error)
+	UpdateAutoScalingGroup(context.Context, *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error)
+}
+
+// EC2 is the subset of the AWS EC2 API used by the target plugins.
+type EC2 interface {
+	DescribeInstanceStatus(context.Context, *ec2.DescribeInstanceStatusInput) (*ec2.DescribeInstanceStatusOutput, error)
+	TerminateInstances(context.Context, *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error)
+}
+
+// NewAutoScaling returns the AutoScaling implementation which calls AWS.
+func NewAutoScaling(cfg aws.Config) AutoScaling {
+	return &autoScalingClient{client: autoscaling.New(cfg)}
+}
+
+// NewEC2 returns the EC2 implementation which calls AWS.
+func NewEC2(cfg aws.Config) EC2 {
+	return &ec2Client{client: ec2.New(cfg)}
+}
+
+// autoScalingClient adapts the SDK client, which builds requests that are
+// then sent, to the AutoScaling interface.
+type autoScalingClient struct {
+	client *autoscaling.Client
+}
+
+func (a *autoScalingClient) CompleteLifecycleAction(ctx context.Context, input *autoscaling.CompleteLifecycleActionInput) (*autoscaling.CompleteLifecycleActionOutput, error) {
+	resp, err := a.client.CompleteLifecycleActionRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.CompleteLifecycleActionOutput, nil
+}
+
+func (a *autoScalingClient) CreateOrUpdateTags(ctx context.Context, input *autoscaling.CreateOrUpdateTagsInput) (*autoscaling.CreateOrUpdateTagsOutput, error) {
+	resp, err := a.client.CreateOrUpdateTagsRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.CreateOrUpdateTagsOutput, nil
+}
+
+func (a *autoScalingClient) DescribeAccountLimits(ctx context.Context, input *autoscaling.DescribeAccountLimitsInput) (*autoscaling.DescribeAccountLimitsOutput, error) {
+	resp, err := a.client.DescribeAccountLimitsRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.DescribeAccountLimitsOutput, nil
+}
+
+func (a *autoScalingClient) DescribeAutoScalingGroups(ctx context.Context, input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	resp, err := a.client.DescribeAutoScalingGroupsRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.DescribeAutoScalingGroupsOutput, nil
+}
+
+func (a *autoScalingClient) DescribeScalingActivities(ctx context.Context, input *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	resp, err := a.client.DescribeScalingActivitiesRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.DescribeScalingActivitiesOutput, nil
+}
+
+func (a *autoScalingClient) DetachInstances(ctx context.Context, input *autoscaling.DetachInstancesInput) (*autoscaling.DetachInstancesOutput, error) {
+	resp, err := a.client.DetachInstancesRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.DetachInstancesOutput, nil
+}
+
+func (a *autoScalingClient) UpdateAutoScalingGroup(ctx context.Context, input *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	resp, err := a.client.UpdateAutoScalingGroupRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.UpdateAutoScalingGroupOutput, nil
+}
+
+// ec2Client adapts the SDK client, which builds requests that are then sent,
+// to the EC2 interface.
+type ec2Client struct {
+	client *ec2.Client
+}
+
+func (e *ec2Client) DescribeInstanceStatus(ctx context.Context, input *ec2.DescribeInstanceStatusInput) (*ec2.DescribeInstanceStatusOutput, error) {
+	resp, err := e.client.DescribeInstanceStatusRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.DescribeInstanceStatusOutput, nil
+}
+
+func (e *ec2Client) TerminateInstances(ctx context.Context, input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
+	resp, err := e.client.TerminateInstancesRequest(input).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.TerminateInstancesOutput, nil
+}
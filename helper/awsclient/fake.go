@@ -0,0 +1,445 @@
+package awsclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/awserr"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// Assert that Fake meets the AutoScaling and EC2 interfaces.
+var (
+	_ AutoScaling = (*Fake)(nil)
+	_ EC2         = (*Fake)(nil)
+)
+
+// Fake is an in-memory implementation of the AutoScaling and EC2 interfaces,
+// modelling AutoScaling Groups along with the desired capacity, activities,
+// tags and state of their instances. Every change completes immediately;
+// launched instances are in service, unless the group has a lifecycle hook,
+// and terminated instances are gone. Instances abandoned by a lifecycle hook
+// are not replaced.
+type Fake struct {
+	lock sync.Mutex
+
+	// groups are the AutoScaling Groups keyed by name, and instances are the
+	// EC2 states of every instance launched keyed by ID.
+	groups    map[string]*fakeGroup
+	instances map[string]ec2.InstanceStateName
+
+	nextInstance int
+	nextActivity int
+}
+
+type fakeGroup struct {
+	group autoscaling.AutoScalingGroup
+
+	// activities are ordered newest first, as returned by AWS.
+	activities []autoscaling.Activity
+
+	// lifecycleHook is the name of the launch lifecycle hook, which holds
+	// new instances in the Pending:Wait state until it is completed.
+	lifecycleHook string
+}
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{
+		groups:    make(map[string]*fakeGroup),
+		instances: make(map[string]ec2.InstanceStateName),
+	}
+}
+
+// AddGroup creates an AutoScaling Group spanning the zones, and launches the
+// desired number of instances into it.
+func (f *Fake) AddGroup(name string, zones []string, desired int64) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	g := &fakeGroup{
+		group: autoscaling.AutoScalingGroup{
+			AutoScalingGroupName: aws.String(name),
+			AvailabilityZones:    zones,
+			DesiredCapacity:      aws.Int64(desired),
+		},
+	}
+	f.groups[name] = g
+	f.reconcile(g)
+}
+
+// SetLifecycleHook sets the launch lifecycle hook of the group.
+func (f *Fake) SetLifecycleHook(name, hook string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if g, ok := f.groups[name]; ok {
+		g.lifecycleHook = hook
+	}
+}
+
+// SetInstanceProtection sets whether the instance is protected from scale in.
+func (f *Fake) SetInstanceProtection(id string, protected bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, g := range f.groups {
+		for i := range g.group.Instances {
+			if *g.group.Instances[i].InstanceId == id {
+				g.group.Instances[i].ProtectedFromScaleIn = aws.Bool(protected)
+			}
+		}
+	}
+}
+
+// Group returns a copy of the group.
+func (f *Fake) Group(name string) (autoscaling.AutoScalingGroup, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	g, ok := f.groups[name]
+	if !ok {
+		return autoscaling.AutoScalingGroup{}, false
+	}
+	return copyGroup(&g.group), true
+}
+
+// InstanceState returns the EC2 state of the instance.
+func (f *Fake) InstanceState(id string) ec2.InstanceStateName {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.instances[id]
+}
+
+// CompleteLifecycleAction satisfies the CompleteLifecycleAction function on
+// the AutoScaling interface.
+func (f *Fake) CompleteLifecycleAction(_ context.Context, input *autoscaling.CompleteLifecycleActionInput) (*autoscaling.CompleteLifecycleActionOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	g, err := f.group(input.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if g.lifecycleHook == "" || g.lifecycleHook != aws.StringValue(input.LifecycleHookName) {
+		return nil, validationError("No Lifecycle Hook found for %s", aws.StringValue(input.LifecycleHookName))
+	}
+
+	i := g.instanceIndex(aws.StringValue(input.InstanceId))
+	if i < 0 || g.group.Instances[i].LifecycleState != autoscaling.LifecycleStatePendingWait {
+		return nil, validationError("No active Lifecycle Action found with instance ID %s", aws.StringValue(input.InstanceId))
+	}
+
+	switch aws.StringValue(input.LifecycleActionResult) {
+	case "CONTINUE":
+		g.group.Instances[i].LifecycleState = autoscaling.LifecycleStateInService
+	case "ABANDON":
+		f.instances[*g.group.Instances[i].InstanceId] = ec2.InstanceStateNameTerminated
+		g.group.Instances = append(g.group.Instances[:i], g.group.Instances[i+1:]...)
+	default:
+		return nil, validationError("Invalid Lifecycle Action Result %s", aws.StringValue(input.LifecycleActionResult))
+	}
+	return &autoscaling.CompleteLifecycleActionOutput{}, nil
+}
+
+// CreateOrUpdateTags satisfies the CreateOrUpdateTags function on the
+// AutoScaling interface.
+func (f *Fake) CreateOrUpdateTags(_ context.Context, input *autoscaling.CreateOrUpdateTagsInput) (*autoscaling.CreateOrUpdateTagsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, tag := range input.Tags {
+		g, err := f.group(tag.ResourceId)
+		if err != nil {
+			return nil, err
+		}
+
+		desc := autoscaling.TagDescription{
+			Key:               tag.Key,
+			PropagateAtLaunch: tag.PropagateAtLaunch,
+			ResourceId:        tag.ResourceId,
+			ResourceType:      tag.ResourceType,
+			Value:             tag.Value,
+		}
+
+		replaced := false
+		for i := range g.group.Tags {
+			if aws.StringValue(g.group.Tags[i].Key) == aws.StringValue(tag.Key) {
+				g.group.Tags[i] = desc
+				replaced = true
+			}
+		}
+		if !replaced {
+			g.group.Tags = append(g.group.Tags, desc)
+		}
+	}
+	return &autoscaling.CreateOrUpdateTagsOutput{}, nil
+}
+
+// DescribeAccountLimits satisfies the DescribeAccountLimits function on the
+// AutoScaling interface.
+func (f *Fake) DescribeAccountLimits(context.Context, *autoscaling.DescribeAccountLimitsInput) (*autoscaling.DescribeAccountLimitsOutput, error) {
+	return &autoscaling.DescribeAccountLimitsOutput{}, nil
+}
+
+// DescribeAutoScalingGroups satisfies the DescribeAutoScalingGroups function
+// on the AutoScaling interface. Unknown groups are omitted from the output.
+func (f *Fake) DescribeAutoScalingGroups(_ context.Context, input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	out := autoscaling.DescribeAutoScalingGroupsOutput{}
+
+	for _, name := range input.AutoScalingGroupNames {
+		if g, ok := f.groups[name]; ok {
+			out.AutoScalingGroups = append(out.AutoScalingGroups, copyGroup(&g.group))
+		}
+	}
+	return &out, nil
+}
+
+// DescribeScalingActivities satisfies the DescribeScalingActivities function
+// on the AutoScaling interface.
+func (f *Fake) DescribeScalingActivities(_ context.Context, input *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	g, err := f.group(input.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]struct{}, len(input.ActivityIds))
+	for _, id := range input.ActivityIds {
+		ids[id] = struct{}{}
+	}
+
+	out := autoscaling.DescribeScalingActivitiesOutput{}
+
+	for _, activity := range g.activities {
+		if _, ok := ids[*activity.ActivityId]; len(ids) == 0 || ok {
+			out.Activities = append(out.Activities, activity)
+		}
+	}
+	return &out, nil
+}
+
+// DetachInstances satisfies the DetachInstances function on the AutoScaling
+// interface. The instances keep running until terminated.
+func (f *Fake) DetachInstances(_ context.Context, input *autoscaling.DetachInstancesInput) (*autoscaling.DetachInstancesOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	g, err := f.group(input.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate all the instances before changing anything, as AWS does.
+	for _, id := range input.InstanceIds {
+		if g.instanceIndex(id) < 0 {
+			return nil, validationError("The instance %s is not part of Auto Scaling group %s", id, *g.group.AutoScalingGroupName)
+		}
+	}
+
+	out := autoscaling.DetachInstancesOutput{}
+
+	for _, id := range input.InstanceIds {
+		i := g.instanceIndex(id)
+		g.group.Instances = append(g.group.Instances[:i], g.group.Instances[i+1:]...)
+
+		if aws.BoolValue(input.ShouldDecrementDesiredCapacity) {
+			*g.group.DesiredCapacity--
+		}
+		out.Activities = append(out.Activities, f.addActivity(g, fmt.Sprintf("Detaching EC2 instance: %s", id)))
+	}
+	return &out, nil
+}
+
+// UpdateAutoScalingGroup satisfies the UpdateAutoScalingGroup function on the
+// AutoScaling interface. Instances are launched or terminated to meet the
+// desired capacity.
+func (f *Fake) UpdateAutoScalingGroup(_ context.Context, input *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	g, err := f.group(input.AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DesiredCapacity != nil {
+		g.group.DesiredCapacity = aws.Int64(*input.DesiredCapacity)
+		f.reconcile(g)
+	}
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+// DescribeInstanceStatus satisfies the DescribeInstanceStatus function on the
+// EC2 interface.
+func (f *Fake) DescribeInstanceStatus(_ context.Context, input *ec2.DescribeInstanceStatusInput) (*ec2.DescribeInstanceStatusOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	out := ec2.DescribeInstanceStatusOutput{}
+
+	for _, id := range input.InstanceIds {
+		state, ok := f.instances[id]
+		if !ok {
+			return nil, awserr.New("InvalidInstanceID.NotFound", fmt.Sprintf("The instance ID '%s' does not exist", id), nil)
+		}
+		out.InstanceStatuses = append(out.InstanceStatuses, ec2.InstanceStatus{
+			InstanceId:    aws.String(id),
+			InstanceState: &ec2.InstanceState{Name: state},
+		})
+	}
+	return &out, nil
+}
+
+// TerminateInstances satisfies the TerminateInstances function on the EC2
+// interface. Instances which are members of a group are also removed from it,
+// without the desired capacity being decremented.
+func (f *Fake) TerminateInstances(_ context.Context, input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, id := range input.InstanceIds {
+		if _, ok := f.instances[id]; !ok {
+			return nil, awserr.New("InvalidInstanceID.NotFound", fmt.Sprintf("The instance ID '%s' does not exist", id), nil)
+		}
+	}
+
+	out := ec2.TerminateInstancesOutput{}
+
+	for _, id := range input.InstanceIds {
+		prev := f.instances[id]
+		f.instances[id] = ec2.InstanceStateNameTerminated
+
+		for _, g := range f.groups {
+			if i := g.instanceIndex(id); i >= 0 {
+				g.group.Instances = append(g.group.Instances[:i], g.group.Instances[i+1:]...)
+			}
+		}
+
+		out.TerminatingInstances = append(out.TerminatingInstances, ec2.InstanceStateChange{
+			InstanceId:    aws.String(id),
+			PreviousState: &ec2.InstanceState{Name: prev},
+			CurrentState:  &ec2.InstanceState{Name: ec2.InstanceStateNameTerminated},
+		})
+	}
+	return &out, nil
+}
+
+// group returns the named group, or the error AWS returns if it is unknown.
+func (f *Fake) group(name *string) (*fakeGroup, error) {
+	g, ok := f.groups[aws.StringValue(name)]
+	if !ok {
+		return nil, validationError("AutoScalingGroup name not found - AutoScalingGroup '%s' not found", aws.StringValue(name))
+	}
+	return g, nil
+}
+
+// reconcile launches or terminates instances so the group meets its desired
+// capacity. Instances are launched into the zone with the fewest instances,
+// and the newest unprotected instances are terminated first.
+func (f *Fake) reconcile(g *fakeGroup) {
+
+	desired := int(*g.group.DesiredCapacity)
+	launched, terminated := 0, 0
+
+	for len(g.group.Instances) < desired {
+		f.nextInstance++
+		id := fmt.Sprintf("i-%017x", f.nextInstance)
+		f.instances[id] = ec2.InstanceStateNameRunning
+
+		state := autoscaling.LifecycleStateInService
+		if g.lifecycleHook != "" {
+			state = autoscaling.LifecycleStatePendingWait
+		}
+
+		g.group.Instances = append(g.group.Instances, autoscaling.Instance{
+			AvailabilityZone:     aws.String(g.smallestZone()),
+			HealthStatus:         aws.String("Healthy"),
+			InstanceId:           aws.String(id),
+			LifecycleState:       state,
+			ProtectedFromScaleIn: aws.Bool(false),
+		})
+		launched++
+	}
+
+	for i := len(g.group.Instances) - 1; i >= 0 && len(g.group.Instances) > desired; i-- {
+		if aws.BoolValue(g.group.Instances[i].ProtectedFromScaleIn) {
+			continue
+		}
+		f.instances[*g.group.Instances[i].InstanceId] = ec2.InstanceStateNameTerminated
+		g.group.Instances = append(g.group.Instances[:i], g.group.Instances[i+1:]...)
+		terminated++
+	}
+
+	if launched > 0 || terminated > 0 {
+		f.addActivity(g, fmt.Sprintf("Launching %d and terminating %d EC2 instances", launched, terminated))
+	}
+}
+
+// addActivity records a completed activity against the group.
+func (f *Fake) addActivity(g *fakeGroup, description string) autoscaling.Activity {
+	f.nextActivity++
+	now := time.Now()
+
+	activity := autoscaling.Activity{
+		ActivityId:           aws.String(fmt.Sprintf("activity-%d", f.nextActivity)),
+		AutoScalingGroupName: g.group.AutoScalingGroupName,
+		Cause:                aws.String("nomad-autoscaler fake"),
+		Description:          aws.String(description),
+		EndTime:              &now,
+		Progress:             aws.Int64(100),
+		StartTime:            &now,
+		StatusCode:           autoscaling.ScalingActivityStatusCodeSuccessful,
+	}
+	g.activities = append([]autoscaling.Activity{activity}, g.activities...)
+	return activity
+}
+
+func (g *fakeGroup) instanceIndex(id string) int {
+	for i, instance := range g.group.Instances {
+		if *instance.InstanceId == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// smallestZone returns the zone of the group with the fewest instances.
+func (g *fakeGroup) smallestZone() string {
+	counts := make(map[string]int)
+	for _, instance := range g.group.Instances {
+		counts[*instance.AvailabilityZone]++
+	}
+
+	zone := ""
+	for _, z := range g.group.AvailabilityZones {
+		if zone == "" || counts[z] < counts[zone] {
+			zone = z
+		}
+	}
+	return zone
+}
+
+// copyGroup copies the group, so that callers cannot modify the fake's state.
+func copyGroup(g *autoscaling.AutoScalingGroup) autoscaling.AutoScalingGroup {
+	out := *g
+	out.DesiredCapacity = aws.Int64(*g.DesiredCapacity)
+	out.AvailabilityZones = append([]string(nil), g.AvailabilityZones...)
+	out.Instances = append([]autoscaling.Instance(nil), g.Instances...)
+	out.Tags = append([]autoscaling.TagDescription(nil), g.Tags...)
+	return out
+}
+
+func validationError(format string, args ...interface{}) error {
+	return awserr.New("ValidationError", fmt.Sprintf(format, args...), nil)
+}
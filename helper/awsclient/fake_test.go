@@ -0,0 +1,178 @@
+package awsclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func testInstanceIDs(g autoscaling.AutoScalingGroup) []string {
+	var out []string
+	for _, instance := range g.Instances {
+		out = append(out, *instance.InstanceId)
+	}
+	return out
+}
+
+func TestFake_UpdateAutoScalingGroup(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewFake()
+	f.AddGroup("asg", []string{"a", "b"}, 2)
+
+	g, ok := f.Group("asg")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"i-00000000000000001", "i-00000000000000002"}, testInstanceIDs(g))
+	assert.Equal(t, "a", *g.Instances[0].AvailabilityZone)
+	assert.Equal(t, "b", *g.Instances[1].AvailabilityZone)
+
+	// Scale out, with the new instance being launched into the first zone.
+	_, err := f.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String("asg"),
+		DesiredCapacity:      aws.Int64(3),
+	})
+	assert.Nil(t, err)
+
+	g, _ = f.Group("asg")
+	assert.Equal(t, int64(3), *g.DesiredCapacity)
+	assert.Equal(t, "a", *g.Instances[2].AvailabilityZone)
+	assert.Equal(t, autoscaling.LifecycleStateInService, g.Instances[2].LifecycleState)
+
+	// Scale in, with the newest unprotected instances being terminated.
+	f.SetInstanceProtection("i-00000000000000003", true)
+
+	_, err = f.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String("asg"),
+		DesiredCapacity:      aws.Int64(2),
+	})
+	assert.Nil(t, err)
+
+	g, _ = f.Group("asg")
+	assert.Equal(t, []string{"i-00000000000000001", "i-00000000000000003"}, testInstanceIDs(g))
+	assert.Equal(t, ec2.InstanceStateNameTerminated, f.InstanceState("i-00000000000000002"))
+
+	resp, err := f.DescribeScalingActivities(ctx, &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String("asg"),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Activities, 3)
+	assert.Equal(t, "activity-3", *resp.Activities[0].ActivityId)
+	assert.Equal(t, int64(100), *resp.Activities[0].Progress)
+
+	// Unknown groups return an error.
+	_, err = f.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String("unknown"),
+		DesiredCapacity:      aws.Int64(2),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestFake_DetachAndTerminate(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewFake()
+	f.AddGroup("asg", []string{"a"}, 3)
+
+	// Detaching an instance which is not a member fails without changing
+	// the group.
+	_, err := f.DetachInstances(ctx, &autoscaling.DetachInstancesInput{
+		AutoScalingGroupName:           aws.String("asg"),
+		InstanceIds:                    []string{"i-00000000000000001", "i-unknown"},
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	})
+	assert.NotNil(t, err)
+
+	g, _ := f.Group("asg")
+	assert.Len(t, g.Instances, 3)
+
+	detachResp, err := f.DetachInstances(ctx, &autoscaling.DetachInstancesInput{
+		AutoScalingGroupName:           aws.String("asg"),
+		InstanceIds:                    []string{"i-00000000000000001"},
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	})
+	assert.Nil(t, err)
+	assert.Len(t, detachResp.Activities, 1)
+
+	g, _ = f.Group("asg")
+	assert.Equal(t, int64(2), *g.DesiredCapacity)
+	assert.Equal(t, []string{"i-00000000000000002", "i-00000000000000003"}, testInstanceIDs(g))
+	assert.Equal(t, ec2.InstanceStateNameRunning, f.InstanceState("i-00000000000000001"))
+
+	_, err = f.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
+		InstanceIds: []string{"i-00000000000000001"},
+	})
+	assert.Nil(t, err)
+
+	statusResp, err := f.DescribeInstanceStatus(ctx, &ec2.DescribeInstanceStatusInput{
+		InstanceIds: []string{"i-00000000000000001", "i-00000000000000002"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ec2.InstanceStateNameTerminated, statusResp.InstanceStatuses[0].InstanceState.Name)
+	assert.Equal(t, ec2.InstanceStateNameRunning, statusResp.InstanceStatuses[1].InstanceState.Name)
+
+	_, err = f.TerminateInstances(ctx, &ec2.TerminateInstancesInput{InstanceIds: []string{"i-unknown"}})
+	assert.NotNil(t, err)
+}
+
+func TestFake_CreateOrUpdateTags(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewFake()
+	f.AddGroup("asg", []string{"a"}, 0)
+
+	for _, value := range []string{"drain", "terminate"} {
+		_, err := f.CreateOrUpdateTags(ctx, &autoscaling.CreateOrUpdateTagsInput{
+			Tags: []autoscaling.Tag{{
+				Key:          aws.String("phase"),
+				Value:        aws.String(value),
+				ResourceId:   aws.String("asg"),
+				ResourceType: aws.String("auto-scaling-group"),
+			}},
+		})
+		assert.Nil(t, err)
+	}
+
+	g, _ := f.Group("asg")
+	assert.Len(t, g.Tags, 1)
+	assert.Equal(t, "terminate", *g.Tags[0].Value)
+}
+
+func TestFake_CompleteLifecycleAction(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewFake()
+	f.AddGroup("asg", []string{"a"}, 0)
+	f.SetLifecycleHook("asg", "launch")
+
+	_, err := f.UpdateAutoScalingGroup(ctx, &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String("asg"),
+		DesiredCapacity:      aws.Int64(2),
+	})
+	assert.Nil(t, err)
+
+	g, _ := f.Group("asg")
+	assert.Equal(t, autoscaling.LifecycleStatePendingWait, g.Instances[0].LifecycleState)
+
+	complete := func(id, result string) error {
+		_, err := f.CompleteLifecycleAction(ctx, &autoscaling.CompleteLifecycleActionInput{
+			AutoScalingGroupName:  aws.String("asg"),
+			InstanceId:            aws.String(id),
+			LifecycleActionResult: aws.String(result),
+			LifecycleHookName:     aws.String("launch"),
+		})
+		return err
+	}
+
+	assert.Nil(t, complete("i-00000000000000001", "CONTINUE"))
+	assert.Nil(t, complete("i-00000000000000002", "ABANDON"))
+	assert.NotNil(t, complete("i-00000000000000001", "CONTINUE"))
+
+	g, _ = f.Group("asg")
+	assert.Equal(t, []string{"i-00000000000000001"}, testInstanceIDs(g))
+	assert.Equal(t, autoscaling.LifecycleStateInService, g.Instances[0].LifecycleState)
+	assert.Equal(t, ec2.InstanceStateNameTerminated, f.InstanceState("i-00000000000000002"))
+}
@@ -0,0 +1,28 @@
+package nomad
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad/api"
+)
+
+// CheckAPI confirms the Nomad API is reachable by querying the cluster leader.
+// The Nomad API client does not accept a context, so the call is abandoned if
+// the context is done before it returns.
+func CheckAPI(ctx context.Context, client *api.Client) error {
+	if client == nil {
+		return errors.New("Nomad client not configured")
+	}
+
+	err := base.RunWithContext(ctx, func() error {
+		_, err := client.Status().Leader()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reach Nomad: %v", err)
+	}
+	return nil
+}
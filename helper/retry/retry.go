@@ -1,4 +1,4 @@
-package plugin
+package retry
 
 import (
 	"context"
@@ -7,18 +7,18 @@ import (
 	"time"
 )
 
-// retryFunc is the function signature for a function which is retryable. The
+// Func is the function signature for a function which is retryable. The
 // stop bool indicates whether or not the retry should be halted indicating a
 // terminal error. The error return can accompany either a true or false stop
 // return to provide context when needed.
-type retryFunc func(ctx context.Context) (stop bool, err error)
+type Func func(ctx context.Context) (stop bool, err error)
 
-// retry will retry the passed function f until any of the following conditions
+// Retry will retry the passed function f until any of the following conditions
 // are met:
 //  - the function returns stop=true and err=nil
 //  - the retryAttempts limit is reached
 //  - the context is cancelled
-func retry(ctx context.Context, retryInterval time.Duration, retryAttempts int, f retryFunc) error {
+func Retry(ctx context.Context, retryInterval time.Duration, retryAttempts int, f Func) error {
 
 	var (
 		retryCount int
@@ -52,6 +52,13 @@ func retry(ctx context.Context, retryInterval time.Duration, retryAttempts int,
 		if retryCount == retryAttempts {
 			return errors.New("reached retry limit")
 		}
-		time.Sleep(retryInterval)
+
+		// Wait for the retry interval, but do not block if the context is
+		// done in the meantime; the check at the top of the loop handles
+		// returning the error.
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
 	}
 }
@@ -1,4 +1,4 @@
-package plugin
+package retry
 
 import (
 	"context"
@@ -9,12 +9,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_retry(t *testing.T) {
+func TestRetry(t *testing.T) {
 	testCases := []struct {
 		inputContext   context.Context
 		inputInterval  time.Duration
 		inputRetry     int
-		inputFunc      retryFunc
+		inputFunc      Func
 		expectedOutput error
 		name           string
 	}{
@@ -38,12 +38,28 @@ func Test_retry(t *testing.T) {
 			expectedOutput: errors.New("reached retry limit"),
 			name:           "function never successful and reaches retry limit",
 		},
+		{
+			inputContext:  cancelledContext(),
+			inputInterval: 1 * time.Millisecond,
+			inputRetry:    3,
+			inputFunc: func(ctx context.Context) (stop bool, err error) {
+				return true, nil
+			},
+			expectedOutput: context.Canceled,
+			name:           "context cancelled before first attempt",
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			actualOutput := retry(tc.inputContext, tc.inputInterval, tc.inputRetry, tc.inputFunc)
+			actualOutput := Retry(tc.inputContext, tc.inputInterval, tc.inputRetry, tc.inputFunc)
 			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
 		})
 	}
 }
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
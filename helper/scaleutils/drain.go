@@ -0,0 +1,246 @@
+package scaleutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/api"
+)
+
+// errDrainTimeout is returned when a node drained without forcing fails to
+// complete its drain before the deadline.
+var errDrainTimeout = errors.New("node failed to drain before the deadline")
+
+// DrainOptions controls how nodes are prepared for termination by a Drainer.
+type DrainOptions struct {
+
+	// Deadline is the deadline used within the DrainSpec when performing
+	// Nomad Node drain.
+	Deadline time.Duration
+
+	// IgnoreSystemJobs leaves the allocations of system jobs running while
+	// the nodes are drained.
+	IgnoreSystemJobs bool
+
+	// IneligibleWait is the time the nodes are marked as ineligible for
+	// scheduling before being drained. If it is zero, the nodes are drained
+	// immediately.
+	IneligibleWait time.Duration
+
+	// NoForce drains the nodes without a deadline. Nodes which have not
+	// drained within the Deadline have their drain cancelled.
+	NoForce bool
+}
+
+// PartialDrainError is returned when only some of the nodes selected for
+// removal drained. The nodes which drained are returned alongside the error
+// and should be terminated, whereas the nodes which failed have had their
+// drain cancelled and are eligible for scheduling.
+type PartialDrainError struct {
+	Drained, Failed []NodeID
+	Err             error
+}
+
+func (e *PartialDrainError) Error() string {
+	return fmt.Sprintf("%v of %v nodes drained: %v",
+		len(e.Drained), len(e.Drained)+len(e.Failed), e.Err)
+}
+
+// Drainer drains Nomad nodes ahead of their termination. It is used by all the
+// targets which scale in Nomad nodes, so that nodes are drained identically.
+type Drainer struct {
+	log   hclog.Logger
+	nomad *api.Client
+}
+
+// NewDrainer returns a new Drainer which uses the passed Nomad client.
+func NewDrainer(client *api.Client, log hclog.Logger) *Drainer {
+	return &Drainer{log: log, nomad: client}
+}
+
+// Drain prepares the nodes for termination, marking them as ineligible and
+// waiting if configured, before draining them. The nodes which drained are
+// returned. Nodes which fail to drain are restored so they can run allocations
+// again, and a PartialDrainError is returned if at least one node drained.
+func (d *Drainer) Drain(ctx context.Context, opts DrainOptions, nodes []NodeID) ([]NodeID, error) {
+	if opts.IneligibleWait > 0 {
+		if err := d.markIneligible(ctx, opts.IneligibleWait, nodes); err != nil {
+			d.RestoreNodes(nodes)
+			return nil, err
+		}
+	}
+	return d.drainNodes(ctx, opts, nodes)
+}
+
+// RestoreNodes cancels any drain of the nodes and marks them as eligible for
+// scheduling, undoing the preparation of nodes which are not scaled in.
+// Failures are logged, as there is nothing further the caller can do.
+func (d *Drainer) RestoreNodes(nodes []NodeID) {
+	for _, node := range nodes {
+		if _, err := d.nomad.Nodes().UpdateDrain(node.NomadID, nil, true, nil); err != nil {
+			d.log.Error("failed to restore node eligibility", "node_id", node.NomadID, "error", err)
+			continue
+		}
+		d.log.Info("restored node eligibility", "node_id", node.NomadID)
+	}
+}
+
+// markIneligible marks the nodes as ineligible for scheduling and then waits,
+// so that allocations have the chance to finish before the nodes are drained.
+func (d *Drainer) markIneligible(ctx context.Context, wait time.Duration, nodes []NodeID) error {
+
+	for _, node := range nodes {
+		d.log.Info("marking node ineligible", "node_id", node.NomadID)
+
+		if _, err := d.nomad.Nodes().ToggleEligibility(node.NomadID, false, nil); err != nil {
+			return fmt.Errorf("failed to mark node %s ineligible: %v", node.NomadID, err)
+		}
+	}
+
+	d.log.Info("waiting before draining ineligible nodes", "wait", wait)
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("context done while waiting to drain ineligible nodes: %v", ctx.Err())
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// drainNodes iterates the provided nodeID list and performs a drain on each
+// one. The nodes which drained are returned. Nodes which fail to drain are
+// restored so they can run allocations again, and a PartialDrainError is
+// returned if at least one node drained.
+func (d *Drainer) drainNodes(ctx context.Context, opts DrainOptions, nodes []NodeID) ([]NodeID, error) {
+
+	// Define a WaitGroup. This allows us to trigger each node drain in a go
+	// routine and then wait for them all to complete before exiting.
+	var wg sync.WaitGroup
+
+	// All nodes to be drained form part of a pool of resource and all should
+	// use the same DrainSpec. A zero deadline drains without forcing the
+	// allocations to stop.
+	drainSpec := api.DrainSpec{Deadline: opts.Deadline, IgnoreSystemJobs: opts.IgnoreSystemJobs}
+	if opts.NoForce {
+		drainSpec.Deadline = 0
+	}
+
+	// Define an error to collect errors from each drain routine, the outcome
+	// of each node, and a mutex to provide thread safety when updating them.
+	var (
+		result          error
+		drained, failed []NodeID
+		resultLock      sync.Mutex
+	)
+
+	for _, node := range nodes {
+
+		// Increment our WaitGroup delta.
+		wg.Add(1)
+
+		// Assign our node to a local variable as we are launching a go routine
+		// from within a for loop.
+		n := node
+
+		// Launch a routine to drain the node. Append any error returned to the
+		// error.
+		go func() {
+
+			// Ensure we call done on the WaitGroup to decrement the count remaining.
+			defer wg.Done()
+
+			err := d.drainNode(ctx, n.NomadID, &drainSpec, opts)
+
+			resultLock.Lock()
+			defer resultLock.Unlock()
+
+			if err == nil {
+				drained = append(drained, n)
+				return
+			}
+
+			d.log.Warn("node failed to drain, not scaling in node", "node_id", n.NomadID, "error", err)
+			failed = append(failed, n)
+			result = multierror.Append(result, fmt.Errorf("node %s: %v", n.NomadID, err))
+		}()
+	}
+
+	wg.Wait()
+
+	if len(failed) == 0 {
+		return drained, nil
+	}
+
+	// The failed nodes are not going to be terminated, so ensure they do not
+	// remain draining or ineligible.
+	d.RestoreNodes(failed)
+
+	if len(drained) == 0 {
+		return nil, result
+	}
+	return drained, &PartialDrainError{Drained: drained, Failed: failed, Err: result}
+}
+
+// drainNode triggers a drain on the supplied ID using the DrainSpec. The
+// function handles monitoring the drain and reporting its terminal status to
+// the caller. When the options do not force the drain, errDrainTimeout is
+// returned if the drain has not completed by the deadline.
+func (d *Drainer) drainNode(ctx context.Context, nodeID string, spec *api.DrainSpec, opts DrainOptions) error {
+
+	d.log.Info("triggering drain on node", "node_id", nodeID, "deadline", spec.Deadline,
+		"ignore_system_jobs", spec.IgnoreSystemJobs, "no_force", opts.NoForce)
+
+	// Update the drain on the node.
+	resp, err := d.nomad.Nodes().UpdateDrain(nodeID, spec, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to drain node: %v", err)
+	}
+
+	monitorCtx := ctx
+	if opts.NoForce {
+		var cancel context.CancelFunc
+		monitorCtx, cancel = context.WithTimeout(ctx, opts.Deadline)
+		defer cancel()
+	}
+
+	// Monitor the drain so we output the log messages. An error here indicates
+	// the drain failed to complete successfully.
+	err = d.monitorNodeDrain(monitorCtx, nodeID, resp.LastIndex, spec.IgnoreSystemJobs)
+	if err == nil {
+		return nil
+	}
+
+	// If only the drain deadline has been reached, the drain is cancelled by
+	// the caller along with any other nodes which failed to drain.
+	if opts.NoForce && ctx.Err() == nil && monitorCtx.Err() == context.DeadlineExceeded {
+		return errDrainTimeout
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("context done while monitoring node drain: %v", err)
+	}
+	return err
+}
+
+// monitorNodeDrain follows the drain of a node, logging the messages we
+// receive to their appropriate level. If ignoreSys is true, the drain is
+// complete without the allocations of system jobs having stopped.
+func (d *Drainer) monitorNodeDrain(ctx context.Context, nodeID string, index uint64, ignoreSys bool) error {
+	for msg := range d.nomad.Nodes().MonitorDrain(ctx, nodeID, index, ignoreSys) {
+		switch msg.Level {
+		case api.MonitorMsgLevelInfo:
+			d.log.Info("received node drain message", "node_id", nodeID, "msg", msg.Message)
+		case api.MonitorMsgLevelWarn:
+			d.log.Warn("received node drain message", "node_id", nodeID, "msg", msg.Message)
+		case api.MonitorMsgLevelError:
+			return fmt.Errorf("received error while draining node: %s", msg.Message)
+		default:
+			d.log.Debug("received node drain message", "node_id", nodeID, "msg", msg.Message)
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,206 @@
+package scaleutils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// drainServer is a stand-in for the Nomad node endpoints used when draining.
+// Nodes within stuck never finish draining, nodes within failing cannot be
+// read once draining, and all other nodes finish as soon as their drain is
+// started.
+type drainServer struct {
+	lock        sync.Mutex
+	stuck       map[string]bool
+	failing     map[string]bool
+	drains      map[string][]*api.NodeUpdateDrainRequest
+	ineligibles []string
+}
+
+func (s *drainServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	w.Header().Set("X-Nomad-Index", "1")
+	w.Header().Set("X-Nomad-LastContact", "0")
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/node/")
+	parts := strings.SplitN(path, "/", 2)
+	id := parts[0]
+
+	switch {
+	case len(parts) == 1 && s.failing[id]:
+		http.Error(w, "node unavailable", http.StatusInternalServerError)
+	case len(parts) == 1:
+		node := api.Node{ID: id}
+		if s.stuck[id] && len(s.drains[id]) == 1 {
+			node.DrainStrategy = &api.DrainStrategy{}
+		}
+		_ = json.NewEncoder(w).Encode(node)
+	case parts[1] == "allocations":
+		_ = json.NewEncoder(w).Encode([]*api.Allocation{})
+	case parts[1] == "drain":
+		var req api.NodeUpdateDrainRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.drains[id] = append(s.drains[id], &req)
+		_ = json.NewEncoder(w).Encode(api.NodeDrainUpdateResponse{})
+	case parts[1] == "eligibility":
+		s.ineligibles = append(s.ineligibles, id)
+		_ = json.NewEncoder(w).Encode(api.NodeEligibilityUpdateResponse{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDrainer_Drain(t *testing.T) {
+
+	nodes := []NodeID{{NomadID: "node1", RemoteID: "i-1"}, {NomadID: "node2", RemoteID: "i-2"}}
+
+	testCases := []struct {
+		inputOpts       DrainOptions
+		stuck           map[string]bool
+		failing         map[string]bool
+		expectedOutput  []NodeID
+		expectedPartial bool
+		expectedError   bool
+		expectedDrains  map[string][]*api.NodeUpdateDrainRequest
+		expectedInelig  []string
+		name            string
+	}{
+		{
+			inputOpts:      DrainOptions{Deadline: time.Minute, IneligibleWait: 10 * time.Millisecond},
+			expectedOutput: nodes,
+			expectedDrains: map[string][]*api.NodeUpdateDrainRequest{
+				"node1": {{NodeID: "node1", DrainSpec: &api.DrainSpec{Deadline: time.Minute}}},
+				"node2": {{NodeID: "node2", DrainSpec: &api.DrainSpec{Deadline: time.Minute}}},
+			},
+			expectedInelig: []string{"node1", "node2"},
+			name:           "ineligible wait",
+		},
+		{
+			inputOpts:      DrainOptions{Deadline: time.Minute, IgnoreSystemJobs: true},
+			expectedOutput: nodes,
+			expectedDrains: map[string][]*api.NodeUpdateDrainRequest{
+				"node1": {{NodeID: "node1", DrainSpec: &api.DrainSpec{Deadline: time.Minute, IgnoreSystemJobs: true}}},
+				"node2": {{NodeID: "node2", DrainSpec: &api.DrainSpec{Deadline: time.Minute, IgnoreSystemJobs: true}}},
+			},
+			name: "ignore system jobs",
+		},
+		{
+			inputOpts:       DrainOptions{Deadline: 200 * time.Millisecond, NoForce: true},
+			stuck:           map[string]bool{"node2": true},
+			expectedOutput:  nodes[:1],
+			expectedPartial: true,
+			expectedDrains: map[string][]*api.NodeUpdateDrainRequest{
+				"node1": {{NodeID: "node1", DrainSpec: &api.DrainSpec{}}},
+				"node2": {
+					{NodeID: "node2", DrainSpec: &api.DrainSpec{}},
+					{NodeID: "node2", MarkEligible: true},
+				},
+			},
+			name: "no force with node failing to drain",
+		},
+		{
+			inputOpts:       DrainOptions{Deadline: time.Minute},
+			failing:         map[string]bool{"node1": true},
+			expectedOutput:  nodes[1:],
+			expectedPartial: true,
+			expectedDrains: map[string][]*api.NodeUpdateDrainRequest{
+				"node1": {
+					{NodeID: "node1", DrainSpec: &api.DrainSpec{Deadline: time.Minute}},
+					{NodeID: "node1", MarkEligible: true},
+				},
+				"node2": {{NodeID: "node2", DrainSpec: &api.DrainSpec{Deadline: time.Minute}}},
+			},
+			name: "node failing to monitor drain",
+		},
+		{
+			inputOpts:     DrainOptions{Deadline: 200 * time.Millisecond, NoForce: true},
+			stuck:         map[string]bool{"node1": true, "node2": true},
+			expectedError: true,
+			expectedDrains: map[string][]*api.NodeUpdateDrainRequest{
+				"node1": {
+					{NodeID: "node1", DrainSpec: &api.DrainSpec{}},
+					{NodeID: "node1", MarkEligible: true},
+				},
+				"node2": {
+					{NodeID: "node2", DrainSpec: &api.DrainSpec{}},
+					{NodeID: "node2", MarkEligible: true},
+				},
+			},
+			name: "no force with no nodes draining",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			srv := &drainServer{
+				stuck:   tc.stuck,
+				failing: tc.failing,
+				drains:  make(map[string][]*api.NodeUpdateDrainRequest),
+			}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			client, err := api.NewClient(&api.Config{Address: ts.URL})
+			assert.Nil(t, err)
+
+			d := NewDrainer(client, hclog.NewNullLogger())
+
+			actualOutput, actualErr := d.Drain(context.Background(), tc.inputOpts, nodes)
+			switch {
+			case tc.expectedError:
+				assert.NotNil(t, actualErr)
+				assert.Nil(t, actualOutput)
+			case tc.expectedPartial:
+				drainErr, ok := actualErr.(*PartialDrainError)
+				if assert.True(t, ok) {
+					assert.Equal(t, tc.expectedOutput, drainErr.Drained)
+					assert.Len(t, drainErr.Failed, len(nodes)-len(tc.expectedOutput))
+					assert.NotContains(t, drainErr.Error(), "context done")
+				}
+				assert.ElementsMatch(t, tc.expectedOutput, actualOutput)
+			default:
+				assert.Nil(t, actualErr)
+				assert.ElementsMatch(t, tc.expectedOutput, actualOutput)
+			}
+
+			srv.lock.Lock()
+			defer srv.lock.Unlock()
+			assert.Equal(t, tc.expectedDrains, srv.drains)
+			assert.Equal(t, tc.expectedInelig, srv.ineligibles)
+		})
+	}
+}
+
+func TestDrainer_markIneligible(t *testing.T) {
+
+	srv := &drainServer{drains: make(map[string][]*api.NodeUpdateDrainRequest)}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	d := NewDrainer(client, hclog.NewNullLogger())
+	nodes := []NodeID{{NomadID: "node1"}, {NomadID: "node2"}}
+
+	assert.Nil(t, d.markIneligible(context.Background(), 10*time.Millisecond, nodes))
+	assert.Equal(t, []string{"node1", "node2"}, srv.ineligibles)
+
+	// The wait is ended early once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, d.markIneligible(ctx, time.Hour, nodes))
+}
@@ -1,8 +1,15 @@
 package scaleutils
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 	"github.com/hashicorp/nomad/api"
 )
 
@@ -17,14 +24,169 @@ type NodeID struct {
 type PoolIdentifier struct {
 	IdentifierKey IdentifierKey
 	Value         string
+
+	// Identifiers are the identifiers nodes must all match when the
+	// IdentifierKey is IdentifierKeyComposite. The Value then describes them
+	// for use within logs and locks, but is not used to identify nodes.
+	Identifiers []*PoolIdentifier
+}
+
+// PoolIdentifierFromConfig returns the pool identifier described by the
+// node_class, datacenter, node_meta.<key> and attribute.<key> params of a
+// cluster target config. If several params are set, a composite identifier is
+// returned so nodes must match all of them. If none are set, nil is returned.
+func PoolIdentifierFromConfig(config map[string]string) *PoolIdentifier {
+
+	var idents []*PoolIdentifier
+
+	for k, v := range config {
+		switch {
+		case k == target.ConfigKeyClass:
+			idents = append(idents, &PoolIdentifier{IdentifierKey: IdentifierKeyClass, Value: v})
+		case k == target.ConfigKeyDatacenter:
+			idents = append(idents, &PoolIdentifier{IdentifierKey: IdentifierKeyDatacenter, Value: v})
+		case strings.HasPrefix(k, target.ConfigKeyPrefixNodeMeta):
+			key := IdentifierKeyPrefixNodeMeta + strings.TrimPrefix(k, target.ConfigKeyPrefixNodeMeta)
+			idents = append(idents, &PoolIdentifier{IdentifierKey: IdentifierKey(key), Value: v})
+		case strings.HasPrefix(k, target.ConfigKeyPrefixAttribute):
+			key := IdentifierKeyPrefixAttribute + strings.TrimPrefix(k, target.ConfigKeyPrefixAttribute)
+			idents = append(idents, &PoolIdentifier{IdentifierKey: IdentifierKey(key), Value: v})
+		}
+	}
+
+	switch len(idents) {
+	case 0:
+		return nil
+	case 1:
+		return idents[0]
+	}
+
+	// Sort the identifiers so the composite value is stable, as it is also
+	// used to identify the pool within logs and locks. The keys and values
+	// are escaped so that the separators within them cannot make the values
+	// of two different pools the same.
+	sort.Slice(idents, func(i, j int) bool { return idents[i].IdentifierKey < idents[j].IdentifierKey })
+
+	entries := make([]string, len(idents))
+	for i, ident := range idents {
+		entries[i] = url.QueryEscape(string(ident.IdentifierKey)) + "=" + url.QueryEscape(ident.Value)
+	}
+	return &PoolIdentifier{
+		IdentifierKey: IdentifierKeyComposite,
+		Value:         strings.Join(entries, ","),
+		Identifiers:   idents,
+	}
+}
+
+// ParseCompositePoolIdentifier parses the value of a composite identifier, as
+// built by PoolIdentifierFromConfig, into the identifiers it is made up of.
+// Any separator within a key or value must be escaped.
+func ParseCompositePoolIdentifier(value string) (*PoolIdentifier, error) {
+
+	var idents []*PoolIdentifier
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid composite node pool identifier entry %q, expected key=value", entry)
+		}
+
+		key, err := url.QueryUnescape(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid composite node pool identifier key %q: %v", parts[0], err)
+		}
+		val, err := url.QueryUnescape(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid composite node pool identifier value %q: %v", parts[1], err)
+		}
+
+		if IdentifierKey(key) == IdentifierKeyComposite {
+			return nil, errors.New("composite node pool identifiers cannot be nested")
+		}
+		idents = append(idents, &PoolIdentifier{IdentifierKey: IdentifierKey(key), Value: val})
+	}
+
+	return &PoolIdentifier{IdentifierKey: IdentifierKeyComposite, Value: value, Identifiers: idents}, nil
 }
 
 // IdentifyNodes filters the supplied node list based on the PoolIdentifier
-// params.
-func (p *PoolIdentifier) IdentifyNodes(n []*api.NodeListStub) ([]*api.NodeListStub, error) {
-	switch p.IdentifierKey {
-	case IdentifierKeyClass:
+// params. Only nodes which are ready, eligible and not draining are returned.
+// The client is used to read the details of the nodes when identifying them by
+// node meta or attribute, as these are not included in the node list.
+func (p *PoolIdentifier) IdentifyNodes(client *api.Client, n []*api.NodeListStub) ([]*api.NodeListStub, error) {
+
+	idents, err := p.identifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the identifiers which only need the node list first, so that the
+	// node details are read for as few nodes as possible.
+	sort.SliceStable(idents, func(i, j int) bool {
+		return !idents[i].needsNodeDetail() && idents[j].needsNodeDetail()
+	})
+
+	out := n
+	details := make(map[string]*api.Node)
+
+	for _, ident := range idents {
+		if out, err = ident.filter(client, out, details); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// identifiers returns the individual identifiers that nodes must match. This
+// is the identifier itself, unless it is a composite of several identifiers.
+func (p *PoolIdentifier) identifiers() ([]*PoolIdentifier, error) {
+
+	if p.IdentifierKey != IdentifierKeyComposite {
+		return []*PoolIdentifier{p}, nil
+	}
+
+	if len(p.Identifiers) == 0 {
+		return nil, errors.New("composite node pool identifier has no identifiers")
+	}
+
+	for _, ident := range p.Identifiers {
+		if ident.IdentifierKey == IdentifierKeyComposite {
+			return nil, errors.New("composite node pool identifiers cannot be nested")
+		}
+	}
+	return p.Identifiers, nil
+}
+
+// needsNodeDetail returns whether the node details must be read in order to
+// identify nodes using the identifier.
+func (p *PoolIdentifier) needsNodeDetail() bool {
+	key := string(p.IdentifierKey)
+	return strings.HasPrefix(key, IdentifierKeyPrefixNodeMeta) ||
+		strings.HasPrefix(key, IdentifierKeyPrefixAttribute)
+}
+
+// filter returns the nodes matching a single, non-composite, identifier. The
+// details map caches the node details read, so that they are only read once
+// when several identifiers need them.
+func (p *PoolIdentifier) filter(client *api.Client, n []*api.NodeListStub, details map[string]*api.Node) ([]*api.NodeListStub, error) {
+
+	key := string(p.IdentifierKey)
+
+	switch {
+	case p.IdentifierKey == IdentifierKeyClass:
 		return filterByClass(n, p.Value), nil
+	case p.IdentifierKey == IdentifierKeyDatacenter:
+		return filterByDatacenter(n, p.Value), nil
+	case strings.HasPrefix(key, IdentifierKeyPrefixNodeMeta) && len(key) > len(IdentifierKeyPrefixNodeMeta):
+		metaKey := strings.TrimPrefix(key, IdentifierKeyPrefixNodeMeta)
+		return filterByNodeDetail(client, n, details, func(node *api.Node) bool {
+			return node.Meta[metaKey] == p.Value
+		})
+	case strings.HasPrefix(key, IdentifierKeyPrefixAttribute) && len(key) > len(IdentifierKeyPrefixAttribute):
+		attrKey := strings.TrimPrefix(key, IdentifierKeyPrefixAttribute)
+		return filterByNodeDetail(client, n, details, func(node *api.Node) bool {
+			return node.Attributes[attrKey] == p.Value
+		})
 	default:
 		return nil, fmt.Errorf("unsupported node pool identifier: %q", p.IdentifierKey)
 	}
@@ -38,6 +200,24 @@ type IdentifierKey string
 // resource. This is the default.
 const IdentifierKeyClass IdentifierKey = "class"
 
+// IdentifierKeyDatacenter uses the Node.Datacenter field to identify nodes
+// into pools of resource.
+const IdentifierKeyDatacenter IdentifierKey = "datacenter"
+
+// IdentifierKeyPrefixNodeMeta and IdentifierKeyPrefixAttribute prefix a node
+// meta or attribute key, such as node_meta.pool, to identify nodes into pools
+// of resource using the value of the node meta or attribute.
+const (
+	IdentifierKeyPrefixNodeMeta  = "node_meta."
+	IdentifierKeyPrefixAttribute = "attribute."
+)
+
+// IdentifierKeyComposite combines several identifiers, identifying the nodes
+// which match all of them. The identifiers are held by the Identifiers field,
+// whereas the value is a comma separated list of escaped key=value pairs, such
+// as "datacenter=hk1,node_meta.pool=game-hk", used to describe the pool.
+const IdentifierKeyComposite IdentifierKey = "composite"
+
 // RemoteProvider is infrastructure provider which hosts and therefore manages
 // the Nomad client instances. This is used to understand how to translate the
 // Nomad NodeID to an ID that the provider understands.
@@ -48,21 +228,70 @@ type RemoteProvider string
 // nodeAttrAWSInstanceID to perform ID translation.
 const RemoteProviderAWSInstanceID RemoteProvider = "aws_instance_id"
 
+// RemoteProviderGCEInstanceID is the Google Cloud Platform remote provider
+// for Compute Engine instances. This provider will use the node attributes
+// as defined by nodeAttrGCEZone and nodeAttrGCEHostname to build the partial
+// instance URL used by the Compute API.
+const RemoteProviderGCEInstanceID RemoteProvider = "gce_instance_id"
+
+// RemoteProviderAzureInstanceID is the Microsoft Azure remote provider for
+// Virtual Machine Scale Set instances. This provider will use the node
+// attribute as defined by nodeAttrAzureName to find the instance ID.
+const RemoteProviderAzureInstanceID RemoteProvider = "azure_instance_id"
+
+// RemoteSelector chooses up to num nodes for removal from the candidates,
+// which are ordered by the NodeIDStrategy and translated to their remote
+// provider IDs.
+type RemoteSelector func(candidates []NodeID, num int) ([]NodeID, error)
+
 // NodeIDStrategy is the strategy used to identify nodes for removal as part of
 // scaling in.
 type NodeIDStrategy string
 
-// IDStrategyNewestCreateIndex uses the Nomad Nodes().List() output in the
-// order it is presented. This means we do not need additional sorting and thus
-// it is fastest. In an environment that uses bin-packing this may also be
-// preferable as nodes with older create indexes are expected to be most
-// packed.
+// IDStrategyNewestCreateIndex selects the nodes with the highest create
+// index, and therefore the newest nodes, first. This does not require any
+// additional API calls and thus it is fastest. In an environment that uses
+// bin-packing this may also be preferable as nodes with older create indexes
+// are expected to be most packed. This is the default.
 const IDStrategyNewestCreateIndex NodeIDStrategy = "newest_create_index"
 
+// IDStrategyOldestCreateIndex selects the nodes with the lowest create index,
+// and therefore the oldest nodes, first.
+const IDStrategyOldestCreateIndex NodeIDStrategy = "oldest_create_index"
+
+// IDStrategyLeastBusy selects the nodes running the fewest non-terminal
+// allocations first.
+const IDStrategyLeastBusy NodeIDStrategy = "least_busy"
+
+// IDStrategyEmptyFirst selects the nodes running no non-terminal allocations
+// first, followed by the remaining nodes ordered as per
+// IDStrategyNewestCreateIndex.
+const IDStrategyEmptyFirst NodeIDStrategy = "empty_first"
+
+// IDStrategyLeastAllocatedResources selects the nodes with the smallest
+// proportion of their CPU and memory allocated first.
+const IDStrategyLeastAllocatedResources NodeIDStrategy = "least_allocated_resources"
+
+// IDStrategyRandom selects nodes in a random order.
+const IDStrategyRandom NodeIDStrategy = "random"
+
 // nodeAttrAWSInstanceID is the node attribute to use when identifying the
 // AWS instanceID of a node.
 const nodeAttrAWSInstanceID = "unique.platform.aws.instance-id"
 
+// nodeAttrGCEZone and nodeAttrGCEHostname are the node attributes used when
+// identifying the GCE instance of a node. The instance name is the first
+// label of the hostname.
+const (
+	nodeAttrGCEZone     = "platform.gce.zone"
+	nodeAttrGCEHostname = "unique.platform.gce.hostname"
+)
+
+// nodeAttrAzureName is the node attribute to use when identifying the Azure
+// VMSS instance ID of a node. The VM name of a scale set instance has the
+// form <scale set name>_<instance ID>.
+const nodeAttrAzureName = "unique.platform.azure.name"
+
 // defaultClassIdentifier is the class used for nodes which have an empty class
 // parameter when using the IdentifierKeyClass.
 const defaultClassIdentifier = "autoscaler-default-pool"
@@ -76,17 +305,7 @@ func filterByClass(n []*api.NodeListStub, id string) []*api.NodeListStub {
 
 	for _, node := range n {
 
-		// Ignore nodes that are not in a ready state.
-		if node.Status != api.NodeStatusReady {
-			continue
-		}
-
-		// Ignore nodes that are not currently eligible.
-		if node.SchedulingEligibility != api.NodeSchedulingEligible {
-			continue
-		}
-
-		if node.Drain {
+		if !isActiveNode(node) {
 			continue
 		}
 
@@ -101,6 +320,117 @@ func filterByClass(n []*api.NodeListStub, id string) []*api.NodeListStub {
 	return out
 }
 
+// filterByDatacenter returns a filtered list of nodes which are active in the
+// cluster and where the specified datacenter matches that of the nodes.
+func filterByDatacenter(n []*api.NodeListStub, dc string) []*api.NodeListStub {
+
+	var out []*api.NodeListStub
+
+	for _, node := range n {
+		if isActiveNode(node) && node.Datacenter == dc {
+			out = append(out, node)
+		}
+	}
+
+	return out
+}
+
+// nodeDetailConcurrency is the maximum number of node details read at once
+// when identifying nodes by node meta or attribute.
+const nodeDetailConcurrency = 8
+
+// filterByNodeDetail returns a filtered list of nodes which are active in the
+// cluster and whose details match. The details of each node are read using
+// the client, unless already found within the details cache.
+func filterByNodeDetail(client *api.Client, n []*api.NodeListStub, details map[string]*api.Node,
+	match func(*api.Node) bool) ([]*api.NodeListStub, error) {
+
+	var active []*api.NodeListStub
+
+	for _, node := range n {
+		if isActiveNode(node) {
+			active = append(active, node)
+		}
+	}
+
+	if err := readNodeDetails(client, active, details); err != nil {
+		return nil, err
+	}
+
+	var out []*api.NodeListStub
+
+	for _, node := range active {
+		if match(details[node.ID]) {
+			out = append(out, node)
+		}
+	}
+
+	return out, nil
+}
+
+// readNodeDetails reads the details of the nodes which are not already within
+// the details cache. The node list does not include the node meta or
+// attributes, so the details are read in batches of concurrent calls to keep
+// the time taken down for large pools.
+func readNodeDetails(client *api.Client, n []*api.NodeListStub, details map[string]*api.Node) error {
+
+	var missing []string
+
+	for _, node := range n {
+		if _, ok := details[node.ID]; !ok {
+			missing = append(missing, node.ID)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	if client == nil {
+		return errors.New("a Nomad client is required to identify nodes by meta or attribute")
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		mErr *multierror.Error
+	)
+
+	// The semaphore limits the number of reads in flight.
+	sem := make(chan struct{}, nodeDetailConcurrency)
+
+	for _, id := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			info, _, err := client.Nodes().Info(id, nil)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				mErr = multierror.Append(mErr, fmt.Errorf("failed to read node %s: %v", id, err))
+				return
+			}
+			details[id] = info
+		}(id)
+	}
+
+	wg.Wait()
+	return mErr.ErrorOrNil()
+}
+
+// isActiveNode returns whether the node is ready, eligible for scheduling and
+// not draining. Only active nodes form part of a pool.
+func isActiveNode(node *api.NodeListStub) bool {
+	return node.Status == api.NodeStatusReady &&
+		node.SchedulingEligibility == api.NodeSchedulingEligible &&
+		!node.Drain
+}
+
 // nodeIDMapFunc is the function signature used to find the Nomad node's remote
 // identifier. Specific implementations can be found below.
 type nodeIDMapFunc func(n *api.Node) (string, error)
@@ -108,7 +438,9 @@ type nodeIDMapFunc func(n *api.Node) (string, error)
 // idFuncMap contains a mapping of RemoteProvider to the function which can
 // pull the remote ID information from the node.
 var idFuncMap = map[RemoteProvider]nodeIDMapFunc{
-	RemoteProviderAWSInstanceID: awsNodeIDMap,
+	RemoteProviderAWSInstanceID:   awsNodeIDMap,
+	RemoteProviderGCEInstanceID:   gceNodeIDMap,
+	RemoteProviderAzureInstanceID: azureNodeIDMap,
 }
 
 // awsNodeIDMap is used to identify the AWS InstanceID of a Nomad node using
@@ -121,3 +453,38 @@ func awsNodeIDMap(n *api.Node) (string, error) {
 	}
 	return val, err
 }
+
+// gceNodeIDMap is used to identify the GCE instance of a Nomad node using the
+// relevant attribute values. The ID is the partial URL of the instance, in the
+// form zones/<zone>/instances/<name>, as accepted by the Compute API.
+func gceNodeIDMap(n *api.Node) (string, error) {
+
+	zone, ok := n.Attributes[nodeAttrGCEZone]
+	if !ok {
+		return "", fmt.Errorf("attribute %q not found", nodeAttrGCEZone)
+	}
+
+	hostname, ok := n.Attributes[nodeAttrGCEHostname]
+	if !ok {
+		return "", fmt.Errorf("attribute %q not found", nodeAttrGCEHostname)
+	}
+
+	name := strings.SplitN(hostname, ".", 2)[0]
+	return fmt.Sprintf("zones/%s/instances/%s", zone, name), nil
+}
+
+// azureNodeIDMap is used to identify the Azure VMSS instance ID of a Nomad
+// node using the relevant attribute value.
+func azureNodeIDMap(n *api.Node) (string, error) {
+
+	name, ok := n.Attributes[nodeAttrAzureName]
+	if !ok {
+		return "", fmt.Errorf("attribute %q not found", nodeAttrAzureName)
+	}
+
+	idx := strings.LastIndex(name, "_")
+	if idx == -1 || idx == len(name)-1 {
+		return "", fmt.Errorf("attribute %q value %q is not a scale set VM name", nodeAttrAzureName, name)
+	}
+	return name[idx+1:], nil
+}
@@ -1,7 +1,12 @@
 package scaleutils
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/nomad/api"
@@ -184,3 +189,338 @@ func Test_awsNodeIDMap(t *testing.T) {
 		})
 	}
 }
+
+func Test_gceNodeIDMap(t *testing.T) {
+	testCases := []struct {
+		inputNode            *api.Node
+		expectedOutputString string
+		expectedOutputError  error
+		name                 string
+	}{
+		{
+			inputNode: &api.Node{
+				ID: "8a3025c6-5739-5563-f5e2-46000113646a",
+				Attributes: map[string]string{
+					"platform.gce.zone":            "us-central1-a",
+					"unique.platform.gce.hostname": "nomad-client-x4kd.c.project.internal",
+				},
+			},
+			expectedOutputString: "zones/us-central1-a/instances/nomad-client-x4kd",
+			expectedOutputError:  nil,
+			name:                 "attributes found",
+		},
+		{
+			inputNode: &api.Node{
+				ID: "8a3025c6-5739-5563-f5e2-46000113646a",
+				Attributes: map[string]string{
+					"unique.platform.gce.hostname": "nomad-client-x4kd",
+				},
+			},
+			expectedOutputString: "",
+			expectedOutputError:  errors.New("attribute \"platform.gce.zone\" not found"),
+			name:                 "zone attribute not found",
+		},
+		{
+			inputNode: &api.Node{
+				ID: "8a3025c6-5739-5563-f5e2-46000113646a",
+				Attributes: map[string]string{
+					"platform.gce.zone": "us-central1-a",
+				},
+			},
+			expectedOutputString: "",
+			expectedOutputError:  errors.New("attribute \"unique.platform.gce.hostname\" not found"),
+			name:                 "hostname attribute not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualString, actualError := gceNodeIDMap(tc.inputNode)
+			assert.Equal(t, tc.expectedOutputString, actualString, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+		})
+	}
+}
+
+func Test_azureNodeIDMap(t *testing.T) {
+	testCases := []struct {
+		inputNode            *api.Node
+		expectedOutputString string
+		expectedOutputError  error
+		name                 string
+	}{
+		{
+			inputNode: &api.Node{
+				ID: "8a3025c6-5739-5563-f5e2-46000113646a",
+				Attributes: map[string]string{
+					"unique.platform.azure.name": "nomad_client_vmss_12",
+				},
+			},
+			expectedOutputString: "12",
+			expectedOutputError:  nil,
+			name:                 "attribute found",
+		},
+		{
+			inputNode: &api.Node{
+				ID: "8a3025c6-5739-5563-f5e2-46000113646a",
+				Attributes: map[string]string{
+					"unique.platform.azure.name": "nomad-client",
+				},
+			},
+			expectedOutputString: "",
+			expectedOutputError:  errors.New("attribute \"unique.platform.azure.name\" value \"nomad-client\" is not a scale set VM name"),
+			name:                 "attribute not scale set VM name",
+		},
+		{
+			inputNode: &api.Node{
+				ID:         "8a3025c6-5739-5563-f5e2-46000113646a",
+				Attributes: map[string]string{},
+			},
+			expectedOutputString: "",
+			expectedOutputError:  errors.New("attribute \"unique.platform.azure.name\" not found"),
+			name:                 "attribute not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualString, actualError := azureNodeIDMap(tc.inputNode)
+			assert.Equal(t, tc.expectedOutputString, actualString, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+		})
+	}
+}
+
+func Test_PoolIdentifierFromConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig    map[string]string
+		expectedOutput *PoolIdentifier
+		name           string
+	}{
+		{
+			inputConfig:    map[string]string{"node_drain_deadline": "5m"},
+			expectedOutput: nil,
+			name:           "no pool params",
+		},
+		{
+			inputConfig:    map[string]string{"node_class": "high-memory"},
+			expectedOutput: &PoolIdentifier{IdentifierKey: IdentifierKeyClass, Value: "high-memory"},
+			name:           "class",
+		},
+		{
+			inputConfig:    map[string]string{"node_meta.pool": "game-hk"},
+			expectedOutput: &PoolIdentifier{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+			name:           "node meta",
+		},
+		{
+			inputConfig: map[string]string{
+				"node_meta.pool":    "game-hk",
+				"datacenter":        "hk1",
+				"attribute.os.name": "ubuntu",
+			},
+			expectedOutput: &PoolIdentifier{
+				IdentifierKey: IdentifierKeyComposite,
+				Value:         "attribute.os.name=ubuntu,datacenter=hk1,node_meta.pool=game-hk",
+				Identifiers: []*PoolIdentifier{
+					{IdentifierKey: "attribute.os.name", Value: "ubuntu"},
+					{IdentifierKey: IdentifierKeyDatacenter, Value: "hk1"},
+					{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+				},
+			},
+			name: "composite",
+		},
+		{
+			inputConfig: map[string]string{
+				"node_meta.pool": "game,hk=1",
+				"datacenter":     "hk1",
+			},
+			expectedOutput: &PoolIdentifier{
+				IdentifierKey: IdentifierKeyComposite,
+				Value:         "datacenter=hk1,node_meta.pool=game%2Chk%3D1",
+				Identifiers: []*PoolIdentifier{
+					{IdentifierKey: IdentifierKeyDatacenter, Value: "hk1"},
+					{IdentifierKey: "node_meta.pool", Value: "game,hk=1"},
+				},
+			},
+			name: "composite with separators in value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, PoolIdentifierFromConfig(tc.inputConfig), tc.name)
+		})
+	}
+}
+
+func Test_ParseCompositePoolIdentifier(t *testing.T) {
+	testCases := []struct {
+		inputValue     string
+		expectedOutput *PoolIdentifier
+		expectedError  error
+		name           string
+	}{
+		{
+			inputValue: "datacenter=hk1,node_meta.pool=game%2Chk%3D1",
+			expectedOutput: &PoolIdentifier{
+				IdentifierKey: IdentifierKeyComposite,
+				Value:         "datacenter=hk1,node_meta.pool=game%2Chk%3D1",
+				Identifiers: []*PoolIdentifier{
+					{IdentifierKey: IdentifierKeyDatacenter, Value: "hk1"},
+					{IdentifierKey: "node_meta.pool", Value: "game,hk=1"},
+				},
+			},
+			name: "escaped separators",
+		},
+		{
+			inputValue:    "datacenter",
+			expectedError: errors.New(`invalid composite node pool identifier entry "datacenter", expected key=value`),
+			name:          "entry without value",
+		},
+		{
+			inputValue:    "datacenter=hk%1",
+			expectedError: errors.New(`invalid composite node pool identifier value "hk%1": invalid URL escape "%1"`),
+			name:          "invalid escape",
+		},
+		{
+			inputValue:    "composite=datacenter%3Dhk1",
+			expectedError: errors.New("composite node pool identifiers cannot be nested"),
+			name:          "nested composite",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualError := ParseCompositePoolIdentifier(tc.inputValue)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedError, actualError, tc.name)
+		})
+	}
+}
+
+func TestPoolIdentifier_IdentifyNodes(t *testing.T) {
+
+	details := map[string]*api.Node{
+		"node1": {Meta: map[string]string{"pool": "game-hk"}, Attributes: map[string]string{"os.name": "ubuntu"}},
+		"node2": {Meta: map[string]string{"pool": "game-hk"}, Attributes: map[string]string{"os.name": "centos"}},
+		"node3": {Meta: map[string]string{"pool": "game-sg"}, Attributes: map[string]string{"os.name": "ubuntu"}},
+		"node5": {Meta: map[string]string{"pool": "game,hk=1"}, Attributes: map[string]string{"os.name": "ubuntu"}},
+	}
+
+	var lock sync.Mutex
+	reads := make(map[string]int)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Header().Set("X-Nomad-LastContact", "0")
+
+		id := strings.TrimPrefix(r.URL.Path, "/v1/node/")
+		lock.Lock()
+		reads[id]++
+		lock.Unlock()
+		_ = json.NewEncoder(w).Encode(details[id])
+	}))
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	inputNodes := []*api.NodeListStub{
+		{ID: "node1", Datacenter: "hk1", NodeClass: "game", Status: api.NodeStatusReady, SchedulingEligibility: api.NodeSchedulingEligible},
+		{ID: "node2", Datacenter: "hk2", NodeClass: "game", Status: api.NodeStatusReady, SchedulingEligibility: api.NodeSchedulingEligible},
+		{ID: "node3", Datacenter: "hk1", NodeClass: "game", Status: api.NodeStatusReady, SchedulingEligibility: api.NodeSchedulingEligible},
+		{ID: "node4", Datacenter: "hk1", NodeClass: "game", Status: api.NodeStatusReady, SchedulingEligibility: api.NodeSchedulingEligible, Drain: true},
+		{ID: "node5", Datacenter: "hk1", NodeClass: "game", Status: api.NodeStatusReady, SchedulingEligibility: api.NodeSchedulingEligible},
+	}
+
+	testCases := []struct {
+		inputIdent     *PoolIdentifier
+		expectedOutput []string
+		expectedError  error
+		expectedReads  map[string]int
+		name           string
+	}{
+		{
+			inputIdent:     &PoolIdentifier{IdentifierKey: IdentifierKeyDatacenter, Value: "hk1"},
+			expectedOutput: []string{"node1", "node3", "node5"},
+			expectedReads:  map[string]int{},
+			name:           "datacenter",
+		},
+		{
+			inputIdent:     &PoolIdentifier{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+			expectedOutput: []string{"node1", "node2"},
+			expectedReads:  map[string]int{"node1": 1, "node2": 1, "node3": 1, "node5": 1},
+			name:           "node meta",
+		},
+		{
+			inputIdent:     &PoolIdentifier{IdentifierKey: "attribute.os.name", Value: "ubuntu"},
+			expectedOutput: []string{"node1", "node3", "node5"},
+			expectedReads:  map[string]int{"node1": 1, "node2": 1, "node3": 1, "node5": 1},
+			name:           "attribute",
+		},
+		{
+			inputIdent: &PoolIdentifier{
+				IdentifierKey: IdentifierKeyComposite,
+				Identifiers: []*PoolIdentifier{
+					{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+					{IdentifierKey: "attribute.os.name", Value: "ubuntu"},
+					{IdentifierKey: IdentifierKeyDatacenter, Value: "hk1"},
+				},
+			},
+			expectedOutput: []string{"node1"},
+			expectedReads:  map[string]int{"node1": 1, "node3": 1, "node5": 1},
+			name:           "composite",
+		},
+		{
+			inputIdent: &PoolIdentifier{
+				IdentifierKey: IdentifierKeyComposite,
+				Identifiers: []*PoolIdentifier{
+					{IdentifierKey: "node_meta.pool", Value: "game,hk=1"},
+					{IdentifierKey: IdentifierKeyDatacenter, Value: "hk1"},
+				},
+			},
+			expectedOutput: []string{"node5"},
+			expectedReads:  map[string]int{"node1": 1, "node3": 1, "node5": 1},
+			name:           "composite with separators in value",
+		},
+		{
+			inputIdent:    &PoolIdentifier{IdentifierKey: IdentifierKeyComposite, Value: "datacenter=hk1"},
+			expectedError: errors.New("composite node pool identifier has no identifiers"),
+			name:          "composite without identifiers",
+		},
+		{
+			inputIdent: &PoolIdentifier{
+				IdentifierKey: IdentifierKeyComposite,
+				Identifiers:   []*PoolIdentifier{{IdentifierKey: IdentifierKeyComposite, Value: "datacenter=hk1"}},
+			},
+			expectedError: errors.New("composite node pool identifiers cannot be nested"),
+			name:          "nested composite",
+		},
+		{
+			inputIdent:    &PoolIdentifier{IdentifierKey: "node_meta.", Value: "game-hk"},
+			expectedError: errors.New(`unsupported node pool identifier: "node_meta."`),
+			name:          "node meta without key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lock.Lock()
+			reads = make(map[string]int)
+			lock.Unlock()
+
+			actualOutput, actualErr := tc.inputIdent.IdentifyNodes(client, inputNodes)
+			assert.Equal(t, tc.expectedError, actualErr, tc.name)
+			if tc.expectedError != nil {
+				return
+			}
+
+			var ids []string
+			for _, n := range actualOutput {
+				ids = append(ids, n.ID)
+			}
+			assert.Equal(t, tc.expectedOutput, ids, tc.name)
+			assert.Equal(t, tc.expectedReads, reads, tc.name)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package scaleutils
+
+import (
+	"fmt"
+)
+
+// ReadyNodes returns the nodes of the pool which are ready and eligible for
+// scheduling, and whose remote provider ID is one of remoteIDs. It allows
+// targets to confirm that the instances launched when scaling out have
+// joined the Nomad cluster.
+func (si *ScaleIn) ReadyNodes(ident *PoolIdentifier, remoteProvider RemoteProvider, remoteIDs []string) ([]NodeID, error) {
+
+	idFunc, ok := idFuncMap[remoteProvider]
+	if !ok {
+		return nil, fmt.Errorf("remote provider ID function not found: %s", remoteProvider)
+	}
+
+	nodes, _, err := si.nomad.Nodes().List(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Nomad nodes from API: %v", err)
+	}
+
+	// The pool identifier only returns nodes which are ready, eligible and
+	// not draining.
+	poolNodes, err := ident.IdentifyNodes(si.nomad, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]struct{}, len(remoteIDs))
+	for _, id := range remoteIDs {
+		wanted[id] = struct{}{}
+	}
+
+	var out []NodeID
+
+	for _, node := range poolNodes {
+
+		nodeInfo, _, err := si.nomad.Nodes().Info(node.ID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read node %s: %v", node.ID, err)
+		}
+
+		// Nodes which cannot be translated were not launched by the remote
+		// provider, so are not those being looked for.
+		id, err := idFunc(nodeInfo)
+		if err != nil {
+			si.log.Debug("failed to identify remote provider ID for node",
+				"node_id", node.ID, "error", err)
+			continue
+		}
+
+		if _, ok := wanted[id]; ok {
+			out = append(out, NodeID{NomadID: node.ID, RemoteID: id})
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package scaleutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleIn_ReadyNodes(t *testing.T) {
+
+	nodes := []*api.Node{
+		{
+			ID:                    "node-1",
+			NodeClass:             "worker",
+			Status:                api.NodeStatusReady,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes:            map[string]string{nodeAttrAWSInstanceID: "i-1"},
+		},
+		{
+			ID:                    "node-2",
+			NodeClass:             "worker",
+			Status:                api.NodeStatusInit,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes:            map[string]string{nodeAttrAWSInstanceID: "i-2"},
+		},
+		{
+			ID:                    "node-3",
+			NodeClass:             "worker",
+			Status:                api.NodeStatusReady,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes:            map[string]string{},
+		},
+		{
+			ID:                    "node-4",
+			NodeClass:             "worker",
+			Status:                api.NodeStatusReady,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes:            map[string]string{nodeAttrAWSInstanceID: "i-4"},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/nodes" {
+			var stubs []*api.NodeListStub
+			for _, n := range nodes {
+				stubs = append(stubs, &api.NodeListStub{
+					ID:                    n.ID,
+					NodeClass:             n.NodeClass,
+					Status:                n.Status,
+					SchedulingEligibility: n.SchedulingEligibility,
+				})
+			}
+			_ = json.NewEncoder(w).Encode(stubs)
+			return
+		}
+		for _, n := range nodes {
+			if r.URL.Path == "/v1/node/"+n.ID {
+				_ = json.NewEncoder(w).Encode(n)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.URL})
+	assert.Nil(t, err)
+
+	si := &ScaleIn{log: hclog.NewNullLogger(), nomad: client}
+	ident := &PoolIdentifier{IdentifierKey: IdentifierKeyClass, Value: "worker"}
+
+	out, err := si.ReadyNodes(ident, RemoteProviderAWSInstanceID, []string{"i-1", "i-2", "i-3"})
+	assert.Nil(t, err)
+	assert.Equal(t, []NodeID{{NomadID: "node-1", RemoteID: "i-1"}}, out)
+
+	_, err = si.ReadyNodes(ident, "gce", nil)
+	assert.True(t, strings.Contains(err.Error(), "remote provider ID function not found"))
+}
@@ -5,8 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"sync"
-	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
 	multierror "github.com/hashicorp/go-multierror"
@@ -14,8 +12,9 @@ import (
 )
 
 type ScaleIn struct {
-	log   hclog.Logger
-	nomad *api.Client
+	log     hclog.Logger
+	nomad   *api.Client
+	drainer *Drainer
 
 	// curNodeID is the ID of the node that the Nomad autoscaler is curently
 	// running on.
@@ -44,49 +43,63 @@ func NewScaleInUtils(cfg *api.Config, log hclog.Logger) (*ScaleIn, error) {
 	return &ScaleIn{
 		log:       log,
 		nomad:     client,
+		drainer:   NewDrainer(client, log),
 		curNodeID: id,
 	}, nil
 }
 
 // RunPreScaleInTasks helps tie together all the tasks required prior to
 // scaling in Nomad nodes, and thus terminating the server in the remote
-// provider.
+// provider. If only some of the nodes drain, the drained nodes are returned
+// along with a PartialDrainError.
 func (si *ScaleIn) RunPreScaleInTasks(ctx context.Context, req *ScaleInReq) ([]NodeID, error) {
 
 	if err := req.validate(); err != nil {
 		return nil, fmt.Errorf("failed to validate request: %v", err)
 	}
 
-	nodes, err := si.identifyTargets(req.Num, req.PoolIdentifier, req.NodeIDStrategy)
+	// When the target selects the nodes, it is given every node within the
+	// pool to choose from.
+	num := req.Num
+	if req.RemoteSelector != nil {
+		num = -1
+	}
+
+	nodes, err := si.identifyTargets(num, req.PoolIdentifier, req.NodeIDStrategy)
 	if err != nil {
 		return nil, fmt.Errorf("failed to identify nodes for removal: %v", err)
 	}
 
 	// Technically we do not need this information until after the nodes have
 	// been drained. However, this doesn't change cluster state and so do this
-	// first to make sure there are no issues in translating.
-	nodeIDMap, err := si.getRemoteIDMap(nodes, req.RemoteProvider)
+	// first to make sure there are no issues in translating. When the target
+	// selects from the whole pool, nodes which cannot be translated are not
+	// candidates, rather than failing the scaling action.
+	nodeIDMap, err := si.getRemoteIDMap(nodes, req.RemoteProvider, req.RemoteSelector != nil)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.RemoteSelector != nil {
+		if nodeIDMap, err = req.RemoteSelector(nodeIDMap, req.Num); err != nil {
+			return nil, fmt.Errorf("failed to select nodes for removal: %v", err)
+		}
+	}
+
 	// If we have not been able to identify any nodes and get their remote
 	// provider ID we cannot continue.
 	if len(nodeIDMap) == 0 {
 		return nil, errors.New("failed to identify nodes for removal")
 	}
 
-	if err := si.drainNodes(ctx, req.DrainDeadline, nodeIDMap); err != nil {
-		return nil, err
-	}
-
-	return nodeIDMap, nil
+	return si.drainer.Drain(ctx, req.drainOptions(), nodeIDMap)
 }
 
 // identifyTargets filters the current Nomad cluster node list and then sorts
 // and selects nodes for removal based on the specified strategy. It is
 // possible the list does not contain as many nodes as requested. In this case,
-// do the limited number available after filtering.
+// do the limited number available after filtering. A negative num selects all
+// the nodes.
 func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy NodeIDStrategy) ([]*api.NodeListStub, error) {
 
 	// Pull a current list of Nomad nodes from the API.
@@ -99,7 +112,7 @@ func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy Node
 	si.log.Debug("filtering node list", "filter", ident.IdentifierKey, "value", ident.Value)
 
 	// Filter our nodes to select only those within our identified pool.
-	filteredNodes, err := ident.IdentifyNodes(nodes)
+	filteredNodes, err := ident.IdentifyNodes(si.nomad, nodes)
 	if err != nil {
 		return nil, err
 	}
@@ -113,12 +126,15 @@ func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy Node
 		return nil, fmt.Errorf("no nodes unfiltered for %s with value %s", ident.IdentifierKey, ident.Value)
 	}
 
-	// Identify the strategy we are using to pick nodes for scale in and
-	// perform our list sorting.
-	switch strategy {
-	case IDStrategyNewestCreateIndex:
-	default:
-		return nil, fmt.Errorf("unsupported scale in node identification strategy: %q", strategy)
+	// Sort the nodes using the strategy, so that we pick nodes for scale in
+	// from the front of the list.
+	filteredNodes, err = RankNodes(si.nomad, filteredNodes, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	if num < 0 {
+		num = len(filteredNodes)
 	}
 
 	// If the caller has requested more nodes than we have available once
@@ -142,7 +158,10 @@ func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy Node
 	return out, nil
 }
 
-func (si *ScaleIn) getRemoteIDMap(nodes []*api.NodeListStub, remoteProvider RemoteProvider) ([]NodeID, error) {
+// getRemoteIDMap translates the Nomad nodes to their remote provider IDs. If
+// skipUntranslatable is true, nodes without a remote provider ID are logged and
+// omitted, otherwise an error is returned.
+func (si *ScaleIn) getRemoteIDMap(nodes []*api.NodeListStub, remoteProvider RemoteProvider, skipUntranslatable bool) ([]NodeID, error) {
 
 	idFunc, ok := idFuncMap[remoteProvider]
 	if !ok {
@@ -167,6 +186,11 @@ func (si *ScaleIn) getRemoteIDMap(nodes []*api.NodeListStub, remoteProvider Remo
 		// provider ID information from the Node info.
 		id, err := idFunc(nodeInfo)
 		if err != nil {
+			if skipUntranslatable {
+				si.log.Warn("skipping node without remote provider ID",
+					"node_id", nodeInfo.ID, "error", err)
+				continue
+			}
 			mErr = multierror.Append(mErr, err)
 		}
 
@@ -181,96 +205,6 @@ func (si *ScaleIn) getRemoteIDMap(nodes []*api.NodeListStub, remoteProvider Remo
 	return out, mErr.ErrorOrNil()
 }
 
-// drainNodes iterates the provided nodeID list and performs a drain on each
-// one.
-func (si *ScaleIn) drainNodes(ctx context.Context, deadline time.Duration, nodes []NodeID) error {
-
-	// Define a WaitGroup. This allows us to trigger each node drain in a go
-	// routine and then wait for them all to complete before exiting.
-	var wg sync.WaitGroup
-
-	// All nodes to be drained form part of a pool of resource and all should
-	// use the same DrainSpec.
-	drainSpec := api.DrainSpec{Deadline: deadline}
-
-	// Define an error to collect errors from each drain routine and a mutex to
-	// provide thread safety when calling multierror.Append.
-	var (
-		result     error
-		resultLock sync.Mutex
-	)
-
-	for _, node := range nodes {
-
-		// Increment our WaitGroup delta.
-		wg.Add(1)
-
-		// Assign our node to a local variable as we are launching a go routine
-		// from within a for loop.
-		n := node
-
-		// Launch a routine to drain the node. Append any error returned to the
-		// error.
-		go func() {
-
-			// Ensure we call done on the WaitGroup to decrement the count remaining.
-			defer wg.Done()
-
-			if err := si.drainNode(ctx, n.NomadID, &drainSpec); err != nil {
-				resultLock.Lock()
-				result = multierror.Append(result, err)
-				resultLock.Unlock()
-			}
-		}()
-	}
-
-	wg.Wait()
-
-	return result
-}
-
-// drainNode triggers a drain on the supplied ID using the DrainSpec. The
-// function handles monitoring the drain and reporting its terminal status to
-// the caller.
-func (si *ScaleIn) drainNode(ctx context.Context, nodeID string, spec *api.DrainSpec) error {
-
-	si.log.Info("triggering drain on node", "node_id", nodeID, "deadline", spec.Deadline)
-
-	// Update the drain on the node.
-	resp, err := si.nomad.Nodes().UpdateDrain(nodeID, spec, false, nil)
-	if err != nil {
-		return fmt.Errorf("failed to drain node: %v", err)
-	}
-
-	// Monitor the drain so we output the log messages. An error here indicates
-	// the drain failed to complete successfully.
-	if err := si.monitorNodeDrain(ctx, nodeID, resp.LastIndex); err != nil {
-		return fmt.Errorf("context done while monitoring node drain: %v", err)
-	}
-	return nil
-}
-
-// monitorNodeDrain follows the drain of a node, logging the messages we
-// receive to their appropriate level.
-//
-// TODO(jrasell): currently the ignoreSys param is hardcoded to false, we will
-//  probably want to expose this to operators in the future.
-func (si *ScaleIn) monitorNodeDrain(ctx context.Context, nodeID string, index uint64) error {
-	for msg := range si.nomad.Nodes().MonitorDrain(ctx, nodeID, index, false) {
-		switch msg.Level {
-		case api.MonitorMsgLevelInfo:
-			si.log.Info("received node drain message", "node_id", nodeID, "msg", msg.Message)
-		case api.MonitorMsgLevelWarn:
-			si.log.Warn("received node drain message", "node_id", nodeID, "msg", msg.Message)
-		case api.MonitorMsgLevelError:
-			return fmt.Errorf("received error while draining node: %s", msg.Message)
-		default:
-			si.log.Debug("received node drain message", "node_id", nodeID, "msg", msg.Message)
-		}
-	}
-	return ctx.Err()
-}
-
 // identifyAutoscalerNodeID identifies the NodeID which the autoscaler is
 // running on.
 //
@@ -0,0 +1,43 @@
+package scaleutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleIn_getRemoteIDMap(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Header().Set("X-Nomad-LastContact", "0")
+
+		node := api.Node{ID: strings.TrimPrefix(r.URL.Path, "/v1/node/")}
+		if node.ID == "node1" {
+			node.Attributes = map[string]string{"unique.platform.aws.instance-id": "i-1"}
+		}
+		_ = json.NewEncoder(w).Encode(node)
+	}))
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	si := &ScaleIn{log: hclog.NewNullLogger(), nomad: client}
+	nodes := []*api.NodeListStub{{ID: "node1"}, {ID: "node2"}}
+
+	// Nodes which cannot be translated fail the translation by default.
+	_, err = si.getRemoteIDMap(nodes, RemoteProviderAWSInstanceID, false)
+	assert.NotNil(t, err)
+
+	// Otherwise they are omitted.
+	actualOutput, err := si.getRemoteIDMap(nodes, RemoteProviderAWSInstanceID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []NodeID{{NomadID: "node1", RemoteID: "i-1"}}, actualOutput)
+}
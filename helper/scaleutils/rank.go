@@ -0,0 +1,172 @@
+package scaleutils
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// nodeRank holds the details of a node used to rank it for removal.
+type nodeRank struct {
+	node *api.NodeListStub
+
+	// allocs is the number of non-terminal allocations on the node.
+	allocs int
+
+	// allocated is the mean proportion of the node's CPU and memory which is
+	// allocated to non-terminal allocations.
+	allocated float64
+}
+
+// ParseNodeIDStrategy parses the strategy, as found within a target config,
+// returning an error if it is not supported. An empty strategy results in
+// the default of IDStrategyNewestCreateIndex.
+func ParseNodeIDStrategy(s string) (NodeIDStrategy, error) {
+	switch strategy := NodeIDStrategy(s); strategy {
+	case "":
+		return IDStrategyNewestCreateIndex, nil
+	case IDStrategyNewestCreateIndex, IDStrategyOldestCreateIndex, IDStrategyLeastBusy,
+		IDStrategyEmptyFirst, IDStrategyLeastAllocatedResources, IDStrategyRandom:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unsupported scale in node identification strategy: %q", s)
+	}
+}
+
+// RankNodes sorts the nodes in the order they should be selected for removal,
+// as defined by the strategy. The client is used to read the allocations and
+// resources of the nodes when the strategy requires them.
+func RankNodes(client *api.Client, nodes []*api.NodeListStub, strategy NodeIDStrategy) ([]*api.NodeListStub, error) {
+
+	newest := func(a, b *nodeRank) bool { return a.node.CreateIndex > b.node.CreateIndex }
+
+	var less func(a, b *nodeRank) bool
+
+	switch strategy {
+	case IDStrategyNewestCreateIndex:
+		less = newest
+	case IDStrategyOldestCreateIndex:
+		less = func(a, b *nodeRank) bool { return a.node.CreateIndex < b.node.CreateIndex }
+	case IDStrategyLeastBusy:
+		less = func(a, b *nodeRank) bool {
+			if a.allocs != b.allocs {
+				return a.allocs < b.allocs
+			}
+			return newest(a, b)
+		}
+	case IDStrategyEmptyFirst:
+		less = func(a, b *nodeRank) bool {
+			if (a.allocs == 0) != (b.allocs == 0) {
+				return a.allocs == 0
+			}
+			return newest(a, b)
+		}
+	case IDStrategyLeastAllocatedResources:
+		less = func(a, b *nodeRank) bool {
+			if a.allocated != b.allocated {
+				return a.allocated < b.allocated
+			}
+			return newest(a, b)
+		}
+	case IDStrategyRandom:
+	default:
+		return nil, fmt.Errorf("unsupported scale in node identification strategy: %q", strategy)
+	}
+
+	ranks := make([]*nodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = &nodeRank{node: node}
+	}
+
+	// Only the allocation based strategies need the, relatively expensive,
+	// allocation details of every node.
+	switch strategy {
+	case IDStrategyLeastBusy, IDStrategyEmptyFirst, IDStrategyLeastAllocatedResources:
+		if err := setAllocRanks(client, ranks, strategy == IDStrategyLeastAllocatedResources); err != nil {
+			return nil, err
+		}
+	}
+
+	if strategy == IDStrategyRandom {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		r.Shuffle(len(ranks), func(i, j int) { ranks[i], ranks[j] = ranks[j], ranks[i] })
+	} else {
+		sort.SliceStable(ranks, func(i, j int) bool { return less(ranks[i], ranks[j]) })
+	}
+
+	out := make([]*api.NodeListStub, len(ranks))
+	for i, r := range ranks {
+		out[i] = r.node
+	}
+	return out, nil
+}
+
+// setAllocRanks sets the allocation count of each node and, if resources is
+// true, the allocated resource proportion.
+func setAllocRanks(client *api.Client, ranks []*nodeRank, resources bool) error {
+
+	for _, r := range ranks {
+
+		allocs, _, err := client.Nodes().Allocations(r.node.ID, nil)
+		if err != nil {
+			return fmt.Errorf("failed to list allocations of node %s: %v", r.node.ID, err)
+		}
+
+		var cpu, mem int64
+
+		for _, alloc := range allocs {
+			if isTerminalAlloc(alloc) {
+				continue
+			}
+			r.allocs++
+
+			if alloc.AllocatedResources == nil {
+				continue
+			}
+			for _, task := range alloc.AllocatedResources.Tasks {
+				cpu += task.Cpu.CpuShares
+				mem += task.Memory.MemoryMB
+			}
+		}
+
+		if !resources {
+			continue
+		}
+
+		node, _, err := client.Nodes().Info(r.node.ID, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read node %s: %v", r.node.ID, err)
+		}
+
+		if node.NodeResources != nil {
+			r.allocated = (proportion(cpu, node.NodeResources.Cpu.CpuShares) +
+				proportion(mem, node.NodeResources.Memory.MemoryMB)) / 2
+		}
+	}
+	return nil
+}
+
+// isTerminalAlloc returns whether the allocation has stopped, or is going to
+// be stopped.
+func isTerminalAlloc(alloc *api.Allocation) bool {
+	if alloc.DesiredStatus != api.AllocDesiredStatusRun {
+		return true
+	}
+	switch alloc.ClientStatus {
+	case api.AllocClientStatusComplete, api.AllocClientStatusFailed, api.AllocClientStatusLost:
+		return true
+	default:
+		return false
+	}
+}
+
+// proportion returns used as a proportion of total, handling a zero total.
+func proportion(used, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) / float64(total)
+}
@@ -0,0 +1,141 @@
+package scaleutils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseNodeIDStrategy(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput NodeIDStrategy
+		expectedError  error
+		name           string
+	}{
+		{
+			input:          "",
+			expectedOutput: IDStrategyNewestCreateIndex,
+			name:           "default strategy",
+		},
+		{
+			input:          "least_busy",
+			expectedOutput: IDStrategyLeastBusy,
+			name:           "supported strategy",
+		},
+		{
+			input:         "busiest",
+			expectedError: errors.New(`unsupported scale in node identification strategy: "busiest"`),
+			name:          "unsupported strategy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualErr := ParseNodeIDStrategy(tc.input)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedError, actualErr, tc.name)
+		})
+	}
+}
+
+func Test_RankNodes(t *testing.T) {
+
+	// node1 is the oldest and empty, node2 runs two allocations using most of
+	// its resources, and node3 is the newest and runs three small allocations.
+	allocs := map[string][]*api.Allocation{
+		"node1": {testTerminalAlloc()},
+		"node2": {testAlloc(400, 512), testAlloc(400, 512)},
+		"node3": {testAlloc(100, 64), testAlloc(100, 64), testAlloc(100, 64)},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Header().Set("X-Nomad-LastContact", "0")
+
+		path := strings.TrimPrefix(r.URL.Path, "/v1/node/")
+		if id := strings.TrimSuffix(path, "/allocations"); id != path {
+			_ = json.NewEncoder(w).Encode(allocs[id])
+			return
+		}
+		_ = json.NewEncoder(w).Encode(api.Node{
+			ID: path,
+			NodeResources: &api.NodeResources{
+				Cpu:    api.NodeCpuResources{CpuShares: 1000},
+				Memory: api.NodeMemoryResources{MemoryMB: 1024},
+			},
+		})
+	}))
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	inputNodes := []*api.NodeListStub{
+		{ID: "node3", CreateIndex: 3},
+		{ID: "node2", CreateIndex: 2},
+		{ID: "node1", CreateIndex: 1},
+	}
+
+	testCases := []struct {
+		inputStrategy  NodeIDStrategy
+		expectedOutput []string
+		expectedError  bool
+	}{
+		{inputStrategy: IDStrategyNewestCreateIndex, expectedOutput: []string{"node3", "node2", "node1"}},
+		{inputStrategy: IDStrategyOldestCreateIndex, expectedOutput: []string{"node1", "node2", "node3"}},
+		{inputStrategy: IDStrategyLeastBusy, expectedOutput: []string{"node1", "node2", "node3"}},
+		{inputStrategy: IDStrategyEmptyFirst, expectedOutput: []string{"node1", "node3", "node2"}},
+		{inputStrategy: IDStrategyLeastAllocatedResources, expectedOutput: []string{"node1", "node3", "node2"}},
+		{inputStrategy: "unknown", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.inputStrategy), func(t *testing.T) {
+			actualOutput, err := RankNodes(client, inputNodes, tc.inputStrategy)
+			if tc.expectedError {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			var ids []string
+			for _, n := range actualOutput {
+				ids = append(ids, n.ID)
+			}
+			assert.Equal(t, tc.expectedOutput, ids)
+		})
+	}
+
+	// The random strategy only changes the order of the nodes.
+	actualOutput, err := RankNodes(client, inputNodes, IDStrategyRandom)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, inputNodes, actualOutput)
+}
+
+func testAlloc(cpu, mem int64) *api.Allocation {
+	return &api.Allocation{
+		DesiredStatus: api.AllocDesiredStatusRun,
+		ClientStatus:  api.AllocClientStatusRunning,
+		AllocatedResources: &api.AllocatedResources{
+			Tasks: map[string]*api.AllocatedTaskResources{
+				"task": {
+					Cpu:    api.AllocatedCpuResources{CpuShares: cpu},
+					Memory: api.AllocatedMemoryResources{MemoryMB: mem},
+				},
+			},
+		},
+	}
+}
+
+func testTerminalAlloc() *api.Allocation {
+	alloc := testAlloc(900, 900)
+	alloc.ClientStatus = api.AllocClientStatusComplete
+	return alloc
+}
@@ -2,9 +2,12 @@ package scaleutils
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 )
 
 const (
@@ -24,9 +27,60 @@ type ScaleInReq struct {
 	// Nomad Node drain.
 	DrainDeadline time.Duration
 
+	// IgnoreSystemJobs leaves the allocations of system jobs running while
+	// the nodes are drained, so that jobs such as log shippers keep running
+	// until the nodes are terminated.
+	IgnoreSystemJobs bool
+
+	// IneligibleWait is the time the nodes are marked as ineligible for
+	// scheduling before being drained, allowing allocations to finish without
+	// being migrated. If it is zero, the nodes are drained immediately.
+	IneligibleWait time.Duration
+
+	// NoForce drains the nodes without a deadline, so that allocations are
+	// never forcibly stopped. Nodes which have not drained within the
+	// DrainDeadline have their drain cancelled and are not scaled in.
+	NoForce bool
+
 	PoolIdentifier *PoolIdentifier
 	RemoteProvider RemoteProvider
 	NodeIDStrategy NodeIDStrategy
+
+	// RemoteSelector is an optional function used to choose the nodes for
+	// removal from all the nodes within the pool, allowing targets to apply
+	// remote provider constraints. If it is nil, the first Num nodes as
+	// ordered by the NodeIDStrategy are chosen.
+	RemoteSelector RemoteSelector
+}
+
+// ParseDrainConfig sets the optional drain params of the request from the
+// target config, returning an error if any of the params are malformed.
+func (sr *ScaleInReq) ParseDrainConfig(config map[string]string) error {
+
+	if v, ok := config[target.ConfigKeyDrainIgnoreSystemJobs]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as boolean", v)
+		}
+		sr.IgnoreSystemJobs = b
+	}
+
+	if v, ok := config[target.ConfigKeyDrainNoForce]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as boolean", v)
+		}
+		sr.NoForce = b
+	}
+
+	if v, ok := config[target.ConfigKeyIneligibleWait]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as time duration", v)
+		}
+		sr.IneligibleWait = d
+	}
+	return nil
 }
 
 // validate is used to ensure that ScaleInReq is correctly populated.
@@ -42,6 +96,10 @@ func (sr *ScaleInReq) validate() error {
 		err = multierror.Append(errors.New("deadline should be non-zero"), err)
 	}
 
+	if sr.IneligibleWait < 0 {
+		err = multierror.Append(errors.New("ineligible wait should not be negative"), err)
+	}
+
 	if sr.PoolIdentifier == nil {
 		err = multierror.Append(errors.New("pool identifier should be non-nil"), err)
 	}
@@ -56,3 +114,13 @@ func (sr *ScaleInReq) validate() error {
 
 	return err.ErrorOrNil()
 }
+
+// drainOptions returns the options used to drain the nodes of the request.
+func (sr *ScaleInReq) drainOptions() DrainOptions {
+	return DrainOptions{
+		Deadline:         sr.DrainDeadline,
+		IgnoreSystemJobs: sr.IgnoreSystemJobs,
+		IneligibleWait:   sr.IneligibleWait,
+		NoForce:          sr.NoForce,
+	}
+}
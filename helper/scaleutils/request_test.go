@@ -110,3 +110,50 @@ func TestScaleInReq_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestScaleInReq_ParseDrainConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutputReq   *ScaleInReq
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig:         map[string]string{},
+			expectedOutputReq:   &ScaleInReq{},
+			expectedOutputError: nil,
+			name:                "no drain options",
+		},
+		{
+			inputConfig: map[string]string{
+				"node_drain_ignore_system_jobs": "true",
+				"node_drain_no_force":           "true",
+				"node_ineligible_wait":          "5m",
+			},
+			expectedOutputReq:   &ScaleInReq{IgnoreSystemJobs: true, NoForce: true, IneligibleWait: 5 * time.Minute},
+			expectedOutputError: nil,
+			name:                "all drain options",
+		},
+		{
+			inputConfig:         map[string]string{"node_drain_ignore_system_jobs": "log shippers"},
+			expectedOutputReq:   &ScaleInReq{},
+			expectedOutputError: errors.New("failed to parse \"log shippers\" as boolean"),
+			name:                "malformed ignore system jobs",
+		},
+		{
+			inputConfig:         map[string]string{"node_ineligible_wait": "a while"},
+			expectedOutputReq:   &ScaleInReq{},
+			expectedOutputError: errors.New("failed to parse \"a while\" as time duration"),
+			name:                "malformed ineligible wait",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualReq := &ScaleInReq{}
+			actualErr := actualReq.ParseDrainConfig(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutputError, actualErr, tc.name)
+			assert.Equal(t, tc.expectedOutputReq, actualReq, tc.name)
+		})
+	}
+}
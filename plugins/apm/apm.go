@@ -1,7 +1,9 @@
 package apm
 
 import (
+	"context"
 	"net/rpc"
+	"time"
 
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/plugins/base"
@@ -13,6 +15,41 @@ type APM interface {
 	SetConfig(config map[string]string) error
 }
 
+// ContextAPM is an optional extension of the APM interface. APM plugins which
+// implement it receive the context of the policy evaluation performing the
+// query, allowing them to stop work once the evaluation is cancelled or its
+// timeout is reached.
+type ContextAPM interface {
+	APM
+	QueryContext(ctx context.Context, q string) (float64, error)
+}
+
+// QueryWithContext performs the query using the passed context. If the APM
+// does not implement the ContextAPM interface, the call returns once the
+// context is done without waiting for the query to finish.
+func QueryWithContext(ctx context.Context, a APM, q string) (float64, error) {
+	if c, ok := a.(ContextAPM); ok {
+		return c.QueryContext(ctx, q)
+	}
+
+	var resp float64
+	err := base.RunWithContext(ctx, func() error {
+		v, err := a.Query(q)
+		resp = v
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp, nil
+}
+
+// Assert that RPC meets the ContextAPM and base.HealthChecker interfaces.
+var (
+	_ ContextAPM         = (*RPC)(nil)
+	_ base.HealthChecker = (*RPC)(nil)
+)
+
 // RPC is a plugin implementation that talks over net/rpc
 type RPC struct {
 	client *rpc.Client
@@ -36,6 +73,34 @@ func (r *RPC) Query(q string) (float64, error) {
 	return resp, nil
 }
 
+// RPCQueryRequest is the request used when performing a query with a context
+// via RPC. The context deadline is sent so the plugin can honour it.
+type RPCQueryRequest struct {
+	Query    string
+	Deadline time.Time
+}
+
+func (r *RPC) QueryContext(ctx context.Context, q string) (float64, error) {
+	var resp float64
+	req := RPCQueryRequest{Query: q, Deadline: base.Deadline(ctx)}
+
+	err := base.CallContext(ctx, r.client, "Plugin.QueryContext", req, &resp)
+	if base.IsRPCMethodNotFound(err) {
+
+		// The plugin was built against a version of the interface without
+		// context support, so fallback to the standard query.
+		err = base.RunWithContext(ctx, func() error {
+			v, err := r.Query(q)
+			resp = v
+			return err
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+	return resp, nil
+}
+
 func (r *RPC) PluginInfo() (*base.PluginInfo, error) {
 	var resp base.PluginInfo
 	err := r.client.Call("Plugin.PluginInfo", new(interface{}), &resp)
@@ -45,11 +110,19 @@ func (r *RPC) PluginInfo() (*base.PluginInfo, error) {
 	return &resp, nil
 }
 
+func (r *RPC) HealthCheck(ctx context.Context) error {
+	return base.HealthCheckRPC(ctx, r.client)
+}
+
 // RPCServer is the net/rpc server
 type RPCServer struct {
 	Impl APM
 }
 
+func (s *RPCServer) HealthCheck(req base.RPCHealthCheckRequest, _ *struct{}) error {
+	return base.HealthCheckRPCServer(req, s.Impl)
+}
+
 func (s *RPCServer) SetConfig(config map[string]string, resp *error) error {
 	err := s.Impl.SetConfig(config)
 	*resp = err
@@ -65,6 +138,18 @@ func (s *RPCServer) Query(q string, resp *float64) error {
 	return nil
 }
 
+func (s *RPCServer) QueryContext(req RPCQueryRequest, resp *float64) error {
+	ctx, cancel := base.ContextWithDeadline(req.Deadline)
+	defer cancel()
+
+	r, err := QueryWithContext(ctx, s.Impl, req.Query)
+	if err != nil {
+		return err
+	}
+	*resp = r
+	return nil
+}
+
 func (s *RPCServer) PluginInfo(_ interface{}, r *base.PluginInfo) error {
 	resp, err := s.Impl.PluginInfo()
 	if resp != nil {
@@ -0,0 +1,193 @@
+package apm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+)
+
+// Assert that QueryCache meets the ContextAPM and base.HealthChecker
+// interfaces.
+var (
+	_ ContextAPM         = (*QueryCache)(nil)
+	_ base.HealthChecker = (*QueryCache)(nil)
+)
+
+// QueryCache wraps an APM implementation, caching the results of queries for
+// the configured TTL. Queries are cached individually, keyed by the query
+// string, so identical queries issued by different policies or checks share a
+// single result. Callers requesting a query which is currently in-flight wait
+// for, and share, the result of that call rather than sending a duplicate
+// request to the APM.
+//
+// Errors are never cached, but are returned to all callers waiting on the
+// in-flight request which produced them.
+//
+// The request to the APM is shared, and so is not bound to the context of any
+// single caller. Instead it is bound by the query timeout, and cancelled once
+// the cache is closed.
+type QueryCache struct {
+	impl    APM
+	ttl     time.Duration
+	timeout time.Duration
+
+	// ctx is the parent context of all APM requests, cancelled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// now is used to retrieve the current time and allows tests to control
+	// cache entry expiry.
+	now func() time.Time
+
+	entriesLock sync.Mutex
+	entries     map[string]*queryCacheEntry
+}
+
+// queryCacheEntry is an individual query cache entry. The done channel is
+// closed once the query has returned and the value, err and expires fields
+// are populated.
+type queryCacheEntry struct {
+	done    chan struct{}
+	value   float64
+	err     error
+	expires time.Time
+}
+
+// defaultQueryCacheTimeout is the maximum duration of a request to the APM
+// made by the QueryCache, matching the default policy evaluation timeout.
+const defaultQueryCacheTimeout = 5 * time.Minute
+
+// NewQueryCache returns a new QueryCache wrapping the passed APM. A TTL of
+// zero disables result caching, but still coalesces concurrent identical
+// queries.
+func NewQueryCache(impl APM, ttl time.Duration) *QueryCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &QueryCache{
+		impl:    impl,
+		ttl:     ttl,
+		timeout: defaultQueryCacheTimeout,
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+		entries: make(map[string]*queryCacheEntry),
+	}
+}
+
+// Close cancels any in-flight requests to the APM. It should be called once
+// the cache is no longer used.
+func (c *QueryCache) Close() {
+	c.cancel()
+}
+
+// SetConfig satisfies the SetConfig function on the base.Plugin interface. Any
+// cached results are purged as they may no longer reflect the configured APM.
+func (c *QueryCache) SetConfig(config map[string]string) error {
+	c.entriesLock.Lock()
+	c.entries = make(map[string]*queryCacheEntry)
+	c.entriesLock.Unlock()
+	return c.impl.SetConfig(config)
+}
+
+// PluginInfo satisfies the PluginInfo function on the base.Plugin interface.
+func (c *QueryCache) PluginInfo() (*base.PluginInfo, error) {
+	return c.impl.PluginInfo()
+}
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. Health checks are never cached.
+func (c *QueryCache) HealthCheck(ctx context.Context) error {
+	return base.HealthCheck(ctx, c.impl)
+}
+
+// Query satisfies the Query function on the APM interface.
+func (c *QueryCache) Query(q string) (float64, error) {
+	return c.QueryContext(context.Background(), q)
+}
+
+// QueryContext satisfies the QueryContext function on the ContextAPM
+// interface. The request to the APM is run independently of the callers, each
+// of which stops waiting for the result once their own context is done.
+func (c *QueryCache) QueryContext(ctx context.Context, q string) (float64, error) {
+
+	c.entriesLock.Lock()
+
+	// If we have an entry for this query it is either in-flight, in which
+	// case we wait for the result, or complete. Completed entries are only
+	// used if they have not expired.
+	if entry, ok := c.entries[q]; ok {
+		select {
+		case <-entry.done:
+			if entry.err == nil && c.now().Before(entry.expires) {
+				c.entriesLock.Unlock()
+				return entry.value, nil
+			}
+		default:
+			c.entriesLock.Unlock()
+			return entry.wait(ctx)
+		}
+	}
+
+	// Queries are removed from policies over time, so use this opportunity to
+	// remove any expired entries to ensure the cache does not grow unbounded.
+	c.purgeExpiredLocked()
+
+	// Create a new entry, marking the query as in-flight so that any other
+	// callers wait for our result.
+	entry := &queryCacheEntry{done: make(chan struct{})}
+	c.entries[q] = entry
+	c.entriesLock.Unlock()
+
+	go c.run(q, entry)
+
+	return entry.wait(ctx)
+}
+
+// run performs the query against the APM, populating the entry with the
+// result.
+func (c *QueryCache) run(q string, entry *queryCacheEntry) {
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	defer cancel()
+
+	entry.value, entry.err = QueryWithContext(ctx, c.impl, q)
+	entry.expires = c.now().Add(c.ttl)
+	close(entry.done)
+
+	// Remove entries which should not be served to future callers. Ensure we
+	// only remove our own entry, as SetConfig may have purged the cache while
+	// the query was in-flight.
+	if entry.err != nil || c.ttl <= 0 {
+		c.entriesLock.Lock()
+		if c.entries[q] == entry {
+			delete(c.entries, q)
+		}
+		c.entriesLock.Unlock()
+	}
+}
+
+// wait waits for the result of the entry, or for the context to be done.
+func (e *queryCacheEntry) wait(ctx context.Context) (float64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-e.done:
+		return e.value, e.err
+	}
+}
+
+// purgeExpiredLocked removes all completed entries which have expired. The
+// caller must hold the entriesLock.
+func (c *QueryCache) purgeExpiredLocked() {
+	now := c.now()
+	for q, entry := range c.entries {
+		select {
+		case <-entry.done:
+			if !now.Before(entry.expires) {
+				delete(c.entries, q)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package apm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAPM is a simple APM implementation used to track the number of queries
+// which reach the underlying plugin.
+type testAPM struct {
+	calls   int32
+	value   float64
+	err     error
+	blockCh chan struct{}
+}
+
+func (t *testAPM) Query(_ string) (float64, error) {
+	atomic.AddInt32(&t.calls, 1)
+	if t.blockCh != nil {
+		<-t.blockCh
+	}
+	return t.value, t.err
+}
+
+func (t *testAPM) PluginInfo() (*base.PluginInfo, error) {
+	return &base.PluginInfo{Name: "test", PluginType: "apm"}, nil
+}
+func (t *testAPM) SetConfig(config map[string]string) error { return nil }
+
+func TestQueryCache_Query(t *testing.T) {
+	testCases := []struct {
+		inputTTL      time.Duration
+		inputAdvance  time.Duration
+		inputErr      error
+		expectedCalls int32
+		expectedValue float64
+		expectedErr   error
+		name          string
+	}{
+		{
+			inputTTL:      time.Minute,
+			inputAdvance:  30 * time.Second,
+			expectedCalls: 1,
+			expectedValue: 13,
+			name:          "result within ttl",
+		},
+		{
+			inputTTL:      time.Minute,
+			inputAdvance:  2 * time.Minute,
+			expectedCalls: 2,
+			expectedValue: 13,
+			name:          "result expired",
+		},
+		{
+			inputTTL:      0,
+			expectedCalls: 2,
+			expectedValue: 13,
+			name:          "caching disabled",
+		},
+		{
+			inputTTL:      time.Minute,
+			inputErr:      errors.New("error"),
+			expectedCalls: 2,
+			expectedErr:   errors.New("error"),
+			name:          "errors not cached",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			now := time.Now()
+			impl := &testAPM{value: 13, err: tc.inputErr}
+			cache := NewQueryCache(impl, tc.inputTTL)
+			cache.now = func() time.Time { return now }
+
+			_, _ = cache.Query("query")
+			now = now.Add(tc.inputAdvance)
+			value, err := cache.Query("query")
+
+			if tc.expectedErr == nil {
+				assert.Equal(t, tc.expectedValue, value, tc.name)
+			}
+			assert.Equal(t, tc.expectedErr, err, tc.name)
+			assert.Equal(t, tc.expectedCalls, atomic.LoadInt32(&impl.calls), tc.name)
+		})
+	}
+}
+
+func TestQueryCache_QueryCoalesce(t *testing.T) {
+
+	impl := &testAPM{value: 13, blockCh: make(chan struct{})}
+	cache := NewQueryCache(impl, 0)
+
+	var wg sync.WaitGroup
+	results := make([]float64, 5)
+
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.Query("query")
+		}(i)
+	}
+
+	// Wait for the first query to reach the APM, then allow a short period
+	// for the other callers to find the in-flight entry before unblocking.
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&impl.calls) == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(impl.blockCh)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&impl.calls))
+	assert.Equal(t, []float64{13, 13, 13, 13, 13}, results)
+
+	// Different queries should not be coalesced.
+	_, _ = cache.Query("another-query")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&impl.calls))
+}
+
+func TestQueryCache_QueryContextCancel(t *testing.T) {
+
+	impl := &testAPM{value: 13, blockCh: make(chan struct{})}
+	cache := NewQueryCache(impl, 0)
+	defer cache.Close()
+
+	// The caller which triggers the APM request stops waiting once its
+	// context is done, without cancelling the request for other callers.
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := cache.QueryContext(ctx, "query")
+		errCh <- err
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&impl.calls) == 1 }, time.Second, 10*time.Millisecond)
+
+	resultCh := make(chan float64, 1)
+	go func() {
+		v, _ := cache.QueryContext(context.Background(), "query")
+		resultCh <- v
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+
+	close(impl.blockCh)
+	assert.Equal(t, float64(13), <-resultCh)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&impl.calls))
+}
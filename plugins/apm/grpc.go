@@ -0,0 +1,101 @@
+package apm
+
+import (
+	"context"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/apm/proto"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	baseproto "github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	"google.golang.org/grpc"
+)
+
+// Assert that GRPCClient meets the ContextAPM and base.HealthChecker
+// interfaces.
+var (
+	_ ContextAPM         = (*GRPCClient)(nil)
+	_ base.HealthChecker = (*GRPCClient)(nil)
+)
+
+// GRPCPlugin is the plugin.GRPCPlugin used to serve and consume APM plugins
+// over gRPC.
+type GRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl APM
+}
+
+func (p *GRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterAPMPluginServer(s, &GRPCServer{Impl: p.Impl})
+	return nil
+}
+
+func (GRPCPlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: proto.NewAPMPluginClient(c)}, nil
+}
+
+// GRPCClient is a plugin implementation that talks over gRPC.
+type GRPCClient struct {
+	client proto.APMPluginClient
+}
+
+func (c *GRPCClient) PluginInfo() (*base.PluginInfo, error) {
+	resp, err := c.client.PluginInfo(context.Background(), &baseproto.PluginInfoRequest{})
+	if err != nil {
+		return nil, base.ErrorFromGRPC(err)
+	}
+	return base.PluginInfoFromProto(resp), nil
+}
+
+func (c *GRPCClient) SetConfig(config map[string]string) error {
+	_, err := c.client.SetConfig(context.Background(), &baseproto.SetConfigRequest{Config: config})
+	return base.ErrorFromGRPC(err)
+}
+
+func (c *GRPCClient) Query(q string) (float64, error) {
+	return c.QueryContext(context.Background(), q)
+}
+
+func (c *GRPCClient) QueryContext(ctx context.Context, q string) (float64, error) {
+	resp, err := c.client.Query(ctx, &proto.QueryRequest{Query: q})
+	if err != nil {
+		return 0, base.ErrorFromGRPC(err)
+	}
+	return resp.GetValue(), nil
+}
+
+func (c *GRPCClient) HealthCheck(ctx context.Context) error {
+	_, err := c.client.HealthCheck(ctx, &baseproto.HealthCheckRequest{})
+	return base.HealthCheckFromGRPC(err)
+}
+
+// GRPCServer is the gRPC server.
+type GRPCServer struct {
+	Impl APM
+}
+
+func (s *GRPCServer) PluginInfo(_ context.Context, _ *baseproto.PluginInfoRequest) (*baseproto.PluginInfoResponse, error) {
+	info, err := s.Impl.PluginInfo()
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return base.PluginInfoToProto(info), nil
+}
+
+func (s *GRPCServer) SetConfig(_ context.Context, req *baseproto.SetConfigRequest) (*baseproto.SetConfigResponse, error) {
+	if err := s.Impl.SetConfig(req.GetConfig()); err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &baseproto.SetConfigResponse{}, nil
+}
+
+func (s *GRPCServer) Query(ctx context.Context, req *proto.QueryRequest) (*proto.QueryResponse, error) {
+	v, err := QueryWithContext(ctx, s.Impl, req.GetQuery())
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &proto.QueryResponse{Value: v}, nil
+}
+
+func (s *GRPCServer) HealthCheck(ctx context.Context, _ *baseproto.HealthCheckRequest) (*baseproto.HealthCheckResponse, error) {
+	return base.HealthCheckGRPCServer(ctx, s.Impl)
+}
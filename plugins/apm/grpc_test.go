@@ -0,0 +1,49 @@
+package apm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGRPCPlugin(t *testing.T) {
+	impl := &testAPM{value: 13}
+
+	client, server := plugin.TestPluginGRPCConn(t, map[string]plugin.Plugin{"apm": &GRPCPlugin{Impl: impl}})
+	defer client.Close()
+	defer server.Stop()
+
+	raw, err := client.Dispense("apm")
+	assert.Nil(t, err)
+	a := raw.(ContextAPM)
+
+	info, err := a.PluginInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, &base.PluginInfo{Name: "test", PluginType: "apm"}, info)
+
+	v, err := a.Query("query")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(13), v)
+
+	// Errors returned by the plugin should be returned to the caller as is.
+	impl.err = errors.New("failed to query")
+	_, err = a.Query("query")
+	assert.Equal(t, errors.New("failed to query"), err)
+
+	// The context of the caller should end the query, and be reported as
+	// such.
+	impl.err = nil
+	impl.blockCh = make(chan struct{})
+	defer close(impl.blockCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = a.QueryContext(ctx, "query")
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,330 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugins/apm/proto/apm.proto
+
+package proto
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	proto1 "github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type QueryRequest struct {
+	Query                string   `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueryRequest) Reset()         { *m = QueryRequest{} }
+func (m *QueryRequest) String() string { return proto.CompactTextString(m) }
+func (*QueryRequest) ProtoMessage()    {}
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_619d5ac3272000f2, []int{0}
+}
+
+func (m *QueryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryRequest.Unmarshal(m, b)
+}
+func (m *QueryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryRequest.Marshal(b, m, deterministic)
+}
+func (m *QueryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryRequest.Merge(m, src)
+}
+func (m *QueryRequest) XXX_Size() int {
+	return xxx_messageInfo_QueryRequest.Size(m)
+}
+func (m *QueryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryRequest proto.InternalMessageInfo
+
+func (m *QueryRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+type QueryResponse struct {
+	Value                float64  `protobuf:"fixed64,1,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueryResponse) Reset()         { *m = QueryResponse{} }
+func (m *QueryResponse) String() string { return proto.CompactTextString(m) }
+func (*QueryResponse) ProtoMessage()    {}
+func (*QueryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_619d5ac3272000f2, []int{1}
+}
+
+func (m *QueryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryResponse.Unmarshal(m, b)
+}
+func (m *QueryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryResponse.Marshal(b, m, deterministic)
+}
+func (m *QueryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryResponse.Merge(m, src)
+}
+func (m *QueryResponse) XXX_Size() int {
+	return xxx_messageInfo_QueryResponse.Size(m)
+}
+func (m *QueryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryResponse proto.InternalMessageInfo
+
+func (m *QueryResponse) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*QueryRequest)(nil), "hashicorp.nomad_autoscaler.plugins.apm.proto.QueryRequest")
+	proto.RegisterType((*QueryResponse)(nil), "hashicorp.nomad_autoscaler.plugins.apm.proto.QueryResponse")
+}
+
+func init() { proto.RegisterFile("plugins/apm/proto/apm.proto", fileDescriptor_619d5ac3272000f2) }
+
+var fileDescriptor_619d5ac3272000f2 = []byte{
+	// 304 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x92, 0x2e, 0xc8, 0x29, 0x4d,
+	0xcf, 0xcc, 0x2b, 0xd6, 0x4f, 0x2c, 0xc8, 0xd5, 0x2f, 0x28, 0xca, 0x2f, 0xc9, 0x07, 0xb1, 0xf4,
+	0xc0, 0x2c, 0x21, 0x9d, 0x8c, 0xc4, 0xe2, 0x8c, 0xcc, 0xe4, 0xfc, 0xa2, 0x02, 0xbd, 0xbc, 0xfc,
+	0xdc, 0xc4, 0x94, 0xf8, 0xc4, 0xd2, 0x92, 0xfc, 0xe2, 0xe4, 0xc4, 0x9c, 0xd4, 0x22, 0x3d, 0xa8,
+	0x3e, 0x3d, 0xb8, 0x6a, 0x29, 0x59, 0x98, 0x51, 0x49, 0x89, 0xc5, 0xa9, 0x50, 0xb3, 0x40, 0x4c,
+	0x88, 0xb4, 0x92, 0x0a, 0x17, 0x4f, 0x60, 0x69, 0x6a, 0x51, 0x65, 0x50, 0x6a, 0x61, 0x69, 0x6a,
+	0x71, 0x89, 0x90, 0x08, 0x17, 0x6b, 0x21, 0x88, 0x2f, 0xc1, 0xa8, 0xc0, 0xa8, 0xc1, 0x19, 0x04,
+	0xe1, 0x28, 0xa9, 0x72, 0xf1, 0x42, 0x55, 0x15, 0x17, 0xe4, 0xe7, 0x15, 0xa7, 0x82, 0x94, 0x95,
+	0x25, 0xe6, 0x94, 0xa6, 0x82, 0x95, 0x31, 0x06, 0x41, 0x38, 0x46, 0x97, 0x58, 0xb8, 0x38, 0x1d,
+	0x03, 0x7c, 0x03, 0xc0, 0x36, 0x0a, 0x4d, 0x66, 0xe4, 0xe2, 0x82, 0x30, 0x3d, 0xf3, 0xd2, 0xf2,
+	0x85, 0x1c, 0xf4, 0x88, 0x70, 0x37, 0xc2, 0x65, 0x7a, 0x08, 0xad, 0x50, 0xb7, 0x49, 0x39, 0x52,
+	0x60, 0x02, 0xc4, 0xdd, 0x4a, 0x0c, 0x42, 0x13, 0x18, 0xb9, 0x38, 0x83, 0x53, 0x4b, 0x9c, 0xf3,
+	0xf3, 0xd2, 0x32, 0xd3, 0x85, 0xec, 0x49, 0x34, 0x12, 0xae, 0x13, 0xe6, 0x26, 0x07, 0xf2, 0x0d,
+	0x80, 0x3b, 0x69, 0x1a, 0x23, 0x17, 0xb7, 0x47, 0x6a, 0x62, 0x4e, 0x49, 0x86, 0x73, 0x46, 0x6a,
+	0x72, 0xb6, 0x10, 0xa9, 0xfe, 0x44, 0xd2, 0x0b, 0x73, 0x96, 0x13, 0x25, 0x46, 0xc0, 0x1d, 0xd6,
+	0xc4, 0xc8, 0xc5, 0x0a, 0x8e, 0x77, 0x21, 0x2b, 0x3d, 0x52, 0x12, 0x9d, 0x1e, 0x72, 0x92, 0x92,
+	0xb2, 0x26, 0x4b, 0x2f, 0xcc, 0x11, 0x4e, 0x96, 0x51, 0xe6, 0xe9, 0x99, 0x25, 0x19, 0xa5, 0x49,
+	0x7a, 0xc9, 0xf9, 0xb9, 0xfa, 0x70, 0xa3, 0xf4, 0xc1, 0x46, 0xe9, 0x22, 0x8c, 0xd2, 0xc7, 0xc8,
+	0x33, 0x49, 0x6c, 0x60, 0xca, 0x18, 0x30, 0x00, 0x00, 0x35, 0xcf, 0xfd, 0x4f, 0x03, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// APMPluginClient is the client API for APMPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type APMPluginClient interface {
+	PluginInfo(ctx context.Context, in *proto1.PluginInfoRequest, opts ...grpc.CallOption) (*proto1.PluginInfoResponse, error)
+	SetConfig(ctx context.Context, in *proto1.SetConfigRequest, opts ...grpc.CallOption) (*proto1.SetConfigResponse, error)
+	// HealthCheck checks the health of the plugin and its dependencies. Plugins
+	// which do not implement health checks should return UNIMPLEMENTED.
+	HealthCheck(ctx context.Context, in *proto1.HealthCheckRequest, opts ...grpc.CallOption) (*proto1.HealthCheckResponse, error)
+	// Query performs the query against the APM and returns the resulting
+	// metric value.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
+}
+
+type aPMPluginClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAPMPluginClient(cc *grpc.ClientConn) APMPluginClient {
+	return &aPMPluginClient{cc}
+}
+
+func (c *aPMPluginClient) PluginInfo(ctx context.Context, in *proto1.PluginInfoRequest, opts ...grpc.CallOption) (*proto1.PluginInfoResponse, error) {
+	out := new(proto1.PluginInfoResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/PluginInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPMPluginClient) SetConfig(ctx context.Context, in *proto1.SetConfigRequest, opts ...grpc.CallOption) (*proto1.SetConfigResponse, error) {
+	out := new(proto1.SetConfigResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/SetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPMPluginClient) HealthCheck(ctx context.Context, in *proto1.HealthCheckRequest, opts ...grpc.CallOption) (*proto1.HealthCheckResponse, error) {
+	out := new(proto1.HealthCheckResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/HealthCheck", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPMPluginClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// APMPluginServer is the server API for APMPlugin service.
+type APMPluginServer interface {
+	PluginInfo(context.Context, *proto1.PluginInfoRequest) (*proto1.PluginInfoResponse, error)
+	SetConfig(context.Context, *proto1.SetConfigRequest) (*proto1.SetConfigResponse, error)
+	// HealthCheck checks the health of the plugin and its dependencies. Plugins
+	// which do not implement health checks should return UNIMPLEMENTED.
+	HealthCheck(context.Context, *proto1.HealthCheckRequest) (*proto1.HealthCheckResponse, error)
+	// Query performs the query against the APM and returns the resulting
+	// metric value.
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
+}
+
+// UnimplementedAPMPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedAPMPluginServer struct {
+}
+
+func (*UnimplementedAPMPluginServer) PluginInfo(ctx context.Context, req *proto1.PluginInfoRequest) (*proto1.PluginInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PluginInfo not implemented")
+}
+func (*UnimplementedAPMPluginServer) SetConfig(ctx context.Context, req *proto1.SetConfigRequest) (*proto1.SetConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
+}
+func (*UnimplementedAPMPluginServer) HealthCheck(ctx context.Context, req *proto1.HealthCheckRequest) (*proto1.HealthCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
+}
+func (*UnimplementedAPMPluginServer) Query(ctx context.Context, req *QueryRequest) (*QueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+
+func RegisterAPMPluginServer(s *grpc.Server, srv APMPluginServer) {
+	s.RegisterService(&_APMPlugin_serviceDesc, srv)
+}
+
+func _APMPlugin_PluginInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto1.PluginInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APMPluginServer).PluginInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/PluginInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APMPluginServer).PluginInfo(ctx, req.(*proto1.PluginInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _APMPlugin_SetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto1.SetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APMPluginServer).SetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/SetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APMPluginServer).SetConfig(ctx, req.(*proto1.SetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _APMPlugin_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto1.HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APMPluginServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/HealthCheck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APMPluginServer).HealthCheck(ctx, req.(*proto1.HealthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _APMPlugin_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APMPluginServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APMPluginServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _APMPlugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "hashicorp.nomad_autoscaler.plugins.apm.proto.APMPlugin",
+	HandlerType: (*APMPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PluginInfo",
+			Handler:    _APMPlugin_PluginInfo_Handler,
+		},
+		{
+			MethodName: "SetConfig",
+			Handler:    _APMPlugin_SetConfig_Handler,
+		},
+		{
+			MethodName: "HealthCheck",
+			Handler:    _APMPlugin_HealthCheck_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _APMPlugin_Query_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugins/apm/proto/apm.proto",
+}
@@ -1,5 +1,7 @@
 package base
 
+import "context"
+
 // Plugin is the common interface that all Autoscaler plugins should implement.
 // It defines basic functionality which helps the Autoscaler core deal with
 // plugins in a common manner.
@@ -24,3 +26,24 @@ type PluginInfo struct {
 	Name       string
 	PluginType string
 }
+
+// HealthChecker is an optional interface which plugins can implement to
+// report their health. The Autoscaler periodically calls HealthCheck and
+// skips the evaluation of policies which use an unhealthy plugin.
+type HealthChecker interface {
+
+	// HealthCheck checks the health of the plugin, including its ability to
+	// reach any remote services it depends upon. A nil error indicates the
+	// plugin is healthy.
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheck calls HealthCheck on the plugin if it implements the
+// HealthChecker interface. Plugins which do not implement the interface are
+// considered healthy.
+func HealthCheck(ctx context.Context, p interface{}) error {
+	if h, ok := p.(HealthChecker); ok {
+		return h.HealthCheck(ctx)
+	}
+	return nil
+}
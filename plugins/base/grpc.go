@@ -0,0 +1,81 @@
+package base
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PluginInfoToProto converts the PluginInfo to its protobuf representation.
+func PluginInfoToProto(info *PluginInfo) *proto.PluginInfoResponse {
+	if info == nil {
+		return &proto.PluginInfoResponse{}
+	}
+	return &proto.PluginInfoResponse{Name: info.Name, PluginType: info.PluginType}
+}
+
+// PluginInfoFromProto converts the protobuf representation of the plugin info
+// to a PluginInfo.
+func PluginInfoFromProto(resp *proto.PluginInfoResponse) *PluginInfo {
+	return &PluginInfo{Name: resp.GetName(), PluginType: resp.GetPluginType()}
+}
+
+// ErrorToGRPC converts an error returned by a plugin implementation into a
+// gRPC status error. Context errors are mapped to their gRPC codes so the
+// client can identify them.
+func ErrorToGRPC(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+// ErrorFromGRPC converts a gRPC status error returned by a plugin back into
+// the error it represents, so callers see the same errors regardless of the
+// plugin protocol in use.
+func ErrorFromGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch s.Code() {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return errors.New(s.Message())
+	}
+}
+
+// HealthCheckFromGRPC converts the error returned by a gRPC health check call.
+// Plugins which do not implement health checks are considered healthy.
+func HealthCheckFromGRPC(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	return ErrorFromGRPC(err)
+}
+
+// HealthCheckGRPCServer performs the health check of the plugin implementation
+// on behalf of a gRPC server.
+func HealthCheckGRPCServer(ctx context.Context, impl interface{}) (*proto.HealthCheckResponse, error) {
+	if err := HealthCheck(ctx, impl); err != nil {
+		return nil, ErrorToGRPC(err)
+	}
+	return &proto.HealthCheckResponse{}, nil
+}
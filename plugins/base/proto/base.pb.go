@@ -0,0 +1,275 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugins/base/proto/base.proto
+
+package proto
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// PluginInfoRequest is used to request the plugin's information.
+type PluginInfoRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PluginInfoRequest) Reset()         { *m = PluginInfoRequest{} }
+func (m *PluginInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*PluginInfoRequest) ProtoMessage()    {}
+func (*PluginInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_19edef855873449e, []int{0}
+}
+
+func (m *PluginInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginInfoRequest.Unmarshal(m, b)
+}
+func (m *PluginInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *PluginInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginInfoRequest.Merge(m, src)
+}
+func (m *PluginInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_PluginInfoRequest.Size(m)
+}
+func (m *PluginInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginInfoRequest proto.InternalMessageInfo
+
+// PluginInfoResponse returns the information used by the plugin to identify
+// itself.
+type PluginInfoResponse struct {
+	// name is the name of the plugin and must match the configured driver.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// plugin_type is the type of the plugin, such as apm, strategy or target.
+	PluginType           string   `protobuf:"bytes,2,opt,name=plugin_type,json=pluginType,proto3" json:"plugin_type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PluginInfoResponse) Reset()         { *m = PluginInfoResponse{} }
+func (m *PluginInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*PluginInfoResponse) ProtoMessage()    {}
+func (*PluginInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_19edef855873449e, []int{1}
+}
+
+func (m *PluginInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginInfoResponse.Unmarshal(m, b)
+}
+func (m *PluginInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginInfoResponse.Marshal(b, m, deterministic)
+}
+func (m *PluginInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginInfoResponse.Merge(m, src)
+}
+func (m *PluginInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_PluginInfoResponse.Size(m)
+}
+func (m *PluginInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginInfoResponse proto.InternalMessageInfo
+
+func (m *PluginInfoResponse) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PluginInfoResponse) GetPluginType() string {
+	if m != nil {
+		return m.PluginType
+	}
+	return ""
+}
+
+// SetConfigRequest is used to set the plugin specific configuration.
+type SetConfigRequest struct {
+	Config               map[string]string `protobuf:"bytes,1,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SetConfigRequest) Reset()         { *m = SetConfigRequest{} }
+func (m *SetConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*SetConfigRequest) ProtoMessage()    {}
+func (*SetConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_19edef855873449e, []int{2}
+}
+
+func (m *SetConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetConfigRequest.Unmarshal(m, b)
+}
+func (m *SetConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *SetConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetConfigRequest.Merge(m, src)
+}
+func (m *SetConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_SetConfigRequest.Size(m)
+}
+func (m *SetConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetConfigRequest proto.InternalMessageInfo
+
+func (m *SetConfigRequest) GetConfig() map[string]string {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+// SetConfigResponse is returned by SetConfig.
+type SetConfigResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetConfigResponse) Reset()         { *m = SetConfigResponse{} }
+func (m *SetConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*SetConfigResponse) ProtoMessage()    {}
+func (*SetConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_19edef855873449e, []int{3}
+}
+
+func (m *SetConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetConfigResponse.Unmarshal(m, b)
+}
+func (m *SetConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *SetConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetConfigResponse.Merge(m, src)
+}
+func (m *SetConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_SetConfigResponse.Size(m)
+}
+func (m *SetConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetConfigResponse proto.InternalMessageInfo
+
+// HealthCheckRequest is used to request the plugin checks its health.
+type HealthCheckRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthCheckRequest) Reset()         { *m = HealthCheckRequest{} }
+func (m *HealthCheckRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthCheckRequest) ProtoMessage()    {}
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_19edef855873449e, []int{4}
+}
+
+func (m *HealthCheckRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthCheckRequest.Unmarshal(m, b)
+}
+func (m *HealthCheckRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthCheckRequest.Marshal(b, m, deterministic)
+}
+func (m *HealthCheckRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthCheckRequest.Merge(m, src)
+}
+func (m *HealthCheckRequest) XXX_Size() int {
+	return xxx_messageInfo_HealthCheckRequest.Size(m)
+}
+func (m *HealthCheckRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthCheckRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthCheckRequest proto.InternalMessageInfo
+
+// HealthCheckResponse is returned by a healthy plugin. Unhealthy plugins
+// return an error describing the problem.
+type HealthCheckResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthCheckResponse) Reset()         { *m = HealthCheckResponse{} }
+func (m *HealthCheckResponse) String() string { return proto.CompactTextString(m) }
+func (*HealthCheckResponse) ProtoMessage()    {}
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_19edef855873449e, []int{5}
+}
+
+func (m *HealthCheckResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthCheckResponse.Unmarshal(m, b)
+}
+func (m *HealthCheckResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthCheckResponse.Marshal(b, m, deterministic)
+}
+func (m *HealthCheckResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthCheckResponse.Merge(m, src)
+}
+func (m *HealthCheckResponse) XXX_Size() int {
+	return xxx_messageInfo_HealthCheckResponse.Size(m)
+}
+func (m *HealthCheckResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthCheckResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthCheckResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*PluginInfoRequest)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.PluginInfoRequest")
+	proto.RegisterType((*PluginInfoResponse)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.PluginInfoResponse")
+	proto.RegisterType((*SetConfigRequest)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.SetConfigRequest")
+	proto.RegisterMapType((map[string]string)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.SetConfigRequest.ConfigEntry")
+	proto.RegisterType((*SetConfigResponse)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.SetConfigResponse")
+	proto.RegisterType((*HealthCheckRequest)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.HealthCheckRequest")
+	proto.RegisterType((*HealthCheckResponse)(nil), "hashicorp.nomad_autoscaler.plugins.base.proto.HealthCheckResponse")
+}
+
+func init() { proto.RegisterFile("plugins/base/proto/base.proto", fileDescriptor_19edef855873449e) }
+
+var fileDescriptor_19edef855873449e = []byte{
+	// 286 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0xc1, 0x4b, 0xc3, 0x30,
+	0x14, 0xc6, 0xe9, 0xa6, 0x03, 0x5f, 0x2f, 0x33, 0x9b, 0x50, 0x04, 0x71, 0xf4, 0xb4, 0xcb, 0x52,
+	0xd0, 0xcb, 0xdc, 0xd1, 0x21, 0x38, 0xbc, 0x48, 0xf5, 0xe4, 0x65, 0xa4, 0xf1, 0xad, 0x2d, 0x6b,
+	0x93, 0xd8, 0xa4, 0x42, 0xff, 0x2d, 0xff, 0x42, 0x59, 0xd2, 0xb9, 0xaa, 0xa7, 0x9d, 0xfa, 0xbd,
+	0x5f, 0x5f, 0xbe, 0xbc, 0xef, 0x05, 0xae, 0x54, 0x51, 0xa7, 0xb9, 0xd0, 0x51, 0xc2, 0x34, 0x46,
+	0xaa, 0x92, 0x46, 0x5a, 0x49, 0xad, 0x24, 0xb3, 0x8c, 0xe9, 0x2c, 0xe7, 0xb2, 0x52, 0x54, 0xc8,
+	0x92, 0xbd, 0xaf, 0x59, 0x6d, 0xa4, 0xe6, 0xac, 0xc0, 0x8a, 0xb6, 0x27, 0xe9, 0xa1, 0x3d, 0x1c,
+	0xc1, 0xf9, 0xb3, 0xa5, 0x2b, 0xb1, 0x91, 0x31, 0x7e, 0xd4, 0xa8, 0x4d, 0xb8, 0x02, 0xd2, 0x85,
+	0x5a, 0x49, 0xa1, 0x91, 0x10, 0x38, 0x11, 0xac, 0xc4, 0xc0, 0x9b, 0x78, 0xd3, 0xb3, 0xd8, 0x6a,
+	0x72, 0x0d, 0xbe, 0x33, 0x5d, 0x9b, 0x46, 0x61, 0xd0, 0xb3, 0xbf, 0xc0, 0xa1, 0xd7, 0x46, 0x61,
+	0xf8, 0xe5, 0xc1, 0xf0, 0x05, 0xcd, 0x52, 0x8a, 0x4d, 0x9e, 0xb6, 0xfe, 0x84, 0xc3, 0x80, 0x5b,
+	0x10, 0x78, 0x93, 0xfe, 0xd4, 0xbf, 0x79, 0xa2, 0x47, 0x0d, 0x4d, 0xff, 0x1a, 0x52, 0x57, 0x3d,
+	0x08, 0x53, 0x35, 0x71, 0x6b, 0x7d, 0x79, 0x07, 0x7e, 0x07, 0x93, 0x21, 0xf4, 0xb7, 0xd8, 0xb4,
+	0xc3, 0xef, 0x24, 0x19, 0xc3, 0xe9, 0x27, 0x2b, 0xea, 0xfd, 0xd4, 0xae, 0x58, 0xf4, 0xe6, 0xde,
+	0x6e, 0x29, 0x9d, 0x2b, 0x5c, 0xfc, 0x70, 0x0c, 0xe4, 0x11, 0x59, 0x61, 0xb2, 0x65, 0x86, 0x7c,
+	0xbb, 0x5f, 0xd5, 0x05, 0x8c, 0x7e, 0x51, 0xd7, 0x7c, 0xbf, 0x78, 0x9b, 0xa7, 0xb9, 0xc9, 0xea,
+	0x84, 0x72, 0x59, 0x46, 0x3f, 0xe9, 0x22, 0x9b, 0x6e, 0x76, 0x48, 0x17, 0xfd, 0x7f, 0xcc, 0x64,
+	0x60, 0x3f, 0xb7, 0xdf, 0x03, 0x00, 0xa4, 0xcc, 0x9d, 0x82, 0xe9, 0x01, 0x00, 0x00,
+}
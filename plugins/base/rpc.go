@@ -0,0 +1,100 @@
+package base
+
+import (
+	"context"
+	"net/rpc"
+	"strings"
+	"time"
+)
+
+// rpcMethodNotFoundPrefix is the error prefix returned by the net/rpc server
+// when the called method is not registered. This occurs when calling an
+// external plugin compiled against an older version of the plugin interfaces.
+const rpcMethodNotFoundPrefix = "rpc: can't find method "
+
+// CallContext performs the net/rpc call, returning early if the context is
+// done before the call completes. net/rpc does not support cancellation, so
+// the call continues within the plugin and its result is discarded.
+func CallContext(ctx context.Context, c *rpc.Client, method string, args, reply interface{}) error {
+	call := c.Go(method, args, reply, make(chan *rpc.Call, 1))
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+		return call.Error
+	}
+}
+
+// IsRPCMethodNotFound returns whether the error indicates the remote plugin
+// does not implement the called method.
+func IsRPCMethodNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := err.(rpc.ServerError)
+	return ok && strings.HasPrefix(err.Error(), rpcMethodNotFoundPrefix)
+}
+
+// ContextWithDeadline builds a context from a deadline which has been sent
+// across the plugin boundary. A zero deadline indicates the caller did not
+// have a deadline and results in a context which is only cancelled by calling
+// the returned CancelFunc.
+func ContextWithDeadline(deadline time.Time) (context.Context, context.CancelFunc) {
+	if deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+// Deadline returns the deadline of the context, or the zero time if the
+// context does not have one, so it can be sent across the plugin boundary.
+func Deadline(ctx context.Context) time.Time {
+	d, _ := ctx.Deadline()
+	return d
+}
+
+// RunWithContext runs f in a separate routine, returning early if the context
+// is done before f completes. It is used to call plugins which do not support
+// contexts, so that callers are not blocked by a plugin which does not return.
+// If the context is done first, f continues to run and its error is
+// discarded.
+func RunWithContext(ctx context.Context, f func() error) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- f() }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errCh:
+		return err
+	}
+}
+
+// RPCHealthCheckRequest is the request used when performing a plugin health
+// check via RPC. The context deadline is sent so the plugin can honour it.
+type RPCHealthCheckRequest struct {
+	Deadline time.Time
+}
+
+// HealthCheckRPC performs a health check of the plugin via RPC. Plugins built
+// against a version of the interfaces without health checks are considered
+// healthy.
+func HealthCheckRPC(ctx context.Context, c *rpc.Client) error {
+	req := RPCHealthCheckRequest{Deadline: Deadline(ctx)}
+
+	err := CallContext(ctx, c, "Plugin.HealthCheck", req, new(struct{}))
+	if IsRPCMethodNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// HealthCheckRPCServer performs the health check of the plugin implementation
+// on behalf of an RPC server.
+func HealthCheckRPCServer(req RPCHealthCheckRequest, impl interface{}) error {
+	ctx, cancel := ContextWithDeadline(req.Deadline)
+	defer cancel()
+
+	return HealthCheck(ctx, impl)
+}
@@ -0,0 +1,80 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"net/rpc"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRPCMethodNotFound(t *testing.T) {
+	testCases := []struct {
+		inputErr       error
+		expectedOutput bool
+		name           string
+	}{
+		{
+			inputErr:       nil,
+			expectedOutput: false,
+			name:           "nil error",
+		},
+		{
+			inputErr:       rpc.ServerError("rpc: can't find method Plugin.QueryContext"),
+			expectedOutput: true,
+			name:           "method not found server error",
+		},
+		{
+			inputErr:       rpc.ServerError("failed to query"),
+			expectedOutput: false,
+			name:           "other server error",
+		},
+		{
+			inputErr:       errors.New("rpc: can't find method Plugin.QueryContext"),
+			expectedOutput: false,
+			name:           "non server error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, IsRPCMethodNotFound(tc.inputErr), tc.name)
+		})
+	}
+}
+
+func TestContextWithDeadline(t *testing.T) {
+
+	// A zero deadline should result in a context without a deadline.
+	ctx, cancel := ContextWithDeadline(time.Time{})
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	assert.True(t, Deadline(ctx).IsZero())
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+
+	// A set deadline should be carried through to the context.
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel = ContextWithDeadline(deadline)
+	defer cancel()
+	assert.True(t, deadline.Equal(Deadline(ctx)))
+}
+
+func TestRunWithContext(t *testing.T) {
+
+	// The function result should be returned if it completes first.
+	err := RunWithContext(context.Background(), func() error { return errors.New("error") })
+	assert.Equal(t, errors.New("error"), err)
+
+	// The context error should be returned if the context is done first.
+	blockCh := make(chan struct{})
+	defer close(blockCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = RunWithContext(ctx, func() error { <-blockCh; return nil })
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
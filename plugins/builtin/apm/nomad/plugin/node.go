@@ -81,9 +81,9 @@ func (a *APMPlugin) getPoolResources(id *scaleutils.PoolIdentifier) (*nodePoolRe
 
 	// Perform our node filtering so we are left with a list of nodes that form
 	// our pool and that are in the correct state.
-	nodePoolList, err := id.IdentifyNodes(nodes)
+	nodePoolList, err := id.IdentifyNodes(a.client, nodes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to identify nodes within pool")
+		return nil, fmt.Errorf("failed to identify nodes within pool: %v", err)
 	}
 	if len(nodePoolList) == 0 {
 		return nil, errors.New("no nodes identified within pool")
@@ -166,6 +166,14 @@ func parseNodePoolQuery(q string) (*nodePoolQuery, error) {
 		},
 	}
 
+	if query.poolIdentifier.IdentifierKey == scaleutils.IdentifierKeyComposite {
+		ident, err := scaleutils.ParseCompositePoolIdentifier(mainParts[1])
+		if err != nil {
+			return nil, err
+		}
+		query.poolIdentifier = ident
+	}
+
 	opMetricParts := strings.SplitN(mainParts[0], "_", 3)
 	if len(opMetricParts) != 3 {
 		return nil, fmt.Errorf("expected node_<operation>_<metric>, received %s", mainParts[0])
@@ -42,6 +42,29 @@ func Test_parseNodePoolQuery(t *testing.T) {
 			expectError: nil,
 			name:        "node percentage-allocated cpu",
 		},
+		{
+			inputQuery: "node_percentage-allocated_cpu/datacenter=hk1,node_meta.pool=game-hk/composite",
+			expectedOutputQuery: &nodePoolQuery{
+				metric: "cpu",
+				poolIdentifier: &scaleutils.PoolIdentifier{
+					IdentifierKey: "composite",
+					Value:         "datacenter=hk1,node_meta.pool=game-hk",
+					Identifiers: []*scaleutils.PoolIdentifier{
+						{IdentifierKey: scaleutils.IdentifierKeyDatacenter, Value: "hk1"},
+						{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+					},
+				},
+				operation: "percentage-allocated",
+			},
+			expectError: nil,
+			name:        "node percentage-allocated cpu composite pool",
+		},
+		{
+			inputQuery:          "node_percentage-allocated_cpu/datacenter/composite",
+			expectedOutputQuery: nil,
+			expectError:         errors.New(`invalid composite node pool identifier entry "datacenter", expected key=value`),
+			name:                "invalid composite pool",
+		},
 
 		{
 			inputQuery:          "",
@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
 
 	hclog "github.com/hashicorp/go-hclog"
@@ -37,6 +38,9 @@ type APMPlugin struct {
 	logger hclog.Logger
 }
 
+// Assert that APMPlugin meets the base.HealthChecker interface.
+var _ base.HealthChecker = (*APMPlugin)(nil)
+
 func NewNomadPlugin(log hclog.Logger) apm.APM {
 	return &APMPlugin{
 		logger: log,
@@ -59,3 +63,9 @@ func (a *APMPlugin) SetConfig(config map[string]string) error {
 func (a *APMPlugin) PluginInfo() (*base.PluginInfo, error) {
 	return pluginInfo, nil
 }
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. It confirms the Nomad API is reachable.
+func (a *APMPlugin) HealthCheck(ctx context.Context) error {
+	return nomadHelper.CheckAPI(ctx, a.client)
+}
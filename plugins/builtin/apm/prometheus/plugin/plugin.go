@@ -46,6 +46,13 @@ type APMPlugin struct {
 	logger hclog.Logger
 }
 
+// Assert that APMPlugin meets the apm.ContextAPM and base.HealthChecker
+// interfaces.
+var (
+	_ apm.ContextAPM     = (*APMPlugin)(nil)
+	_ base.HealthChecker = (*APMPlugin)(nil)
+)
+
 func NewPrometheusPlugin(log hclog.Logger) apm.APM {
 	return &APMPlugin{
 		logger: log,
@@ -84,8 +91,15 @@ func (a *APMPlugin) PluginInfo() (*base.PluginInfo, error) {
 }
 
 func (a *APMPlugin) Query(q string) (float64, error) {
+	return a.QueryContext(context.Background(), q)
+}
+
+// QueryContext satisfies the QueryContext function on the apm.ContextAPM
+// interface. The query is still bounded by the plugin timeout in case the
+// context does not have a deadline.
+func (a *APMPlugin) QueryContext(ctx context.Context, q string) (float64, error) {
 	v1api := v1.NewAPI(a.client)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	result, warnings, err := v1api.Query(ctx, q, time.Now())
@@ -115,3 +129,17 @@ func (a *APMPlugin) Query(q string) (float64, error) {
 
 	return floatVal, nil
 }
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. It confirms Prometheus is reachable by performing a trivial
+// query.
+func (a *APMPlugin) HealthCheck(ctx context.Context) error {
+	if a.client == nil {
+		return fmt.Errorf("prometheus client not configured")
+	}
+
+	if _, _, err := v1.NewAPI(a.client).Query(ctx, "1", time.Now()); err != nil {
+		return fmt.Errorf("failed to reach Prometheus: %v", err)
+	}
+	return nil
+}
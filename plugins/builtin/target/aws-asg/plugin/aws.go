@@ -9,6 +9,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws/external"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/hashicorp/nomad-autoscaler/helper/awsclient"
+	"github.com/hashicorp/nomad-autoscaler/helper/retry"
 	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
 	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 )
@@ -56,35 +58,82 @@ func (t *TargetPlugin) setupAWSClients(config map[string]string) error {
 	}
 
 	// Set up our AWS clients.
-	t.ec2 = ec2.New(cfg)
-	t.asg = autoscaling.New(cfg)
+	t.ec2 = awsclient.NewEC2(cfg)
+	t.asg = awsclient.NewAutoScaling(cfg)
 
 	return nil
 }
 
-// scaleOut updates the Auto Scaling Group desired count to match what the
-// Autoscaler has deemed required.
-func (t *TargetPlugin) scaleOut(ctx context.Context, asg *autoscaling.AutoScalingGroup, count int64) error {
+// scaleOut increases the Auto Scaling Group desired count by num capacity
+// units, and waits for the launched instances to join the Nomad cluster. Any lifecycle hook
+// configured is completed for each instance once it has joined.
+func (t *TargetPlugin) scaleOut(ctx context.Context, asg *autoscaling.AutoScalingGroup, num int64, config map[string]string) error {
+
+	joinReq, err := generateJoinReq(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate scale out request: %v", err)
+	}
+
+	desired := *asg.DesiredCapacity + num
 
 	// Create a logger for this action to pre-populate useful information we
 	// would like on all log lines.
 	log := t.logger.With("action", "scale_out", "asg_name", *asg.AutoScalingGroupName,
-		"desired_count", count)
+		"desired_count", desired)
+
+	// Record the instances which are already members of the ASG, so that
+	// those launched by this action can be identified.
+	existing := make(map[string]struct{}, len(asg.Instances))
+	for _, instance := range asg.Instances {
+		existing[*instance.InstanceId] = struct{}{}
+	}
 
 	input := autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: asg.AutoScalingGroupName,
 		AvailabilityZones:    asg.AvailabilityZones,
-		DesiredCapacity:      aws.Int64(count),
+		DesiredCapacity:      aws.Int64(desired),
 	}
 
-	// Ignore the response from Send() as its empty.
-	_, err := t.asg.UpdateAutoScalingGroupRequest(&input).Send(ctx)
+	// Ignore the response as its empty.
+	_, err = t.asg.UpdateAutoScalingGroup(ctx, &input)
 	if err != nil {
 		return fmt.Errorf("failed to update Autoscaling Group: %v", err)
 	}
 
-	if err := t.ensureASGInstancesCount(ctx, count, *asg.AutoScalingGroupName); err != nil {
-		return fmt.Errorf("failed to confirm scale out AWS AutoScaling Group: %v", err)
+	// If the ASG has a warm pool, AWS moves its instances into the ASG when
+	// the desired capacity increases, and they are then identified by their
+	// membership of the ASG like any other. Configuring the warm pool itself
+	// is left to the operator, as the AWS SDK used does not expose the warm
+	// pool API.
+	//
+	// The join deadline covers both the launching of the instances and them
+	// joining Nomad.
+	joinCtx, cancel := context.WithTimeout(ctx, joinReq.deadline)
+	defer cancel()
+
+	launched, weights, err := t.ensureInstancesLaunch(joinCtx, *asg.AutoScalingGroupName, existing, num)
+	if err != nil {
+		log.Warn("not all instances launched", "launched", len(launched), "error", err)
+	}
+
+	joined, err := t.ensureInstancesJoin(joinCtx, *asg.AutoScalingGroupName, joinReq, launched)
+	if err != nil {
+		log.Warn("not all instances joined Nomad", "joined", len(joined), "error", err)
+	}
+
+	// Instances which did not join Nomad are abandoned if held by a lifecycle
+	// hook, which causes AWS to terminate them.
+	if joinReq.lifecycleHook != "" {
+		for _, id := range missingIDs(launched, joined) {
+			if err := t.completeLifecycleAction(ctx, *asg.AutoScalingGroupName, joinReq.lifecycleHook,
+				id, lifecycleActionAbandon); err != nil {
+				log.Error("failed to abandon instance lifecycle action", "instance_id", id, "error", err)
+			}
+		}
+	}
+
+	if joinedCapacity := nodesCapacity(weights, joined); joinedCapacity < num {
+		return &partialScaleOutError{requested: num, launched: idsCapacity(weights, launched), joined: joinedCapacity}
 	}
 
 	log.Info("successfully performed and verified scaling out")
@@ -98,8 +147,15 @@ func (t *TargetPlugin) scaleIn(ctx context.Context, asg *autoscaling.AutoScaling
 		return fmt.Errorf("failed to generate scale in request: %v", err)
 	}
 
+	// Choose the nodes to remove using the current state of the ASG, so that
+	// protected instances are skipped and the zones are kept balanced.
+	scaleReq.RemoteSelector = t.instanceSelector(ctx, *asg.AutoScalingGroupName)
+
+	// If only some of the nodes drained, the drained nodes are still removed
+	// and the partial result returned once they have been.
 	ids, err := t.scaleInUtils.RunPreScaleInTasks(ctx, scaleReq)
-	if err != nil {
+	drainErr, partial := err.(*scaleutils.PartialDrainError)
+	if err != nil && !partial {
 		return fmt.Errorf("failed to perform Nomad scale in tasks: %v", err)
 	}
 
@@ -139,15 +195,19 @@ func (t *TargetPlugin) scaleIn(ctx context.Context, asg *autoscaling.AutoScaling
 	log.Info("successfully terminated EC2 instances")
 	eWriter.write(ctx, scalingEventTerminate)
 
+	if partial {
+		return drainErr
+	}
 	return nil
 }
 
 func (t *TargetPlugin) generateScaleReq(num int64, config map[string]string) (*scaleutils.ScaleInReq, error) {
 
-	// Pull the class key from the config mapping. This is a required value and
-	// we cannot scale without this.
-	class, ok := config[target.ConfigKeyClass]
-	if !ok {
+	// Identify the node pool from the config mapping. This is a required value
+	// and we cannot scale without this. The node class is the usual way of
+	// identifying the pool, and so is named when no pool is found.
+	pool := scaleutils.PoolIdentifierFromConfig(config)
+	if pool == nil {
 		return nil, fmt.Errorf("required config param %q not found", target.ConfigKeyClass)
 	}
 
@@ -163,16 +223,28 @@ func (t *TargetPlugin) generateScaleReq(num int64, config map[string]string) (*s
 		drain = d
 	}
 
-	return &scaleutils.ScaleInReq{
-		Num:           int(num),
-		DrainDeadline: drain,
-		PoolIdentifier: &scaleutils.PoolIdentifier{
-			IdentifierKey: scaleutils.IdentifierKeyClass,
-			Value:         class,
-		},
+	// The node_selection_strategy is an optional parameter, with the newest
+	// nodes being selected by default.
+	strategy, err := scaleutils.ParseNodeIDStrategy(config[target.ConfigKeyNodeSelectionStrategy])
+	if err != nil {
+		return nil, err
+	}
+
+	req := scaleutils.ScaleInReq{
+		Num:            int(num),
+		DrainDeadline:  drain,
+		PoolIdentifier: pool,
 		RemoteProvider: scaleutils.RemoteProviderAWSInstanceID,
-		NodeIDStrategy: scaleutils.IDStrategyNewestCreateIndex,
-	}, nil
+		NodeIDStrategy: strategy,
+	}
+
+	// The drain options are optional parameters, with the nodes being drained
+	// immediately and forcibly once the drain deadline is reached by default.
+	if err := req.ParseDrainConfig(config); err != nil {
+		return nil, err
+	}
+
+	return &req, nil
 }
 
 func (t *TargetPlugin) detachInstances(ctx context.Context, asgName *string, instanceIDs []string) error {
@@ -183,7 +255,7 @@ func (t *TargetPlugin) detachInstances(ctx context.Context, asgName *string, ins
 		ShouldDecrementDesiredCapacity: aws.Bool(true),
 	}
 
-	asgResp, err := t.asg.DetachInstancesRequest(&asgInput).Send(ctx)
+	asgResp, err := t.asg.DetachInstances(ctx, &asgInput)
 	if err != nil {
 		return fmt.Errorf("failed to detach intances from Autoscaling Group: %v", err)
 	}
@@ -211,7 +283,7 @@ func (t *TargetPlugin) terminateInstances(ctx context.Context, instanceIDs []str
 
 	// TODO(jrasell) the response includes information about instance status
 	//  changes which we may want to validate in the future.
-	_, err := t.ec2.TerminateInstancesRequest(&ec2Input).Send(ctx)
+	_, err := t.ec2.TerminateInstances(ctx, &ec2Input)
 	if err != nil {
 		return fmt.Errorf("failed to terminate EC2 intances: %v", err)
 	}
@@ -225,11 +297,25 @@ func (t *TargetPlugin) terminateInstances(ctx context.Context, instanceIDs []str
 	return err
 }
 
+// checkAWSCredentials performs a cheap, read-only AWS call to confirm the
+// configured credentials are valid.
+func (t *TargetPlugin) checkAWSCredentials(ctx context.Context) error {
+	if t.asg == nil {
+		return fmt.Errorf("AWS clients not configured")
+	}
+
+	_, err := t.asg.DescribeAccountLimits(ctx, &autoscaling.DescribeAccountLimitsInput{})
+	if err != nil {
+		return fmt.Errorf("failed to validate AWS credentials: %v", err)
+	}
+	return nil
+}
+
 func (t *TargetPlugin) describeASG(ctx context.Context, asgName string) (*autoscaling.AutoScalingGroup, error) {
 
 	input := autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []string{asgName}}
 
-	resp, err := t.asg.DescribeAutoScalingGroupsRequest(&input).Send(ctx)
+	resp, err := t.asg.DescribeAutoScalingGroups(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -250,7 +336,7 @@ func (t *TargetPlugin) describeActivities(ctx context.Context, asgName string, i
 		input.ActivityIds = ids
 	}
 
-	resp, err := t.asg.DescribeScalingActivitiesRequest(&input).Send(ctx)
+	resp, err := t.asg.DescribeScalingActivities(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -291,7 +377,7 @@ func (t *TargetPlugin) ensureActivitiesComplete(ctx context.Context, ids []strin
 		return false, fmt.Errorf("waiting for %v activities to finish", len(ids))
 	}
 
-	return retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+	return retry.Retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
 }
 
 func (t *TargetPlugin) ensureInstancesTerminate(ctx context.Context, ids []string) error {
@@ -300,7 +386,7 @@ func (t *TargetPlugin) ensureInstancesTerminate(ctx context.Context, ids []strin
 
 		input := ec2.DescribeInstanceStatusInput{InstanceIds: ids}
 
-		resp, err := t.ec2.DescribeInstanceStatusRequest(&input).Send(ctx)
+		resp, err := t.ec2.DescribeInstanceStatus(ctx, &input)
 		if err != nil {
 			return true, err
 		}
@@ -322,22 +408,5 @@ func (t *TargetPlugin) ensureInstancesTerminate(ctx context.Context, ids []strin
 		return false, fmt.Errorf("waiting for %v instances to terminate", len(ids))
 	}
 
-	return retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
-}
-
-func (t *TargetPlugin) ensureASGInstancesCount(ctx context.Context, desired int64, asgName string) error {
-
-	f := func(ctx context.Context) (bool, error) {
-		asg, err := t.describeASG(ctx, asgName)
-		if err != nil {
-			return true, err
-		}
-
-		if len(asg.Instances) == int(desired) {
-			return true, nil
-		}
-		return false, fmt.Errorf("AutoScaling Group at %v instances of desired %v", asg.Instances, desired)
-	}
-
-	return retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+	return retry.Retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
 }
@@ -61,6 +61,29 @@ func TestTargetPlugin_generateScaleReq(t *testing.T) {
 			expectedOutputError: nil,
 			name:                "drain_deadline not specified within config",
 		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"datacenter":     "hk1",
+				"node_meta.pool": "game-hk",
+			},
+			expectedOutputReq: &scaleutils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 15 * time.Minute,
+				PoolIdentifier: &scaleutils.PoolIdentifier{
+					IdentifierKey: scaleutils.IdentifierKeyComposite,
+					Value:         "datacenter=hk1,node_meta.pool=game-hk",
+					Identifiers: []*scaleutils.PoolIdentifier{
+						{IdentifierKey: scaleutils.IdentifierKeyDatacenter, Value: "hk1"},
+						{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+					},
+				},
+				RemoteProvider: scaleutils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: scaleutils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "pool identified by datacenter and node meta",
+		},
 		{
 			inputNum: 2,
 			inputConfig: map[string]string{
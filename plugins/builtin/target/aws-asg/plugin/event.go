@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/awsclient"
 )
 
 // scalingEvent represents an individual task within a long running cluster
@@ -33,12 +34,12 @@ const (
 
 type eventWriter struct {
 	logger  hclog.Logger
-	asg     *autoscaling.Client
+	asg     awsclient.AutoScaling
 	ids     []string
 	asgName string
 }
 
-func newEventWriter(log hclog.Logger, asgClient *autoscaling.Client, ids []string, asg string) *eventWriter {
+func newEventWriter(log hclog.Logger, asgClient awsclient.AutoScaling, ids []string, asg string) *eventWriter {
 	return &eventWriter{
 		logger:  log,
 		asg:     asgClient,
@@ -58,7 +59,7 @@ func (e *eventWriter) write(ctx context.Context, event scalingEvent) {
 	// reconciliation, but not necessarily scaling actions. This could fail if
 	// the AWS credentials are missing the autoscaling:CreateOrUpdateTags IAM
 	// action.
-	if _, err := e.asg.CreateOrUpdateTagsRequest(&input).Send(ctx); err != nil {
+	if _, err := e.asg.CreateOrUpdateTags(ctx, &input); err != nil {
 		e.logger.Error("failed to update AutoScaling Group tag", "error", err, "event", event)
 	}
 	e.logger.Trace("successfully updated AutoScaling Group tag", "event", event)
@@ -0,0 +1,225 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// lifecycleActionContinue and lifecycleActionAbandon are the results used
+	// when completing the lifecycle action of an instance.
+	lifecycleActionContinue = "CONTINUE"
+	lifecycleActionAbandon  = "ABANDON"
+
+	// defaultNodeJoinDeadline is the time allowed for instances to launch and
+	// join Nomad when not specified by an operator.
+	defaultNodeJoinDeadline = 10 * time.Minute
+)
+
+// joinPollInterval is the interval at which the ASG and Nomad are checked
+// while waiting for instances to launch and join.
+var joinPollInterval = 10 * time.Second
+
+// joinReq describes how the instances launched when scaling out are confirmed
+// to have joined Nomad.
+type joinReq struct {
+
+	// pool identifies the Nomad nodes the instances join as, using the node
+	// class, datacenter, meta or attributes. If it is nil, joining is not
+	// verified.
+	pool *scaleutils.PoolIdentifier
+
+	// lifecycleHook is the name of the ASG launch lifecycle hook to complete
+	// once each instance has joined. It is optional.
+	lifecycleHook string
+
+	// deadline is the time allowed for the instances to launch and join.
+	deadline time.Duration
+}
+
+// partialScaleOutError is returned when less capacity than requested has
+// launched and joined Nomad before the deadline. The capacity is in capacity
+// units, which are instances unless the ASG uses instance weights.
+type partialScaleOutError struct {
+	requested, launched, joined int64
+}
+
+func (e *partialScaleOutError) Error() string {
+	return fmt.Sprintf("scale out partially completed: %v of %v requested capacity units launched and %v joined Nomad",
+		e.launched, e.requested, e.joined)
+}
+
+func generateJoinReq(config map[string]string) (*joinReq, error) {
+
+	req := joinReq{
+		pool:          scaleutils.PoolIdentifierFromConfig(config),
+		lifecycleHook: config[configKeyLifecycleHookName],
+		deadline:      defaultNodeJoinDeadline,
+	}
+
+	if deadlineString, ok := config[configKeyNodeJoinDeadline]; ok {
+		d, err := time.ParseDuration(deadlineString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration", deadlineString)
+		}
+		req.deadline = d
+	}
+
+	// Completing the lifecycle action of an instance which has not joined
+	// Nomad would defeat the purpose of the hook.
+	if req.lifecycleHook != "" && req.pool == nil {
+		return nil, fmt.Errorf("required config param %q not found, and is needed by %q",
+			target.ConfigKeyClass, configKeyLifecycleHookName)
+	}
+	return &req, nil
+}
+
+// ensureInstancesLaunch waits for instances, not within existing, totalling
+// num capacity units to be added to the ASG. The instances found are returned
+// along with their weights, even if they total less than num when the context
+// is done.
+func (t *TargetPlugin) ensureInstancesLaunch(ctx context.Context, asgName string, existing map[string]struct{}, num int64) ([]string, map[string]int64, error) {
+
+	var (
+		launched []string
+		weights  map[string]int64
+	)
+
+	for {
+		asg, err := t.describeASG(ctx, asgName)
+		if err != nil {
+			t.logger.Warn("failed to describe AutoScaling Group", "error", err)
+		} else if w, err := instanceWeights(asg.Instances); err != nil {
+			t.logger.Warn("failed to read AutoScaling Group instance weights", "error", err)
+		} else {
+			launched, weights = newInstanceIDs(asg.Instances, existing), w
+		}
+
+		capacity := idsCapacity(weights, launched)
+		if capacity >= num {
+			return launched, weights, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return launched, weights, fmt.Errorf("%v of %v capacity units launched: %v", capacity, num, ctx.Err())
+		case <-time.After(joinPollInterval):
+		}
+	}
+}
+
+// ensureInstancesJoin waits for the instances to join Nomad as ready nodes of
+// the expected pool, completing the lifecycle action of each as it joins.
+// The instances which joined are returned, even if not all have when the
+// context is done.
+func (t *TargetPlugin) ensureInstancesJoin(ctx context.Context, asgName string, req *joinReq, instanceIDs []string) ([]scaleutils.NodeID, error) {
+
+	// Without a pool, joining cannot be verified so the instances are
+	// assumed to have joined.
+	if req.pool == nil {
+		t.logger.Debug("not verifying instances joined Nomad as no node pool is configured")
+
+		var out []scaleutils.NodeID
+		for _, id := range instanceIDs {
+			out = append(out, scaleutils.NodeID{RemoteID: id})
+		}
+		return out, nil
+	}
+
+	var joined []scaleutils.NodeID
+	completed := make(map[string]struct{})
+
+	for {
+		nodes, err := t.scaleInUtils.ReadyNodes(req.pool, scaleutils.RemoteProviderAWSInstanceID, instanceIDs)
+		if err != nil {
+			t.logger.Warn("failed to read ready Nomad nodes", "error", err)
+		} else {
+			joined = nodes
+		}
+
+		// Complete the lifecycle action of each newly joined instance, so AWS
+		// moves it into service.
+		for _, node := range joined {
+			if _, ok := completed[node.RemoteID]; ok || req.lifecycleHook == "" {
+				continue
+			}
+			if err := t.completeLifecycleAction(ctx, asgName, req.lifecycleHook,
+				node.RemoteID, lifecycleActionContinue); err != nil {
+				t.logger.Warn("failed to complete instance lifecycle action",
+					"instance_id", node.RemoteID, "error", err)
+				continue
+			}
+			t.logger.Debug("completed instance lifecycle action",
+				"instance_id", node.RemoteID, "node_id", node.NomadID)
+			completed[node.RemoteID] = struct{}{}
+		}
+
+		if len(joined) >= len(instanceIDs) {
+			return joined, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return joined, fmt.Errorf("%v of %v instances joined Nomad: %v", len(joined), len(instanceIDs), ctx.Err())
+		case <-time.After(joinPollInterval):
+		}
+	}
+}
+
+func (t *TargetPlugin) completeLifecycleAction(ctx context.Context, asgName, hook, instanceID, result string) error {
+
+	input := autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String(asgName),
+		InstanceId:            aws.String(instanceID),
+		LifecycleActionResult: aws.String(result),
+		LifecycleHookName:     aws.String(hook),
+	}
+
+	_, err := t.asg.CompleteLifecycleAction(ctx, &input)
+	return err
+}
+
+// newInstanceIDs returns the IDs of the instances which are not within
+// existing.
+func newInstanceIDs(instances []autoscaling.Instance, existing map[string]struct{}) []string {
+	var out []string
+	for _, instance := range instances {
+		if _, ok := existing[*instance.InstanceId]; !ok {
+			out = append(out, *instance.InstanceId)
+		}
+	}
+	return out
+}
+
+// idsCapacity returns the capacity units of the instances, using the weights
+// returned by instanceWeights.
+func idsCapacity(weights map[string]int64, instanceIDs []string) int64 {
+	var out int64
+	for _, id := range instanceIDs {
+		out += weights[id]
+	}
+	return out
+}
+
+// missingIDs returns the instance IDs which have not joined Nomad.
+func missingIDs(instanceIDs []string, joined []scaleutils.NodeID) []string {
+
+	joinedIDs := make(map[string]struct{}, len(joined))
+	for _, node := range joined {
+		joinedIDs[node.RemoteID] = struct{}{}
+	}
+
+	var out []string
+	for _, id := range instanceIDs {
+		if _, ok := joinedIDs[id]; !ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_generateJoinReq(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutputReq   *joinReq
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig:         map[string]string{},
+			expectedOutputReq:   &joinReq{deadline: 10 * time.Minute},
+			expectedOutputError: nil,
+			name:                "empty config",
+		},
+		{
+			inputConfig: map[string]string{
+				"node_class":                  "high-memory",
+				"aws_asg_lifecycle_hook_name": "nomad-join",
+				"node_join_deadline":          "5m",
+			},
+			expectedOutputReq: &joinReq{
+				pool:          &scaleutils.PoolIdentifier{IdentifierKey: scaleutils.IdentifierKeyClass, Value: "high-memory"},
+				lifecycleHook: "nomad-join",
+				deadline:      5 * time.Minute,
+			},
+			expectedOutputError: nil,
+			name:                "full config",
+		},
+		{
+			inputConfig: map[string]string{
+				"node_join_deadline": "soon",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("failed to parse \"soon\" as time duration"),
+			name:                "malformed node_join_deadline config value",
+		},
+		{
+			inputConfig: map[string]string{
+				"aws_asg_lifecycle_hook_name": "nomad-join",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("required config param \"node_class\" not found, and is needed by \"aws_asg_lifecycle_hook_name\""),
+			name:                "lifecycle hook without class",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualReq, actualErr := generateJoinReq(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutputReq, actualReq, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualErr, tc.name)
+		})
+	}
+}
+
+func Test_newInstanceIDs(t *testing.T) {
+	instances := []autoscaling.Instance{
+		{InstanceId: aws.String("i-1")},
+		{InstanceId: aws.String("i-2")},
+		{InstanceId: aws.String("i-3")},
+	}
+
+	existing := map[string]struct{}{"i-2": {}}
+	assert.Equal(t, []string{"i-1", "i-3"}, newInstanceIDs(instances, existing))
+	assert.Nil(t, newInstanceIDs(instances[1:2], existing))
+}
+
+func Test_missingIDs(t *testing.T) {
+	joined := []scaleutils.NodeID{{NomadID: "node-1", RemoteID: "i-1"}}
+
+	assert.Equal(t, []string{"i-2", "i-3"}, missingIDs([]string{"i-1", "i-2", "i-3"}, joined))
+	assert.Nil(t, missingIDs([]string{"i-1"}, joined))
+}
+
+func Test_partialScaleOutError(t *testing.T) {
+	err := &partialScaleOutError{requested: 3, launched: 2, joined: 1}
+	assert.EqualError(t, err, "scale out partially completed: 2 of 3 requested capacity units launched and 1 joined Nomad")
+}
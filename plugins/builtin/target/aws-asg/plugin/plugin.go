@@ -6,8 +6,8 @@ import (
 	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/awsclient"
 	"github.com/hashicorp/nomad-autoscaler/helper/nomad"
 	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
@@ -28,6 +28,15 @@ const (
 	configKeySessionToken = "aws_session_token"
 	configKeyASGName      = "aws_asg_name"
 
+	// configKeyLifecycleHookName is the target config key naming an ASG
+	// launch lifecycle hook, which is completed for each new instance once
+	// it has joined Nomad.
+	configKeyLifecycleHookName = "aws_asg_lifecycle_hook_name"
+
+	// configKeyNodeJoinDeadline is the target config key which sets the time
+	// allowed for new instances to launch and join Nomad when scaling out.
+	configKeyNodeJoinDeadline = "node_join_deadline"
+
 	// configValues are the default values used when a configuration key is not
 	// supplied by the operator that are specific to the plugin.
 	configValueRegionDefault = "us-east-1"
@@ -44,15 +53,19 @@ var (
 	}
 )
 
-// Assert that TargetPlugin meets the target.Target interface.
-var _ target.Target = (*TargetPlugin)(nil)
+// Assert that TargetPlugin meets the target.ContextTarget and
+// base.HealthChecker interfaces.
+var (
+	_ target.ContextTarget = (*TargetPlugin)(nil)
+	_ base.HealthChecker   = (*TargetPlugin)(nil)
+)
 
 // TargetPlugin is the AWS ASG implementation of the target.Target interface.
 type TargetPlugin struct {
 	config       map[string]string
 	logger       hclog.Logger
-	asg          *autoscaling.Client
-	ec2          *ec2.Client
+	asg          awsclient.AutoScaling
+	ec2          awsclient.EC2
 	scaleInUtils *scaleutils.ScaleIn
 }
 
@@ -87,8 +100,20 @@ func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
 	return pluginInfo, nil
 }
 
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. It confirms the AWS credentials are valid.
+func (t *TargetPlugin) HealthCheck(ctx context.Context) error {
+	return t.checkAWSCredentials(ctx)
+}
+
 // Scale satisfies the Scale function on the target.Target interface.
 func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
+	return t.ScaleContext(context.Background(), action, config)
+}
+
+// ScaleContext satisfies the ScaleContext function on the target.ContextTarget
+// interface.
+func (t *TargetPlugin) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
 
 	// AWS can't support dry-run like Nomad, so just exit.
 	if action.Count == strategy.MetaValueDryRunCount {
@@ -100,7 +125,6 @@ func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) e
 	if !ok {
 		return fmt.Errorf("required config param %s not found", configKeyASGName)
 	}
-	ctx := context.Background()
 
 	// Describe the ASG. This serves to both validate the config value is
 	// correct and ensure the AWS client is configured correctly. The response
@@ -111,6 +135,13 @@ func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) e
 		return fmt.Errorf("failed to describe AWS Autoscaling Group: %v", err)
 	}
 
+	// The desired capacity of an ASG with instance weights is in capacity
+	// units, so the weights are needed to account for the instances removed.
+	weights, err := instanceWeights(curASG.Instances)
+	if err != nil {
+		return err
+	}
+
 	// The AWS ASG target requires different details depending on which
 	// direction we want to scale. Therefore calculate the direction and the
 	// relevant number so we can correctly perform the AWS work.
@@ -120,13 +151,26 @@ func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) e
 	case "in":
 		err = t.scaleIn(ctx, curASG, num, config)
 	case "out":
-		err = t.scaleOut(ctx, curASG, num)
+		err = t.scaleOut(ctx, curASG, num, config)
 	default:
 		t.logger.Info("scaling not required", "asg_name", asgName,
 			"current_count", *curASG.DesiredCapacity, "strategy_count", action.Count)
 		return nil
 	}
 
+	// If only some of the nodes were removed, report the count the target
+	// reached so the autoscaler can account for the nodes which were.
+	if drainErr, ok := err.(*scaleutils.PartialDrainError); ok {
+		return &target.PartialScaleError{Count: *curASG.DesiredCapacity - nodesCapacity(weights, drainErr.Drained), Err: drainErr}
+	}
+
+	// Likewise, if only some of the instances joined Nomad, the desired
+	// capacity has still been raised. Report the count reached, so that the
+	// cooldown is enforced rather than scaling out again on top.
+	if outErr, ok := err.(*partialScaleOutError); ok {
+		return &target.PartialScaleError{Count: *curASG.DesiredCapacity + outErr.joined, Err: outErr}
+	}
+
 	// If we received an error while scaling, format this with an outer message
 	// so its nice for the operators and then return any error to the caller.
 	if err != nil {
@@ -137,13 +181,18 @@ func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) e
 
 // Status satisfies the Status function on the target.Target interface.
 func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error) {
+	return t.StatusContext(context.Background(), config)
+}
+
+// StatusContext satisfies the StatusContext function on the
+// target.ContextTarget interface.
+func (t *TargetPlugin) StatusContext(ctx context.Context, config map[string]string) (*target.Status, error) {
 
 	// We cannot get the status of an ASG if we don't know its name.
 	asgName, ok := config[configKeyASGName]
 	if !ok {
 		return nil, fmt.Errorf("required config param %s not found", configKeyASGName)
 	}
-	ctx := context.Background()
 
 	asg, err := t.describeASG(ctx, asgName)
 	if err != nil {
@@ -171,13 +220,15 @@ func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error)
 	return &resp, nil
 }
 
+// calculateDirection returns the number of capacity units to add or remove,
+// along with the direction of scaling.
 func (t *TargetPlugin) calculateDirection(asgDesired, strategyDesired int64) (int64, string) {
 
 	if strategyDesired < asgDesired {
 		return asgDesired - strategyDesired, "in"
 	}
 	if strategyDesired > asgDesired {
-		return strategyDesired, "out"
+		return strategyDesired - asgDesired, "out"
 	}
 	return 0, ""
 }
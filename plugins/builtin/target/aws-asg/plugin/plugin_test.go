@@ -1,11 +1,24 @@
 package plugin
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/awsclient"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
 	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/hashicorp/nomad/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,7 +33,7 @@ func TestTargetPlugin_calculateDirection(t *testing.T) {
 		{
 			inputAsgDesired:      10,
 			inputStrategyDesired: 11,
-			expectedOutputNum:    11,
+			expectedOutputNum:    1,
 			expectedOutputString: "out",
 			name:                 "scale out desired",
 		},
@@ -120,6 +133,167 @@ func Test_processLastActivity(t *testing.T) {
 	}
 }
 
+// testNomadServer serves the Nomad node endpoints used when scaling, with a
+// ready node of the worker class for each instance of the fake ASG. Nodes
+// finish draining immediately.
+type testNomadServer struct {
+	asg     *awsclient.Fake
+	asgName string
+
+	lock     sync.Mutex
+	drained  []string
+	maxNodes int
+}
+
+// setMaxNodes limits the nodes served to those of the first max instances,
+// so that any further instances never join Nomad.
+func (s *testNomadServer) setMaxNodes(max int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.maxNodes = max
+}
+
+func (s *testNomadServer) nodes() []*api.Node {
+	g, _ := s.asg.Group(s.asgName)
+
+	s.lock.Lock()
+	instances := g.Instances
+	if s.maxNodes > 0 && len(instances) > s.maxNodes {
+		instances = instances[:s.maxNodes]
+	}
+	s.lock.Unlock()
+
+	var out []*api.Node
+	for i, instance := range instances {
+		out = append(out, &api.Node{
+			ID:                    "node-" + *instance.InstanceId,
+			NodeClass:             "worker",
+			Status:                api.NodeStatusReady,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes:            map[string]string{"unique.platform.aws.instance-id": *instance.InstanceId},
+			CreateIndex:           uint64(i + 1),
+		})
+	}
+	return out
+}
+
+func (s *testNomadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Nomad-Index", "1")
+	w.Header().Set("X-Nomad-LastContact", "0")
+
+	nodes := s.nodes()
+
+	switch {
+	case r.URL.Path == "/v1/nodes":
+		var stubs []*api.NodeListStub
+		for _, n := range nodes {
+			stubs = append(stubs, &api.NodeListStub{
+				ID:                    n.ID,
+				NodeClass:             n.NodeClass,
+				Status:                n.Status,
+				SchedulingEligibility: n.SchedulingEligibility,
+				CreateIndex:           n.CreateIndex,
+			})
+		}
+		_ = json.NewEncoder(w).Encode(stubs)
+		return
+	case strings.HasSuffix(r.URL.Path, "/drain"):
+		s.lock.Lock()
+		s.drained = append(s.drained, strings.Split(r.URL.Path, "/")[3])
+		s.lock.Unlock()
+		_ = json.NewEncoder(w).Encode(api.NodeDrainUpdateResponse{})
+		return
+	case strings.HasSuffix(r.URL.Path, "/allocations"):
+		_ = json.NewEncoder(w).Encode([]*api.Allocation{})
+		return
+	}
+
+	for _, n := range nodes {
+		if r.URL.Path == "/v1/node/"+n.ID {
+			_ = json.NewEncoder(w).Encode(n)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func newTestTargetPlugin(t *testing.T, asg *awsclient.Fake, srv *httptest.Server) *TargetPlugin {
+	scaleInUtils, err := scaleutils.NewScaleInUtils(&api.Config{Address: srv.URL}, hclog.NewNullLogger())
+	assert.Nil(t, err)
+
+	return &TargetPlugin{
+		logger:       hclog.NewNullLogger(),
+		asg:          asg,
+		ec2:          asg,
+		scaleInUtils: scaleInUtils,
+	}
+}
+
+func TestTargetPlugin_ScaleContext(t *testing.T) {
+	oldJoinPollInterval := joinPollInterval
+	defer func() { joinPollInterval = oldJoinPollInterval }()
+	joinPollInterval = 10 * time.Millisecond
+
+	asg := awsclient.NewFake()
+	asg.AddGroup("asg", []string{"zone-a", "zone-b"}, 3)
+
+	nomad := &testNomadServer{asg: asg, asgName: "asg"}
+	srv := httptest.NewServer(nomad)
+	defer srv.Close()
+
+	tp := newTestTargetPlugin(t, asg, srv)
+	ctx := context.Background()
+	config := map[string]string{
+		configKeyASGName:      "asg",
+		target.ConfigKeyClass: "worker",
+	}
+
+	// Scaling in removes the newest node from the largest zone, writing the
+	// event tags as each phase completes.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 2}, config))
+
+	g, _ := asg.Group("asg")
+	assert.Equal(t, int64(2), *g.DesiredCapacity)
+	assert.Len(t, g.Instances, 2)
+	assert.Equal(t, ec2.InstanceStateNameTerminated, asg.InstanceState("i-00000000000000003"))
+	assert.Equal(t, []string{"node-i-00000000000000003"}, nomad.drained)
+	assert.Len(t, g.Tags, 1)
+	assert.Equal(t, fmt.Sprintf("%v_1", tagKey), *g.Tags[0].Key)
+	assert.Equal(t, "terminate_i-00000000000000003", *g.Tags[0].Value)
+
+	// Scaling out waits for the new instances to join Nomad, completing
+	// their lifecycle actions.
+	asg.SetLifecycleHook("asg", "launch")
+	config[configKeyLifecycleHookName] = "launch"
+
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 4}, config))
+
+	g, _ = asg.Group("asg")
+	assert.Equal(t, int64(4), *g.DesiredCapacity)
+	assert.Len(t, g.Instances, 4)
+	for _, instance := range g.Instances {
+		assert.Equal(t, autoscaling.LifecycleStateInService, instance.LifecycleState)
+	}
+
+	status, err := tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.True(t, status.Ready)
+	assert.Equal(t, int64(4), status.Count)
+	assert.Contains(t, status.Meta, target.MetaKeyLastEvent)
+
+	// Scaling out when not all the instances join Nomad reports the count
+	// reached, so that the cooldown is still enforced.
+	delete(config, configKeyLifecycleHookName)
+	config[configKeyNodeJoinDeadline] = "200ms"
+	nomad.setMaxNodes(5)
+
+	err = tp.ScaleContext(ctx, strategy.Action{Count: 6}, config)
+	perr, ok := err.(*target.PartialScaleError)
+	if assert.True(t, ok, "unexpected error: %v", err) {
+		assert.Equal(t, int64(5), perr.Count)
+	}
+}
+
 func int64ToPtr(v int64) *int64 {
 	return &v
 }
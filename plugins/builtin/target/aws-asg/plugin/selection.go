@@ -0,0 +1,169 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+)
+
+// instanceSelector returns the scaleutils.RemoteSelector used to choose the
+// instances of the ASG to remove when scaling in. The ASG is described when
+// the selection is made, so that instance protection is current. The number
+// requested is in capacity units, which only differ from instances when the
+// ASG uses a mixed instances policy with instance weights.
+func (t *TargetPlugin) instanceSelector(ctx context.Context, asgName string) scaleutils.RemoteSelector {
+	return func(candidates []scaleutils.NodeID, num int) ([]scaleutils.NodeID, error) {
+
+		asg, err := t.describeASG(ctx, asgName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe AWS Autoscaling Group: %v", err)
+		}
+
+		weights, err := instanceWeights(asg.Instances)
+		if err != nil {
+			return nil, err
+		}
+
+		selected := selectInstances(asg.Instances, weights, candidates, num)
+		if len(selected) == 0 {
+			return nil, errors.New("no unprotected in service instances of the AutoScaling Group found")
+		}
+
+		if capacity := nodesCapacity(weights, selected); capacity < int64(num) {
+			t.logger.Warn("can only select portion of requested capacity for removal",
+				"requested", num, "available", capacity)
+		}
+		return selected, nil
+	}
+}
+
+// instanceWeights returns the weighted capacity of each instance, keyed by
+// instance ID. The desired capacity of an ASG with a mixed instances policy
+// using instance weights is in capacity units, with each instance counting
+// its weight towards it. Instances without a weight count once, so mixed
+// instance types without weights need no special handling.
+func instanceWeights(instances []autoscaling.Instance) (map[string]int64, error) {
+
+	out := make(map[string]int64, len(instances))
+
+	for _, instance := range instances {
+		if instance.WeightedCapacity == nil {
+			out[*instance.InstanceId] = 1
+			continue
+		}
+
+		weight, err := strconv.ParseInt(*instance.WeightedCapacity, 10, 64)
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("failed to parse weighted capacity %q of instance %s",
+				*instance.WeightedCapacity, *instance.InstanceId)
+		}
+		out[*instance.InstanceId] = weight
+	}
+	return out, nil
+}
+
+// nodesCapacity returns the capacity units of the nodes, using the weights
+// returned by instanceWeights. Nodes of unknown instances count once.
+func nodesCapacity(weights map[string]int64, nodes []scaleutils.NodeID) int64 {
+	var out int64
+	for _, node := range nodes {
+		if weight, ok := weights[node.RemoteID]; ok {
+			out += weight
+		} else {
+			out++
+		}
+	}
+	return out
+}
+
+// selectInstances chooses candidates for removal whose weights total at most
+// num capacity units. Only candidates which are in service instances of the
+// ASG, and are not protected from scale in, are chosen. To keep the ASG
+// balanced, each instance is chosen from the availability zone with the most
+// in service instances remaining, honouring the order of the candidates
+// within each zone. Candidates which would take the ASG below the requested
+// capacity are skipped.
+func selectInstances(instances []autoscaling.Instance, weights map[string]int64, candidates []scaleutils.NodeID, num int) []scaleutils.NodeID {
+
+	// Count the in service instances of each zone, which includes those that
+	// are protected or are not Nomad nodes of the pool.
+	zoneCounts := make(map[string]int)
+	instanceZones := make(map[string]string)
+
+	for _, instance := range instances {
+		if instance.LifecycleState != autoscaling.LifecycleStateInService {
+			continue
+		}
+		zone := *instance.AvailabilityZone
+		zoneCounts[zone]++
+
+		if instance.ProtectedFromScaleIn == nil || !*instance.ProtectedFromScaleIn {
+			instanceZones[*instance.InstanceId] = zone
+		}
+	}
+
+	// Group the eligible candidates by zone, retaining their order.
+	zoneCandidates := make(map[string][]scaleutils.NodeID)
+
+	for _, node := range candidates {
+		if zone, ok := instanceZones[node.RemoteID]; ok {
+			zoneCandidates[zone] = append(zoneCandidates[zone], node)
+		}
+	}
+
+	var out []scaleutils.NodeID
+	remaining := int64(num)
+
+	for remaining > 0 {
+		zone, ok := largestZone(zoneCounts, zoneCandidates)
+		if !ok {
+			break
+		}
+
+		// Skip the candidates of the zone which are too large to remove.
+		nodes := zoneCandidates[zone]
+		for len(nodes) > 0 && weights[nodes[0].RemoteID] > remaining {
+			nodes = nodes[1:]
+		}
+		if len(nodes) == 0 {
+			zoneCandidates[zone] = nil
+			continue
+		}
+
+		out = append(out, nodes[0])
+		remaining -= weights[nodes[0].RemoteID]
+		zoneCandidates[zone] = nodes[1:]
+		zoneCounts[zone]--
+	}
+
+	return out
+}
+
+// largestZone returns the zone with the most instances which still has
+// candidates. Ties are broken using the zone name so the choice is stable.
+func largestZone(zoneCounts map[string]int, zoneCandidates map[string][]scaleutils.NodeID) (string, bool) {
+
+	var zones []string
+	for zone, nodes := range zoneCandidates {
+		if len(nodes) > 0 {
+			zones = append(zones, zone)
+		}
+	}
+
+	if len(zones) == 0 {
+		return "", false
+	}
+
+	sort.Slice(zones, func(i, j int) bool {
+		if zoneCounts[zones[i]] != zoneCounts[zones[j]] {
+			return zoneCounts[zones[i]] > zoneCounts[zones[j]]
+		}
+		return zones[i] < zones[j]
+	})
+	return zones[0], true
+}
@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_selectInstances(t *testing.T) {
+
+	instance := func(id, zone string, state autoscaling.LifecycleState, protected bool) autoscaling.Instance {
+		return autoscaling.Instance{
+			InstanceId:           aws.String(id),
+			AvailabilityZone:     aws.String(zone),
+			LifecycleState:       state,
+			ProtectedFromScaleIn: aws.Bool(protected),
+		}
+	}
+	weighted := func(instance autoscaling.Instance, weight string) autoscaling.Instance {
+		instance.WeightedCapacity = aws.String(weight)
+		return instance
+	}
+	node := func(id string) scaleutils.NodeID {
+		return scaleutils.NodeID{NomadID: "node-" + id, RemoteID: id}
+	}
+
+	testCases := []struct {
+		inputInstances  []autoscaling.Instance
+		inputCandidates []scaleutils.NodeID
+		inputNum        int
+		expectedOutput  []scaleutils.NodeID
+		name            string
+	}{
+		{
+			inputInstances: []autoscaling.Instance{
+				instance("i-1", "us-east-1a", autoscaling.LifecycleStateInService, false),
+				instance("i-2", "us-east-1a", autoscaling.LifecycleStateInService, false),
+				instance("i-3", "us-east-1a", autoscaling.LifecycleStateInService, false),
+				instance("i-4", "us-east-1b", autoscaling.LifecycleStateInService, false),
+			},
+			inputCandidates: []scaleutils.NodeID{node("i-4"), node("i-3"), node("i-2"), node("i-1")},
+			inputNum:        2,
+			expectedOutput:  []scaleutils.NodeID{node("i-3"), node("i-2")},
+			name:            "over-represented zone preferred",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				instance("i-1", "us-east-1a", autoscaling.LifecycleStateInService, false),
+				instance("i-2", "us-east-1a", autoscaling.LifecycleStateInService, false),
+				instance("i-3", "us-east-1b", autoscaling.LifecycleStateInService, false),
+				instance("i-4", "us-east-1b", autoscaling.LifecycleStateInService, false),
+			},
+			inputCandidates: []scaleutils.NodeID{node("i-4"), node("i-3"), node("i-2"), node("i-1")},
+			inputNum:        2,
+			expectedOutput:  []scaleutils.NodeID{node("i-2"), node("i-4")},
+			name:            "balanced zones alternate",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				instance("i-1", "us-east-1a", autoscaling.LifecycleStateInService, true),
+				instance("i-2", "us-east-1a", autoscaling.LifecycleStatePending, false),
+				instance("i-3", "us-east-1b", autoscaling.LifecycleStateInService, false),
+			},
+			inputCandidates: []scaleutils.NodeID{node("i-1"), node("i-2"), node("i-3"), node("i-5")},
+			inputNum:        3,
+			expectedOutput:  []scaleutils.NodeID{node("i-3")},
+			name:            "protected, pending and unknown instances skipped",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				instance("i-1", "us-east-1a", autoscaling.LifecycleStateInService, true),
+			},
+			inputCandidates: []scaleutils.NodeID{node("i-1")},
+			inputNum:        1,
+			expectedOutput:  nil,
+			name:            "all instances protected",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				weighted(instance("i-1", "us-east-1a", autoscaling.LifecycleStateInService, false), "4"),
+				weighted(instance("i-2", "us-east-1a", autoscaling.LifecycleStateInService, false), "1"),
+				weighted(instance("i-3", "us-east-1b", autoscaling.LifecycleStateInService, false), "2"),
+			},
+			inputCandidates: []scaleutils.NodeID{node("i-1"), node("i-2"), node("i-3")},
+			inputNum:        3,
+			expectedOutput:  []scaleutils.NodeID{node("i-2"), node("i-3")},
+			name:            "weighted instances exceeding capacity skipped",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			weights, err := instanceWeights(tc.inputInstances)
+			assert.Nil(t, err)
+
+			actualOutput := selectInstances(tc.inputInstances, weights, tc.inputCandidates, tc.inputNum)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+		})
+	}
+}
+
+func Test_instanceWeights(t *testing.T) {
+	testCases := []struct {
+		inputInstances []autoscaling.Instance
+		expectedOutput map[string]int64
+		expectedError  bool
+		name           string
+	}{
+		{
+			inputInstances: []autoscaling.Instance{{InstanceId: aws.String("i-1")}},
+			expectedOutput: map[string]int64{"i-1": 1},
+			name:           "no weights",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				{InstanceId: aws.String("i-1"), WeightedCapacity: aws.String("1")},
+				{InstanceId: aws.String("i-2"), WeightedCapacity: aws.String("4")},
+			},
+			expectedOutput: map[string]int64{"i-1": 1, "i-2": 4},
+			name:           "weighted instances",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				{InstanceId: aws.String("i-1"), WeightedCapacity: aws.String("1.5")},
+			},
+			expectedError: true,
+			name:          "fractional weight",
+		},
+		{
+			inputInstances: []autoscaling.Instance{
+				{InstanceId: aws.String("i-1"), WeightedCapacity: aws.String("0")},
+			},
+			expectedError: true,
+			name:          "zero weight",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, err := instanceWeights(tc.inputInstances)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedError, err != nil, tc.name)
+		})
+	}
+}
+
+func Test_nodesCapacity(t *testing.T) {
+	weights := map[string]int64{"i-1": 1, "i-2": 4}
+	nodes := []scaleutils.NodeID{{RemoteID: "i-1"}, {RemoteID: "i-2"}, {RemoteID: "i-3"}}
+
+	assert.Equal(t, int64(6), nodesCapacity(weights, nodes))
+	assert.Equal(t, int64(5), idsCapacity(weights, []string{"i-1", "i-2"}))
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	azureVMSS "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/azure-vmss/plugin"
+)
+
+func main() {
+	plugins.Serve(factory)
+}
+
+// factory returns a new instance of the Azure VMSS plugin.
+func factory(log hclog.Logger) interface{} {
+	return azureVMSS.NewAzureVMSSPlugin(log)
+}
@@ -0,0 +1,202 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+// setupAzureClients takes the passed config mapping and instantiates the
+// required Azure service clients.
+func (t *TargetPlugin) setupAzureClients(config map[string]string) error {
+
+	subscriptionID, ok := config[configKeySubscriptionID]
+	if !ok {
+		return fmt.Errorf("required config param %s not found", configKeySubscriptionID)
+	}
+
+	var (
+		authorizer autorest.Authorizer
+		err        error
+	)
+
+	// If the operator has supplied service principal credentials via the
+	// config then use these, otherwise the credentials are taken from the
+	// environment, falling back to the managed identity of the VM.
+	tenantID, tenantOK := config[configKeyTenantID]
+	clientID, clientOK := config[configKeyClientID]
+	secret, secretOK := config[configKeyClientSecret]
+
+	if tenantOK && clientOK && secretOK {
+		t.logger.Trace("setting Azure access credentials from config map")
+		authorizer, err = auth.NewClientCredentialsConfig(clientID, secret, tenantID).Authorizer()
+	} else {
+		authorizer, err = auth.NewAuthorizerFromEnvironment()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create Azure authorizer: %v", err)
+	}
+
+	vmss := compute.NewVirtualMachineScaleSetsClient(subscriptionID)
+	vmss.Authorizer = authorizer
+	t.vmss = &vmss
+
+	return nil
+}
+
+// vmssFromConfig returns the resource group and name of the VMSS identified by
+// the target config.
+func vmssFromConfig(config map[string]string) (string, string, error) {
+
+	resourceGroup, ok := config[configKeyResourceGroup]
+	if !ok {
+		return "", "", fmt.Errorf("required config param %s not found", configKeyResourceGroup)
+	}
+
+	vmssName, ok := config[configKeyVMSSName]
+	if !ok {
+		return "", "", fmt.Errorf("required config param %s not found", configKeyVMSSName)
+	}
+	return resourceGroup, vmssName, nil
+}
+
+// scaleOut updates the VMSS capacity to the desired count, and waits for the
+// update to complete.
+func (t *TargetPlugin) scaleOut(ctx context.Context, resourceGroup, vmssName string, sku *compute.Sku, desired int64) error {
+
+	// Create a logger for this action to pre-populate useful information we
+	// would like on all log lines.
+	log := t.logger.With("action", "scale_out", "vmss_name", vmssName, "desired_count", desired)
+
+	// The SKU name and tier are included as the update replaces the SKU.
+	update := compute.VirtualMachineScaleSetUpdate{
+		Sku: &compute.Sku{
+			Name:     sku.Name,
+			Tier:     sku.Tier,
+			Capacity: &desired,
+		},
+	}
+
+	future, err := t.vmss.Update(ctx, resourceGroup, vmssName, update)
+	if err != nil {
+		return fmt.Errorf("failed to update Virtual Machine Scale Set: %v", err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, t.vmss.Client); err != nil {
+		return fmt.Errorf("failed to confirm scale out Azure Virtual Machine Scale Set: %v", err)
+	}
+
+	log.Info("successfully performed and verified scaling out")
+	return nil
+}
+
+// scaleIn drains num nodes of the VMSS, before deleting their instances. The
+// capacity of the VMSS is reduced as the instances are deleted.
+func (t *TargetPlugin) scaleIn(ctx context.Context, resourceGroup, vmssName string, num int64, config map[string]string) error {
+
+	scaleReq, err := t.generateScaleReq(num, config)
+	if err != nil {
+		return fmt.Errorf("failed to generate scale in request: %v", err)
+	}
+
+	// If only some of the nodes drained, the drained nodes are still removed
+	// and the partial result returned once they have been.
+	ids, err := t.scaleInUtils.RunPreScaleInTasks(ctx, scaleReq)
+	drainErr, partial := err.(*scaleutils.PartialDrainError)
+	if err != nil && !partial {
+		return fmt.Errorf("failed to perform Nomad scale in tasks: %v", err)
+	}
+
+	var instanceIDs []string
+
+	for _, node := range ids {
+		instanceIDs = append(instanceIDs, node.RemoteID)
+	}
+
+	// Create a logger for this action to pre-populate useful information we
+	// would like on all log lines.
+	log := t.logger.With("action", "scale_in", "vmss_name", vmssName, "instances", instanceIDs)
+
+	log.Debug("deleting instances from Virtual Machine Scale Set")
+
+	future, err := t.vmss.DeleteInstances(ctx, resourceGroup, vmssName,
+		compute.VirtualMachineScaleSetVMInstanceRequiredIDs{InstanceIds: &instanceIDs})
+	if err != nil {
+		return fmt.Errorf("failed to delete instances from Virtual Machine Scale Set: %v", err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, t.vmss.Client); err != nil {
+		return fmt.Errorf("failed to confirm scale in Azure Virtual Machine Scale Set: %v", err)
+	}
+
+	log.Info("successfully deleted instances from Virtual Machine Scale Set")
+	if partial {
+		return drainErr
+	}
+	return nil
+}
+
+func (t *TargetPlugin) generateScaleReq(num int64, config map[string]string) (*scaleutils.ScaleInReq, error) {
+
+	// Identify the node pool from the config mapping. This is a required value
+	// and we cannot scale without this. The node class is the usual way of
+	// identifying the pool, and so is named when no pool is found.
+	pool := scaleutils.PoolIdentifierFromConfig(config)
+	if pool == nil {
+		return nil, fmt.Errorf("required config param %q not found", target.ConfigKeyClass)
+	}
+
+	// The drain_deadline is an optional parameter so define out default and
+	// then attempt to find an operator specified value.
+	drain := scaleutils.DefaultDrainDeadline
+
+	if drainString, ok := config[target.ConfigKeyDrainDeadline]; ok {
+		d, err := time.ParseDuration(drainString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration", drainString)
+		}
+		drain = d
+	}
+
+	// The node_selection_strategy is an optional parameter, with the newest
+	// nodes being selected by default.
+	strategy, err := scaleutils.ParseNodeIDStrategy(config[target.ConfigKeyNodeSelectionStrategy])
+	if err != nil {
+		return nil, err
+	}
+
+	req := scaleutils.ScaleInReq{
+		Num:            int(num),
+		DrainDeadline:  drain,
+		PoolIdentifier: pool,
+		RemoteProvider: scaleutils.RemoteProviderAzureInstanceID,
+		NodeIDStrategy: strategy,
+	}
+
+	// The drain options are optional parameters, with the nodes being drained
+	// immediately and forcibly once the drain deadline is reached by default.
+	if err := req.ParseDrainConfig(config); err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// checkAzureCredentials performs a cheap, read-only Azure call to confirm the
+// configured credentials are valid.
+func (t *TargetPlugin) checkAzureCredentials(ctx context.Context) error {
+	if t.vmss == nil {
+		return fmt.Errorf("Azure clients not configured")
+	}
+
+	if _, err := t.vmss.ListAll(ctx); err != nil {
+		return fmt.Errorf("failed to validate Azure credentials: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_vmssFromConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig           map[string]string
+		expectedResourceGroup string
+		expectedVMSSName      string
+		expectedOutputError   error
+		name                  string
+	}{
+		{
+			inputConfig: map[string]string{
+				"azure_resource_group": "rg",
+				"azure_vmss_name":      "nomad-client",
+			},
+			expectedResourceGroup: "rg",
+			expectedVMSSName:      "nomad-client",
+			expectedOutputError:   nil,
+			name:                  "valid config",
+		},
+		{
+			inputConfig: map[string]string{
+				"azure_vmss_name": "nomad-client",
+			},
+			expectedOutputError: errors.New("required config param azure_resource_group not found"),
+			name:                "resource group not set",
+		},
+		{
+			inputConfig: map[string]string{
+				"azure_resource_group": "rg",
+			},
+			expectedOutputError: errors.New("required config param azure_vmss_name not found"),
+			name:                "name not set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resourceGroup, vmssName, err := vmssFromConfig(tc.inputConfig)
+			assert.Equal(t, tc.expectedResourceGroup, resourceGroup, tc.name)
+			assert.Equal(t, tc.expectedVMSSName, vmssName, tc.name)
+			assert.Equal(t, tc.expectedOutputError, err, tc.name)
+		})
+	}
+}
+
+func TestTargetPlugin_generateScaleReq(t *testing.T) {
+	testCases := []struct {
+		inputNum            int64
+		inputConfig         map[string]string
+		expectedOutputReq   *scaleutils.ScaleInReq
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_deadline": "5m",
+			},
+			expectedOutputReq: &scaleutils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 5 * time.Minute,
+				PoolIdentifier: &scaleutils.PoolIdentifier{
+					IdentifierKey: scaleutils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: scaleutils.RemoteProviderAzureInstanceID,
+				NodeIDStrategy: scaleutils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with drain_deadline in config",
+		},
+		{
+			inputNum:            2,
+			inputConfig:         map[string]string{},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("required config param \"node_class\" not found"),
+			name:                "no class key found in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_deadline": "time to make a cuppa",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("failed to parse \"time to make a cuppa\" as time duration"),
+			name:                "invalid drain_deadline format",
+		},
+	}
+
+	tp := TargetPlugin{}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualReq, actualErr := tp.generateScaleReq(tc.inputNum, tc.inputConfig)
+			assert.Equal(t, tc.expectedOutputReq, actualReq, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualErr, tc.name)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-01/compute"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/nomad"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// pluginName is the unique name of the this plugin amongst Target plugins.
+	pluginName = "azure-vmss"
+
+	// configKeys represents the known configuration parameters required at
+	// varying points throughout the plugins lifecycle.
+	configKeySubscriptionID = "azure_subscription_id"
+	configKeyTenantID       = "azure_tenant_id"
+	configKeyClientID       = "azure_client_id"
+	configKeyClientSecret   = "azure_client_secret"
+	configKeyResourceGroup  = "azure_resource_group"
+	configKeyVMSSName       = "azure_vmss_name"
+
+	// provisioningStateSucceeded is the provisioning state of a VMSS which is
+	// not currently changing.
+	provisioningStateSucceeded = "Succeeded"
+)
+
+var (
+	PluginConfig = &plugins.InternalPluginConfig{
+		Factory: func(l hclog.Logger) interface{} { return NewAzureVMSSPlugin(l) },
+	}
+
+	pluginInfo = &base.PluginInfo{
+		Name:       pluginName,
+		PluginType: plugins.PluginTypeTarget,
+	}
+)
+
+// Assert that TargetPlugin meets the target.ContextTarget and
+// base.HealthChecker interfaces.
+var (
+	_ target.ContextTarget = (*TargetPlugin)(nil)
+	_ base.HealthChecker   = (*TargetPlugin)(nil)
+)
+
+// TargetPlugin is the Azure VMSS implementation of the target.Target
+// interface.
+type TargetPlugin struct {
+	config       map[string]string
+	logger       hclog.Logger
+	vmss         *compute.VirtualMachineScaleSetsClient
+	scaleInUtils *scaleutils.ScaleIn
+}
+
+// NewAzureVMSSPlugin returns the Azure VMSS implementation of the
+// target.Target interface.
+func NewAzureVMSSPlugin(log hclog.Logger) *TargetPlugin {
+	return &TargetPlugin{
+		logger: log,
+	}
+}
+
+// SetConfig satisfies the SetConfig function on the base.Plugin interface.
+func (t *TargetPlugin) SetConfig(config map[string]string) error {
+
+	t.config = config
+
+	if err := t.setupAzureClients(config); err != nil {
+		return err
+	}
+
+	utils, err := scaleutils.NewScaleInUtils(nomad.ConfigFromNamespacedMap(config), t.logger)
+	if err != nil {
+		return err
+	}
+	t.scaleInUtils = utils
+
+	return nil
+}
+
+// PluginInfo satisfies the PluginInfo function on the base.Plugin interface.
+func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
+	return pluginInfo, nil
+}
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. It confirms the Azure credentials are valid.
+func (t *TargetPlugin) HealthCheck(ctx context.Context) error {
+	return t.checkAzureCredentials(ctx)
+}
+
+// Scale satisfies the Scale function on the target.Target interface.
+func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
+	return t.ScaleContext(context.Background(), action, config)
+}
+
+// ScaleContext satisfies the ScaleContext function on the target.ContextTarget
+// interface.
+func (t *TargetPlugin) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
+
+	// Azure can't support dry-run like Nomad, so just exit.
+	if action.Count == strategy.MetaValueDryRunCount {
+		return nil
+	}
+
+	resourceGroup, vmssName, err := vmssFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// Get the VMSS. This serves to both validate the config values are
+	// correct and ensure the Azure client is configured correctly. The
+	// response can also be used when performing the scaling, meaning we only
+	// need to call it once.
+	curVMSS, err := t.vmss.Get(ctx, resourceGroup, vmssName)
+	if err != nil {
+		return fmt.Errorf("failed to get Azure Virtual Machine Scale Set: %v", err)
+	}
+
+	if curVMSS.Sku == nil || curVMSS.Sku.Capacity == nil {
+		return fmt.Errorf("Azure Virtual Machine Scale Set %s has no capacity", vmssName)
+	}
+
+	// The Azure VMSS target requires different details depending on which
+	// direction we want to scale. Therefore calculate the direction and the
+	// relevant number so we can correctly perform the Azure work.
+	num, direction := t.calculateDirection(*curVMSS.Sku.Capacity, action.Count)
+
+	switch direction {
+	case "in":
+		err = t.scaleIn(ctx, resourceGroup, vmssName, num, config)
+	case "out":
+		err = t.scaleOut(ctx, resourceGroup, vmssName, curVMSS.Sku, *curVMSS.Sku.Capacity+num)
+	default:
+		t.logger.Info("scaling not required", "vmss_name", vmssName,
+			"current_count", *curVMSS.Sku.Capacity, "strategy_count", action.Count)
+		return nil
+	}
+
+	// If only some of the nodes were removed, report the count the target
+	// reached so the autoscaler can account for the nodes which were.
+	if drainErr, ok := err.(*scaleutils.PartialDrainError); ok {
+		return &target.PartialScaleError{Count: *curVMSS.Sku.Capacity - int64(len(drainErr.Drained)), Err: drainErr}
+	}
+
+	// If we received an error while scaling, format this with an outer message
+	// so its nice for the operators and then return any error to the caller.
+	if err != nil {
+		err = fmt.Errorf("failed to perform scaling action: %v", err)
+	}
+	return err
+}
+
+// Status satisfies the Status function on the target.Target interface.
+func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error) {
+	return t.StatusContext(context.Background(), config)
+}
+
+// StatusContext satisfies the StatusContext function on the
+// target.ContextTarget interface.
+func (t *TargetPlugin) StatusContext(ctx context.Context, config map[string]string) (*target.Status, error) {
+
+	resourceGroup, vmssName, err := vmssFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	vmss, err := t.vmss.Get(ctx, resourceGroup, vmssName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure Virtual Machine Scale Set: %v", err)
+	}
+
+	if vmss.Sku == nil || vmss.Sku.Capacity == nil {
+		return nil, fmt.Errorf("Azure Virtual Machine Scale Set %s has no capacity", vmssName)
+	}
+
+	instanceView, err := t.vmss.GetInstanceView(ctx, resourceGroup, vmssName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure Virtual Machine Scale Set instance view: %v", err)
+	}
+
+	// The VMSS is only ready to be scaled when it is not currently changing.
+	resp := target.Status{
+		Ready: vmss.VirtualMachineScaleSetProperties != nil &&
+			vmss.ProvisioningState != nil &&
+			*vmss.ProvisioningState == provisioningStateSucceeded,
+		Count: *vmss.Sku.Capacity,
+		Meta:  make(map[string]string),
+	}
+
+	processInstanceView(instanceView, &resp)
+
+	return &resp, nil
+}
+
+// calculateDirection returns the number of instances to add or remove, along
+// with the direction of scaling.
+func (t *TargetPlugin) calculateDirection(vmssCapacity, strategyDesired int64) (int64, string) {
+
+	if strategyDesired < vmssCapacity {
+		return vmssCapacity - strategyDesired, "in"
+	}
+	if strategyDesired > vmssCapacity {
+		return strategyDesired - vmssCapacity, "out"
+	}
+	return 0, ""
+}
+
+// processInstanceView updates the status object based on the statuses within
+// the instance view of the VMSS. The most recent status time is the last
+// scaling activity.
+func processInstanceView(view compute.VirtualMachineScaleSetInstanceView, status *target.Status) {
+
+	if view.Statuses == nil {
+		return
+	}
+
+	var last int64
+
+	for _, s := range *view.Statuses {
+		if s.Time != nil && s.Time.UnixNano() > last {
+			last = s.Time.UnixNano()
+		}
+	}
+
+	if last > 0 {
+		status.Meta[target.MetaKeyLastEvent] = strconv.FormatInt(last, 10)
+	}
+}
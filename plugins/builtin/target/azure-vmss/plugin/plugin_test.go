@@ -0,0 +1,289 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2020-06-01/compute"
+	"github.com/Azure/go-autorest/autorest/date"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetPlugin_calculateDirection(t *testing.T) {
+	testCases := []struct {
+		inputVMSSCapacity    int64
+		inputStrategyDesired int64
+		expectedOutputNum    int64
+		expectedOutputString string
+		name                 string
+	}{
+		{
+			inputVMSSCapacity:    10,
+			inputStrategyDesired: 11,
+			expectedOutputNum:    1,
+			expectedOutputString: "out",
+			name:                 "scale out desired",
+		},
+		{
+			inputVMSSCapacity:    10,
+			inputStrategyDesired: 9,
+			expectedOutputNum:    1,
+			expectedOutputString: "in",
+			name:                 "scale in desired",
+		},
+		{
+			inputVMSSCapacity:    10,
+			inputStrategyDesired: 10,
+			expectedOutputNum:    0,
+			expectedOutputString: "",
+			name:                 "scale not desired",
+		},
+	}
+
+	tp := TargetPlugin{}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualNum, actualString := tp.calculateDirection(tc.inputVMSSCapacity, tc.inputStrategyDesired)
+			assert.Equal(t, tc.expectedOutputNum, actualNum, tc.name)
+			assert.Equal(t, tc.expectedOutputString, actualString, tc.name)
+		})
+	}
+}
+
+func Test_processInstanceView(t *testing.T) {
+
+	older := date.Time{Time: time.Date(2020, time.April, 13, 8, 4, 0, 0, time.UTC)}
+	newer := date.Time{Time: time.Date(2020, time.April, 13, 8, 5, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		inputView      compute.VirtualMachineScaleSetInstanceView
+		expectedStatus *target.Status
+		name           string
+	}{
+		{
+			inputView: compute.VirtualMachineScaleSetInstanceView{
+				Statuses: &[]compute.InstanceViewStatus{{Time: &older}, {Time: &newer}, {}},
+			},
+			expectedStatus: &target.Status{
+				Meta: map[string]string{
+					"nomad_autoscaler.last_event": "1586765100000000000",
+				},
+			},
+			name: "latest status time used",
+		},
+		{
+			inputView:      compute.VirtualMachineScaleSetInstanceView{},
+			expectedStatus: &target.Status{Meta: map[string]string{}},
+			name:           "no statuses",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := &target.Status{Meta: map[string]string{}}
+			processInstanceView(tc.inputView, status)
+			assert.Equal(t, tc.expectedStatus, status, tc.name)
+		})
+	}
+}
+
+// testServer is a stand-in for both the ARM endpoints, serving a single
+// VMSS, and the Nomad node endpoints, with a ready node of the worker class
+// for each instance of the VMSS. Changes to the VMSS and node drains complete
+// immediately.
+type testServer struct {
+	lock      sync.Mutex
+	instances []string
+	next      int
+	updated   time.Time
+	drained   []string
+}
+
+func (s *testServer) resize(size int) {
+	for len(s.instances) < size {
+		s.instances = append(s.instances, fmt.Sprint(s.next))
+		s.next++
+	}
+	s.instances = s.instances[:size]
+	s.updated = time.Now()
+}
+
+func (s *testServer) vmss() compute.VirtualMachineScaleSet {
+	capacity := int64(len(s.instances))
+	return compute.VirtualMachineScaleSet{
+		Sku: &compute.Sku{Name: stringToPtr("Standard_D2s_v3"), Tier: stringToPtr("Standard"), Capacity: &capacity},
+		VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+			ProvisioningState: stringToPtr(provisioningStateSucceeded),
+		},
+	}
+}
+
+func (s *testServer) nodes() []*api.Node {
+	var out []*api.Node
+	for i, id := range s.instances {
+		out = append(out, &api.Node{
+			ID:                    "node-" + id,
+			NodeClass:             "worker",
+			Status:                api.NodeStatusReady,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes:            map[string]string{"unique.platform.azure.name": "nomad-client_" + id},
+			CreateIndex:           uint64(i + 1),
+		})
+	}
+	return out
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	vmssPath := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/nomad-client"
+
+	switch {
+	case r.URL.Path == vmssPath && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(s.vmss())
+		return
+	case r.URL.Path == vmssPath && r.Method == http.MethodPatch:
+		var update compute.VirtualMachineScaleSetUpdate
+		_ = json.NewDecoder(r.Body).Decode(&update)
+		s.resize(int(*update.Sku.Capacity))
+		_ = json.NewEncoder(w).Encode(s.vmss())
+		return
+	case r.URL.Path == vmssPath+"/instanceView":
+		updated := date.Time{Time: s.updated}
+		_ = json.NewEncoder(w).Encode(compute.VirtualMachineScaleSetInstanceView{
+			Statuses: &[]compute.InstanceViewStatus{{
+				Code: stringToPtr("ProvisioningState/succeeded"),
+				Time: &updated,
+			}},
+		})
+		return
+	case r.URL.Path == vmssPath+"/delete":
+		var req compute.VirtualMachineScaleSetVMInstanceRequiredIDs
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		deleted := make(map[string]struct{})
+		for _, id := range *req.InstanceIds {
+			deleted[id] = struct{}{}
+		}
+
+		var remaining []string
+		for _, id := range s.instances {
+			if _, ok := deleted[id]; !ok {
+				remaining = append(remaining, id)
+			}
+		}
+		s.instances = remaining
+		s.updated = time.Now()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("X-Nomad-Index", "1")
+	w.Header().Set("X-Nomad-LastContact", "0")
+
+	nodes := s.nodes()
+
+	switch {
+	case r.URL.Path == "/v1/nodes":
+		var stubs []*api.NodeListStub
+		for _, n := range nodes {
+			stubs = append(stubs, &api.NodeListStub{
+				ID:                    n.ID,
+				NodeClass:             n.NodeClass,
+				Status:                n.Status,
+				SchedulingEligibility: n.SchedulingEligibility,
+				CreateIndex:           n.CreateIndex,
+			})
+		}
+		_ = json.NewEncoder(w).Encode(stubs)
+		return
+	case strings.HasSuffix(r.URL.Path, "/drain"):
+		s.drained = append(s.drained, strings.Split(r.URL.Path, "/")[3])
+		_ = json.NewEncoder(w).Encode(api.NodeDrainUpdateResponse{})
+		return
+	case strings.HasSuffix(r.URL.Path, "/allocations"):
+		_ = json.NewEncoder(w).Encode([]*api.Allocation{})
+		return
+	}
+
+	for _, n := range nodes {
+		if r.URL.Path == "/v1/node/"+n.ID {
+			_ = json.NewEncoder(w).Encode(n)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func TestTargetPlugin_ScaleContext(t *testing.T) {
+
+	srv := &testServer{}
+	srv.resize(3)
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	vmss := compute.NewVirtualMachineScaleSetsClientWithBaseURI(ts.URL, "sub")
+
+	scaleInUtils, err := scaleutils.NewScaleInUtils(&api.Config{Address: ts.URL}, hclog.NewNullLogger())
+	assert.Nil(t, err)
+
+	tp := &TargetPlugin{
+		logger:       hclog.NewNullLogger(),
+		vmss:         &vmss,
+		scaleInUtils: scaleInUtils,
+	}
+
+	config := map[string]string{
+		"azure_resource_group": "rg",
+		"azure_vmss_name":      "nomad-client",
+		"node_class":           "worker",
+	}
+
+	status, err := tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.True(t, status.Ready)
+	assert.Equal(t, int64(3), status.Count)
+	assert.Contains(t, status.Meta, target.MetaKeyLastEvent)
+
+	// Scaling out updates the VMSS capacity.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 5}, config))
+	assert.Len(t, srv.instances, 5)
+
+	// Scaling in drains and deletes the newest nodes.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 3}, config))
+	assert.Equal(t, []string{"0", "1", "2"}, srv.instances)
+	assert.ElementsMatch(t, []string{"node-3", "node-4"}, srv.drained)
+
+	status, err = tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), status.Count)
+
+	// Dry-run actions are ignored.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: strategy.MetaValueDryRunCount}, config))
+	assert.Len(t, srv.instances, 3)
+
+	// The resource group and name must be configured.
+	_, err = tp.StatusContext(ctx, map[string]string{"azure_vmss_name": "nomad-client"})
+	assert.NotNil(t, err)
+}
+
+func stringToPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	execTarget "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/exec/plugin"
+)
+
+func main() {
+	plugins.Serve(factory)
+}
+
+// factory returns a new instance of the exec plugin.
+func factory(log hclog.Logger) interface{} {
+	return execTarget.NewExecPlugin(log)
+}
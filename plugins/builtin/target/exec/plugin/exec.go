@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+// operations identify the request being made of a command, allowing a single
+// executable to be configured for several operations.
+const (
+	operationStatus = "status"
+	operationScale  = "scale"
+	operationHealth = "health"
+)
+
+// commandInput is written as JSON to the stdin of each command.
+type commandInput struct {
+	Operation string                `json:"operation"`
+	Config    map[string]string     `json:"config,omitempty"`
+	Action    *target.ActionPayload `json:"action,omitempty"`
+}
+
+// parseCommand splits the command configured under key into the executable
+// and its arguments. Arguments are separated by whitespace, with no support
+// for shell quoting.
+func parseCommand(config map[string]string, key string, required bool) ([]string, error) {
+	command := strings.Fields(config[key])
+	if len(command) == 0 && required {
+		return nil, fmt.Errorf("required config param %s not found", key)
+	}
+	return command, nil
+}
+
+// run runs the command with the input written to its stdin as JSON, returning
+// its stdout. Any stderr output is logged, and included in a returned error.
+func (t *TargetPlugin) run(ctx context.Context, command []string, input *commandInput) ([]byte, error) {
+
+	stdin, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode command input: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	log := t.logger.With("command", command[0], "operation", input.Operation)
+	log.Debug("running command")
+
+	err = cmd.Run()
+
+	msg := strings.TrimSpace(stderr.String())
+	if msg != "" {
+		log.Info("command wrote to stderr", "stderr", msg)
+	}
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %v", t.timeout)
+		}
+		if msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseCommand(t *testing.T) {
+	testCases := []struct {
+		inputConfig    map[string]string
+		inputRequired  bool
+		expectedOutput []string
+		expectedError  bool
+		name           string
+	}{
+		{
+			inputConfig:    map[string]string{"exec_status_command": "/usr/local/bin/pool status --pool  game "},
+			inputRequired:  true,
+			expectedOutput: []string{"/usr/local/bin/pool", "status", "--pool", "game"},
+			name:           "command with arguments",
+		},
+		{
+			inputConfig:   map[string]string{},
+			inputRequired: true,
+			expectedError: true,
+			name:          "required command missing",
+		},
+		{
+			inputConfig:   map[string]string{"exec_status_command": "  "},
+			inputRequired: true,
+			expectedError: true,
+			name:          "required command empty",
+		},
+		{
+			inputConfig:    map[string]string{},
+			inputRequired:  false,
+			expectedOutput: []string{},
+			name:           "optional command missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, err := parseCommand(tc.inputConfig, "exec_status_command", tc.inputRequired)
+			if tc.expectedError {
+				assert.NotNil(t, err, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+		})
+	}
+}
+
+func TestTargetPlugin_run(t *testing.T) {
+	tp := &TargetPlugin{logger: hclog.NewNullLogger(), timeout: time.Minute}
+	ctx := context.Background()
+
+	// The input is written to stdin as JSON.
+	out, err := tp.run(ctx, []string{"cat"}, &commandInput{Operation: operationStatus})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"operation":"status"}`, string(out))
+
+	// Stderr is included in the error of a failed command.
+	_, err = tp.run(ctx, []string{"sh", "-c", `echo "pool unreachable" >&2; exit 2`},
+		&commandInput{Operation: operationHealth})
+	assert.EqualError(t, err, "exit status 2: pool unreachable")
+
+	// Commands are killed once the timeout is reached.
+	tp.timeout = 50 * time.Millisecond
+	_, err = tp.run(ctx, []string{"sleep", "10"}, &commandInput{Operation: operationScale})
+	assert.EqualError(t, err, "timed out after 50ms")
+}
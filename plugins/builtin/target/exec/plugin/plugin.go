@@ -0,0 +1,178 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// pluginName is the unique name of the this plugin amongst Target plugins.
+	pluginName = "exec"
+
+	// configKeys represents the known configuration parameters required at
+	// varying points throughout the plugins lifecycle. The commands are only
+	// read from the plugin config so that scaling policies cannot run
+	// arbitrary executables.
+	configKeyStatusCommand = "exec_status_command"
+	configKeyScaleCommand  = "exec_scale_command"
+	configKeyHealthCommand = "exec_health_command"
+	configKeyTimeout       = "exec_timeout"
+
+	// defaultTimeout is the timeout of each command when not specified by an
+	// operator.
+	defaultTimeout = 10 * time.Minute
+)
+
+var (
+	PluginConfig = &plugins.InternalPluginConfig{
+		Factory: func(l hclog.Logger) interface{} { return NewExecPlugin(l) },
+	}
+
+	pluginInfo = &base.PluginInfo{
+		Name:       pluginName,
+		PluginType: plugins.PluginTypeTarget,
+	}
+)
+
+// Assert that TargetPlugin meets the target.ContextTarget and
+// base.HealthChecker interfaces.
+var (
+	_ target.ContextTarget = (*TargetPlugin)(nil)
+	_ base.HealthChecker   = (*TargetPlugin)(nil)
+)
+
+// TargetPlugin is the exec implementation of the target.Target interface.
+// Status and scaling requests are passed to operator provided executables,
+// allowing infrastructure the autoscaler cannot otherwise reach to be scaled.
+type TargetPlugin struct {
+	config        map[string]string
+	logger        hclog.Logger
+	statusCommand []string
+	scaleCommand  []string
+	healthCommand []string
+	timeout       time.Duration
+}
+
+// NewExecPlugin returns the exec implementation of the target.Target
+// interface.
+func NewExecPlugin(log hclog.Logger) *TargetPlugin {
+	return &TargetPlugin{
+		logger: log,
+	}
+}
+
+// SetConfig satisfies the SetConfig function on the base.Plugin interface.
+func (t *TargetPlugin) SetConfig(config map[string]string) error {
+
+	t.config = config
+
+	statusCommand, err := parseCommand(config, configKeyStatusCommand, true)
+	if err != nil {
+		return err
+	}
+
+	scaleCommand, err := parseCommand(config, configKeyScaleCommand, true)
+	if err != nil {
+		return err
+	}
+
+	healthCommand, err := parseCommand(config, configKeyHealthCommand, false)
+	if err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+
+	if v, ok := config[configKeyTimeout]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as time duration", v)
+		}
+		timeout = d
+	}
+
+	t.statusCommand = statusCommand
+	t.scaleCommand = scaleCommand
+	t.healthCommand = healthCommand
+	t.timeout = timeout
+
+	return nil
+}
+
+// PluginInfo satisfies the PluginInfo function on the base.Plugin interface.
+func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
+	return pluginInfo, nil
+}
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. If no health command is configured, the plugin is assumed
+// healthy.
+func (t *TargetPlugin) HealthCheck(ctx context.Context) error {
+	if len(t.healthCommand) == 0 {
+		return nil
+	}
+	if _, err := t.run(ctx, t.healthCommand, &commandInput{Operation: operationHealth}); err != nil {
+		return fmt.Errorf("failed to run health command: %v", err)
+	}
+	return nil
+}
+
+// Scale satisfies the Scale function on the target.Target interface.
+func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
+	return t.ScaleContext(context.Background(), action, config)
+}
+
+// ScaleContext satisfies the ScaleContext function on the target.ContextTarget
+// interface. The scale command should only exit once the scaling has
+// completed, with a non-zero exit code indicating failure.
+func (t *TargetPlugin) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
+
+	// The scale command is not expected to support dry-run like Nomad, so
+	// just exit.
+	if action.Count == strategy.MetaValueDryRunCount {
+		return nil
+	}
+
+	input := commandInput{
+		Operation: operationScale,
+		Config:    config,
+		Action:    target.NewActionPayload(action),
+	}
+
+	if _, err := t.run(ctx, t.scaleCommand, &input); err != nil {
+		return fmt.Errorf("failed to run scale command: %v", err)
+	}
+
+	t.logger.Info("successfully ran scale command", "count", action.Count,
+		"direction", action.Direction)
+	return nil
+}
+
+// Status satisfies the Status function on the target.Target interface.
+func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error) {
+	return t.StatusContext(context.Background(), config)
+}
+
+// StatusContext satisfies the StatusContext function on the
+// target.ContextTarget interface.
+func (t *TargetPlugin) StatusContext(ctx context.Context, config map[string]string) (*target.Status, error) {
+
+	out, err := t.run(ctx, t.statusCommand, &commandInput{Operation: operationStatus, Config: config})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run status command: %v", err)
+	}
+
+	var status target.StatusPayload
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode status command output: %v", err)
+	}
+	return status.Status(), nil
+}
@@ -0,0 +1,99 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/stretchr/testify/assert"
+)
+
+// testScript is a stand-in for a pool managed outside of the autoscaler. It
+// records the input of each scale request, and reports the last count scaled
+// to as the status.
+const testScript = `#!/bin/sh
+dir=$(dirname "$0")
+case "$1" in
+status)
+  cat > "$dir/status_input"
+  echo "{\"count\": $(cat "$dir/count"), \"ready\": true, \"last_event\": 10}"
+  ;;
+scale)
+  cat > "$dir/scale_input"
+  sed 's/.*"count":\([0-9]*\).*/\1/' "$dir/scale_input" > "$dir/count"
+  echo "scaled" >&2
+  ;;
+esac
+`
+
+func TestTargetPlugin_ScaleContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exec-target")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	script := filepath.Join(dir, "pool")
+	assert.Nil(t, ioutil.WriteFile(script, []byte(testScript), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "count"), []byte("2"), 0644))
+
+	tp := NewExecPlugin(hclog.NewNullLogger())
+	assert.NotNil(t, tp.SetConfig(map[string]string{"exec_status_command": script + " status"}))
+	assert.Nil(t, tp.SetConfig(map[string]string{
+		"exec_status_command": script + " status",
+		"exec_scale_command":  script + " scale",
+	}))
+
+	ctx := context.Background()
+	assert.Nil(t, tp.HealthCheck(ctx))
+
+	config := map[string]string{"pool": "bare-metal"}
+
+	status, err := tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, &target.Status{
+		Ready: true,
+		Count: 2,
+		Meta:  map[string]string{target.MetaKeyLastEvent: "10"},
+	}, status)
+
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "status_input"))
+	assert.JSONEq(t, `{"operation":"status","config":{"pool":"bare-metal"}}`, string(b))
+
+	// Scaling passes the action and config to the scale command.
+	action := strategy.Action{
+		Count:     4,
+		Direction: strategy.ScaleDirectionUp,
+		Reason:    "capacity",
+		Meta:      map[string]interface{}{"foo": "bar"},
+	}
+	assert.Nil(t, tp.ScaleContext(ctx, action, config))
+
+	var input commandInput
+	b, _ = ioutil.ReadFile(filepath.Join(dir, "scale_input"))
+	assert.Nil(t, json.Unmarshal(b, &input))
+	assert.Equal(t, commandInput{
+		Operation: operationScale,
+		Config:    config,
+		Action: &target.ActionPayload{
+			Count:     4,
+			Direction: "up",
+			Reason:    "capacity",
+			Meta:      map[string]interface{}{"foo": "bar"},
+		},
+	}, input)
+
+	status, err = tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), status.Count)
+
+	// Dry-run actions are ignored.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: strategy.MetaValueDryRunCount}, config))
+	status, err = tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), status.Count)
+}
@@ -0,0 +1,246 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/helper/retry"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	defaultRetryInterval = 10 * time.Second
+	defaultRetryLimit    = 15
+)
+
+// instanceGroup is a zonal or regional Managed Instance Group. The Compute
+// API uses separate services for each, which are otherwise equivalent.
+type instanceGroup interface {
+	name() string
+	get(ctx context.Context, service *compute.Service) (*compute.InstanceGroupManager, error)
+	resize(ctx context.Context, service *compute.Service, size int64) error
+	deleteInstances(ctx context.Context, service *compute.Service, instances []string) error
+}
+
+type zonalInstanceGroup struct {
+	project, zone, migName string
+}
+
+func (z *zonalInstanceGroup) name() string { return z.migName }
+
+func (z *zonalInstanceGroup) get(ctx context.Context, service *compute.Service) (*compute.InstanceGroupManager, error) {
+	return service.InstanceGroupManagers.Get(z.project, z.zone, z.migName).Context(ctx).Do()
+}
+
+func (z *zonalInstanceGroup) resize(ctx context.Context, service *compute.Service, size int64) error {
+	_, err := service.InstanceGroupManagers.Resize(z.project, z.zone, z.migName, size).Context(ctx).Do()
+	return err
+}
+
+func (z *zonalInstanceGroup) deleteInstances(ctx context.Context, service *compute.Service, instances []string) error {
+	req := compute.InstanceGroupManagersDeleteInstancesRequest{Instances: instances}
+	_, err := service.InstanceGroupManagers.DeleteInstances(z.project, z.zone, z.migName, &req).Context(ctx).Do()
+	return err
+}
+
+type regionalInstanceGroup struct {
+	project, region, migName string
+}
+
+func (r *regionalInstanceGroup) name() string { return r.migName }
+
+func (r *regionalInstanceGroup) get(ctx context.Context, service *compute.Service) (*compute.InstanceGroupManager, error) {
+	return service.RegionInstanceGroupManagers.Get(r.project, r.region, r.migName).Context(ctx).Do()
+}
+
+func (r *regionalInstanceGroup) resize(ctx context.Context, service *compute.Service, size int64) error {
+	_, err := service.RegionInstanceGroupManagers.Resize(r.project, r.region, r.migName, size).Context(ctx).Do()
+	return err
+}
+
+func (r *regionalInstanceGroup) deleteInstances(ctx context.Context, service *compute.Service, instances []string) error {
+	req := compute.RegionInstanceGroupManagersDeleteInstancesRequest{Instances: instances}
+	_, err := service.RegionInstanceGroupManagers.DeleteInstances(r.project, r.region, r.migName, &req).Context(ctx).Do()
+	return err
+}
+
+// instanceGroupFromConfig returns the MIG identified by the target config.
+// Exactly one of the zone or region must be set.
+func instanceGroupFromConfig(config map[string]string) (instanceGroup, error) {
+
+	for _, key := range []string{configKeyProject, configKeyMIGName} {
+		if _, ok := config[key]; !ok {
+			return nil, fmt.Errorf("required config param %s not found", key)
+		}
+	}
+
+	zone, zoneOK := config[configKeyZone]
+	region, regionOK := config[configKeyRegion]
+
+	switch {
+	case zoneOK && regionOK:
+		return nil, fmt.Errorf("only one of config params %s and %s can be set", configKeyZone, configKeyRegion)
+	case zoneOK:
+		return &zonalInstanceGroup{project: config[configKeyProject], zone: zone, migName: config[configKeyMIGName]}, nil
+	case regionOK:
+		return &regionalInstanceGroup{project: config[configKeyProject], region: region, migName: config[configKeyMIGName]}, nil
+	default:
+		return nil, fmt.Errorf("required config param %s or %s not found", configKeyZone, configKeyRegion)
+	}
+}
+
+// setupGCEClients takes the passed config mapping and instantiates the
+// required GCE service clients.
+func (t *TargetPlugin) setupGCEClients(config map[string]string) error {
+
+	// Without configured credentials, the client uses the application default
+	// credentials such as those of the instance service account.
+	var opts []option.ClientOption
+
+	if credentials, ok := config[configKeyCredentials]; ok {
+		t.logger.Trace("setting GCE credentials from config map")
+		opts = append(opts, option.WithCredentialsJSON([]byte(credentials)))
+	}
+
+	service, err := compute.NewService(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create GCE client: %v", err)
+	}
+	t.service = service
+
+	return nil
+}
+
+// scaleOut resizes the MIG to the desired size, and waits for the instances to
+// be created.
+func (t *TargetPlugin) scaleOut(ctx context.Context, mig instanceGroup, desired int64) error {
+
+	// Create a logger for this action to pre-populate useful information we
+	// would like on all log lines.
+	log := t.logger.With("action", "scale_out", "mig_name", mig.name(), "desired_count", desired)
+
+	if err := mig.resize(ctx, t.service, desired); err != nil {
+		return fmt.Errorf("failed to resize Managed Instance Group: %v", err)
+	}
+
+	if err := t.ensureMIGStable(ctx, mig); err != nil {
+		return fmt.Errorf("failed to confirm scale out GCE Managed Instance Group: %v", err)
+	}
+
+	log.Info("successfully performed and verified scaling out")
+	return nil
+}
+
+// scaleIn drains num nodes of the MIG, before deleting their instances. The
+// target size of the MIG is reduced as each instance is deleted.
+func (t *TargetPlugin) scaleIn(ctx context.Context, mig instanceGroup, num int64, config map[string]string) error {
+
+	scaleReq, err := t.generateScaleReq(num, config)
+	if err != nil {
+		return fmt.Errorf("failed to generate scale in request: %v", err)
+	}
+
+	// If only some of the nodes drained, the drained nodes are still removed
+	// and the partial result returned once they have been.
+	ids, err := t.scaleInUtils.RunPreScaleInTasks(ctx, scaleReq)
+	drainErr, partial := err.(*scaleutils.PartialDrainError)
+	if err != nil && !partial {
+		return fmt.Errorf("failed to perform Nomad scale in tasks: %v", err)
+	}
+
+	// The remote IDs are the partial instance URLs accepted by the Compute
+	// API when deleting instances.
+	var instances []string
+
+	for _, node := range ids {
+		instances = append(instances, node.RemoteID)
+	}
+
+	// Create a logger for this action to pre-populate useful information we
+	// would like on all log lines.
+	log := t.logger.With("action", "scale_in", "mig_name", mig.name(), "instances", instances)
+
+	log.Debug("deleting instances from Managed Instance Group")
+
+	if err := mig.deleteInstances(ctx, t.service, instances); err != nil {
+		return fmt.Errorf("failed to delete instances from Managed Instance Group: %v", err)
+	}
+
+	if err := t.ensureMIGStable(ctx, mig); err != nil {
+		return fmt.Errorf("failed to confirm scale in GCE Managed Instance Group: %v", err)
+	}
+
+	log.Info("successfully deleted instances from Managed Instance Group")
+	if partial {
+		return drainErr
+	}
+	return nil
+}
+
+func (t *TargetPlugin) generateScaleReq(num int64, config map[string]string) (*scaleutils.ScaleInReq, error) {
+
+	// Identify the node pool from the config mapping. This is a required value
+	// and we cannot scale without this. The node class is the usual way of
+	// identifying the pool, and so is named when no pool is found.
+	pool := scaleutils.PoolIdentifierFromConfig(config)
+	if pool == nil {
+		return nil, fmt.Errorf("required config param %q not found", target.ConfigKeyClass)
+	}
+
+	// The drain_deadline is an optional parameter so define out default and
+	// then attempt to find an operator specified value.
+	drain := scaleutils.DefaultDrainDeadline
+
+	if drainString, ok := config[target.ConfigKeyDrainDeadline]; ok {
+		d, err := time.ParseDuration(drainString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration", drainString)
+		}
+		drain = d
+	}
+
+	// The node_selection_strategy is an optional parameter, with the newest
+	// nodes being selected by default.
+	strategy, err := scaleutils.ParseNodeIDStrategy(config[target.ConfigKeyNodeSelectionStrategy])
+	if err != nil {
+		return nil, err
+	}
+
+	req := scaleutils.ScaleInReq{
+		Num:            int(num),
+		DrainDeadline:  drain,
+		PoolIdentifier: pool,
+		RemoteProvider: scaleutils.RemoteProviderGCEInstanceID,
+		NodeIDStrategy: strategy,
+	}
+
+	// The drain options are optional parameters, with the nodes being drained
+	// immediately and forcibly once the drain deadline is reached by default.
+	if err := req.ParseDrainConfig(config); err != nil {
+		return nil, err
+	}
+
+	return &req, nil
+}
+
+// ensureMIGStable waits for the MIG to finish creating or deleting instances.
+func (t *TargetPlugin) ensureMIGStable(ctx context.Context, mig instanceGroup) error {
+
+	f := func(ctx context.Context) (bool, error) {
+		curMIG, err := mig.get(ctx, t.service)
+		if err != nil {
+			return true, err
+		}
+
+		if curMIG.Status != nil && curMIG.Status.IsStable {
+			return true, nil
+		}
+		return false, fmt.Errorf("waiting for Managed Instance Group to become stable")
+	}
+
+	return retry.Retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+}
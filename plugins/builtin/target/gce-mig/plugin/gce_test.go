@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_instanceGroupFromConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutput      instanceGroup
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig: map[string]string{
+				"gce_project":  "project",
+				"gce_zone":     "us-central1-a",
+				"gce_mig_name": "nomad-client",
+			},
+			expectedOutput:      &zonalInstanceGroup{project: "project", zone: "us-central1-a", migName: "nomad-client"},
+			expectedOutputError: nil,
+			name:                "zonal MIG",
+		},
+		{
+			inputConfig: map[string]string{
+				"gce_project":  "project",
+				"gce_region":   "us-central1",
+				"gce_mig_name": "nomad-client",
+			},
+			expectedOutput:      &regionalInstanceGroup{project: "project", region: "us-central1", migName: "nomad-client"},
+			expectedOutputError: nil,
+			name:                "regional MIG",
+		},
+		{
+			inputConfig: map[string]string{
+				"gce_project":  "project",
+				"gce_zone":     "us-central1-a",
+				"gce_region":   "us-central1",
+				"gce_mig_name": "nomad-client",
+			},
+			expectedOutput:      nil,
+			expectedOutputError: errors.New("only one of config params gce_zone and gce_region can be set"),
+			name:                "zone and region set",
+		},
+		{
+			inputConfig: map[string]string{
+				"gce_project":  "project",
+				"gce_mig_name": "nomad-client",
+			},
+			expectedOutput:      nil,
+			expectedOutputError: errors.New("required config param gce_zone or gce_region not found"),
+			name:                "zone or region not set",
+		},
+		{
+			inputConfig: map[string]string{
+				"gce_project": "project",
+				"gce_zone":    "us-central1-a",
+			},
+			expectedOutput:      nil,
+			expectedOutputError: errors.New("required config param gce_mig_name not found"),
+			name:                "name not set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualError := instanceGroupFromConfig(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+		})
+	}
+}
+
+func TestTargetPlugin_generateScaleReq(t *testing.T) {
+	testCases := []struct {
+		inputNum            int64
+		inputConfig         map[string]string
+		expectedOutputReq   *scaleutils.ScaleInReq
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_deadline": "5m",
+			},
+			expectedOutputReq: &scaleutils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 5 * time.Minute,
+				PoolIdentifier: &scaleutils.PoolIdentifier{
+					IdentifierKey: scaleutils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: scaleutils.RemoteProviderGCEInstanceID,
+				NodeIDStrategy: scaleutils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with drain_deadline in config",
+		},
+		{
+			inputNum:            2,
+			inputConfig:         map[string]string{},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("required config param \"node_class\" not found"),
+			name:                "no class key found in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_deadline": "time to make a cuppa",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("failed to parse \"time to make a cuppa\" as time duration"),
+			name:                "invalid drain_deadline format",
+		},
+	}
+
+	tp := TargetPlugin{}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualReq, actualErr := tp.generateScaleReq(tc.inputNum, tc.inputConfig)
+			assert.Equal(t, tc.expectedOutputReq, actualReq, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualErr, tc.name)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/nomad"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	compute "google.golang.org/api/compute/v1"
+)
+
+const (
+	// pluginName is the unique name of the this plugin amongst Target plugins.
+	pluginName = "gce-mig"
+
+	// configKeys represents the known configuration parameters required at
+	// varying points throughout the plugins lifecycle.
+	configKeyCredentials = "gce_credentials"
+	configKeyProject     = "gce_project"
+	configKeyRegion      = "gce_region"
+	configKeyZone        = "gce_zone"
+	configKeyMIGName     = "gce_mig_name"
+)
+
+var (
+	PluginConfig = &plugins.InternalPluginConfig{
+		Factory: func(l hclog.Logger) interface{} { return NewGCEMIGPlugin(l) },
+	}
+
+	pluginInfo = &base.PluginInfo{
+		Name:       pluginName,
+		PluginType: plugins.PluginTypeTarget,
+	}
+)
+
+// Assert that TargetPlugin meets the target.ContextTarget and
+// base.HealthChecker interfaces.
+var (
+	_ target.ContextTarget = (*TargetPlugin)(nil)
+	_ base.HealthChecker   = (*TargetPlugin)(nil)
+)
+
+// TargetPlugin is the GCE MIG implementation of the target.Target interface.
+type TargetPlugin struct {
+	config       map[string]string
+	logger       hclog.Logger
+	service      *compute.Service
+	scaleInUtils *scaleutils.ScaleIn
+}
+
+// NewGCEMIGPlugin returns the GCE MIG implementation of the target.Target
+// interface.
+func NewGCEMIGPlugin(log hclog.Logger) *TargetPlugin {
+	return &TargetPlugin{
+		logger: log,
+	}
+}
+
+// SetConfig satisfies the SetConfig function on the base.Plugin interface.
+func (t *TargetPlugin) SetConfig(config map[string]string) error {
+
+	t.config = config
+
+	if err := t.setupGCEClients(config); err != nil {
+		return err
+	}
+
+	utils, err := scaleutils.NewScaleInUtils(nomad.ConfigFromNamespacedMap(config), t.logger)
+	if err != nil {
+		return err
+	}
+	t.scaleInUtils = utils
+
+	return nil
+}
+
+// PluginInfo satisfies the PluginInfo function on the base.Plugin interface.
+func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
+	return pluginInfo, nil
+}
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. The Compute API calls are all scoped to a project, which is only
+// known from the target config, so this confirms the client is configured.
+func (t *TargetPlugin) HealthCheck(_ context.Context) error {
+	if t.service == nil {
+		return fmt.Errorf("GCE client not configured")
+	}
+	return nil
+}
+
+// Scale satisfies the Scale function on the target.Target interface.
+func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
+	return t.ScaleContext(context.Background(), action, config)
+}
+
+// ScaleContext satisfies the ScaleContext function on the target.ContextTarget
+// interface.
+func (t *TargetPlugin) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
+
+	// GCE can't support dry-run like Nomad, so just exit.
+	if action.Count == strategy.MetaValueDryRunCount {
+		return nil
+	}
+
+	mig, err := instanceGroupFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// Get the MIG. This serves to both validate the config values are
+	// correct and ensure the GCE client is configured correctly. The response
+	// can also be used when performing the scaling, meaning we only need to
+	// call it once.
+	curMIG, err := mig.get(ctx, t.service)
+	if err != nil {
+		return fmt.Errorf("failed to get GCE Managed Instance Group: %v", err)
+	}
+
+	// The GCE MIG target requires different details depending on which
+	// direction we want to scale. Therefore calculate the direction and the
+	// relevant number so we can correctly perform the GCE work.
+	num, direction := t.calculateDirection(curMIG.TargetSize, action.Count)
+
+	switch direction {
+	case "in":
+		err = t.scaleIn(ctx, mig, num, config)
+	case "out":
+		err = t.scaleOut(ctx, mig, curMIG.TargetSize+num)
+	default:
+		t.logger.Info("scaling not required", "mig_name", mig.name(),
+			"current_count", curMIG.TargetSize, "strategy_count", action.Count)
+		return nil
+	}
+
+	// If only some of the nodes were removed, report the count the target
+	// reached so the autoscaler can account for the nodes which were.
+	if drainErr, ok := err.(*scaleutils.PartialDrainError); ok {
+		return &target.PartialScaleError{Count: curMIG.TargetSize - int64(len(drainErr.Drained)), Err: drainErr}
+	}
+
+	// If we received an error while scaling, format this with an outer message
+	// so its nice for the operators and then return any error to the caller.
+	if err != nil {
+		err = fmt.Errorf("failed to perform scaling action: %v", err)
+	}
+	return err
+}
+
+// Status satisfies the Status function on the target.Target interface.
+func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error) {
+	return t.StatusContext(context.Background(), config)
+}
+
+// StatusContext satisfies the StatusContext function on the
+// target.ContextTarget interface.
+func (t *TargetPlugin) StatusContext(ctx context.Context, config map[string]string) (*target.Status, error) {
+
+	mig, err := instanceGroupFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	curMIG, err := mig.get(ctx, t.service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GCE Managed Instance Group: %v", err)
+	}
+
+	// The MIG is only ready to be scaled when it is not currently changing,
+	// such as creating, deleting or recreating instances.
+	resp := target.Status{
+		Ready: curMIG.Status != nil && curMIG.Status.IsStable,
+		Count: curMIG.TargetSize,
+		Meta:  make(map[string]string),
+	}
+
+	return &resp, nil
+}
+
+// calculateDirection returns the number of instances to add or remove, along
+// with the direction of scaling.
+func (t *TargetPlugin) calculateDirection(migTarget, strategyDesired int64) (int64, string) {
+
+	if strategyDesired < migTarget {
+		return migTarget - strategyDesired, "in"
+	}
+	if strategyDesired > migTarget {
+		return strategyDesired - migTarget, "out"
+	}
+	return 0, ""
+}
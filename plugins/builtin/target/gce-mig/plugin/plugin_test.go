@@ -0,0 +1,231 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+func TestTargetPlugin_calculateDirection(t *testing.T) {
+	testCases := []struct {
+		inputMIGTarget       int64
+		inputStrategyDesired int64
+		expectedOutputNum    int64
+		expectedOutputString string
+		name                 string
+	}{
+		{
+			inputMIGTarget:       10,
+			inputStrategyDesired: 11,
+			expectedOutputNum:    1,
+			expectedOutputString: "out",
+			name:                 "scale out desired",
+		},
+		{
+			inputMIGTarget:       10,
+			inputStrategyDesired: 9,
+			expectedOutputNum:    1,
+			expectedOutputString: "in",
+			name:                 "scale in desired",
+		},
+		{
+			inputMIGTarget:       10,
+			inputStrategyDesired: 10,
+			expectedOutputNum:    0,
+			expectedOutputString: "",
+			name:                 "scale not desired",
+		},
+	}
+
+	tp := TargetPlugin{}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualNum, actualString := tp.calculateDirection(tc.inputMIGTarget, tc.inputStrategyDesired)
+			assert.Equal(t, tc.expectedOutputNum, actualNum, tc.name)
+			assert.Equal(t, tc.expectedOutputString, actualString, tc.name)
+		})
+	}
+}
+
+// testServer is a stand-in for both the Compute API, serving a single zonal
+// MIG, and the Nomad node endpoints, with a ready node of the worker class for
+// each instance of the MIG. Changes to the MIG and node drains complete
+// immediately.
+type testServer struct {
+	lock      sync.Mutex
+	zone      string
+	instances []string
+	next      int
+	drained   []string
+}
+
+func (s *testServer) resize(size int) {
+	for len(s.instances) < size {
+		s.next++
+		s.instances = append(s.instances, fmt.Sprintf("nomad-client-%d", s.next))
+	}
+	s.instances = s.instances[:size]
+}
+
+func (s *testServer) nodes() []*api.Node {
+	var out []*api.Node
+	for i, name := range s.instances {
+		out = append(out, &api.Node{
+			ID:                    "node-" + name,
+			NodeClass:             "worker",
+			Status:                api.NodeStatusReady,
+			SchedulingEligibility: api.NodeSchedulingEligible,
+			Attributes: map[string]string{
+				"platform.gce.zone":            s.zone,
+				"unique.platform.gce.hostname": name + ".c.project.internal",
+			},
+			CreateIndex: uint64(i + 1),
+		})
+	}
+	return out
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	migPath := "/compute/v1/projects/project/zones/" + s.zone + "/instanceGroupManagers/nomad-client"
+
+	switch {
+	case r.URL.Path == migPath:
+		_ = json.NewEncoder(w).Encode(compute.InstanceGroupManager{
+			Name:       "nomad-client",
+			TargetSize: int64(len(s.instances)),
+			Status:     &compute.InstanceGroupManagerStatus{IsStable: true},
+		})
+		return
+	case r.URL.Path == migPath+"/resize":
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		s.resize(size)
+		_ = json.NewEncoder(w).Encode(compute.Operation{Status: "DONE"})
+		return
+	case r.URL.Path == migPath+"/deleteInstances":
+		var req compute.InstanceGroupManagersDeleteInstancesRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		deleted := make(map[string]struct{})
+		for _, instance := range req.Instances {
+			deleted[instance] = struct{}{}
+		}
+
+		var remaining []string
+		for _, name := range s.instances {
+			if _, ok := deleted["zones/"+s.zone+"/instances/"+name]; !ok {
+				remaining = append(remaining, name)
+			}
+		}
+		s.instances = remaining
+		_ = json.NewEncoder(w).Encode(compute.Operation{Status: "DONE"})
+		return
+	}
+
+	w.Header().Set("X-Nomad-Index", "1")
+	w.Header().Set("X-Nomad-LastContact", "0")
+
+	nodes := s.nodes()
+
+	switch {
+	case r.URL.Path == "/v1/nodes":
+		var stubs []*api.NodeListStub
+		for _, n := range nodes {
+			stubs = append(stubs, &api.NodeListStub{
+				ID:                    n.ID,
+				NodeClass:             n.NodeClass,
+				Status:                n.Status,
+				SchedulingEligibility: n.SchedulingEligibility,
+				CreateIndex:           n.CreateIndex,
+			})
+		}
+		_ = json.NewEncoder(w).Encode(stubs)
+		return
+	case strings.HasSuffix(r.URL.Path, "/drain"):
+		s.drained = append(s.drained, strings.Split(r.URL.Path, "/")[3])
+		_ = json.NewEncoder(w).Encode(api.NodeDrainUpdateResponse{})
+		return
+	case strings.HasSuffix(r.URL.Path, "/allocations"):
+		_ = json.NewEncoder(w).Encode([]*api.Allocation{})
+		return
+	}
+
+	for _, n := range nodes {
+		if r.URL.Path == "/v1/node/"+n.ID {
+			_ = json.NewEncoder(w).Encode(n)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func TestTargetPlugin_ScaleContext(t *testing.T) {
+
+	srv := &testServer{zone: "us-central1-a"}
+	srv.resize(3)
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	service, err := compute.NewService(ctx, option.WithEndpoint(ts.URL+"/compute/v1/projects/"),
+		option.WithoutAuthentication())
+	assert.Nil(t, err)
+
+	scaleInUtils, err := scaleutils.NewScaleInUtils(&api.Config{Address: ts.URL}, hclog.NewNullLogger())
+	assert.Nil(t, err)
+
+	tp := &TargetPlugin{
+		logger:       hclog.NewNullLogger(),
+		service:      service,
+		scaleInUtils: scaleInUtils,
+	}
+	assert.Nil(t, tp.HealthCheck(ctx))
+
+	config := map[string]string{
+		"gce_project":  "project",
+		"gce_zone":     "us-central1-a",
+		"gce_mig_name": "nomad-client",
+		"node_class":   "worker",
+	}
+
+	status, err := tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.True(t, status.Ready)
+	assert.Equal(t, int64(3), status.Count)
+
+	// Scaling out resizes the MIG.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 5}, config))
+	assert.Len(t, srv.instances, 5)
+
+	// Scaling in drains and deletes the newest nodes.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 3}, config))
+	assert.Equal(t, []string{"nomad-client-1", "nomad-client-2", "nomad-client-3"}, srv.instances)
+	assert.ElementsMatch(t, []string{"node-nomad-client-4", "node-nomad-client-5"}, srv.drained)
+
+	status, err = tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), status.Count)
+
+	// Dry-run actions are ignored.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: strategy.MetaValueDryRunCount}, config))
+	assert.Len(t, srv.instances, 3)
+}
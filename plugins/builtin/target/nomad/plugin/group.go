@@ -0,0 +1,135 @@
+package nomad
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// configKeyGroupRatios is the optional target config key used to scale
+// several groups of the same job together. It is a comma separated list of
+// group:weight pairs which must include the group identified by the Group
+// key, for example "game:4,gateway:1" runs one gateway per four game servers.
+const configKeyGroupRatios = "group_ratios"
+
+// parseGroupRatios parses the group ratios from the target config, returning
+// the weight of each group keyed by the group name. If the target config does
+// not include group ratios, nil is returned.
+func parseGroupRatios(config map[string]string) (map[string]int64, error) {
+
+	v, ok := config[configKeyGroupRatios]
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	ratios := make(map[string]int64)
+
+	for _, pair := range strings.Split(v, ",") {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected group:weight", configKeyGroupRatios, pair)
+		}
+
+		weight, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("invalid %s weight %q, expected a positive integer", configKeyGroupRatios, parts[1])
+		}
+		ratios[parts[0]] = weight
+	}
+
+	if _, ok := ratios[config[configKeyGroup]]; !ok {
+		return nil, fmt.Errorf("%s must include group %q", configKeyGroupRatios, config[configKeyGroup])
+	}
+	return ratios, nil
+}
+
+// groupCounts returns the count of each group in the ratios when the primary
+// group has the passed count. Counts are rounded up, so groups never fall
+// below their ratio, and are capped to the min and max of each group's scaling
+// block.
+func groupCounts(job *api.Job, group string, count int64, ratios map[string]int64) (map[string]int64, error) {
+
+	scaling := scalingPoliciesFromJob(job)
+	counts := make(map[string]int64)
+
+	for name, weight := range ratios {
+
+		// Ensure the group exists within the job, otherwise the ratio can't
+		// be honoured.
+		var found bool
+		for _, tg := range job.TaskGroups {
+			if tg.Name != nil && *tg.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("task group %q not found", name)
+		}
+
+		c := (count*weight + ratios[group] - 1) / ratios[group]
+
+		if policy, ok := scaling[name]; ok {
+			if policy.Min != nil && c < *policy.Min {
+				c = *policy.Min
+			}
+			if policy.Max != nil && c > *policy.Max {
+				c = *policy.Max
+			}
+		}
+		counts[name] = c
+	}
+	return counts, nil
+}
+
+// scaleGroups atomically updates the count of each group in the ratios. The
+// job is registered with the new counts, enforcing the modify index it was
+// read at, so that either every group is scaled or none are. The evaluation
+// created by the registration is recorded against each group, as it is not
+// part of their scaling events.
+func (t *TargetPlugin) scaleGroups(jobID, group string, count int64, ratios map[string]int64) error {
+
+	job, _, err := t.client.Jobs().Info(jobID, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read job %s: %v", jobID, err)
+	}
+
+	counts, err := groupCounts(job, group, count, ratios)
+	if err != nil {
+		return err
+	}
+
+	for _, tg := range job.TaskGroups {
+		if tg.Name == nil {
+			continue
+		}
+		if c, ok := counts[*tg.Name]; ok {
+			groupCount := int(c)
+			tg.Count = &groupCount
+		}
+	}
+
+	if job.JobModifyIndex == nil {
+		return fmt.Errorf("job %s has no modify index", jobID)
+	}
+	opts := api.RegisterOptions{EnforceIndex: true, ModifyIndex: *job.JobModifyIndex}
+
+	resp, _, err := t.client.Jobs().RegisterOpts(job, &opts, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register job %s: %v", jobID, err)
+	}
+
+	t.logger.Info("scaled task groups", "job_id", jobID, "counts", counts, "eval_id", resp.EvalID)
+
+	if resp.EvalID != "" {
+		groups := make([]string, 0, len(counts))
+		for name := range counts {
+			groups = append(groups, name)
+		}
+		t.statusHandler(jobID).setRegisterEval(groups,
+			registerEval{id: resp.EvalID, createIndex: resp.EvalCreateIndex})
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseGroupRatios(t *testing.T) {
+	testCases := []struct {
+		inputConfig    map[string]string
+		expectedOutput map[string]int64
+		expectedError  error
+		name           string
+	}{
+		{
+			inputConfig:    map[string]string{"Group": "game"},
+			expectedOutput: nil,
+			expectedError:  nil,
+			name:           "no group ratios",
+		},
+		{
+			inputConfig:    map[string]string{"Group": "game", "group_ratios": "game:4, gateway:1"},
+			expectedOutput: map[string]int64{"game": 4, "gateway": 1},
+			expectedError:  nil,
+			name:           "valid group ratios",
+		},
+		{
+			inputConfig:    map[string]string{"Group": "game", "group_ratios": "game:4,gateway"},
+			expectedOutput: nil,
+			expectedError:  fmt.Errorf(`invalid group_ratios entry "gateway", expected group:weight`),
+			name:           "missing weight",
+		},
+		{
+			inputConfig:    map[string]string{"Group": "game", "group_ratios": "game:4,gateway:0"},
+			expectedOutput: nil,
+			expectedError:  fmt.Errorf(`invalid group_ratios weight "0", expected a positive integer`),
+			name:           "non-positive weight",
+		},
+		{
+			inputConfig:    map[string]string{"Group": "game", "group_ratios": "gateway:1"},
+			expectedOutput: nil,
+			expectedError:  fmt.Errorf(`group_ratios must include group "game"`),
+			name:           "primary group missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualErr := parseGroupRatios(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedError, actualErr, tc.name)
+		})
+	}
+}
+
+func Test_groupCounts(t *testing.T) {
+
+	job := &api.Job{
+		TaskGroups: []*api.TaskGroup{
+			{Name: stringToPtr("game")},
+			{
+				Name:    stringToPtr("gateway"),
+				Scaling: &api.ScalingPolicy{Min: int64ToPtr(1), Max: int64ToPtr(3)},
+			},
+		},
+	}
+	ratios := map[string]int64{"game": 4, "gateway": 1}
+
+	testCases := []struct {
+		inputCount     int64
+		inputRatios    map[string]int64
+		expectedOutput map[string]int64
+		expectedError  error
+		name           string
+	}{
+		{
+			inputCount:     8,
+			inputRatios:    ratios,
+			expectedOutput: map[string]int64{"game": 8, "gateway": 2},
+			name:           "exact ratio",
+		},
+		{
+			inputCount:     9,
+			inputRatios:    ratios,
+			expectedOutput: map[string]int64{"game": 9, "gateway": 3},
+			name:           "rounded up",
+		},
+		{
+			inputCount:     0,
+			inputRatios:    ratios,
+			expectedOutput: map[string]int64{"game": 0, "gateway": 1},
+			name:           "capped to scaling block min",
+		},
+		{
+			inputCount:     20,
+			inputRatios:    ratios,
+			expectedOutput: map[string]int64{"game": 20, "gateway": 3},
+			name:           "capped to scaling block max",
+		},
+		{
+			inputCount:    4,
+			inputRatios:   map[string]int64{"game": 4, "lobby": 1},
+			expectedError: fmt.Errorf(`task group "lobby" not found`),
+			name:          "group not in job",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualErr := groupCounts(job, "game", tc.inputCount, tc.inputRatios)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedError, actualErr, tc.name)
+		})
+	}
+}
+
+// testServer is a stand-in for the Nomad job endpoints, serving a single job
+// and recording the requests made to register and scale it.
+type testServer struct {
+	lock      sync.Mutex
+	job       *api.Job
+	registers []*api.JobRegisterRequest
+	scales    []*api.ScalingRequest
+	failScale bool
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	w.Header().Set("X-Nomad-Index", "1")
+	w.Header().Set("X-Nomad-LastContact", "0")
+
+	switch {
+	case r.URL.Path == "/v1/job/"+*s.job.ID && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(s.job)
+		return
+	case r.URL.Path == "/v1/jobs" && r.Method == http.MethodPut:
+		var req api.JobRegisterRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.registers = append(s.registers, &req)
+		_ = json.NewEncoder(w).Encode(api.JobRegisterResponse{
+			EvalID:          fmt.Sprintf("eval-%d", len(s.registers)),
+			EvalCreateIndex: uint64(10 + len(s.registers)),
+		})
+		return
+	case r.URL.Path == "/v1/job/"+*s.job.ID+"/scale" && r.Method == http.MethodPut && s.failScale:
+		http.Error(w, "scaling unavailable", http.StatusInternalServerError)
+		return
+	case r.URL.Path == "/v1/job/"+*s.job.ID+"/scale" && r.Method == http.MethodPut:
+		var req api.ScalingRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.scales = append(s.scales, &req)
+		_ = json.NewEncoder(w).Encode(api.JobRegisterResponse{})
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func TestTargetPlugin_Scale(t *testing.T) {
+
+	srv := &testServer{
+		job: &api.Job{
+			ID:             stringToPtr("arena"),
+			JobModifyIndex: uint64ToPtr(10),
+			TaskGroups: []*api.TaskGroup{
+				{Name: stringToPtr("game"), Count: intToPtr(4)},
+				{Name: stringToPtr("gateway"), Count: intToPtr(1)},
+				{Name: stringToPtr("lobby"), Count: intToPtr(2)},
+			},
+		},
+	}
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	tp := &TargetPlugin{
+		client:         client,
+		logger:         hclog.NewNullLogger(),
+		statusHandlers: make(map[string]*jobScaleStatusHandler),
+	}
+
+	// Without group ratios, only the group is scaled.
+	config := map[string]string{"Job": "arena", "Group": "game"}
+	assert.Nil(t, tp.Scale(strategy.Action{Count: 6, Reason: "busy"}, config))
+	assert.Len(t, srv.registers, 0)
+	assert.Len(t, srv.scales, 1)
+	assert.Equal(t, int64(6), *srv.scales[0].Count)
+
+	// With group ratios, the groups are registered together and the scaling
+	// event is registered against the primary group.
+	config["group_ratios"] = "game:4,gateway:1"
+	assert.Nil(t, tp.Scale(strategy.Action{Count: 10, Reason: "busy"}, config))
+	assert.Len(t, srv.registers, 1)
+	assert.True(t, srv.registers[0].EnforceIndex)
+	assert.Equal(t, uint64(10), srv.registers[0].JobModifyIndex)
+
+	counts := make(map[string]int)
+	for _, tg := range srv.registers[0].Job.TaskGroups {
+		counts[*tg.Name] = *tg.Count
+	}
+	assert.Equal(t, map[string]int{"game": 10, "gateway": 3, "lobby": 2}, counts)
+
+	assert.Len(t, srv.scales, 2)
+	assert.Nil(t, srv.scales[1].Count)
+	assert.Equal(t, "game", srv.scales[1].Target["Group"])
+	assert.Equal(t, "busy", srv.scales[1].Message)
+
+	// The evaluation of the registration is recorded against each group, as
+	// the scaling event does not include it.
+	jsh := tp.statusHandlers["arena"]
+	if assert.NotNil(t, jsh) {
+		assert.Equal(t, "eval-1", jsh.groupEvalID("game", nil))
+		assert.Equal(t, "eval-1", jsh.groupEvalID("gateway", nil))
+		assert.Equal(t, "", jsh.groupEvalID("lobby", nil))
+	}
+
+	// Failing to register the scaling event does not fail the action, as the
+	// groups have already been scaled.
+	srv.failScale = true
+	assert.Nil(t, tp.Scale(strategy.Action{Count: 12, Reason: "busy"}, config))
+	assert.Len(t, srv.registers, 2)
+	assert.Equal(t, "eval-2", jsh.groupEvalID("game", nil))
+	srv.failScale = false
+
+	// Dry-run actions only register the scaling event.
+	assert.Nil(t, tp.Scale(strategy.Action{Count: strategy.MetaValueDryRunCount}, config))
+	assert.Len(t, srv.registers, 2)
+	assert.Len(t, srv.scales, 3)
+
+	// If a group is missing from the job, no group is scaled.
+	srv.job.TaskGroups = srv.job.TaskGroups[:2]
+	config["group_ratios"] = "game:4,lobby:1"
+	assert.NotNil(t, tp.Scale(strategy.Action{Count: 10}, config))
+	assert.Len(t, srv.registers, 2)
+	assert.Len(t, srv.scales, 3)
+}
+
+func stringToPtr(s string) *string { return &s }
+
+func intToPtr(i int) *int { return &i }
+
+func int64ToPtr(i int64) *int64 { return &i }
+
+func uint64ToPtr(u uint64) *uint64 { return &u }
@@ -1,7 +1,9 @@
 package nomad
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -49,8 +51,13 @@ var (
 	}
 )
 
-// Assert that TargetPlugin meets the target.Target interface.
-var _ target.Target = (*TargetPlugin)(nil)
+// Assert that TargetPlugin meets the target.Target, base.HealthChecker and
+// io.Closer interfaces.
+var (
+	_ target.Target      = (*TargetPlugin)(nil)
+	_ base.HealthChecker = (*TargetPlugin)(nil)
+	_ io.Closer          = (*TargetPlugin)(nil)
+)
 
 // TargetPlugin is the Nomad implementation of the target.Target interface.
 type TargetPlugin struct {
@@ -65,6 +72,11 @@ type TargetPlugin struct {
 
 	// gcRunning indicates whether the GC loop is running or not.
 	gcRunning bool
+
+	// doneCh is closed when the plugin is closed, stopping the GC loop and
+	// the job status handlers.
+	doneCh    chan struct{}
+	closeOnce sync.Once
 }
 
 // NewNomadPlugin returns the Nomad implementation of the target.Target
@@ -73,6 +85,7 @@ func NewNomadPlugin(log hclog.Logger) *TargetPlugin {
 	return &TargetPlugin{
 		logger:         log,
 		statusHandlers: make(map[string]*jobScaleStatusHandler),
+		doneCh:         make(chan struct{}),
 	}
 }
 
@@ -99,15 +112,48 @@ func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
 	return pluginInfo, nil
 }
 
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. It confirms the Nomad API is reachable.
+func (t *TargetPlugin) HealthCheck(ctx context.Context) error {
+	return nomadHelper.CheckAPI(ctx, t.client)
+}
+
+// Close satisfies the io.Closer interface. It stops the GC loop and the job
+// status handlers once the plugin is no longer used.
+func (t *TargetPlugin) Close() error {
+	t.closeOnce.Do(func() { close(t.doneCh) })
+	return nil
+}
+
 // Scale satisfies the Scale function on the target.Target interface.
 func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
-	var countIntPtr *int
+
+	ratios, err := parseGroupRatios(config)
+	if err != nil {
+		return err
+	}
+
+	var (
+		countIntPtr  *int
+		groupsScaled bool
+	)
 	if action.Count != strategy.MetaValueDryRunCount {
-		countInt := int(action.Count)
-		countIntPtr = &countInt
+
+		// When the target covers several groups, they are scaled together
+		// and the scale call below only registers the scaling event against
+		// the primary group, so that cooldown is still enforced.
+		if ratios != nil {
+			if err := t.scaleGroups(config[configKeyJobID], config[configKeyGroup], action.Count, ratios); err != nil {
+				return fmt.Errorf("failed to scale groups of job %s: %v", config[configKeyJobID], err)
+			}
+			groupsScaled = true
+		} else {
+			countInt := int(action.Count)
+			countIntPtr = &countInt
+		}
 	}
 
-	_, _, err := t.client.Jobs().Scale(config[configKeyJobID],
+	_, _, err = t.client.Jobs().Scale(config[configKeyJobID],
 		config[configKeyGroup],
 		countIntPtr,
 		action.Reason,
@@ -116,7 +162,14 @@ func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) e
 		nil)
 
 	if err != nil {
-		return fmt.Errorf("failed to scale group %s/%s: %v", config["job_id"], config["group"], err)
+		// The groups have already been scaled when using ratios, so failing
+		// to register the event must not report the action as failed.
+		if groupsScaled {
+			t.logger.Warn("failed to register scaling event", "job_id", config[configKeyJobID],
+				"group", config[configKeyGroup], "error", err)
+			return nil
+		}
+		return fmt.Errorf("failed to scale group %s/%s: %v", config[configKeyJobID], config[configKeyGroup], err)
 	}
 	return nil
 }
@@ -144,7 +197,7 @@ func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error)
 
 	// Create a handler for the job if one does not currently exist.
 	if _, ok := t.statusHandlers[jobID]; !ok {
-		t.statusHandlers[jobID] = newJobScaleStatusHandler(t.client, jobID, t.logger)
+		t.statusHandlers[jobID] = newJobScaleStatusHandler(t.client, jobID, t.logger, t.doneCh)
 	}
 
 	// If the handler is not in a running state, start it and wait for the
@@ -158,6 +211,18 @@ func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error)
 	return t.statusHandlers[jobID].status(group)
 }
 
+// statusHandler returns the status handler of the job, creating one if it does
+// not currently exist. The handler is started by the first call to Status.
+func (t *TargetPlugin) statusHandler(jobID string) *jobScaleStatusHandler {
+	t.statusHandlersLock.Lock()
+	defer t.statusHandlersLock.Unlock()
+
+	if _, ok := t.statusHandlers[jobID]; !ok {
+		t.statusHandlers[jobID] = newJobScaleStatusHandler(t.client, jobID, t.logger, t.doneCh)
+	}
+	return t.statusHandlers[jobID]
+}
+
 // garbageCollectionLoop runs a long lived loop, triggering the garbage
 // collector at a specified interval.
 func (t *TargetPlugin) garbageCollectionLoop() {
@@ -168,6 +233,9 @@ func (t *TargetPlugin) garbageCollectionLoop() {
 
 	for {
 		select {
+		case <-t.doneCh:
+			ticker.Stop()
+			return
 		case <-ticker.C:
 			t.logger.Debug("triggering run of handler garbage collection")
 			t.garbageCollect()
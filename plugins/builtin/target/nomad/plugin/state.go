@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
@@ -20,6 +21,30 @@ const (
 	// metaKeyJobStoppedSuffix is the key suffix used when adding a meta item
 	// to the status response detailing the jobs current stopped status.
 	metaKeyJobStoppedSuffix = ".stopped"
+
+	// metaKeyDeploymentActiveSuffix is the key suffix used when adding a meta
+	// item to the status response detailing the ID of a deployment of the
+	// job which is currently in progress.
+	metaKeyDeploymentActiveSuffix = ".deployment_active"
+
+	// metaKeyUnhealthySuffix is the key suffix used when adding a meta item to
+	// the status response detailing the number of unhealthy allocations of
+	// the group within the latest deployment.
+	metaKeyUnhealthySuffix = ".unhealthy"
+
+	// metaKeyPlacementFailureSuffix is the key suffix used when adding a meta
+	// item to the status response detailing the ID of the evaluation which
+	// failed to place allocations of the group.
+	metaKeyPlacementFailureSuffix = ".placement_failure"
+
+	// deploymentStatuses are the statuses of a deployment which is still in
+	// progress.
+	deploymentStatusRunning = "running"
+	deploymentStatusPaused  = "paused"
+
+	// evalStatusPending is the status of an evaluation which has not yet been
+	// processed by the scheduler.
+	evalStatusPending = "pending"
 )
 
 // jobScaleStatusHandler is an individual handler on the /v1/job/<job>/scale
@@ -30,11 +55,33 @@ type jobScaleStatusHandler struct {
 	jobID  string
 	logger hclog.Logger
 
+	// doneCh stops the blocking query loop once closed. As the queries can
+	// not be canceled, the loop stops once the current query returns.
+	doneCh <-chan struct{}
+
 	// scaleStatus is the internal reflection of the response objects from the
 	// job scale status API.
 	scaleStatus      *api.JobScaleStatusResponse
 	scaleStatusError error
 
+	// scalingPolicies are the scaling blocks of the job task groups, keyed by
+	// the group name. They are refreshed when the job modify index changes.
+	scalingPolicies      map[string]*api.ScalingPolicy
+	scalingPoliciesIndex uint64
+
+	// deployment is the latest deployment of the job, if any.
+	deployment *api.Deployment
+
+	// evals are the evaluations created by the most recent scaling event of
+	// each task group, keyed by the group name.
+	evals map[string]*api.Evaluation
+
+	// registerEvals are the evaluations created by the plugin registering the
+	// job to scale several groups together, keyed by the group name. These
+	// are not recorded within the scaling events of the groups.
+	registerEvals     map[string]registerEval
+	registerEvalsLock sync.RWMutex
+
 	// initialDone helps synchronise the caller waiting for the state to be
 	// populated after starting the API query loop.
 	initialDone chan bool
@@ -49,9 +96,16 @@ type jobScaleStatusHandler struct {
 	lastUpdated int64
 }
 
-func newJobScaleStatusHandler(client *api.Client, jobID string, logger hclog.Logger) *jobScaleStatusHandler {
+// registerEval is the evaluation created by registering a job.
+type registerEval struct {
+	id          string
+	createIndex uint64
+}
+
+func newJobScaleStatusHandler(client *api.Client, jobID string, logger hclog.Logger, doneCh <-chan struct{}) *jobScaleStatusHandler {
 	return &jobScaleStatusHandler{
 		client:      client,
+		doneCh:      doneCh,
 		initialDone: make(chan bool),
 		jobID:       jobID,
 		logger:      logger.With(configKeyJobID, jobID),
@@ -93,15 +147,26 @@ func (jsh *jobScaleStatusHandler) status(group string) (*target.Status, error) {
 	}
 
 	// Hydrate the response object with the information we have collected that
-	// is nil safe.
+	// is nil safe. The group is not ready while it is part of a deployment
+	// which is in progress, as its count is still changing.
+	deploymentActive := jsh.deploymentActive(group)
+
 	resp := target.Status{
-		Ready: !jsh.scaleStatus.JobStopped,
+		Ready: !jsh.scaleStatus.JobStopped && !deploymentActive,
 		Count: int64(status.Running),
 		Meta: map[string]string{
 			metaKeyPrefix + jsh.jobID + metaKeyJobStoppedSuffix: strconv.FormatBool(jsh.scaleStatus.JobStopped),
 		},
 	}
 
+	if deploymentActive {
+		resp.Meta[metaKeyPrefix+jsh.jobID+metaKeyDeploymentActiveSuffix] = jsh.deployment.ID
+	}
+
+	if status.Unhealthy > 0 {
+		resp.Meta[metaKeyPrefix+jsh.jobID+"."+group+metaKeyUnhealthySuffix] = strconv.Itoa(status.Unhealthy)
+	}
+
 	// Scaling events are an ordered list. If we have entries take the
 	// timestamp of the most recent and add this to our meta.
 	//
@@ -112,6 +177,28 @@ func (jsh *jobScaleStatusHandler) status(group string) (*target.Status, error) {
 		resp.Meta[target.MetaKeyLastEvent] = strconv.FormatUint(status.Events[0].Time, 10)
 	}
 
+	// If the task group has a scaling block, its limits must be honoured
+	// regardless of those set within the policy.
+	if policy, ok := jsh.scalingPolicies[group]; ok {
+		if policy.Min != nil {
+			resp.Meta[target.MetaKeyMinCount] = strconv.FormatInt(*policy.Min, 10)
+		}
+		if policy.Max != nil {
+			resp.Meta[target.MetaKeyMaxCount] = strconv.FormatInt(*policy.Max, 10)
+		}
+	}
+
+	// If the most recent scaling of the group failed to place all of its
+	// allocations, scaling out further will not help until the cluster has
+	// capacity. The max count is therefore limited to the current count, which
+	// still allows the group to be scaled in.
+	if evalID := jsh.placementFailure(group, status); evalID != "" {
+		resp.Meta[metaKeyPrefix+jsh.jobID+"."+group+metaKeyPlacementFailureSuffix] = evalID
+		if policy, ok := jsh.scalingPolicies[group]; !ok || policy.Max == nil || *policy.Max > resp.Count {
+			resp.Meta[target.MetaKeyMaxCount] = strconv.FormatInt(resp.Count, 10)
+		}
+	}
+
 	return &resp, nil
 }
 
@@ -127,6 +214,15 @@ func (jsh *jobScaleStatusHandler) start() {
 
 	for {
 		status, meta, err := jsh.client.Jobs().ScaleStatus(jsh.jobID, q)
+
+		select {
+		case <-jsh.doneCh:
+			jsh.logger.Debug("stopping job status handler")
+			jsh.setStopState()
+			return
+		default:
+		}
+
 		if err != nil {
 
 			// If the job is not found on the cluster, stop the handlers loop
@@ -145,7 +241,12 @@ func (jsh *jobScaleStatusHandler) start() {
 
 			// If the error was anything other than the job not being found,
 			// try again.
-			time.Sleep(10 * time.Second)
+			select {
+			case <-jsh.doneCh:
+				jsh.setStopState()
+				return
+			case <-time.After(10 * time.Second):
+			}
 			continue
 		}
 
@@ -158,6 +259,8 @@ func (jsh *jobScaleStatusHandler) start() {
 		}
 
 		// Update the handlers state.
+		jsh.updateScalingPolicies(status.JobModifyIndex)
+		jsh.updateDeploymentState(status)
 		jsh.updateStatusState(status, nil)
 
 		// Mark the handler as initialized and notify initialDone channel.
@@ -185,6 +288,144 @@ func (jsh *jobScaleStatusHandler) updateStatusState(status *api.JobScaleStatusRe
 	jsh.lastUpdated = time.Now().UTC().UnixNano()
 }
 
+// updateScalingPolicies reads the scaling blocks of the job if it has been
+// modified since they were last read. Failures are logged, with the previous
+// scaling blocks being kept, as the status of the job remains useful without
+// them.
+func (jsh *jobScaleStatusHandler) updateScalingPolicies(modifyIndex uint64) {
+	if jsh.scalingPolicies != nil && jsh.scalingPoliciesIndex == modifyIndex {
+		return
+	}
+
+	job, _, err := jsh.client.Jobs().Info(jsh.jobID, nil)
+	if err != nil {
+		jsh.logger.Warn("failed to read job scaling blocks", "error", err)
+		return
+	}
+
+	jsh.scalingPolicies = scalingPoliciesFromJob(job)
+	jsh.scalingPoliciesIndex = modifyIndex
+}
+
+// updateDeploymentState reads the latest deployment of the job, along with the
+// evaluation of the most recent scaling event of each group. Failures are
+// logged, with the previous state being kept.
+func (jsh *jobScaleStatusHandler) updateDeploymentState(status *api.JobScaleStatusResponse) {
+
+	deployment, _, err := jsh.client.Jobs().LatestDeployment(jsh.jobID, nil)
+	if err != nil {
+		jsh.logger.Warn("failed to read latest job deployment", "error", err)
+	} else {
+		jsh.deployment = deployment
+	}
+
+	evals := make(map[string]*api.Evaluation)
+
+	for group, tg := range status.TaskGroups {
+		evalID := jsh.groupEvalID(group, tg.Events)
+		if evalID == "" {
+			continue
+		}
+
+		// Evaluations do not change once processed, so avoid reading them
+		// again.
+		if eval, ok := jsh.evals[group]; ok && eval.ID == evalID && eval.Status != evalStatusPending {
+			evals[group] = eval
+			continue
+		}
+
+		eval, _, err := jsh.client.Evaluations().Info(evalID, nil)
+		if err != nil {
+			jsh.logger.Warn("failed to read scaling event evaluation",
+				"group", group, "eval_id", evalID, "error", err)
+			continue
+		}
+		evals[group] = eval
+	}
+	jsh.evals = evals
+}
+
+// deploymentActive returns whether the group is part of the latest deployment
+// of the job, and whether that deployment is still in progress.
+func (jsh *jobScaleStatusHandler) deploymentActive(group string) bool {
+	if jsh.deployment == nil {
+		return false
+	}
+	if _, ok := jsh.deployment.TaskGroups[group]; !ok {
+		return false
+	}
+	return jsh.deployment.Status == deploymentStatusRunning ||
+		jsh.deployment.Status == deploymentStatusPaused
+}
+
+// placementFailure returns the ID of the evaluation of the most recent scaling
+// event of the group if it failed to place allocations of the group, and the
+// group is still running fewer allocations than desired. Otherwise an empty
+// string is returned.
+func (jsh *jobScaleStatusHandler) placementFailure(group string, status *api.TaskGroupScaleStatus) string {
+	eval, ok := jsh.evals[group]
+	if !ok || eval.ID != jsh.groupEvalID(group, status.Events) {
+		return ""
+	}
+	if _, ok := eval.FailedTGAllocs[group]; !ok || status.Running >= status.Desired {
+		return ""
+	}
+	return eval.ID
+}
+
+// setRegisterEval records the evaluation created by the plugin registering
+// the job to scale the groups, so that it is checked for placement failures.
+func (jsh *jobScaleStatusHandler) setRegisterEval(groups []string, eval registerEval) {
+	jsh.registerEvalsLock.Lock()
+	defer jsh.registerEvalsLock.Unlock()
+
+	if jsh.registerEvals == nil {
+		jsh.registerEvals = make(map[string]registerEval)
+	}
+	for _, group := range groups {
+		jsh.registerEvals[group] = eval
+	}
+}
+
+// groupEvalID returns the ID of the evaluation created by the most recent
+// change to the count of the group. This is either the evaluation of a scaling
+// event, or that of the plugin registering the job to scale the group
+// alongside others. Events which did not change the count, such as those
+// registered in dry-run mode, do not create an evaluation.
+func (jsh *jobScaleStatusHandler) groupEvalID(group string, events []api.ScalingEvent) string {
+	jsh.registerEvalsLock.RLock()
+	reg, ok := jsh.registerEvals[group]
+	jsh.registerEvalsLock.RUnlock()
+
+	// Scaling events are ordered newest first, so once an event older than
+	// the registration is reached, the registration is the most recent.
+	for _, e := range events {
+		if ok && e.CreateIndex < reg.createIndex {
+			break
+		}
+		if e.EvalID != nil && *e.EvalID != "" {
+			return *e.EvalID
+		}
+	}
+	if ok {
+		return reg.id
+	}
+	return ""
+}
+
+// scalingPoliciesFromJob returns the scaling blocks of the job task groups,
+// keyed by the group name.
+func scalingPoliciesFromJob(job *api.Job) map[string]*api.ScalingPolicy {
+	policies := make(map[string]*api.ScalingPolicy)
+
+	for _, tg := range job.TaskGroups {
+		if tg.Name != nil && tg.Scaling != nil {
+			policies[*tg.Name] = tg.Scaling
+		}
+	}
+	return policies
+}
+
 // setStopState handles updating state when the job status handler is going to
 // stop.
 func (jsh *jobScaleStatusHandler) setStopState() {
@@ -1,7 +1,10 @@
 package nomad
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	hclog "github.com/hashicorp/go-hclog"
@@ -17,13 +20,31 @@ func Test_newJobStateHandler(t *testing.T) {
 	assert.Nil(t, err)
 
 	// Create the new handler and perform assertions.
-	jsh := newJobScaleStatusHandler(c, "test", hclog.NewNullLogger())
+	jsh := newJobScaleStatusHandler(c, "test", hclog.NewNullLogger(), nil)
 	assert.NotNil(t, jsh.client)
 	assert.Equal(t, "test", jsh.jobID)
 	assert.NotNil(t, jsh.initialDone)
 	assert.NotNil(t, jsh.client)
 }
 
+func Test_jobScaleStatusHandler_start_done(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	doneCh := make(chan struct{})
+	close(doneCh)
+
+	// The loop stops once the current query returns, rather than retrying.
+	jsh := newJobScaleStatusHandler(client, "arena", hclog.NewNullLogger(), doneCh)
+	jsh.start()
+	assert.False(t, jsh.isRunning)
+}
+
 func Test_jobStateHandler_status(t *testing.T) {
 	testCases := []struct {
 		inputJSH       *jobScaleStatusHandler
@@ -149,3 +170,267 @@ func Test_jobStateHandler_stop(t *testing.T) {
 	assert.Nil(t, jsh.scaleStatusError)
 	assert.Greater(t, jsh.lastUpdated, int64(0))
 }
+
+func Test_jobStateHandler_status_scalingPolicies(t *testing.T) {
+	jsh := &jobScaleStatusHandler{
+		jobID: "arena",
+		scaleStatus: &api.JobScaleStatusResponse{
+			TaskGroups: map[string]api.TaskGroupScaleStatus{
+				"game":    {Running: 4},
+				"gateway": {Running: 1},
+			},
+		},
+		scalingPolicies: scalingPoliciesFromJob(&api.Job{
+			TaskGroups: []*api.TaskGroup{
+				{
+					Name:    stringToPtr("game"),
+					Scaling: &api.ScalingPolicy{Min: int64ToPtr(2), Max: int64ToPtr(20)},
+				},
+				{Name: stringToPtr("gateway")},
+			},
+		}),
+	}
+
+	// The limits of the group's scaling block are included in the meta.
+	status, err := jsh.status("game")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"nomad_autoscaler.target.nomad.arena.stopped": "false",
+		"nomad_autoscaler.min_count":                  "2",
+		"nomad_autoscaler.max_count":                  "20",
+	}, status.Meta)
+
+	// Groups without a scaling block have no limits.
+	status, err = jsh.status("gateway")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"nomad_autoscaler.target.nomad.arena.stopped": "false",
+	}, status.Meta)
+}
+
+func Test_jobStateHandler_status_deployment(t *testing.T) {
+
+	eventEvalID := "eval-1"
+
+	testCases := []struct {
+		inputJSH       *jobScaleStatusHandler
+		expectedReturn *target.Status
+		name           string
+	}{
+		{
+			inputJSH: &jobScaleStatusHandler{
+				deployment: &api.Deployment{
+					ID:         "deploy-1",
+					Status:     "running",
+					TaskGroups: map[string]*api.DeploymentState{"game": {}},
+				},
+			},
+			expectedReturn: &target.Status{
+				Ready: false,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.arena.stopped":           "false",
+					"nomad_autoscaler.last_event":                           "20",
+					"nomad_autoscaler.target.nomad.arena.deployment_active": "deploy-1",
+				},
+			},
+			name: "group deployment in progress",
+		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				deployment: &api.Deployment{
+					ID:         "deploy-1",
+					Status:     "running",
+					TaskGroups: map[string]*api.DeploymentState{"gateway": {}},
+				},
+			},
+			expectedReturn: &target.Status{
+				Ready: true,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.arena.stopped": "false",
+					"nomad_autoscaler.last_event":                 "20",
+				},
+			},
+			name: "other group deployment in progress",
+		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				deployment: &api.Deployment{
+					ID:         "deploy-1",
+					Status:     "successful",
+					TaskGroups: map[string]*api.DeploymentState{"game": {}},
+				},
+			},
+			expectedReturn: &target.Status{
+				Ready: true,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.arena.stopped": "false",
+					"nomad_autoscaler.last_event":                 "20",
+				},
+			},
+			name: "group deployment complete",
+		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				evals: map[string]*api.Evaluation{
+					"game": {
+						ID:             eventEvalID,
+						FailedTGAllocs: map[string]*api.AllocationMetric{"game": {}},
+					},
+				},
+			},
+			expectedReturn: &target.Status{
+				Ready: true,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.arena.stopped":                "false",
+					"nomad_autoscaler.last_event":                                "20",
+					"nomad_autoscaler.target.nomad.arena.game.placement_failure": "eval-1",
+					"nomad_autoscaler.max_count":                                 "3",
+				},
+			},
+			name: "placement failure of latest scaling event",
+		},
+		{
+			inputJSH: &jobScaleStatusHandler{
+				evals: map[string]*api.Evaluation{
+					"game": {
+						ID:             "eval-0",
+						FailedTGAllocs: map[string]*api.AllocationMetric{"game": {}},
+					},
+				},
+			},
+			expectedReturn: &target.Status{
+				Ready: true,
+				Count: 3,
+				Meta: map[string]string{
+					"nomad_autoscaler.target.nomad.arena.stopped": "false",
+					"nomad_autoscaler.last_event":                 "20",
+				},
+			},
+			name: "placement failure of previous scaling event",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.inputJSH.jobID = "arena"
+			tc.inputJSH.scaleStatus = &api.JobScaleStatusResponse{
+				TaskGroups: map[string]api.TaskGroupScaleStatus{
+					"game": {
+						Desired: 5,
+						Running: 3,
+						Events:  []api.ScalingEvent{{Time: 20}, {EvalID: &eventEvalID, Time: 10}},
+					},
+				},
+			}
+			actualReturn, actualErr := tc.inputJSH.status("game")
+			assert.Equal(t, tc.expectedReturn, actualReturn, tc.name)
+			assert.Nil(t, actualErr, tc.name)
+		})
+	}
+}
+
+func Test_jobStateHandler_status_unhealthy(t *testing.T) {
+	jsh := &jobScaleStatusHandler{
+		jobID: "arena",
+		scaleStatus: &api.JobScaleStatusResponse{
+			TaskGroups: map[string]api.TaskGroupScaleStatus{
+				"game": {Running: 3, Unhealthy: 2},
+			},
+		},
+	}
+
+	status, err := jsh.status("game")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", status.Meta["nomad_autoscaler.target.nomad.arena.game.unhealthy"])
+}
+
+func Test_jobStateHandler_updateDeploymentState(t *testing.T) {
+
+	var evalReads int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Nomad-Index", "1")
+		w.Header().Set("X-Nomad-LastContact", "0")
+
+		switch r.URL.Path {
+		case "/v1/job/arena/deployment":
+			_ = json.NewEncoder(w).Encode(api.Deployment{ID: "deploy-1", Status: "running"})
+		case "/v1/evaluation/eval-1":
+			evalReads++
+			_ = json.NewEncoder(w).Encode(api.Evaluation{ID: "eval-1", Status: "complete"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	client, err := api.NewClient(&api.Config{Address: ts.URL})
+	assert.Nil(t, err)
+
+	jsh := newJobScaleStatusHandler(client, "arena", hclog.NewNullLogger(), nil)
+
+	evalID := "eval-1"
+	status := &api.JobScaleStatusResponse{
+		TaskGroups: map[string]api.TaskGroupScaleStatus{
+			"game":    {Events: []api.ScalingEvent{{EvalID: &evalID}}},
+			"gateway": {},
+		},
+	}
+
+	jsh.updateDeploymentState(status)
+	assert.Equal(t, "deploy-1", jsh.deployment.ID)
+	assert.Equal(t, "eval-1", jsh.evals["game"].ID)
+	assert.NotContains(t, jsh.evals, "gateway")
+
+	// Processed evaluations are not read again.
+	jsh.updateDeploymentState(status)
+	assert.Equal(t, 1, evalReads)
+}
+
+func Test_jobStateHandler_groupEvalID(t *testing.T) {
+
+	eventEvalID := "eval-event"
+
+	testCases := []struct {
+		name           string
+		inputEvents    []api.ScalingEvent
+		inputRegister  *registerEval
+		expectedEvalID string
+	}{
+		{
+			name:           "no events or registration",
+			expectedEvalID: "",
+		},
+		{
+			name:           "event without registration",
+			inputEvents:    []api.ScalingEvent{{CreateIndex: 20}, {EvalID: &eventEvalID, CreateIndex: 10}},
+			expectedEvalID: "eval-event",
+		},
+		{
+			name:           "registration newer than event",
+			inputEvents:    []api.ScalingEvent{{CreateIndex: 20}, {EvalID: &eventEvalID, CreateIndex: 10}},
+			inputRegister:  &registerEval{id: "eval-register", createIndex: 15},
+			expectedEvalID: "eval-register",
+		},
+		{
+			name:           "event newer than registration",
+			inputEvents:    []api.ScalingEvent{{EvalID: &eventEvalID, CreateIndex: 20}},
+			inputRegister:  &registerEval{id: "eval-register", createIndex: 15},
+			expectedEvalID: "eval-event",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jsh := newJobScaleStatusHandler(nil, "arena", hclog.NewNullLogger(), nil)
+			if tc.inputRegister != nil {
+				jsh.setRegisterEval([]string{"game"}, *tc.inputRegister)
+			}
+			assert.Equal(t, tc.expectedEvalID, jsh.groupEvalID("game", tc.inputEvents))
+		})
+	}
+}
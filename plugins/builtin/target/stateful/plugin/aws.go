@@ -3,12 +3,16 @@ package plugin
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/external"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/awsclient"
+	"github.com/hashicorp/nomad-autoscaler/helper/retry"
 	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
 	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 )
@@ -18,58 +22,73 @@ const (
 	defaultRetryLimit    = 15
 )
 
-// setupAWSClients takes the passed config mapping and instantiates the
-// required AWS service clients.
-func (t *TargetPlugin) setupAWSClients(config map[string]string) error {
+// awsProvider is the AWS AutoScaling Group implementation of the provider
+// interface.
+type awsProvider struct {
+	logger hclog.Logger
+	asg    awsclient.AutoScaling
+	ec2    awsclient.EC2
+}
 
-	// Load our default AWS config. This handles pulling configuration from
-	// default profiles and environment variables.
-	cfg, err := external.LoadDefaultAWSConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load default AWS config: %v", err)
-	}
+// SetConfig satisfies the SetConfig function on the provider interface.
+func (a *awsProvider) SetConfig(config map[string]string) error {
+	return a.setupAWSClients(config)
+}
 
-	// Check for a configured region and set the value to our internal default
-	// if nothing is found.
-	region, ok := config[configKeyRegion]
+// RemoteProvider satisfies the RemoteProvider function on the provider
+// interface.
+func (a *awsProvider) RemoteProvider() utils.RemoteProvider {
+	return utils.RemoteProviderAWSInstanceID
+}
+
+// GroupName satisfies the GroupName function on the provider interface.
+func (a *awsProvider) GroupName(config map[string]string) (string, error) {
+	asgName, ok := config[configKeyASGName]
 	if !ok {
-		region = configValueRegionDefault
+		return "", fmt.Errorf("required config param %s not found", configKeyASGName)
 	}
+	return asgName, nil
+}
 
-	// If the default config is empty, update it.
-	if cfg.Region == "" {
-		t.logger.Trace("setting AWS region for client", "region", region)
-		cfg.Region = region
+// Status satisfies the Status function on the provider interface.
+func (a *awsProvider) Status(ctx context.Context, asgName string) (*target.Status, error) {
+
+	asg, err := a.describeASG(ctx, asgName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe AWS Autoscaling Group: %v", err)
 	}
 
-	// Attempt to pull access credentials for the AWS client from the user
-	// supplied configuration. In order to use these static credentials both
-	// the access key and secret key need to be present; the session token is
-	// optional.
-	keyID, idOK := config[configKeyAccessID]
-	secretKey, keyOK := config[configKeySecretKey]
-	session, _ := config[configKeySessionToken]
+	events, err := a.describeActivities(ctx, asgName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe AWS Autoscaling Group activities: %v", err)
+	}
 
-	if idOK && keyOK {
-		t.logger.Trace("setting AWS access credentials from config map")
-		cfg.Credentials = aws.NewStaticCredentialsProvider(keyID, secretKey, session)
+	// Set our initial status. The asg.Status field is only set when the ASG is
+	// being deleted.
+	resp := target.Status{
+		Ready: asg.Status == nil,
+		Count: *asg.DesiredCapacity,
+		Meta:  make(map[string]string),
 	}
 
-	// Set up our AWS clients.
-	t.ec2 = ec2.New(cfg)
-	t.asg = autoscaling.New(cfg)
+	// If we have previous activities then process the last.
+	if len(events) > 0 {
+		processLastActivity(events[0], &resp)
+	}
 
-	return nil
+	return &resp, nil
 }
 
-// scaleOut updates the Auto Scaling Group desired count to match what the
-// Autoscaler has deemed required.
-func (t *TargetPlugin) scaleOut(ctx context.Context, asg *autoscaling.AutoScalingGroup, count int64) error {
+// ScaleOut satisfies the ScaleOut function on the provider interface. It
+// updates the Auto Scaling Group desired count to match what the Autoscaler
+// has deemed required.
+func (a *awsProvider) ScaleOut(ctx context.Context, asgName string, count int64) error {
 
-	// Create a logger for this action to pre-populate useful information we
-	// would like on all log lines.
-	log := t.logger.With("action", "scale_out", "asg_name", *asg.AutoScalingGroupName,
-		"desired_count", count)
+	// Describe the ASG, as the update requires its availability zones.
+	asg, err := a.describeASG(ctx, asgName)
+	if err != nil {
+		return fmt.Errorf("failed to describe AWS Autoscaling Group: %v", err)
+	}
 
 	input := autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: asg.AutoScalingGroupName,
@@ -77,54 +96,34 @@ func (t *TargetPlugin) scaleOut(ctx context.Context, asg *autoscaling.AutoScalin
 		DesiredCapacity:      aws.Int64(count),
 	}
 
-	// Ignore the response from Send() as its empty.
-	_, err := t.asg.UpdateAutoScalingGroupRequest(&input).Send(ctx)
+	// Ignore the response as its empty.
+	_, err = a.asg.UpdateAutoScalingGroup(ctx, &input)
 	if err != nil {
 		return fmt.Errorf("failed to update Autoscaling Group: %v", err)
 	}
 
-	if err := t.ensureASGInstancesCount(ctx, count, *asg.AutoScalingGroupName); err != nil {
+	if err := a.ensureASGInstancesCount(ctx, count, asgName); err != nil {
 		return fmt.Errorf("failed to confirm scale out AWS AutoScaling Group: %v", err)
 	}
-
-	log.Info("successfully performed and verified scaling out")
 	return nil
 }
 
-func (t *TargetPlugin) scaleIn(ctx context.Context, asg *autoscaling.AutoScalingGroup, num int64, config map[string]string) error {
-
-	scaleReq, err := t.generateScaleReq(num, config)
-	if err != nil {
-		return fmt.Errorf("failed to generate scale in request: %v", err)
-	}
-
-	ids, err := t.scaleInUtils.RunPreScaleInTasks(ctx, scaleReq)
-	if err != nil {
-		return fmt.Errorf("failed to perform Nomad scale in tasks: %v", err)
-	}
-
-	// Grab the instanceIDs once as it is used multiple times throughout the
-	// scale in event.
-	var instanceIDs []string
-
-	for _, node := range ids {
-		instanceIDs = append(instanceIDs, node.RemoteID)
-	}
+// ScaleIn satisfies the ScaleIn function on the provider interface. The
+// instances are detached from the ASG before being terminated, with each
+// phase recorded as a tag on the ASG.
+func (a *awsProvider) ScaleIn(ctx context.Context, asgName string, instanceIDs []string) error {
 
 	// Create the event writer and write that the drain event has been
-	// completed which is part of the RunPreScaleInTasks() function.
-	eWriter := newEventWriter(t.logger, t.asg, instanceIDs, *asg.AutoScalingGroupName)
+	// completed, as the nodes are drained before the provider is called.
+	eWriter := newEventWriter(a.logger, a.asg, instanceIDs, asgName)
 	eWriter.write(ctx, scalingEventDrain)
 
-	// Create a logger for this action to pre-populate useful information we
-	// would like on all log lines.
-	log := t.logger.With("action", "scale_in", "asg_name", *asg.AutoScalingGroupName,
-		"instances", instanceIDs)
+	log := a.logger.With("asg_name", asgName, "instances", instanceIDs)
 
 	// Detach the desired instances.
 	log.Debug("detaching instances from AutoScaling Group")
 
-	if err := t.detachInstances(ctx, asg.AutoScalingGroupName, instanceIDs); err != nil {
+	if err := a.detachInstances(ctx, aws.String(asgName), instanceIDs); err != nil {
 		return fmt.Errorf("failed to scale in AWS AutoScaling Group: %v", err)
 	}
 	log.Info("successfully detached instances from AutoScaling Group")
@@ -133,7 +132,7 @@ func (t *TargetPlugin) scaleIn(ctx context.Context, asg *autoscaling.AutoScaling
 	// Terminate the detached instances.
 	log.Debug("terminating EC2 instances")
 
-	if err := t.terminateInstances(ctx, instanceIDs); err != nil {
+	if err := a.terminateInstances(ctx, instanceIDs); err != nil {
 		return fmt.Errorf("failed to scale in AWS AutoScaling Group: %v", err)
 	}
 	log.Info("successfully terminated EC2 instances")
@@ -142,40 +141,56 @@ func (t *TargetPlugin) scaleIn(ctx context.Context, asg *autoscaling.AutoScaling
 	return nil
 }
 
-func (t *TargetPlugin) generateScaleReq(num int64, config map[string]string) (*utils.ScaleInReq, error) {
+// HealthCheck satisfies the HealthCheck function on the provider interface.
+func (a *awsProvider) HealthCheck(ctx context.Context) error {
+	return a.checkAWSCredentials(ctx)
+}
 
-	// Pull the class key from the config mapping. This is a required value and
-	// we cannot scale without this.
-	class, ok := config[target.ConfigKeyClass]
+// setupAWSClients takes the passed config mapping and instantiates the
+// required AWS service clients.
+func (a *awsProvider) setupAWSClients(config map[string]string) error {
+
+	// Load our default AWS config. This handles pulling configuration from
+	// default profiles and environment variables.
+	cfg, err := external.LoadDefaultAWSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load default AWS config: %v", err)
+	}
+
+	// Check for a configured region and set the value to our internal default
+	// if nothing is found.
+	region, ok := config[configKeyRegion]
 	if !ok {
-		return nil, fmt.Errorf("required config param %q not found", target.ConfigKeyClass)
+		region = configValueRegionDefault
 	}
 
-	// The drain_deadline is an optional parameter so define out default and
-	// then attempt to find an operator specified value.
-	drain := utils.DefaultDrainDeadline
+	// If the default config is empty, update it.
+	if cfg.Region == "" {
+		a.logger.Trace("setting AWS region for client", "region", region)
+		cfg.Region = region
+	}
 
-	if drainString, ok := config[target.ConfigKeyDrainDeadline]; ok {
-		d, err := time.ParseDuration(drainString)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse %q as time duration", drainString)
-		}
-		drain = d
-	}
-
-	return &utils.ScaleInReq{
-		Num:           int(num),
-		DrainDeadline: drain,
-		PoolIdentifier: &utils.PoolIdentifier{
-			IdentifierKey: utils.IdentifierKeyClass,
-			Value:         class,
-		},
-		RemoteProvider: utils.RemoteProviderAWSInstanceID,
-		NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
-	}, nil
+	// Attempt to pull access credentials for the AWS client from the user
+	// supplied configuration. In order to use these static credentials both
+	// the access key and secret key need to be present; the session token is
+	// optional.
+	keyID, idOK := config[configKeyAccessID]
+	secretKey, keyOK := config[configKeySecretKey]
+	session, _ := config[configKeySessionToken]
+
+	if idOK && keyOK {
+		a.logger.Trace("setting AWS access credentials from config map")
+		cfg.Credentials = aws.NewStaticCredentialsProvider(keyID, secretKey, session)
+	}
+
+	// Set up our AWS clients.
+	a.ec2 = awsclient.NewEC2(cfg)
+	a.asg = awsclient.NewAutoScaling(cfg)
+
+	return nil
 }
 
-func (t *TargetPlugin) detachInstances(ctx context.Context, asgName *string, instanceIDs []string) error {
+func (a *awsProvider) detachInstances(ctx context.Context, asgName *string, instanceIDs []string) error {
 
 	asgInput := autoscaling.DetachInstancesInput{
 		AutoScalingGroupName:           asgName,
@@ -183,7 +198,7 @@ func (t *TargetPlugin) detachInstances(ctx context.Context, asgName *string, ins
 		ShouldDecrementDesiredCapacity: aws.Bool(true),
 	}
 
-	asgResp, err := t.asg.DetachInstancesRequest(&asgInput).Send(ctx)
+	asgResp, err := a.asg.DetachInstances(ctx, &asgInput)
 	if err != nil {
 		return fmt.Errorf("failed to detach intances from Autoscaling Group: %v", err)
 	}
@@ -199,19 +214,19 @@ func (t *TargetPlugin) detachInstances(ctx context.Context, asgName *string, ins
 	// Confirm that the detachments complete before moving on. I (jrasell) am
 	// not exactly sure what happens if we terminate an instance which is still
 	// detaching from an ASG, but we might as well avoid finding out if we can.
-	if err := t.ensureActivitiesComplete(ctx, activityIDs, *asgName); err != nil {
+	if err := a.ensureActivitiesComplete(ctx, activityIDs, *asgName); err != nil {
 		err = fmt.Errorf("failed to detached instances from AutoScaling Group: %v", err)
 	}
 	return err
 }
 
-func (t *TargetPlugin) terminateInstances(ctx context.Context, instanceIDs []string) error {
+func (a *awsProvider) terminateInstances(ctx context.Context, instanceIDs []string) error {
 
 	ec2Input := ec2.TerminateInstancesInput{InstanceIds: instanceIDs}
 
 	// TODO(jrasell) the response includes information about instance status
 	//  changes which we may want to validate in the future.
-	_, err := t.ec2.TerminateInstancesRequest(&ec2Input).Send(ctx)
+	_, err := a.ec2.TerminateInstances(ctx, &ec2Input)
 	if err != nil {
 		return fmt.Errorf("failed to terminate EC2 intances: %v", err)
 	}
@@ -219,17 +234,31 @@ func (t *TargetPlugin) terminateInstances(ctx context.Context, instanceIDs []str
 	// Confirm that the instances have indeed terminated properly. This allows
 	// us to handle reconciliation if the error is transient, or at least
 	// allows operators to see the error and perform manual actions to resolve.
-	if err := t.ensureInstancesTerminate(ctx, instanceIDs); err != nil {
+	if err := a.ensureInstancesTerminate(ctx, instanceIDs); err != nil {
 		err = fmt.Errorf("failed to terminate EC2 instances: %v", err)
 	}
 	return err
 }
 
-func (t *TargetPlugin) describeASG(ctx context.Context, asgName string) (*autoscaling.AutoScalingGroup, error) {
+// checkAWSCredentials performs a cheap, read-only AWS call to confirm the
+// configured credentials are valid.
+func (a *awsProvider) checkAWSCredentials(ctx context.Context) error {
+	if a.asg == nil {
+		return fmt.Errorf("AWS clients not configured")
+	}
+
+	_, err := a.asg.DescribeAccountLimits(ctx, &autoscaling.DescribeAccountLimitsInput{})
+	if err != nil {
+		return fmt.Errorf("failed to validate AWS credentials: %v", err)
+	}
+	return nil
+}
+
+func (a *awsProvider) describeASG(ctx context.Context, asgName string) (*autoscaling.AutoScalingGroup, error) {
 
 	input := autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []string{asgName}}
 
-	resp, err := t.asg.DescribeAutoScalingGroupsRequest(&input).Send(ctx)
+	resp, err := a.asg.DescribeAutoScalingGroups(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +269,7 @@ func (t *TargetPlugin) describeASG(ctx context.Context, asgName string) (*autosc
 	return &resp.AutoScalingGroups[0], nil
 }
 
-func (t *TargetPlugin) describeActivities(ctx context.Context, asgName string, ids []string) ([]autoscaling.Activity, error) {
+func (a *awsProvider) describeActivities(ctx context.Context, asgName string, ids []string) ([]autoscaling.Activity, error) {
 
 	input := autoscaling.DescribeScalingActivitiesInput{AutoScalingGroupName: aws.String(asgName)}
 
@@ -250,7 +279,7 @@ func (t *TargetPlugin) describeActivities(ctx context.Context, asgName string, i
 		input.ActivityIds = ids
 	}
 
-	resp, err := t.asg.DescribeScalingActivitiesRequest(&input).Send(ctx)
+	resp, err := a.asg.DescribeScalingActivities(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
@@ -263,11 +292,11 @@ func (t *TargetPlugin) describeActivities(ctx context.Context, asgName string, i
 	return resp.Activities, nil
 }
 
-func (t *TargetPlugin) ensureActivitiesComplete(ctx context.Context, ids []string, asg string) error {
+func (a *awsProvider) ensureActivitiesComplete(ctx context.Context, ids []string, asg string) error {
 
 	f := func(ctx context.Context) (bool, error) {
 
-		activities, err := t.describeActivities(ctx, asg, ids)
+		activities, err := a.describeActivities(ctx, asg, ids)
 		if err != nil {
 			return true, err
 		}
@@ -291,16 +320,16 @@ func (t *TargetPlugin) ensureActivitiesComplete(ctx context.Context, ids []strin
 		return false, fmt.Errorf("waiting for %v activities to finish", len(ids))
 	}
 
-	return retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+	return retry.Retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
 }
 
-func (t *TargetPlugin) ensureInstancesTerminate(ctx context.Context, ids []string) error {
+func (a *awsProvider) ensureInstancesTerminate(ctx context.Context, ids []string) error {
 
 	f := func(ctx context.Context) (bool, error) {
 
 		input := ec2.DescribeInstanceStatusInput{InstanceIds: ids}
 
-		resp, err := t.ec2.DescribeInstanceStatusRequest(&input).Send(ctx)
+		resp, err := a.ec2.DescribeInstanceStatus(ctx, &input)
 		if err != nil {
 			return true, err
 		}
@@ -322,13 +351,13 @@ func (t *TargetPlugin) ensureInstancesTerminate(ctx context.Context, ids []strin
 		return false, fmt.Errorf("waiting for %v instances to terminate", len(ids))
 	}
 
-	return retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+	return retry.Retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
 }
 
-func (t *TargetPlugin) ensureASGInstancesCount(ctx context.Context, desired int64, asgName string) error {
+func (a *awsProvider) ensureASGInstancesCount(ctx context.Context, desired int64, asgName string) error {
 
 	f := func(ctx context.Context) (bool, error) {
-		asg, err := t.describeASG(ctx, asgName)
+		asg, err := a.describeASG(ctx, asgName)
 		if err != nil {
 			return true, err
 		}
@@ -339,5 +368,23 @@ func (t *TargetPlugin) ensureASGInstancesCount(ctx context.Context, desired int6
 		return false, fmt.Errorf("AutoScaling Group at %v instances of desired %v", asg.Instances, desired)
 	}
 
-	return retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+	return retry.Retry(ctx, defaultRetryInterval, defaultRetryLimit, f)
+}
+
+// processLastActivity updates the status object based on the details within
+// the last scaling activity.
+func processLastActivity(activity autoscaling.Activity, status *target.Status) {
+
+	// If the last activities progress is not nil then check whether this
+	// finished or not. In the event there is a current activity in progress
+	// set ready to false so the autoscaler will not perform any actions.
+	if activity.Progress != nil && *activity.Progress != 100 {
+		status.Ready = false
+	}
+
+	// EndTime isn't always populated, especially if the activity has not yet
+	// finished :).
+	if activity.EndTime != nil {
+		status.Meta[target.MetaKeyLastEvent] = strconv.FormatInt(activity.EndTime.UnixNano(), 10)
+	}
 }
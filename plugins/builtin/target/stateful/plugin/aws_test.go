@@ -1,85 +1,58 @@
 package plugin
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"testing"
-	"time"
 
-	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/awsclient"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestTargetPlugin_generateScaleReq(t *testing.T) {
-	testCases := []struct {
-		inputNum            int64
-		inputConfig         map[string]string
-		expectedOutputReq   *utils.ScaleInReq
-		expectedOutputError error
-		name                string
-	}{
-		{
-			inputNum: 2,
-			inputConfig: map[string]string{
-				"node_class":          "high-memory",
-				"node_drain_deadline": "5m",
-			},
-			expectedOutputReq: &utils.ScaleInReq{
-				Num:           2,
-				DrainDeadline: 5 * time.Minute,
-				PoolIdentifier: &utils.PoolIdentifier{
-					IdentifierKey: utils.IdentifierKeyClass,
-					Value:         "high-memory",
-				},
-				RemoteProvider: utils.RemoteProviderAWSInstanceID,
-				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
-			},
-			expectedOutputError: nil,
-			name:                "valid request with drain_deadline in config",
-		},
-		{
-			inputNum:            2,
-			inputConfig:         map[string]string{},
-			expectedOutputReq:   nil,
-			expectedOutputError: errors.New("required config param \"node_class\" not found"),
-			name:                "no class key found in config",
-		},
-		{
-			inputNum: 2,
-			inputConfig: map[string]string{
-				"node_class": "high-memory",
-			},
-			expectedOutputReq: &utils.ScaleInReq{
-				Num:           2,
-				DrainDeadline: 15 * time.Minute,
-				PoolIdentifier: &utils.PoolIdentifier{
-					IdentifierKey: utils.IdentifierKeyClass,
-					Value:         "high-memory",
-				},
-				RemoteProvider: utils.RemoteProviderAWSInstanceID,
-				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
-			},
-			expectedOutputError: nil,
-			name:                "drain_deadline not specified within config",
-		},
-		{
-			inputNum: 2,
-			inputConfig: map[string]string{
-				"node_class":          "high-memory",
-				"node_drain_deadline": "time to make a cuppa",
-			},
-			expectedOutputReq:   nil,
-			expectedOutputError: errors.New("failed to parse \"time to make a cuppa\" as time duration"),
-			name:                "malformed drain_deadline config value",
-		},
-	}
+func Test_awsProvider(t *testing.T) {
 
-	tp := TargetPlugin{}
+	asg := awsclient.NewFake()
+	asg.AddGroup("asg", []string{"zone-a"}, 2)
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			actualReq, actualErr := tp.generateScaleReq(tc.inputNum, tc.inputConfig)
-			assert.Equal(t, tc.expectedOutputReq, actualReq, tc.name)
-			assert.Equal(t, tc.expectedOutputError, actualErr, tc.name)
-		})
-	}
+	p := &awsProvider{logger: hclog.NewNullLogger(), asg: asg, ec2: asg}
+	ctx := context.Background()
+
+	assert.Nil(t, p.HealthCheck(ctx))
+
+	name, err := p.GroupName(map[string]string{configKeyASGName: "asg"})
+	assert.Nil(t, err)
+	assert.Equal(t, "asg", name)
+
+	// Scaling out waits for the instances to be launched.
+	assert.Nil(t, p.ScaleOut(ctx, "asg", 3))
+
+	g, _ := asg.Group("asg")
+	assert.Equal(t, int64(3), *g.DesiredCapacity)
+	assert.Len(t, g.Instances, 3)
+
+	// Scaling in detaches and terminates the instances, with the last phase
+	// recorded in the ASG tags.
+	assert.Nil(t, p.ScaleIn(ctx, "asg", []string{"i-00000000000000001"}))
+
+	g, _ = asg.Group("asg")
+	assert.Equal(t, int64(2), *g.DesiredCapacity)
+	assert.Len(t, g.Instances, 2)
+	assert.Equal(t, ec2.InstanceStateNameTerminated, asg.InstanceState("i-00000000000000001"))
+	assert.Len(t, g.Tags, 1)
+	assert.Equal(t, fmt.Sprintf("%v_1", tagKey), *g.Tags[0].Key)
+	assert.Equal(t, "terminate_i-00000000000000001", *g.Tags[0].Value)
+
+	status, err := p.Status(ctx, "asg")
+	assert.Nil(t, err)
+	assert.True(t, status.Ready)
+	assert.Equal(t, int64(2), status.Count)
+	assert.Contains(t, status.Meta, target.MetaKeyLastEvent)
+
+	// Unknown instances and groups fail.
+	assert.NotNil(t, p.ScaleIn(ctx, "asg", []string{"i-unknown"}))
+	_, err = p.Status(ctx, "unknown")
+	assert.NotNil(t, err)
 }
@@ -0,0 +1,240 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/helper/retry"
+	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// configKeys represents the known configuration parameters of the HTTP
+	// provider. The group is a target config param, with the others being
+	// plugin config params.
+	configKeyHTTPAddress = "http_address"
+	configKeyHTTPToken   = "http_token"
+	configKeyHTTPTimeout = "http_timeout"
+	configKeyHTTPGroup   = "http_group"
+
+	// defaultHTTPTimeout is the timeout of each request made to the instance
+	// API when not specified by an operator.
+	defaultHTTPTimeout = 30 * time.Second
+)
+
+// httpProvider is the generic HTTP instance API implementation of the
+// provider interface. It is modelled on the instance group APIs of clouds
+// such as Kingsoft Cloud, which are typically fronted by a thin internal
+// service exposing the following endpoints:
+//
+//   - GET  /v1/health                          confirms the API is reachable
+//   - GET  /v1/groups/:group                   returns the groupStatus
+//   - PUT  /v1/groups/:group/desired_count     sets {"desired_count": n}
+//   - POST /v1/groups/:group/terminate         terminates {"instance_ids": []}
+//
+// Terminating instances is expected to decrement the desired count of the
+// group. Requests are authenticated using a bearer token if configured.
+type httpProvider struct {
+	logger         hclog.Logger
+	address        string
+	token          string
+	client         *http.Client
+	remoteProvider utils.RemoteProvider
+
+	// retryInterval is the interval at which the group is checked while
+	// waiting for a change to complete.
+	retryInterval time.Duration
+}
+
+// desiredCountRequest is the body of a request to set the desired count of a
+// group.
+type desiredCountRequest struct {
+	DesiredCount int64 `json:"desired_count"`
+}
+
+// terminateRequest is the body of a request to terminate instances of a
+// group.
+type terminateRequest struct {
+	InstanceIDs []string `json:"instance_ids"`
+}
+
+// SetConfig satisfies the SetConfig function on the provider interface.
+func (h *httpProvider) SetConfig(config map[string]string) error {
+
+	address, ok := config[configKeyHTTPAddress]
+	if !ok || address == "" {
+		return fmt.Errorf("required config param %s not found", configKeyHTTPAddress)
+	}
+
+	timeout := defaultHTTPTimeout
+
+	if v, ok := config[configKeyHTTPTimeout]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as time duration", v)
+		}
+		timeout = d
+	}
+
+	remoteProvider, err := remoteProviderFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	h.address = strings.TrimSuffix(address, "/")
+	h.token = config[configKeyHTTPToken]
+	h.client = &http.Client{Timeout: timeout}
+	h.remoteProvider = remoteProvider
+	h.retryInterval = defaultRetryInterval
+	return nil
+}
+
+// RemoteProvider satisfies the RemoteProvider function on the provider
+// interface.
+func (h *httpProvider) RemoteProvider() utils.RemoteProvider { return h.remoteProvider }
+
+// GroupName satisfies the GroupName function on the provider interface.
+func (h *httpProvider) GroupName(config map[string]string) (string, error) {
+	group, ok := config[configKeyHTTPGroup]
+	if !ok {
+		return "", fmt.Errorf("required config param %s not found", configKeyHTTPGroup)
+	}
+	return group, nil
+}
+
+// Status satisfies the Status function on the provider interface.
+func (h *httpProvider) Status(ctx context.Context, group string) (*target.Status, error) {
+	status, err := h.groupStatus(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	return status.Status(), nil
+}
+
+// ScaleOut satisfies the ScaleOut function on the provider interface.
+func (h *httpProvider) ScaleOut(ctx context.Context, group string, count int64) error {
+
+	body := desiredCountRequest{DesiredCount: count}
+
+	if err := h.do(ctx, http.MethodPut, groupPath(group, "desired_count"), &body, nil); err != nil {
+		return fmt.Errorf("failed to update instance group: %v", err)
+	}
+
+	if err := h.ensureGroupReady(ctx, group, count); err != nil {
+		return fmt.Errorf("failed to confirm scale out of instance group: %v", err)
+	}
+	return nil
+}
+
+// ScaleIn satisfies the ScaleIn function on the provider interface.
+func (h *httpProvider) ScaleIn(ctx context.Context, group string, instanceIDs []string) error {
+
+	body := terminateRequest{InstanceIDs: instanceIDs}
+
+	if err := h.do(ctx, http.MethodPost, groupPath(group, "terminate"), &body, nil); err != nil {
+		return fmt.Errorf("failed to terminate instances: %v", err)
+	}
+
+	if err := h.ensureGroupReady(ctx, group, -1); err != nil {
+		return fmt.Errorf("failed to confirm scale in of instance group: %v", err)
+	}
+	return nil
+}
+
+// HealthCheck satisfies the HealthCheck function on the provider interface.
+func (h *httpProvider) HealthCheck(ctx context.Context) error {
+	if err := h.do(ctx, http.MethodGet, "/v1/health", nil, nil); err != nil {
+		return fmt.Errorf("failed to reach instance API: %v", err)
+	}
+	return nil
+}
+
+func (h *httpProvider) groupStatus(ctx context.Context, group string) (*groupStatus, error) {
+	var status groupStatus
+	if err := h.do(ctx, http.MethodGet, groupPath(group), nil, &status); err != nil {
+		return nil, fmt.Errorf("failed to read instance group: %v", err)
+	}
+	return &status, nil
+}
+
+// ensureGroupReady waits for the group to become ready. If desired is not
+// negative, the group's desired count must also match.
+func (h *httpProvider) ensureGroupReady(ctx context.Context, group string, desired int64) error {
+
+	f := func(ctx context.Context) (bool, error) {
+		status, err := h.groupStatus(ctx, group)
+		if err != nil {
+			return true, err
+		}
+
+		if status.Ready && (desired < 0 || status.Count == desired) {
+			return true, nil
+		}
+		return false, fmt.Errorf("instance group at %v instances of desired %v, ready %v",
+			status.Count, desired, status.Ready)
+	}
+
+	return retry.Retry(ctx, h.retryInterval, defaultRetryLimit, f)
+}
+
+// do performs the request, encoding the body and decoding the response into
+// out if they are not nil.
+func (h *httpProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+
+	var reqBody io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, h.address+path, reqBody)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// groupPath returns the API path of the group, followed by any elements.
+func groupPath(group string, elems ...string) string {
+	path := "/v1/groups/" + url.PathEscape(group)
+	for _, e := range elems {
+		path += "/" + e
+	}
+	return path
+}
@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// testInstanceAPI is a fake generic HTTP instance API serving a single group.
+type testInstanceAPI struct {
+	lock       sync.Mutex
+	status     groupStatus
+	terminated []string
+}
+
+func (api *testInstanceAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /v1/health":
+	case "GET /v1/groups/game-pool":
+		_ = json.NewEncoder(w).Encode(api.status)
+	case "PUT /v1/groups/game-pool/desired_count":
+		var req desiredCountRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		api.status.Count = req.DesiredCount
+	case "POST /v1/groups/game-pool/terminate":
+		var req terminateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		api.terminated = append(api.terminated, req.InstanceIDs...)
+		api.status.Count -= int64(len(req.InstanceIDs))
+	default:
+		http.Error(w, "group not found", http.StatusNotFound)
+	}
+}
+
+func newTestHTTPProvider(t *testing.T, srv *httptest.Server) *httpProvider {
+	p := &httpProvider{logger: hclog.NewNullLogger()}
+	err := p.SetConfig(map[string]string{
+		"http_address": srv.URL + "/",
+		"http_token":   "secret",
+	})
+	assert.Nil(t, err)
+	p.retryInterval = time.Millisecond
+	return p
+}
+
+func Test_httpProvider(t *testing.T) {
+	api := &testInstanceAPI{status: groupStatus{Count: 2, Ready: true}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	p := newTestHTTPProvider(t, srv)
+	ctx := context.Background()
+
+	assert.Nil(t, p.HealthCheck(ctx))
+
+	group, err := p.GroupName(map[string]string{"http_group": "game-pool"})
+	assert.Nil(t, err)
+	assert.Equal(t, "game-pool", group)
+
+	status, err := p.Status(ctx, group)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), status.Count)
+	assert.True(t, status.Ready)
+
+	assert.Nil(t, p.ScaleOut(ctx, group, 4))
+	assert.Equal(t, int64(4), api.status.Count)
+
+	assert.Nil(t, p.ScaleIn(ctx, group, []string{"ksc-1", "ksc-2"}))
+	assert.Equal(t, int64(2), api.status.Count)
+	assert.Equal(t, []string{"ksc-1", "ksc-2"}, api.terminated)
+
+	_, err = p.Status(ctx, "unknown")
+	assert.EqualError(t, err, "failed to read instance group: unexpected response code 404: group not found")
+}
+
+func Test_httpProvider_ensureGroupReady(t *testing.T) {
+	api := &testInstanceAPI{status: groupStatus{Count: 2, Ready: false}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	p := newTestHTTPProvider(t, srv)
+
+	// The group never becomes ready, so the retry limit is reached.
+	err := p.ensureGroupReady(context.Background(), "game-pool", 2)
+	assert.EqualError(t, err, "reached retry limit")
+
+	api.lock.Lock()
+	api.status.Ready = true
+	api.lock.Unlock()
+
+	assert.Nil(t, p.ensureGroupReady(context.Background(), "game-pool", 2))
+	assert.Nil(t, p.ensureGroupReady(context.Background(), "game-pool", -1))
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"io"
 	"strconv"
+	"sync"
 
-	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/helper/nomad"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
@@ -28,9 +28,20 @@ const (
 	configKeySessionToken = "aws_session_token"
 	configKeyASGName      = "aws_asg_name"
 
+	// configKeyIdleWaitDeadline is the target config key which enables
+	// waiting for busy nodes to become idle before they are drained, up to
+	// the specified duration.
+	configKeyIdleWaitDeadline = "idle_wait_deadline"
+
 	// configValues are the default values used when a configuration key is not
 	// supplied by the operator that are specific to the plugin.
 	configValueRegionDefault = "us-east-1"
+
+	// metaKeys are the keys of the busy state derived meta included in the
+	// target status.
+	metaKeyBusyNodes              = "nomad_autoscaler.stateful.busy_nodes"
+	metaKeyIdleNodes              = "nomad_autoscaler.stateful.idle_nodes"
+	metaKeyNodesPendingRetirement = "nomad_autoscaler.stateful.nodes_pending_retirement"
 )
 
 var (
@@ -44,23 +55,39 @@ var (
 	}
 )
 
-// Assert that TargetPlugin meets the target.Target interface.
-var _ target.Target = (*TargetPlugin)(nil)
+// Assert that TargetPlugin meets the target.ContextTarget, base.HealthChecker
+// and io.Closer interfaces.
+var (
+	_ target.ContextTarget = (*TargetPlugin)(nil)
+	_ base.HealthChecker   = (*TargetPlugin)(nil)
+	_ io.Closer            = (*TargetPlugin)(nil)
+)
 
-// TargetPlugin is the AWS ASG implementation of the target.Target interface.
+// TargetPlugin is the stateful implementation of the target.Target interface.
+// It retires nodes once they are idle, using a provider to manage the
+// instances hosting them.
 type TargetPlugin struct {
 	config       map[string]string
 	logger       hclog.Logger
-	asg          *autoscaling.Client
-	ec2          *ec2.Client
+	provider     provider
 	scaleInUtils *utils.ScaleIn
+	redis        *utils.RedisClient
+
+	// retirements is the number of nodes pending retirement, keyed by the
+	// name of the instance group they belong to. A retirement starts once a
+	// scale in is triggered and completes once the instances have been
+	// terminated, or the scale in has failed. The lock should be used when
+	// accessing the map.
+	retirements     map[string]int
+	retirementsLock sync.RWMutex
 }
 
 // NewAWSASGPlugin returns the AWS ASG implementation of the target.Target
 // interface.
 func NewAWSASGPlugin(log hclog.Logger) *TargetPlugin {
 	return &TargetPlugin{
-		logger: log,
+		logger:      log,
+		retirements: make(map[string]int),
 	}
 }
 
@@ -69,15 +96,45 @@ func (t *TargetPlugin) SetConfig(config map[string]string) error {
 
 	t.config = config
 
-	if err := t.setupAWSClients(config); err != nil {
+	p, err := newProvider(config, t.logger)
+	if err != nil {
 		return err
 	}
+	t.provider = p
+
+	// Coordinating node retirement using Redis is optional, and is enabled
+	// by configuring its address. Each plugin instance has its own client so
+	// that instances can use different Redis servers.
+	redisCfg, err := utils.RedisConfigFromMap(config)
+	if err != nil {
+		return fmt.Errorf("failed to parse Redis config: %v", err)
+	}
+
+	if t.redis != nil {
+		t.redis.Close()
+		t.redis = nil
+	}
+
+	if redisCfg != nil {
+		if t.redis, err = utils.NewRedisClient(redisCfg, t.logger.Named("redis")); err != nil {
+			return fmt.Errorf("failed to setup Redis client: %v", err)
+		}
+	}
+
+	busyCfg, err := utils.BusyStateConfigFromMap(config)
+	if err != nil {
+		return fmt.Errorf("failed to parse busy state config: %v", err)
+	}
 
-	utils, err := utils.NewScaleInUtils(nomad.ConfigFromNamespacedMap(config), utils.DmsConfigFromMap(config), t.logger)
+	scaleInUtils, err := utils.NewScaleInUtils(nomad.ConfigFromNamespacedMap(config), busyCfg, t.redis, t.logger)
 	if err != nil {
 		return err
 	}
-	t.scaleInUtils = utils
+	t.scaleInUtils = scaleInUtils
+
+	if t.redis != nil {
+		t.scaleInUtils.EnableRetirementCoordination(t.redis)
+	}
 
 	return nil
 }
@@ -87,46 +144,83 @@ func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
 	return pluginInfo, nil
 }
 
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. It confirms the provider is correctly configured and that the
+// busy state backend, and Redis if configured, are reachable.
+func (t *TargetPlugin) HealthCheck(ctx context.Context) error {
+	if t.provider == nil {
+		return fmt.Errorf("provider not configured")
+	}
+	if err := t.provider.HealthCheck(ctx); err != nil {
+		return err
+	}
+	if t.scaleInUtils == nil {
+		return fmt.Errorf("scale in utils not configured")
+	}
+	if err := t.scaleInUtils.CheckBusyState(); err != nil {
+		return err
+	}
+	return t.scaleInUtils.CheckRedis()
+}
+
+// Close satisfies the io.Closer interface. It closes the Redis client, if
+// configured, once the plugin is no longer used.
+func (t *TargetPlugin) Close() error {
+	if t.redis == nil {
+		return nil
+	}
+	return t.redis.Close()
+}
+
 // Scale satisfies the Scale function on the target.Target interface.
 func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
+	return t.ScaleContext(context.Background(), action, config)
+}
+
+// ScaleContext satisfies the ScaleContext function on the target.ContextTarget
+// interface.
+func (t *TargetPlugin) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
 
-	// AWS can't support dry-run like Nomad, so just exit.
+	// The providers can't support dry-run like Nomad, so just exit.
 	if action.Count == strategy.MetaValueDryRunCount {
 		return nil
 	}
 
-	// We cannot scale an ASG without knowing the ASG name.
-	asgName, ok := config[configKeyASGName]
-	if !ok {
-		return fmt.Errorf("required config param %s not found", configKeyASGName)
+	// We cannot scale an instance group without knowing which it is.
+	group, err := t.provider.GroupName(config)
+	if err != nil {
+		return err
 	}
-	ctx := context.Background()
 
-	// Describe the ASG. This serves to both validate the config value is
-	// correct and ensure the AWS client is configured correctly. The response
-	// can also be used when performing the scaling, meaning we only need to
-	// call it once.
-	curASG, err := t.describeASG(ctx, asgName)
+	// Read the status of the group. This serves to both validate the config
+	// value is correct and ensure the provider is configured correctly.
+	status, err := t.provider.Status(ctx, group)
 	if err != nil {
-		return fmt.Errorf("failed to describe AWS Autoscaling Group: %v", err)
+		return err
 	}
 
-	// The AWS ASG target requires different details depending on which
-	// direction we want to scale. Therefore calculate the direction and the
-	// relevant number so we can correctly perform the AWS work.
-	num, direction := t.calculateDirection(*curASG.DesiredCapacity, action.Count)
+	// The providers require different details depending on which direction
+	// we want to scale. Therefore calculate the direction and the relevant
+	// number so we can correctly perform the work.
+	num, direction := t.calculateDirection(status.Count, action.Count)
 
 	switch direction {
 	case "in":
-		err = t.scaleIn(ctx, curASG, num, config)
+		err = t.scaleIn(ctx, group, num, config)
 	case "out":
-		err = t.scaleOut(ctx, curASG, num)
+		err = t.scaleOut(ctx, group, num)
 	default:
-		t.logger.Info("scaling not required", "asg_name", asgName,
-			"current_count", *curASG.DesiredCapacity, "strategy_count", action.Count)
+		t.logger.Info("scaling not required", "group", group,
+			"current_count", status.Count, "strategy_count", action.Count)
 		return nil
 	}
 
+	// If only some of the nodes were removed, report the count the target
+	// reached so the autoscaler can account for the nodes which were.
+	if drainErr, ok := err.(*utils.PartialDrainError); ok {
+		return &target.PartialScaleError{Count: status.Count - int64(len(drainErr.Drained)), Err: drainErr}
+	}
+
 	// If we received an error while scaling, format this with an outer message
 	// so its nice for the operators and then return any error to the caller.
 	if err != nil {
@@ -137,65 +231,86 @@ func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) e
 
 // Status satisfies the Status function on the target.Target interface.
 func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error) {
+	return t.StatusContext(context.Background(), config)
+}
 
-	// We cannot get the status of an ASG if we don't know its name.
-	asgName, ok := config[configKeyASGName]
-	if !ok {
-		return nil, fmt.Errorf("required config param %s not found", configKeyASGName)
-	}
-	ctx := context.Background()
+// StatusContext satisfies the StatusContext function on the
+// target.ContextTarget interface.
+func (t *TargetPlugin) StatusContext(ctx context.Context, config map[string]string) (*target.Status, error) {
 
-	asg, err := t.describeASG(ctx, asgName)
+	// We cannot get the status of an instance group if we don't know which
+	// it is.
+	group, err := t.provider.GroupName(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe AWS Autoscaling Group: %v", err)
+		return nil, err
 	}
 
-	events, err := t.describeActivities(ctx, asgName, nil)
+	resp, err := t.provider.Status(ctx, group)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe AWS Autoscaling Group activities: %v", err)
+		return nil, err
 	}
 
-	// Set our initial status. The asg.Status field is only set when the ASG is
-	// being deleted.
-	resp := target.Status{
-		Ready: asg.Status == nil,
-		Count: *asg.DesiredCapacity,
-		Meta:  make(map[string]string),
-	}
+	// A retirement in progress means the group is not ready, as the policy
+	// handler should not trigger another scale in on top of it.
+	t.processRetirement(group, resp)
+	t.processBusyState(config, resp)
+
+	return resp, nil
+}
 
-	// If we have previous activities then process the last.
-	if events != nil && len(events) > 0 {
-		processLastActivity(events[0], &resp)
+// setRetirement records the number of nodes of the group pending retirement.
+// A count of zero marks the retirement as complete.
+func (t *TargetPlugin) setRetirement(group string, count int) {
+	t.retirementsLock.Lock()
+	defer t.retirementsLock.Unlock()
+
+	if count == 0 {
+		delete(t.retirements, group)
+		return
 	}
+	t.retirements[group] = count
+}
 
-	return &resp, nil
+// processRetirement updates the status object based on any retirement of the
+// group's nodes which is in progress.
+func (t *TargetPlugin) processRetirement(group string, status *target.Status) {
+	t.retirementsLock.RLock()
+	count := t.retirements[group]
+	t.retirementsLock.RUnlock()
+
+	status.Meta[metaKeyNodesPendingRetirement] = strconv.Itoa(count)
+	if count > 0 {
+		status.Ready = false
+	}
 }
 
-func (t *TargetPlugin) calculateDirection(asgDesired, strategyDesired int64) (int64, string) {
+// processBusyState updates the status object with the number of busy and
+// idle nodes within the pool. Failing to get the counts is not fatal to the
+// status call, so the meta is omitted instead.
+func (t *TargetPlugin) processBusyState(config map[string]string, status *target.Status) {
 
-	if strategyDesired < asgDesired {
-		return asgDesired - strategyDesired, "in"
+	pool := utils.PoolIdentifierFromConfig(config)
+	if pool == nil || t.scaleInUtils == nil {
+		return
 	}
-	if strategyDesired > asgDesired {
-		return strategyDesired, "out"
+
+	busy, idle, err := t.scaleInUtils.PoolBusyState(context.Background(), pool)
+	if err != nil {
+		t.logger.Warn("failed to get pool busy state", "pool", pool.Value, "error", err)
+		return
 	}
-	return 0, ""
+
+	status.Meta[metaKeyBusyNodes] = strconv.Itoa(busy)
+	status.Meta[metaKeyIdleNodes] = strconv.Itoa(idle)
 }
 
-// processLastActivity updates the status object based on the details within
-// the last scaling activity.
-func processLastActivity(activity autoscaling.Activity, status *target.Status) {
+func (t *TargetPlugin) calculateDirection(groupDesired, strategyDesired int64) (int64, string) {
 
-	// If the last activities progress is not nil then check whether this
-	// finished or not. In the event there is a current activity in progress
-	// set ready to false so the autoscaler will not perform any actions.
-	if activity.Progress != nil && *activity.Progress != 100 {
-		status.Ready = false
+	if strategyDesired < groupDesired {
+		return groupDesired - strategyDesired, "in"
 	}
-
-	// EndTime isn't always populated, especially if the activity has not yet
-	// finished :).
-	if activity.EndTime != nil {
-		status.Meta[target.MetaKeyLastEvent] = strconv.FormatInt(activity.EndTime.UnixNano(), 10)
+	if strategyDesired > groupDesired {
+		return strategyDesired, "out"
 	}
+	return 0, ""
 }
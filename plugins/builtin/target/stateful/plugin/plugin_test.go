@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 	"github.com/stretchr/testify/assert"
 )
@@ -120,6 +121,49 @@ func Test_processLastActivity(t *testing.T) {
 	}
 }
 
+func TestTargetPlugin_processRetirement(t *testing.T) {
+	testCases := []struct {
+		inputRetirement int
+		expectedStatus  *target.Status
+		name            string
+	}{
+		{
+			inputRetirement: 2,
+			expectedStatus: &target.Status{
+				Ready: false,
+				Count: 1,
+				Meta: map[string]string{
+					"nomad_autoscaler.stateful.nodes_pending_retirement": "2",
+				},
+			},
+			name: "retirement in progress",
+		},
+		{
+			inputRetirement: 0,
+			expectedStatus: &target.Status{
+				Ready: true,
+				Count: 1,
+				Meta: map[string]string{
+					"nomad_autoscaler.stateful.nodes_pending_retirement": "0",
+				},
+			},
+			name: "no retirement in progress",
+		},
+	}
+
+	tp := NewAWSASGPlugin(hclog.NewNullLogger())
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tp.setRetirement("test-asg", tc.inputRetirement)
+
+			status := &target.Status{Ready: true, Count: 1, Meta: map[string]string{}}
+			tp.processRetirement("test-asg", status)
+			assert.Equal(t, tc.expectedStatus, status, tc.name)
+		})
+	}
+}
+
 func int64ToPtr(v int64) *int64 {
 	return &v
 }
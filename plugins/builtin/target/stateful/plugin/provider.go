@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// configKeyProvider is the plugin config key which selects the provider
+	// hosting the nodes of the stateful pools.
+	configKeyProvider = "provider"
+
+	// configKeyRemoteIDSource is the plugin config key which selects how the
+	// Nomad nodes are translated to instance IDs by the providers which are
+	// not able to use a platform specific node attribute.
+	configKeyRemoteIDSource = "remote_id_source"
+)
+
+// providerName identifies the infrastructure provider which hosts the nodes.
+type providerName string
+
+const (
+	// providerNameAWS manages the nodes using an AWS AutoScaling Group. This
+	// is the default.
+	providerNameAWS providerName = "aws"
+
+	// providerNameHTTP manages the nodes using a generic HTTP instance group
+	// API, such as those exposed by Kingsoft Cloud.
+	providerNameHTTP providerName = "http"
+
+	// providerNameScript manages the nodes by running operator supplied
+	// commands.
+	providerNameScript providerName = "script"
+)
+
+// provider is the interface implemented by the infrastructure providers which
+// host the nodes of a stateful pool. The plugin performs the busy aware
+// retirement of the nodes, leaving the provider to manage the instances.
+type provider interface {
+
+	// SetConfig configures the provider using the plugin config.
+	SetConfig(config map[string]string) error
+
+	// RemoteProvider returns how Nomad nodes are translated to the IDs of the
+	// provider's instances.
+	RemoteProvider() utils.RemoteProvider
+
+	// GroupName returns the name of the instance group identified by the
+	// target config.
+	GroupName(config map[string]string) (string, error)
+
+	// Status returns the status of the instance group.
+	Status(ctx context.Context, group string) (*target.Status, error)
+
+	// ScaleOut updates the desired count of the instance group.
+	ScaleOut(ctx context.Context, group string, count int64) error
+
+	// ScaleIn removes the instances, which have already been drained, from
+	// the instance group and terminates them.
+	ScaleIn(ctx context.Context, group string, instanceIDs []string) error
+
+	// HealthCheck confirms the provider is reachable and correctly
+	// configured.
+	HealthCheck(ctx context.Context) error
+}
+
+// newProvider returns the provider configured, defaulting to AWS.
+func newProvider(config map[string]string, log hclog.Logger) (provider, error) {
+
+	name := providerNameAWS
+	if v, ok := config[configKeyProvider]; ok {
+		name = providerName(v)
+	}
+
+	var p provider
+
+	switch name {
+	case providerNameAWS:
+		p = &awsProvider{logger: log.Named(string(name))}
+	case providerNameHTTP:
+		p = &httpProvider{logger: log.Named(string(name))}
+	case providerNameScript:
+		p = &scriptProvider{logger: log.Named(string(name))}
+	default:
+		return nil, fmt.Errorf("unsupported provider: %q", name)
+	}
+
+	if err := p.SetConfig(config); err != nil {
+		return nil, fmt.Errorf("failed to configure %s provider: %v", name, err)
+	}
+	return p, nil
+}
+
+// remoteProviderFromConfig returns the configured source of instance IDs for
+// the providers which support a choice, defaulting to the node name.
+func remoteProviderFromConfig(config map[string]string) (utils.RemoteProvider, error) {
+
+	v, ok := config[configKeyRemoteIDSource]
+	if !ok {
+		return utils.RemoteProviderNodeName, nil
+	}
+
+	switch rp := utils.RemoteProvider(v); rp {
+	case utils.RemoteProviderNodeName, utils.RemoteProviderNodeMeta:
+		return rp, nil
+	default:
+		return "", fmt.Errorf("unsupported %q: %q", configKeyRemoteIDSource, v)
+	}
+}
+
+// groupStatus is the status of an instance group as reported by the HTTP and
+// script providers. It shares its encoding with the webhook and exec targets,
+// with the count being the number of instances the group is converging on.
+type groupStatus = target.StatusPayload
@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newProvider(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutputType  provider
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig:         map[string]string{},
+			expectedOutputType:  &awsProvider{},
+			expectedOutputError: nil,
+			name:                "default aws provider",
+		},
+		{
+			inputConfig: map[string]string{
+				"provider":     "http",
+				"http_address": "http://127.0.0.1:8080",
+			},
+			expectedOutputType:  &httpProvider{},
+			expectedOutputError: nil,
+			name:                "http provider",
+		},
+		{
+			inputConfig: map[string]string{
+				"provider": "http",
+			},
+			expectedOutputType:  nil,
+			expectedOutputError: errors.New("failed to configure http provider: required config param http_address not found"),
+			name:                "http provider missing address",
+		},
+		{
+			inputConfig: map[string]string{
+				"provider":                 "script",
+				"script_status_command":    "cat status.json",
+				"script_scale_out_command": "./scale-out.sh",
+				"script_terminate_command": "./terminate.sh",
+			},
+			expectedOutputType:  &scriptProvider{},
+			expectedOutputError: nil,
+			name:                "script provider",
+		},
+		{
+			inputConfig: map[string]string{
+				"provider":              "script",
+				"script_status_command": "cat status.json",
+			},
+			expectedOutputType:  nil,
+			expectedOutputError: errors.New("failed to configure script provider: required config param script_scale_out_command not found"),
+			name:                "script provider missing command",
+		},
+		{
+			inputConfig: map[string]string{
+				"provider": "gce",
+			},
+			expectedOutputType:  nil,
+			expectedOutputError: errors.New("unsupported provider: \"gce\""),
+			name:                "unsupported provider",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualError := newProvider(tc.inputConfig, hclog.NewNullLogger())
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+			assert.IsType(t, tc.expectedOutputType, actualOutput, tc.name)
+		})
+	}
+}
+
+func Test_remoteProviderFromConfig(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutput      utils.RemoteProvider
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig:         map[string]string{},
+			expectedOutput:      utils.RemoteProviderNodeName,
+			expectedOutputError: nil,
+			name:                "default node name",
+		},
+		{
+			inputConfig:         map[string]string{"remote_id_source": "node_meta"},
+			expectedOutput:      utils.RemoteProviderNodeMeta,
+			expectedOutputError: nil,
+			name:                "node meta",
+		},
+		{
+			inputConfig:         map[string]string{"remote_id_source": "aws_instance_id"},
+			expectedOutput:      "",
+			expectedOutputError: errors.New("unsupported \"remote_id_source\": \"aws_instance_id\""),
+			name:                "unsupported source",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualError := remoteProviderFromConfig(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+		})
+	}
+}
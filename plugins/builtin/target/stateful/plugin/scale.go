@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+// scaleOut updates the instance group desired count to match what the
+// Autoscaler has deemed required.
+func (t *TargetPlugin) scaleOut(ctx context.Context, group string, count int64) error {
+
+	// Create a logger for this action to pre-populate useful information we
+	// would like on all log lines.
+	log := t.logger.With("action", "scale_out", "group", group, "desired_count", count)
+
+	if err := t.provider.ScaleOut(ctx, group, count); err != nil {
+		return err
+	}
+
+	log.Info("successfully performed and verified scaling out")
+	return nil
+}
+
+// scaleIn retires num nodes of the instance group. The nodes are selected,
+// waited upon until idle and drained, before the provider terminates their
+// instances. If only some of the nodes drained, those nodes are terminated and
+// the utils.PartialDrainError is returned.
+func (t *TargetPlugin) scaleIn(ctx context.Context, group string, num int64, config map[string]string) error {
+
+	scaleReq, err := t.generateScaleReq(num, config)
+	if err != nil {
+		return fmt.Errorf("failed to generate scale in request: %v", err)
+	}
+
+	// Track the retirement for its whole duration, initially assuming all the
+	// requested nodes will be retired, so the group is reported as not ready.
+	t.setRetirement(group, int(num))
+	defer t.setRetirement(group, 0)
+
+	// If only some of the nodes drained, the drained nodes are still
+	// terminated and the partial result is reported once done.
+	ids, err := t.scaleInUtils.RunPreScaleInTasks(ctx, scaleReq)
+	drainErr, partial := err.(*utils.PartialDrainError)
+	if err != nil && !partial {
+		return fmt.Errorf("failed to perform Nomad scale in tasks: %v", err)
+	}
+	defer t.scaleInUtils.RunPostScaleInTasks(scaleReq, ids)
+	t.setRetirement(group, len(ids))
+
+	var instanceIDs []string
+
+	for _, node := range ids {
+		instanceIDs = append(instanceIDs, node.RemoteID)
+	}
+
+	// Create a logger for this action to pre-populate useful information we
+	// would like on all log lines.
+	log := t.logger.With("action", "scale_in", "group", group, "instances", instanceIDs)
+
+	if err := t.provider.ScaleIn(ctx, group, instanceIDs); err != nil {
+		return err
+	}
+
+	log.Info("successfully terminated instances")
+
+	if partial {
+		return drainErr
+	}
+	return nil
+}
+
+func (t *TargetPlugin) generateScaleReq(num int64, config map[string]string) (*utils.ScaleInReq, error) {
+
+	// Identify the pool of nodes from the config mapping. This is a required
+	// value and we cannot scale without this. The node class is the usual way
+	// of identifying the pool, and so is named when no pool is found.
+	pool := utils.PoolIdentifierFromConfig(config)
+	if pool == nil {
+		return nil, fmt.Errorf("required config param %q not found", target.ConfigKeyClass)
+	}
+
+	// The drain_deadline is an optional parameter so define out default and
+	// then attempt to find an operator specified value.
+	drain := utils.DefaultDrainDeadline
+
+	if drainString, ok := config[target.ConfigKeyDrainDeadline]; ok {
+		d, err := time.ParseDuration(drainString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration", drainString)
+		}
+		drain = d
+	}
+
+	// The idle_wait_deadline is an optional parameter. If it is not set, busy
+	// nodes are not waited upon.
+	var idleWait time.Duration
+
+	if idleWaitString, ok := config[configKeyIdleWaitDeadline]; ok {
+		d, err := time.ParseDuration(idleWaitString)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration", idleWaitString)
+		}
+		idleWait = d
+	}
+
+	// The node_selection_strategy is an optional parameter; nodes are always
+	// ranked idle first, with the strategy ordering the idle and busy nodes.
+	strategy, err := scaleutils.ParseNodeIDStrategy(config[target.ConfigKeyNodeSelectionStrategy])
+	if err != nil {
+		return nil, err
+	}
+
+	req := &utils.ScaleInReq{
+		Num:              int(num),
+		DrainDeadline:    drain,
+		IdleWaitDeadline: idleWait,
+		PoolIdentifier:   pool,
+		RemoteProvider:   t.provider.RemoteProvider(),
+		NodeIDStrategy:   strategy,
+	}
+
+	// The drain options are optional parameters, with the defaults matching
+	// the behaviour prior to their introduction.
+	if err := req.ParseDrainConfig(config); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
@@ -0,0 +1,191 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetPlugin_generateScaleReq(t *testing.T) {
+	testCases := []struct {
+		inputNum            int64
+		inputConfig         map[string]string
+		expectedOutputReq   *utils.ScaleInReq
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_deadline": "5m",
+			},
+			expectedOutputReq: &utils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 5 * time.Minute,
+				PoolIdentifier: &utils.PoolIdentifier{
+					IdentifierKey: utils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: utils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with drain_deadline in config",
+		},
+		{
+			inputNum:            2,
+			inputConfig:         map[string]string{},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("required config param \"node_class\" not found"),
+			name:                "no class key found in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class": "high-memory",
+			},
+			expectedOutputReq: &utils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 15 * time.Minute,
+				PoolIdentifier: &utils.PoolIdentifier{
+					IdentifierKey: utils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: utils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "drain_deadline not specified within config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_deadline": "time to make a cuppa",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("failed to parse \"time to make a cuppa\" as time duration"),
+			name:                "malformed drain_deadline config value",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":         "high-memory",
+				"idle_wait_deadline": "20m",
+			},
+			expectedOutputReq: &utils.ScaleInReq{
+				Num:              2,
+				DrainDeadline:    15 * time.Minute,
+				IdleWaitDeadline: 20 * time.Minute,
+				PoolIdentifier: &utils.PoolIdentifier{
+					IdentifierKey: utils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: utils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with idle_wait_deadline in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":         "high-memory",
+				"idle_wait_deadline": "until the match ends",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("failed to parse \"until the match ends\" as time duration"),
+			name:                "malformed idle_wait_deadline config value",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":              "high-memory",
+				"node_selection_strategy": "least_busy",
+			},
+			expectedOutputReq: &utils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 15 * time.Minute,
+				PoolIdentifier: &utils.PoolIdentifier{
+					IdentifierKey: utils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: utils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: utils.IDStrategyLeastBusy,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with node_selection_strategy in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"datacenter":     "hk1",
+				"node_meta.pool": "game-hk",
+			},
+			expectedOutputReq: &utils.ScaleInReq{
+				Num:           2,
+				DrainDeadline: 15 * time.Minute,
+				PoolIdentifier: &utils.PoolIdentifier{
+					IdentifierKey: utils.IdentifierKeyComposite,
+					Value:         "datacenter=hk1,node_meta.pool=game-hk",
+					Identifiers: []*utils.PoolIdentifier{
+						{IdentifierKey: utils.IdentifierKeyDatacenter, Value: "hk1"},
+						{IdentifierKey: "node_meta.pool", Value: "game-hk"},
+					},
+				},
+				RemoteProvider: utils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with composite pool in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":                    "high-memory",
+				"node_drain_ignore_system_jobs": "true",
+				"node_drain_no_force":           "true",
+				"node_ineligible_wait":          "5m",
+			},
+			expectedOutputReq: &utils.ScaleInReq{
+				Num:              2,
+				DrainDeadline:    15 * time.Minute,
+				IgnoreSystemJobs: true,
+				IneligibleWait:   5 * time.Minute,
+				NoForce:          true,
+				PoolIdentifier: &utils.PoolIdentifier{
+					IdentifierKey: utils.IdentifierKeyClass,
+					Value:         "high-memory",
+				},
+				RemoteProvider: utils.RemoteProviderAWSInstanceID,
+				NodeIDStrategy: utils.IDStrategyNewestCreateIndex,
+			},
+			expectedOutputError: nil,
+			name:                "valid request with drain options in config",
+		},
+		{
+			inputNum: 2,
+			inputConfig: map[string]string{
+				"node_class":          "high-memory",
+				"node_drain_no_force": "eventually",
+			},
+			expectedOutputReq:   nil,
+			expectedOutputError: errors.New("failed to parse \"eventually\" as boolean"),
+			name:                "malformed node_drain_no_force config value",
+		},
+	}
+
+	tp := TargetPlugin{provider: &awsProvider{}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualReq, actualErr := tp.generateScaleReq(tc.inputNum, tc.inputConfig)
+			assert.Equal(t, tc.expectedOutputReq, actualReq, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualErr, tc.name)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/utils"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// configKeys represents the known configuration parameters of the script
+	// provider. The group is a target config param, with the others being
+	// plugin config params.
+	configKeyScriptStatusCommand    = "script_status_command"
+	configKeyScriptScaleOutCommand  = "script_scale_out_command"
+	configKeyScriptTerminateCommand = "script_terminate_command"
+	configKeyScriptHealthCommand    = "script_health_command"
+	configKeyScriptTimeout          = "script_timeout"
+	configKeyScriptGroup            = "script_group"
+
+	// defaultScriptTimeout is the timeout of each command when not specified
+	// by an operator.
+	defaultScriptTimeout = 10 * time.Minute
+
+	// envKeys are the environment variables used to pass the details of the
+	// action to the commands.
+	envKeyScriptGroup       = "NOMAD_AUTOSCALER_GROUP"
+	envKeyScriptCount       = "NOMAD_AUTOSCALER_COUNT"
+	envKeyScriptInstanceIDs = "NOMAD_AUTOSCALER_INSTANCE_IDS"
+)
+
+// scriptProvider is the local command implementation of the provider
+// interface. Each command is run using "sh -c", with the group passed in the
+// NOMAD_AUTOSCALER_GROUP environment variable:
+//
+//   - the status command writes the groupStatus as JSON to stdout
+//   - the scale out command creates instances until the group has
+//     NOMAD_AUTOSCALER_COUNT instances
+//   - the terminate command terminates the comma separated instances in
+//     NOMAD_AUTOSCALER_INSTANCE_IDS
+//   - the optional health command confirms the provider is usable
+//
+// A command should only exit once its change has completed, with a non-zero
+// exit code indicating failure.
+type scriptProvider struct {
+	logger           hclog.Logger
+	statusCommand    string
+	scaleOutCommand  string
+	terminateCommand string
+	healthCommand    string
+	timeout          time.Duration
+	remoteProvider   utils.RemoteProvider
+}
+
+// SetConfig satisfies the SetConfig function on the provider interface.
+func (s *scriptProvider) SetConfig(config map[string]string) error {
+
+	for _, key := range []string{configKeyScriptStatusCommand, configKeyScriptScaleOutCommand,
+		configKeyScriptTerminateCommand} {
+		if config[key] == "" {
+			return fmt.Errorf("required config param %s not found", key)
+		}
+	}
+
+	timeout := defaultScriptTimeout
+
+	if v, ok := config[configKeyScriptTimeout]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as time duration", v)
+		}
+		timeout = d
+	}
+
+	remoteProvider, err := remoteProviderFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	s.statusCommand = config[configKeyScriptStatusCommand]
+	s.scaleOutCommand = config[configKeyScriptScaleOutCommand]
+	s.terminateCommand = config[configKeyScriptTerminateCommand]
+	s.healthCommand = config[configKeyScriptHealthCommand]
+	s.timeout = timeout
+	s.remoteProvider = remoteProvider
+	return nil
+}
+
+// RemoteProvider satisfies the RemoteProvider function on the provider
+// interface.
+func (s *scriptProvider) RemoteProvider() utils.RemoteProvider { return s.remoteProvider }
+
+// GroupName satisfies the GroupName function on the provider interface.
+func (s *scriptProvider) GroupName(config map[string]string) (string, error) {
+	group, ok := config[configKeyScriptGroup]
+	if !ok {
+		return "", fmt.Errorf("required config param %s not found", configKeyScriptGroup)
+	}
+	return group, nil
+}
+
+// Status satisfies the Status function on the provider interface.
+func (s *scriptProvider) Status(ctx context.Context, group string) (*target.Status, error) {
+
+	out, err := s.run(ctx, s.statusCommand, map[string]string{envKeyScriptGroup: group})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run status command: %v", err)
+	}
+
+	var status groupStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, fmt.Errorf("failed to decode status command output: %v", err)
+	}
+	return status.Status(), nil
+}
+
+// ScaleOut satisfies the ScaleOut function on the provider interface.
+func (s *scriptProvider) ScaleOut(ctx context.Context, group string, count int64) error {
+
+	env := map[string]string{
+		envKeyScriptGroup: group,
+		envKeyScriptCount: strconv.FormatInt(count, 10),
+	}
+
+	if _, err := s.run(ctx, s.scaleOutCommand, env); err != nil {
+		return fmt.Errorf("failed to run scale out command: %v", err)
+	}
+	return nil
+}
+
+// ScaleIn satisfies the ScaleIn function on the provider interface.
+func (s *scriptProvider) ScaleIn(ctx context.Context, group string, instanceIDs []string) error {
+
+	env := map[string]string{
+		envKeyScriptGroup:       group,
+		envKeyScriptInstanceIDs: strings.Join(instanceIDs, ","),
+	}
+
+	if _, err := s.run(ctx, s.terminateCommand, env); err != nil {
+		return fmt.Errorf("failed to run terminate command: %v", err)
+	}
+	return nil
+}
+
+// HealthCheck satisfies the HealthCheck function on the provider interface.
+// If no health command is configured, the provider is assumed healthy.
+func (s *scriptProvider) HealthCheck(ctx context.Context) error {
+	if s.healthCommand == "" {
+		return nil
+	}
+	if _, err := s.run(ctx, s.healthCommand, nil); err != nil {
+		return fmt.Errorf("failed to run health command: %v", err)
+	}
+	return nil
+}
+
+// run runs the command with the additional environment variables, returning
+// its stdout. Any stderr output is included in a returned error.
+func (s *scriptProvider) run(ctx context.Context, command string, env map[string]string) ([]byte, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = os.Environ()
+
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	s.logger.Debug("running command", "command", command, "env", env)
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
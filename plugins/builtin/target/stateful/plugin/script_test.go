@@ -0,0 +1,47 @@
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_scriptProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stateful-script")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "out")
+
+	p := &scriptProvider{logger: hclog.NewNullLogger()}
+	err = p.SetConfig(map[string]string{
+		"script_status_command":    `echo '{"count": 3, "ready": true, "last_event": 10}'`,
+		"script_scale_out_command": `echo "out $NOMAD_AUTOSCALER_GROUP $NOMAD_AUTOSCALER_COUNT" > ` + out,
+		"script_terminate_command": `echo "in $NOMAD_AUTOSCALER_GROUP $NOMAD_AUTOSCALER_INSTANCE_IDS" > ` + out,
+		"script_health_command":    `echo "unhealthy" >&2; exit 1`,
+	})
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+
+	status, err := p.Status(ctx, "game-pool")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), status.Count)
+	assert.True(t, status.Ready)
+	assert.Equal(t, "10", status.Meta["nomad_autoscaler.last_event"])
+
+	assert.Nil(t, p.ScaleOut(ctx, "game-pool", 5))
+	b, _ := ioutil.ReadFile(out)
+	assert.Equal(t, "out game-pool 5\n", string(b))
+
+	assert.Nil(t, p.ScaleIn(ctx, "game-pool", []string{"host-1", "host-2"}))
+	b, _ = ioutil.ReadFile(out)
+	assert.Equal(t, "in game-pool host-1,host-2\n", string(b))
+
+	assert.EqualError(t, p.HealthCheck(ctx), "failed to run health command: exit status 1: unhealthy")
+}
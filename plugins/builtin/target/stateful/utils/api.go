@@ -20,11 +20,22 @@ import (
 	"time"
 )
 
+const (
+	// defaultDmsRequestTimeout is the timeout applied to each request made by
+	// the default HTTP client, so that an unresponsive DMS cannot block
+	// scaling indefinitely.
+	defaultDmsRequestTimeout = 10 * time.Second
+)
+
 var (
 	// ClientConnTimeout is the timeout applied when attempting to contact a
 	// client directly before switching to a connection through the Nomad
 	// server.
 	ClientConnTimeout = 1 * time.Second
+
+	// ErrDmsAddressNotSet is returned when creating a DMS client without an
+	// address configured, or set via the DMS_ADDR environment variable.
+	ErrDmsAddressNotSet = errors.New("DMS address must be set")
 )
 
 const (
@@ -44,7 +55,8 @@ type HttpBasicAuth struct {
 
 // DmsApiConfig is used to configure the creation of a client
 type DmsApiConfig struct {
-	// Address is the address of the Nomad agent
+	// Address is the address of the DMS. It has no default and must be set,
+	// either directly or using the DMS_ADDR environment variable.
 	Address string
 	// SecretID to use. This can be overwritten per request.
 	SecretID string
@@ -69,8 +81,8 @@ type DmsApiConfig struct {
 	TLSConfig *TLSConfig
 }
 
-// ClientConfig copies the configuration with a new client address, region, and
-// whether the client has TLS enabled.
+// ClientConfig copies the configuration with a new client address and whether
+// the client has TLS enabled.
 func (c *DmsApiConfig) ClientConfig(address string, tlsEnabled bool) *DmsApiConfig {
 	scheme := "http"
 	if tlsEnabled {
@@ -85,11 +97,6 @@ func (c *DmsApiConfig) ClientConfig(address string, tlsEnabled bool) *DmsApiConf
 		TLSConfig:  c.TLSConfig.Copy(),
 	}
 
-	// Update the tls server name for connecting to a client
-	if tlsEnabled && config.TLSConfig != nil {
-		config.TLSConfig.TLSServerName = fmt.Sprintf("client.nomad")
-	}
-
 	return config
 }
 
@@ -140,6 +147,7 @@ func (t *TLSConfig) Copy() *TLSConfig {
 
 func defaultHttpClient() *http.Client {
 	httpClient := cleanhttp.DefaultClient()
+	httpClient.Timeout = defaultDmsRequestTimeout
 	transport := httpClient.Transport.(*http.Transport)
 	transport.TLSHandshakeTimeout = 10 * time.Second
 	transport.TLSClientConfig = &tls.Config{
@@ -152,7 +160,6 @@ func defaultHttpClient() *http.Client {
 // DefaultConfig returns a default configuration for the client
 func DefaultConfig() *DmsApiConfig {
 	config := &DmsApiConfig{
-		Address:   "http://127.0.0.1:4646",
 		TLSConfig: &TLSConfig{},
 	}
 	if addr := os.Getenv("DMS_ADDR"); addr != "" {
@@ -261,6 +268,13 @@ func ConfigureTLS(httpClient *http.Client, tlsConfig *TLSConfig) error {
 		return fmt.Errorf("config HTTP DmsApiClient must be set")
 	}
 
+	return configureTLSConfig(httpClient.Transport.(*http.Transport).TLSClientConfig, tlsConfig)
+}
+
+// configureTLSConfig applies a set of TLS configurations to the crypto/tls
+// config.
+func configureTLSConfig(clientTLSConfig *tls.Config, tlsConfig *TLSConfig) error {
+
 	var clientCert tls.Certificate
 	foundClientCert := false
 	if tlsConfig.ClientCert != "" || tlsConfig.ClientKey != "" {
@@ -287,7 +301,6 @@ func ConfigureTLS(httpClient *http.Client, tlsConfig *TLSConfig) error {
 		}
 	}
 
-	clientTLSConfig := httpClient.Transport.(*http.Transport).TLSClientConfig
 	rootConfig := &rootcerts.Config{
 		CAFile:        tlsConfig.CACert,
 		CAPath:        tlsConfig.CAPath,
@@ -309,7 +322,7 @@ func ConfigureTLS(httpClient *http.Client, tlsConfig *TLSConfig) error {
 	return nil
 }
 
-// DmsApiClient provides a client to the Nomad API
+// DmsApiClient provides a client to the DMS API
 type DmsApiClient struct {
 	httpClient *http.Client
 	config     DmsApiConfig
@@ -322,6 +335,9 @@ func NewDmsApiClient(config *DmsApiConfig) (*DmsApiClient, error) {
 
 	if config.Address == "" {
 		config.Address = defConfig.Address
+	}
+	if config.Address == "" {
+		return nil, ErrDmsAddressNotSet
 	} else if _, err := url.Parse(config.Address); err != nil {
 		return nil, fmt.Errorf("invalid address '%s': %v", config.Address, err)
 	}
@@ -341,7 +357,7 @@ func NewDmsApiClient(config *DmsApiConfig) (*DmsApiClient, error) {
 	return client, nil
 }
 
-// Address return the address of the Nomad agent
+// Address return the address of the DMS
 func (c *DmsApiClient) Address() string {
 	return c.config.Address
 }
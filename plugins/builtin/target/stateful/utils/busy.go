@@ -0,0 +1,310 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/api"
+)
+
+const (
+	configKeyBusyStateBackend       = "busy_state_backend"
+	configKeyBusyStateRedisKey      = "busy_state_redis_key"
+	configKeyBusyStateNodeMetaKey   = "busy_state_node_meta_key"
+	configKeyBusyStateRetries       = "busy_state_retries"
+	configKeyBusyStateRetryInterval = "busy_state_retry_interval"
+	configKeyBusyStateFailClosed    = "busy_state_fail_closed"
+
+	// defaultBusyStateRetries and defaultBusyStateRetryInterval control the
+	// retrying of failed busy state lookups when not specified by an
+	// operator.
+	defaultBusyStateRetries       = 3
+	defaultBusyStateRetryInterval = 1 * time.Second
+)
+
+// BusyStateBackendName identifies the source of the busy state of nodes.
+type BusyStateBackendName string
+
+const (
+	// BusyStateBackendDMS reads the busy state of nodes from the DMS HTTP API.
+	// This is the default.
+	BusyStateBackendDMS BusyStateBackendName = "dms"
+
+	// BusyStateBackendRedis reads the busy state of nodes from a Redis hash,
+	// whose fields are Nomad node IDs and values are booleans.
+	BusyStateBackendRedis BusyStateBackendName = "redis"
+
+	// BusyStateBackendNodeMeta reads the busy state of nodes from a boolean
+	// Nomad node meta value.
+	BusyStateBackendNodeMeta BusyStateBackendName = "node_meta"
+)
+
+// BusyStateBackend is the interface used to look up whether nodes are busy,
+// and therefore should not be drained.
+type BusyStateBackend interface {
+
+	// BusyState returns whether each of the nodes is busy, keyed by Nomad
+	// node ID. Nodes whose busy state is not known are omitted.
+	BusyState(nodeIDs []string) (map[string]bool, error)
+}
+
+// BusyStateConfig is used to configure the lookup of the busy state of nodes.
+type BusyStateConfig struct {
+	Backend BusyStateBackendName
+
+	// DMS configures the BusyStateBackendDMS backend.
+	DMS *DmsApiConfig
+
+	// RedisKey is the hash key used by the BusyStateBackendRedis backend.
+	RedisKey string
+
+	// NodeMetaKey is the node meta key used by the BusyStateBackendNodeMeta
+	// backend.
+	NodeMetaKey string
+
+	// Retries is the number of times a failed lookup is retried, waiting
+	// RetryInterval between attempts.
+	Retries       int
+	RetryInterval time.Duration
+
+	// FailClosed causes nodes whose busy state is not known to be treated as
+	// busy, so that they are never drained.
+	FailClosed bool
+}
+
+// BusyStateConfigFromMap converts the map representation of a busy state
+// config to the proper object that can be used to setup a backend.
+func BusyStateConfigFromMap(cfg map[string]string) (*BusyStateConfig, error) {
+
+	c := &BusyStateConfig{
+		Backend:       BusyStateBackendDMS,
+		DMS:           DmsConfigFromMap(cfg),
+		RedisKey:      cfg[configKeyBusyStateRedisKey],
+		NodeMetaKey:   cfg[configKeyBusyStateNodeMetaKey],
+		Retries:       defaultBusyStateRetries,
+		RetryInterval: defaultBusyStateRetryInterval,
+	}
+
+	var err error
+
+	if v, ok := cfg[configKeyBusyStateBackend]; ok {
+		c.Backend = BusyStateBackendName(v)
+	}
+	if v, ok := cfg[configKeyBusyStateRetries]; ok {
+		if c.Retries, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as int: %v", configKeyBusyStateRetries, err)
+		}
+	}
+	if v, ok := cfg[configKeyBusyStateRetryInterval]; ok {
+		if c.RetryInterval, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration: %v", configKeyBusyStateRetryInterval, err)
+		}
+	}
+	if v, ok := cfg[configKeyBusyStateFailClosed]; ok {
+		if c.FailClosed, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as bool: %v", configKeyBusyStateFailClosed, err)
+		}
+	}
+
+	switch c.Backend {
+	case BusyStateBackendDMS:
+	case BusyStateBackendRedis:
+		if c.RedisKey == "" {
+			return nil, fmt.Errorf("%q must be set when using the %q busy state backend", configKeyBusyStateRedisKey, c.Backend)
+		}
+	case BusyStateBackendNodeMeta:
+		if c.NodeMetaKey == "" {
+			return nil, fmt.Errorf("%q must be set when using the %q busy state backend", configKeyBusyStateNodeMetaKey, c.Backend)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported busy state backend: %q", c.Backend)
+	}
+
+	if c.Retries < 0 {
+		return nil, fmt.Errorf("%q should not be negative", configKeyBusyStateRetries)
+	}
+	return c, nil
+}
+
+// newBusyStateBackend returns the backend configured. The Redis client is
+// only required by the BusyStateBackendRedis backend.
+func newBusyStateBackend(cfg *BusyStateConfig, nomad *api.Client, redisClient *RedisClient) (BusyStateBackend, error) {
+	switch cfg.Backend {
+	case BusyStateBackendDMS:
+		// The DMS backend is the default, so a missing address must not fail
+		// the plugin configuration. Instead it is reported by the lookups,
+		// and therefore the health check and any scale in.
+		client, err := NewDmsApiClient(cfg.DMS)
+		if err == ErrDmsAddressNotSet {
+			return &dmsBusyState{err: err}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate DMS client: %v", err)
+		}
+		return &dmsBusyState{client: client}, nil
+	case BusyStateBackendRedis:
+		if redisClient == nil {
+			return nil, fmt.Errorf("redis must be configured to use the %q busy state backend", cfg.Backend)
+		}
+		return &redisBusyState{getConn: redisClient.Conn, key: cfg.RedisKey}, nil
+	case BusyStateBackendNodeMeta:
+		return &nodeMetaBusyState{nomad: nomad, key: cfg.NodeMetaKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported busy state backend: %q", cfg.Backend)
+	}
+}
+
+// dmsBusyState is the BusyStateBackendDMS implementation of BusyStateBackend.
+// If the client could not be created, err is returned by every lookup.
+type dmsBusyState struct {
+	client *DmsApiClient
+	err    error
+}
+
+func (d *dmsBusyState) BusyState(_ []string) (map[string]bool, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	resp, err := d.client.Dms().List()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Nodes, nil
+}
+
+// redisBusyState is the BusyStateBackendRedis implementation of
+// BusyStateBackend.
+type redisBusyState struct {
+	getConn func() redis.Conn
+	key     string
+}
+
+func (r *redisBusyState) BusyState(_ []string) (map[string]bool, error) {
+	conn := r.getConn()
+	defer conn.Close()
+
+	values, err := redis.StringMap(conn.Do("HGETALL", r.key))
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]bool, len(values))
+	for id, v := range values {
+		busy, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse busy state %q of node %s: %v", v, id, err)
+		}
+		out[id] = busy
+	}
+	return out, nil
+}
+
+// nodeMetaBusyState is the BusyStateBackendNodeMeta implementation of
+// BusyStateBackend.
+type nodeMetaBusyState struct {
+	nomad *api.Client
+	key   string
+}
+
+func (n *nodeMetaBusyState) BusyState(nodeIDs []string) (map[string]bool, error) {
+
+	// Confirm the API is reachable, even if there are no nodes to look up,
+	// so that the backend can be health checked.
+	if len(nodeIDs) == 0 {
+		_, err := n.nomad.Status().Leader()
+		return nil, err
+	}
+
+	out := make(map[string]bool, len(nodeIDs))
+
+	for _, id := range nodeIDs {
+		node, _, err := n.nomad.Nodes().Info(id, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read node %s: %v", id, err)
+		}
+
+		v, ok := node.Meta[n.key]
+		if !ok {
+			continue
+		}
+		busy, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse busy state %q of node %s: %v", v, id, err)
+		}
+		out[id] = busy
+	}
+	return out, nil
+}
+
+// busyStateChecker wraps a BusyStateBackend, retrying failed lookups and
+// resolving nodes whose busy state is not known.
+type busyStateChecker struct {
+	backend       BusyStateBackend
+	log           hclog.Logger
+	retries       int
+	retryInterval time.Duration
+	failClosed    bool
+}
+
+func newBusyStateChecker(backend BusyStateBackend, cfg *BusyStateConfig, log hclog.Logger) *busyStateChecker {
+	return &busyStateChecker{
+		backend:       backend,
+		log:           log,
+		retries:       cfg.Retries,
+		retryInterval: cfg.RetryInterval,
+		failClosed:    cfg.FailClosed,
+	}
+}
+
+// busyNodes returns whether each of the nodes is busy, keyed by Nomad node
+// ID. Every node is included; nodes whose busy state is not known are busy
+// only when failing closed. Retries are abandoned once the context is done.
+func (b *busyStateChecker) busyNodes(ctx context.Context, nodeIDs []string) (map[string]bool, error) {
+
+	var (
+		state map[string]bool
+		err   error
+	)
+
+	for attempt := 0; attempt <= b.retries; attempt++ {
+		if attempt > 0 {
+			b.log.Warn("failed to look up node busy state, retrying", "attempt", attempt, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("context done while retrying node busy state lookup: %v", err)
+			case <-time.After(b.retryInterval):
+			}
+		}
+		if state, err = b.backend.BusyState(nodeIDs); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up node busy state: %v", err)
+	}
+
+	out := make(map[string]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		busy, ok := state[id]
+		if !ok {
+			b.log.Debug("busy state of node not known", "node_id", id, "fail_closed", b.failClosed)
+			busy = b.failClosed
+		}
+		out[id] = busy
+	}
+	return out, nil
+}
+
+// check confirms the backend can be reached.
+func (b *busyStateChecker) check() error {
+	if b == nil {
+		return errors.New("busy state backend not configured")
+	}
+	_, err := b.backend.BusyState(nil)
+	return err
+}
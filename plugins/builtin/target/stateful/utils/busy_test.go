@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusyStateConfigFromMap(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig:         map[string]string{},
+			expectedOutputError: nil,
+			name:                "default dms backend",
+		},
+		{
+			inputConfig: map[string]string{
+				"busy_state_backend":   "redis",
+				"busy_state_redis_key": "game:busy",
+			},
+			expectedOutputError: nil,
+			name:                "redis backend",
+		},
+		{
+			inputConfig: map[string]string{
+				"busy_state_backend": "redis",
+			},
+			expectedOutputError: errors.New("\"busy_state_redis_key\" must be set when using the \"redis\" busy state backend"),
+			name:                "redis backend without key",
+		},
+		{
+			inputConfig: map[string]string{
+				"busy_state_backend": "node_meta",
+			},
+			expectedOutputError: errors.New("\"busy_state_node_meta_key\" must be set when using the \"node_meta\" busy state backend"),
+			name:                "node meta backend without key",
+		},
+		{
+			inputConfig: map[string]string{
+				"busy_state_backend": "crystal_ball",
+			},
+			expectedOutputError: errors.New("unsupported busy state backend: \"crystal_ball\""),
+			name:                "unsupported backend",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, actualError := BusyStateConfigFromMap(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+		})
+	}
+}
+
+// testBusyStateBackend returns the queued errors in turn, followed by the
+// state.
+type testBusyStateBackend struct {
+	errs  []error
+	state map[string]bool
+	calls int
+}
+
+func (b *testBusyStateBackend) BusyState(_ []string) (map[string]bool, error) {
+	b.calls++
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return nil, err
+	}
+	return b.state, nil
+}
+
+func Test_busyStateChecker_busyNodes(t *testing.T) {
+	testCases := []struct {
+		inputBackend        *testBusyStateBackend
+		inputConfig         *BusyStateConfig
+		expectedOutput      map[string]bool
+		expectedOutputError bool
+		inputCtxDone        bool
+		expectedCalls       int
+		name                string
+	}{
+		{
+			inputBackend:   &testBusyStateBackend{state: map[string]bool{"node1": true}},
+			inputConfig:    &BusyStateConfig{},
+			expectedOutput: map[string]bool{"node1": true, "node2": false},
+			expectedCalls:  1,
+			name:           "unknown node fails open",
+		},
+		{
+			inputBackend:   &testBusyStateBackend{state: map[string]bool{"node1": false}},
+			inputConfig:    &BusyStateConfig{FailClosed: true},
+			expectedOutput: map[string]bool{"node1": false, "node2": true},
+			expectedCalls:  1,
+			name:           "unknown node fails closed",
+		},
+		{
+			inputBackend: &testBusyStateBackend{
+				errs:  []error{errors.New("timeout")},
+				state: map[string]bool{"node1": false, "node2": false},
+			},
+			inputConfig:    &BusyStateConfig{Retries: 1, RetryInterval: time.Millisecond},
+			expectedOutput: map[string]bool{"node1": false, "node2": false},
+			expectedCalls:  2,
+			name:           "retry succeeds",
+		},
+		{
+			inputBackend: &testBusyStateBackend{
+				errs: []error{errors.New("timeout"), errors.New("timeout")},
+			},
+			inputConfig:         &BusyStateConfig{Retries: 1, RetryInterval: time.Millisecond},
+			expectedOutputError: true,
+			expectedCalls:       2,
+			name:                "retries exhausted",
+		},
+		{
+			inputBackend: &testBusyStateBackend{
+				errs: []error{errors.New("timeout")},
+			},
+			inputConfig:         &BusyStateConfig{Retries: 1, RetryInterval: time.Hour},
+			inputCtxDone:        true,
+			expectedOutputError: true,
+			expectedCalls:       1,
+			name:                "context done while retrying",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.inputCtxDone {
+				cancel()
+			}
+
+			b := newBusyStateChecker(tc.inputBackend, tc.inputConfig, hclog.NewNullLogger())
+			actualOutput, actualError := b.busyNodes(ctx, []string{"node1", "node2"})
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualError != nil, tc.name)
+			assert.Equal(t, tc.expectedCalls, tc.inputBackend.calls, tc.name)
+		})
+	}
+}
+
+func Test_redisBusyState(t *testing.T) {
+	r := newTestRedis()
+	r.hashes = map[string]map[string]string{"game:busy": {"node1": "true", "node2": "0"}}
+
+	b := &redisBusyState{getConn: r.conn, key: "game:busy"}
+
+	actualOutput, err := b.BusyState(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"node1": true, "node2": false}, actualOutput)
+}
+
+func Test_newBusyStateBackend_dmsAddressNotSet(t *testing.T) {
+	if addr, ok := os.LookupEnv("DMS_ADDR"); ok {
+		defer os.Setenv("DMS_ADDR", addr)
+		os.Unsetenv("DMS_ADDR")
+	}
+
+	cfg, err := BusyStateConfigFromMap(map[string]string{})
+	assert.Nil(t, err)
+
+	// The missing address is reported by the lookups, rather than failing the
+	// creation of the default backend.
+	b, err := newBusyStateBackend(cfg, nil, nil)
+	assert.Nil(t, err)
+
+	_, err = b.BusyState(nil)
+	assert.Equal(t, ErrDmsAddressNotSet, err)
+}
@@ -3,40 +3,37 @@ package utils
 import (
 	"fmt"
 
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
 	"github.com/hashicorp/nomad/api"
 )
 
 // NodeID provides a mapping between the Nomad ID of a node and its remote
-// infrastructure provider specific ID.
-type NodeID struct {
-	NomadID, RemoteID string
-}
+// infrastructure provider specific ID. It is shared with the scaleutils
+// helper, which drains the nodes.
+type NodeID = scaleutils.NodeID
 
 // PoolIdentifier is the information used to identify nodes into pools of
-// resources. This then forms our scalable unit.
-type PoolIdentifier struct {
-	IdentifierKey IdentifierKey
-	Value         string
-}
-
-// IdentifyNodes filters the supplied node list based on the PoolIdentifier
-// params.
-func (p *PoolIdentifier) IdentifyNodes(n []*api.NodeListStub) ([]*api.NodeListStub, error) {
-	switch p.IdentifierKey {
-	case IdentifierKeyClass:
-		return filterByClass(n, p.Value), nil
-	default:
-		return nil, fmt.Errorf("unsupported node pool identifier: %q", p.IdentifierKey)
-	}
-}
+// resources. This then forms our scalable unit. Pools are identified by the
+// scaleutils helper, so that the same pool config selects the same nodes
+// across targets.
+type PoolIdentifier = scaleutils.PoolIdentifier
 
 // IdentifierKey is the identifier to group nodes into a pool of resource and
 // thus forms the scalable object.
-type IdentifierKey string
+type IdentifierKey = scaleutils.IdentifierKey
+
+// The supported identifiers, as documented within the scaleutils helper.
+const (
+	IdentifierKeyClass      = scaleutils.IdentifierKeyClass
+	IdentifierKeyDatacenter = scaleutils.IdentifierKeyDatacenter
+	IdentifierKeyComposite  = scaleutils.IdentifierKeyComposite
+)
 
-// IdentifierKeyClass uses the Node.Class field to identify nodes into pools of
-// resource. This is the default.
-const IdentifierKeyClass IdentifierKey = "class"
+// PoolIdentifierFromConfig returns the PoolIdentifier described by the target
+// config, or nil if the config does not identify a pool.
+func PoolIdentifierFromConfig(config map[string]string) *PoolIdentifier {
+	return scaleutils.PoolIdentifierFromConfig(config)
+}
 
 // RemoteProvider is infrastructure provider which hosts and therefore manages
 // the Nomad client instances. This is used to understand how to translate the
@@ -48,58 +45,37 @@ type RemoteProvider string
 // nodeAttrAWSInstanceID to perform ID translation.
 const RemoteProviderAWSInstanceID RemoteProvider = "aws_instance_id"
 
-// NodeIDStrategy is the strategy used to identify nodes for removal as part of
-// scaling in.
-type NodeIDStrategy string
+// RemoteProviderNodeName uses the Nomad node name, which is the hostname of
+// the instance unless overridden, as the remote ID.
+const RemoteProviderNodeName RemoteProvider = "node_name"
+
+// RemoteProviderNodeMeta uses the node meta value as defined by
+// nodeMetaRemoteID as the remote ID. This allows operators to advertise the
+// instance ID of any infrastructure provider within the client config.
+const RemoteProviderNodeMeta RemoteProvider = "node_meta"
 
-// IDStrategyNewestCreateIndex uses the Nomad Nodes().List() output in the
-// order it is presented. This means we do not need additional sorting and thus
-// it is fastest. In an environment that uses bin-packing this may also be
-// preferable as nodes with older create indexes are expected to be most
-// packed.
-const IDStrategyNewestCreateIndex NodeIDStrategy = "newest_create_index"
+// NodeIDStrategy is the strategy used to identify nodes for removal as part of
+// scaling in. The strategies are shared with the scaleutils helper, so that
+// nodes are ranked identically across targets.
+type NodeIDStrategy = scaleutils.NodeIDStrategy
+
+// The supported strategies, as documented within the scaleutils helper.
+const (
+	IDStrategyNewestCreateIndex       = scaleutils.IDStrategyNewestCreateIndex
+	IDStrategyOldestCreateIndex       = scaleutils.IDStrategyOldestCreateIndex
+	IDStrategyLeastBusy               = scaleutils.IDStrategyLeastBusy
+	IDStrategyEmptyFirst              = scaleutils.IDStrategyEmptyFirst
+	IDStrategyLeastAllocatedResources = scaleutils.IDStrategyLeastAllocatedResources
+	IDStrategyRandom                  = scaleutils.IDStrategyRandom
+)
 
 // nodeAttrAWSInstanceID is the node attribute to use when identifying the
 // AWS instanceID of a node.
 const nodeAttrAWSInstanceID = "unique.platform.aws.instance-id"
 
-// defaultClassIdentifier is the class used for nodes which have an empty class
-// parameter when using the IdentifierKeyClass.
-const defaultClassIdentifier = "autoscaler-default-pool"
-
-// filterByClass returns a filtered list of nodes which are active in the
-// cluster and where the specified class matches that of the nodes.
-func filterByClass(n []*api.NodeListStub, id string) []*api.NodeListStub {
-
-	// Create our output list object.
-	var out []*api.NodeListStub
-
-	for _, node := range n {
-
-		// Ignore nodes that are not in a ready state.
-		if node.Status != api.NodeStatusReady {
-			continue
-		}
-
-		// Ignore nodes that are not currently eligible.
-		if node.SchedulingEligibility != api.NodeSchedulingEligible {
-			continue
-		}
-
-		if node.Drain {
-			continue
-		}
-
-		// Perform the node class check. We ensure an empty class is treated as
-		// the default value.
-		if node.NodeClass != "" && node.NodeClass == id ||
-			node.NodeClass == "" && id == defaultClassIdentifier {
-			out = append(out, node)
-		}
-	}
-
-	return out
-}
+// nodeMetaRemoteID is the node meta key to use when identifying the remote ID
+// of a node using the RemoteProviderNodeMeta provider.
+const nodeMetaRemoteID = "nomad_autoscaler.remote_id"
 
 // nodeIDMapFunc is the function signature used to find the Nomad node's remote
 // identifier. Specific implementations can be found below.
@@ -109,6 +85,8 @@ type nodeIDMapFunc func(n *api.Node) (string, error)
 // pull the remote ID information from the node.
 var idFuncMap = map[RemoteProvider]nodeIDMapFunc{
 	RemoteProviderAWSInstanceID: awsNodeIDMap,
+	RemoteProviderNodeName:      nodeNameIDMap,
+	RemoteProviderNodeMeta:      nodeMetaIDMap,
 }
 
 // awsNodeIDMap is used to identify the AWS InstanceID of a Nomad node using
@@ -121,3 +99,23 @@ func awsNodeIDMap(n *api.Node) (string, error) {
 	}
 	return val, err
 }
+
+// nodeNameIDMap is used to identify the remote ID of a Nomad node using its
+// name.
+func nodeNameIDMap(n *api.Node) (string, error) {
+	if n.Name == "" {
+		return "", fmt.Errorf("node %s has no name", n.ID)
+	}
+	return n.Name, nil
+}
+
+// nodeMetaIDMap is used to identify the remote ID of a Nomad node using the
+// relevant meta value.
+func nodeMetaIDMap(n *api.Node) (string, error) {
+	var err error
+	val, ok := n.Meta[nodeMetaRemoteID]
+	if !ok {
+		err = fmt.Errorf("meta %q not found", nodeMetaRemoteID)
+	}
+	return val, err
+}
@@ -5,17 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"sync"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
 	"github.com/hashicorp/nomad/api"
 )
 
+// idleWaitPollInterval is the interval at which the busy state of nodes is
+// polled when waiting for busy nodes to become idle.
+var idleWaitPollInterval = 10 * time.Second
+
 type ScaleIn struct {
-	log   hclog.Logger
-	nomad *api.Client
+	log     hclog.Logger
+	nomad   *api.Client
+	drainer *scaleutils.Drainer
 
 	// curNodeID is the ID of the node that the Nomad autoscaler is curently
 	// running on.
@@ -24,12 +29,19 @@ type ScaleIn struct {
 	//  autoscaler components are updated to handle reconciliation.
 	curNodeID string
 
-	dms *DmsApiClient
+	// busy looks up whether nodes are busy, and therefore should not be
+	// drained.
+	busy *busyStateChecker
+
+	// retirement coordinates node retirement using Redis. It is nil unless
+	// enabled via EnableRetirementCoordination.
+	retirement *retirementCoordinator
 }
 
 // NewScaleInUtils returns a new ScaleIn implementation which provides helper
-// functions for performing scaling in operations.
-func NewScaleInUtils(cfg *api.Config, dmsCfg *DmsApiConfig, log hclog.Logger) (*ScaleIn, error) {
+// functions for performing scaling in operations. The Redis client is only
+// required when the busy state of nodes is read from Redis.
+func NewScaleInUtils(cfg *api.Config, busyCfg *BusyStateConfig, redisClient *RedisClient, log hclog.Logger) (*ScaleIn, error) {
 
 	client, err := api.NewClient(cfg)
 	if err != nil {
@@ -43,29 +55,72 @@ func NewScaleInUtils(cfg *api.Config, dmsCfg *DmsApiConfig, log hclog.Logger) (*
 		log.Error("failed to identify Nomad Autoscaler nodeID", "error", err)
 	}
 
-	dmsApiClient, err := NewDmsApiClient(dmsCfg)
+	// Without the busy state of nodes, scaling in would risk terminating busy
+	// nodes, so failing to setup the backend is terminal.
+	backend, err := newBusyStateBackend(busyCfg, client, redisClient)
 	if err != nil {
-		log.Error("failed to identify Nomad Autoscaler nodeID", "error", err)
+		return nil, fmt.Errorf("failed to setup busy state backend: %v", err)
 	}
 
 	return &ScaleIn{
 		log:       log,
 		nomad:     client,
-		dms:       dmsApiClient,
+		drainer:   scaleutils.NewDrainer(client, log),
+		busy:      newBusyStateChecker(backend, busyCfg, log),
 		curNodeID: id,
 	}, nil
 }
 
+// EnableRetirementCoordination enables the use of Redis, via the client, to
+// lock pools while their nodes are retired, and to mark the nodes being
+// retired.
+func (si *ScaleIn) EnableRetirementCoordination(client *RedisClient) {
+	si.retirement = newRetirementCoordinator(client.Conn, si.log)
+}
+
+// CheckRedis returns an error if retirement coordination is enabled and Redis
+// cannot be reached.
+func (si *ScaleIn) CheckRedis() error {
+	if si.retirement == nil {
+		return nil
+	}
+	if err := si.retirement.ping(); err != nil {
+		return fmt.Errorf("failed to reach Redis: %v", err)
+	}
+	return nil
+}
+
+// PartialDrainError is returned by RunPreScaleInTasks when only some of the
+// nodes selected for removal drained. Nodes are drained by the scaleutils
+// helper, so that the drain behaves identically across targets.
+type PartialDrainError = scaleutils.PartialDrainError
+
 // RunPreScaleInTasks helps tie together all the tasks required prior to
 // scaling in Nomad nodes, and thus terminating the server in the remote
-// provider.
-func (si *ScaleIn) RunPreScaleInTasks(ctx context.Context, req *ScaleInReq) ([]NodeID, error) {
+// provider. When retirement coordination is enabled, the pool remains locked
+// and the returned nodes marked as retiring until RunPostScaleInTasks is
+// called. If only some of the nodes drain, the drained nodes are returned
+// along with a PartialDrainError.
+func (si *ScaleIn) RunPreScaleInTasks(ctx context.Context, req *ScaleInReq) (_ []NodeID, retErr error) {
 
 	if err := req.validate(); err != nil {
 		return nil, fmt.Errorf("failed to validate request: %v", err)
 	}
 
-	nodes, err := si.identifyTargets(req.Num, req.PoolIdentifier, req.NodeIDStrategy)
+	// Lock the pool before identifying the nodes, so that another autoscaler
+	// instance cannot select the same nodes.
+	if si.retirement != nil {
+		if err := si.retirement.lockPool(req.PoolIdentifier); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if retErr != nil && !isPartialDrain(retErr) {
+				si.retirement.unlockPool(req.PoolIdentifier)
+			}
+		}()
+	}
+
+	nodes, err := si.identifyTargets(ctx, req.Num, req.PoolIdentifier, req.NodeIDStrategy)
 	if err != nil {
 		return nil, fmt.Errorf("failed to identify nodes for removal: %v", err)
 	}
@@ -78,9 +133,41 @@ func (si *ScaleIn) RunPreScaleInTasks(ctx context.Context, req *ScaleInReq) ([]N
 		return nil, err
 	}
 
-	//TODO:基于redis过滤nodes
+	// Mark all the candidates as retiring before checking whether they are
+	// busy, so that no new work is assigned to them in the meantime. The
+	// markers of any candidates which are not retired are then cleared.
+	candidates := nodeIDMap
+
+	if si.retirement != nil {
+		ttl := req.IdleWaitDeadline + req.IneligibleWait + req.DrainDeadline + retiringMarkerTTLMargin
+		if err := si.retirement.markRetiring(candidates, ttl); err != nil {
+			si.retirement.clearRetiring(candidates)
+			return nil, err
+		}
+	}
+
+	// Either wait for the busy nodes to become idle, or remove them from the
+	// selection, so that no running sessions are interrupted by the drain.
+	if req.IdleWaitDeadline > 0 {
+		nodeIDMap, err = si.waitForIdleNodes(ctx, req.IdleWaitDeadline, nodeIDMap)
+	} else {
+		nodeIDMap, err = si.filterBusyNodes(ctx, nodeIDMap)
+	}
+
+	if si.retirement != nil {
+		si.retirement.clearRetiring(excludeNodes(candidates, nodeIDMap))
+		defer func() {
+			if drainErr, ok := retErr.(*PartialDrainError); ok {
+				si.retirement.clearRetiring(drainErr.Failed)
+			} else if retErr != nil {
+				si.retirement.clearRetiring(nodeIDMap)
+			}
+		}()
+	}
 
-	nodeIDMap, err = si.filterBusyNodes(nodeIDMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify idle nodes: %v", err)
+	}
 
 	// If we have not been able to identify any nodes and get their remote
 	// provider ID we cannot continue.
@@ -88,18 +175,47 @@ func (si *ScaleIn) RunPreScaleInTasks(ctx context.Context, req *ScaleInReq) ([]N
 		return nil, errors.New("failed to identify nodes for removal")
 	}
 
-	if err := si.drainNodes(ctx, req.DrainDeadline, nodeIDMap); err != nil {
-		return nil, err
+	return si.drainer.Drain(ctx, req.drainOptions(), nodeIDMap)
+}
+
+// isPartialDrain returns whether the error is a PartialDrainError, in which
+// case some of the nodes are still to be terminated.
+func isPartialDrain(err error) bool {
+	_, ok := err.(*PartialDrainError)
+	return ok
+}
+
+// RunPostScaleInTasks performs the tasks required once the nodes returned by
+// RunPreScaleInTasks have been terminated, or their termination has failed.
+func (si *ScaleIn) RunPostScaleInTasks(req *ScaleInReq, nodes []NodeID) {
+	if si.retirement != nil {
+		si.retirement.clearRetiring(nodes)
+		si.retirement.unlockPool(req.PoolIdentifier)
 	}
+}
 
-	return nodeIDMap, nil
+// excludeNodes returns the nodes which are not within the exclude list.
+func excludeNodes(nodes, exclude []NodeID) []NodeID {
+
+	excluded := make(map[string]struct{}, len(exclude))
+	for _, node := range exclude {
+		excluded[node.NomadID] = struct{}{}
+	}
+
+	var out []NodeID
+	for _, node := range nodes {
+		if _, ok := excluded[node.NomadID]; !ok {
+			out = append(out, node)
+		}
+	}
+	return out
 }
 
-// identifyTargets filters the current Nomad cluster node list and then sorts
+// identifyTargets filters the current Nomad cluster node list and then ranks
 // and selects nodes for removal based on the specified strategy. It is
 // possible the list does not contain as many nodes as requested. In this case,
 // do the limited number available after filtering.
-func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy NodeIDStrategy) ([]*api.NodeListStub, error) {
+func (si *ScaleIn) identifyTargets(ctx context.Context, num int, ident *PoolIdentifier, strategy NodeIDStrategy) ([]*api.NodeListStub, error) {
 
 	// Pull a current list of Nomad nodes from the API.
 	nodes, _, err := si.nomad.Nodes().List(nil)
@@ -111,7 +227,7 @@ func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy Node
 	si.log.Debug("filtering node list", "filter", ident.IdentifierKey, "value", ident.Value)
 
 	// Filter our nodes to select only those within our identified pool.
-	filteredNodes, err := ident.IdentifyNodes(nodes)
+	filteredNodes, err := ident.IdentifyNodes(si.nomad, nodes)
 	if err != nil {
 		return nil, err
 	}
@@ -125,12 +241,11 @@ func (si *ScaleIn) identifyTargets(num int, ident *PoolIdentifier, strategy Node
 		return nil, fmt.Errorf("no nodes unfiltered for %s with value %s", ident.IdentifierKey, ident.Value)
 	}
 
-	// Identify the strategy we are using to pick nodes for scale in and
-	// perform our list sorting.
-	switch strategy {
-	case IDStrategyNewestCreateIndex:
-	default:
-		return nil, fmt.Errorf("unsupported scale in node identification strategy: %q", strategy)
+	// Rank the whole pool using the strategy, with idle nodes first, so that
+	// the nodes selected are those which can be removed soonest.
+	filteredNodes, err = si.rankNodes(ctx, filteredNodes, strategy)
+	if err != nil {
+		return nil, err
 	}
 
 	// If the caller has requested more nodes than we have available once
@@ -193,122 +308,160 @@ func (si *ScaleIn) getRemoteIDMap(nodes []*api.NodeListStub, remoteProvider Remo
 	return out, mErr.ErrorOrNil()
 }
 
-func (si *ScaleIn) filterBusyNodes(nodes []NodeID) ([]NodeID, error) {
+// CheckBusyState returns an error if the backend which reports the busy state
+// of nodes cannot be reached.
+func (si *ScaleIn) CheckBusyState() error {
+	if err := si.busy.check(); err != nil {
+		return fmt.Errorf("failed to reach busy state backend: %v", err)
+	}
+	return nil
+}
 
-	var (
-		out  []NodeID
-		mErr *multierror.Error
-	)
-	nodesStatus, err := si.dms.Dms().List()
+// PoolBusyState returns the number of busy and idle nodes within the pool.
+// Nodes which are ineligible or draining, such as those
+// pending retirement, are not included.
+func (si *ScaleIn) PoolBusyState(ctx context.Context, ident *PoolIdentifier) (int, int, error) {
+
+	nodes, _, err := si.nomad.Nodes().List(nil)
 	if err != nil {
-		return out, err
+		return 0, 0, fmt.Errorf("failed to list Nomad nodes from API: %v", err)
 	}
 
-	for _, node := range nodes {
+	filteredNodes, err := ident.IdentifyNodes(si.nomad, nodes)
+	if err != nil {
+		return 0, 0, err
+	}
 
-		if busy, ok := nodesStatus.Nodes[node.NomadID]; ok {
-			//存在
-			if busy {
-				si.log.Debug("identified busy node",
-					"node_id", node.NomadID, "remote_id", node.RemoteID)
-				continue
-			}
-		}
+	ids := make([]string, len(filteredNodes))
+	for i, node := range filteredNodes {
+		ids[i] = node.ID
+	}
 
-		out = append(out, NodeID{NomadID: node.NomadID, RemoteID: node.RemoteID})
+	nodesStatus, err := si.busy.busyNodes(ctx, ids)
+	if err != nil {
+		return 0, 0, err
 	}
 
-	return out, mErr.ErrorOrNil()
+	var busy int
+	for _, node := range filteredNodes {
+		if nodesStatus[node.ID] {
+			busy++
+		}
+	}
+	return busy, len(filteredNodes) - busy, nil
 }
 
-// drainNodes iterates the provided nodeID list and performs a drain on each
-// one.
-func (si *ScaleIn) drainNodes(ctx context.Context, deadline time.Duration, nodes []NodeID) error {
+// filterBusyNodes removes the nodes which are busy from the list.
+func (si *ScaleIn) filterBusyNodes(ctx context.Context, nodes []NodeID) ([]NodeID, error) {
 
-	// Define a WaitGroup. This allows us to trigger each node drain in a go
-	// routine and then wait for them all to complete before exiting.
-	var wg sync.WaitGroup
+	nodesStatus, err := si.busy.busyNodes(ctx, nomadIDs(nodes))
+	if err != nil {
+		return nil, err
+	}
 
-	// All nodes to be drained form part of a pool of resource and all should
-	// use the same DrainSpec.
-	drainSpec := api.DrainSpec{Deadline: deadline}
+	idle, busy := partitionBusyNodes(nodesStatus, nodes)
+	for _, node := range busy {
+		si.log.Debug("identified busy node",
+			"node_id", node.NomadID, "remote_id", node.RemoteID)
+	}
+	return idle, nil
+}
 
-	// Define an error to collect errors from each drain routine and a mutex to
-	// provide thread safety when calling multierror.Append.
-	var (
-		result     error
-		resultLock sync.Mutex
-	)
+// waitForIdleNodes marks the nodes as ineligible, so that no new work is
+// placed on them, and then polls their busy state until all the nodes are
+// idle or the deadline is reached. The nodes which are idle are returned. Any
+// nodes which are still busy once the deadline is reached are marked as
+// eligible again so they can continue to be used.
+func (si *ScaleIn) waitForIdleNodes(ctx context.Context, deadline time.Duration, nodes []NodeID) ([]NodeID, error) {
 
-	for _, node := range nodes {
+	if err := si.setNodesEligibility(nodes, false); err != nil {
+		si.setNodesEligibility(nodes, true)
+		return nil, err
+	}
 
-		// Increment our WaitGroup delta.
-		wg.Add(1)
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
 
-		// Assign our node to a local variable as we are launching a go routine
-		// from within a for loop.
-		n := node
+	ticker := time.NewTicker(idleWaitPollInterval)
+	defer ticker.Stop()
 
-		// Launch a routine to drain the node. Append any error returned to the
-		// error.
-		go func() {
+	var idle, busy []NodeID
 
-			// Ensure we call done on the WaitGroup to decrement the count remaining.
-			defer wg.Done()
+	for {
+		// Failing to look up the busy state should not abandon the wait, as
+		// the problem may well be transient. The previous state is discarded
+		// though, since nodes may have become busy since. When failing
+		// closed, every node is busy until the state can be looked up.
+		nodesStatus, err := si.busy.busyNodes(ctx, nomadIDs(nodes))
+		if err != nil {
+			si.log.Warn("failed to look up node busy state", "error", err)
+			idle, busy = nil, nil
+			if si.busy.failClosed {
+				busy = nodes
+			}
+		} else {
+			idle, busy = partitionBusyNodes(nodesStatus, nodes)
+			if len(busy) == 0 {
+				return idle, nil
+			}
+			si.log.Info("waiting for busy nodes to become idle",
+				"idle", len(idle), "busy", len(busy))
+		}
 
-			if err := si.drainNode(ctx, n.NomadID, &drainSpec); err != nil {
-				resultLock.Lock()
-				result = multierror.Append(result, err)
-				resultLock.Unlock()
+		select {
+		case <-ctx.Done():
+			si.setNodesEligibility(nodes, true)
+			return nil, ctx.Err()
+		case <-timer.C:
+			if idle == nil && busy == nil {
+				si.setNodesEligibility(nodes, true)
+				return nil, errors.New("failed to look up node busy state by idle wait deadline")
 			}
-		}()
+			si.log.Warn("idle wait deadline reached, retaining busy nodes",
+				"deadline", deadline, "busy", len(busy))
+			si.setNodesEligibility(busy, true)
+			return idle, nil
+		case <-ticker.C:
+		}
 	}
-
-	wg.Wait()
-
-	return result
 }
 
-// drainNode triggers a drain on the supplied ID using the DrainSpec. The
-// function handles monitoring the drain and reporting its terminal status to
-// the caller.
-func (si *ScaleIn) drainNode(ctx context.Context, nodeID string, spec *api.DrainSpec) error {
+// setNodesEligibility sets the scheduling eligibility of each node. Failures
+// are logged and returned, but do not stop the remaining nodes being updated.
+func (si *ScaleIn) setNodesEligibility(nodes []NodeID, eligible bool) error {
 
-	si.log.Info("triggering drain on node", "node_id", nodeID, "deadline", spec.Deadline)
+	var mErr *multierror.Error
 
-	// Update the drain on the node.
-	resp, err := si.nomad.Nodes().UpdateDrain(nodeID, spec, false, nil)
-	if err != nil {
-		return fmt.Errorf("failed to drain node: %v", err)
+	for _, node := range nodes {
+		if _, err := si.nomad.Nodes().ToggleEligibility(node.NomadID, eligible, nil); err != nil {
+			si.log.Error("failed to update node eligibility", "node_id", node.NomadID,
+				"eligible", eligible, "error", err)
+			mErr = multierror.Append(mErr, fmt.Errorf("failed to update eligibility of node %s: %v", node.NomadID, err))
+		}
 	}
+	return mErr.ErrorOrNil()
+}
 
-	// Monitor the drain so we output the log messages. An error here indicates
-	// the drain failed to complete successfully.
-	if err := si.monitorNodeDrain(ctx, nodeID, resp.LastIndex); err != nil {
-		return fmt.Errorf("context done while monitoring node drain: %v", err)
+// partitionBusyNodes splits the nodes into those which are idle and those
+// which are busy according to the node busy state.
+func partitionBusyNodes(status map[string]bool, nodes []NodeID) (idle, busy []NodeID) {
+	for _, node := range nodes {
+		if status[node.NomadID] {
+			busy = append(busy, node)
+		} else {
+			idle = append(idle, node)
+		}
 	}
-	return nil
+	return idle, busy
 }
 
-// monitorNodeDrain follows the drain of a node, logging the messages we
-// receive to their appropriate level.
-//
-// TODO(jrasell): currently the ignoreSys param is hardcoded to false, we will
-//  probably want to expose this to operators in the future.
-func (si *ScaleIn) monitorNodeDrain(ctx context.Context, nodeID string, index uint64) error {
-	for msg := range si.nomad.Nodes().MonitorDrain(ctx, nodeID, index, false) {
-		switch msg.Level {
-		case api.MonitorMsgLevelInfo:
-			si.log.Info("received node drain message", "node_id", nodeID, "msg", msg.Message)
-		case api.MonitorMsgLevelWarn:
-			si.log.Warn("received node drain message", "node_id", nodeID, "msg", msg.Message)
-		case api.MonitorMsgLevelError:
-			return fmt.Errorf("received error while draining node: %s", msg.Message)
-		default:
-			si.log.Debug("received node drain message", "node_id", nodeID, "msg", msg.Message)
-		}
+// nomadIDs returns the Nomad node ID of each node.
+func nomadIDs(nodes []NodeID) []string {
+	out := make([]string, len(nodes))
+	for i, node := range nodes {
+		out[i] = node.NomadID
 	}
-	return ctx.Err()
+	return out
 }
 
 // identifyAutoscalerNodeID identifies the NodeID which the autoscaler is
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_partitionBusyNodes(t *testing.T) {
+	status := map[string]bool{"node1": true, "node2": true, "node3": false}
+	nodes := []NodeID{{NomadID: "node1"}, {NomadID: "node2"}, {NomadID: "node3"}}
+
+	idle, busy := partitionBusyNodes(status, nodes)
+	assert.Equal(t, []NodeID{{NomadID: "node3"}}, idle)
+	assert.Equal(t, []NodeID{{NomadID: "node1"}, {NomadID: "node2"}}, busy)
+}
+
+// testIdleWaitServer serves both the DMS node status and Nomad node
+// eligibility endpoints. Each DMS poll pops the next busy state from the
+// queue, with the final state being returned once the queue is exhausted. A
+// nil state fails the poll.
+type testIdleWaitServer struct {
+	lock        sync.Mutex
+	states      []map[string]bool
+	eligibility map[string]bool
+}
+
+func (s *testIdleWaitServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch {
+	case r.URL.Path == "/v1/nodes":
+		state := s.states[0]
+		if len(s.states) > 1 {
+			s.states = s.states[1:]
+		}
+		if state == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(DmsNodes{Nodes: state})
+	case strings.HasSuffix(r.URL.Path, "/eligibility"):
+		var req api.NodeUpdateEligibilityRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		s.eligibility[req.NodeID] = req.Eligibility == api.NodeSchedulingEligible
+		_ = json.NewEncoder(w).Encode(api.NodeEligibilityUpdateResponse{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestScaleIn_waitForIdleNodes(t *testing.T) {
+	idleWaitPollInterval = 10 * time.Millisecond
+
+	testCases := []struct {
+		name                string
+		inputStates         []map[string]bool
+		inputDeadline       time.Duration
+		inputFailClosed     bool
+		expectedIdle        []NodeID
+		expectedError       bool
+		expectedEligibility map[string]bool
+	}{
+		{
+			name: "nodes become idle",
+			inputStates: []map[string]bool{
+				{"node1": true, "node2": false},
+				{"node1": true, "node2": false},
+				{"node1": false, "node2": false},
+			},
+			inputDeadline:       time.Minute,
+			expectedIdle:        []NodeID{{NomadID: "node1"}, {NomadID: "node2"}},
+			expectedEligibility: map[string]bool{"node1": false, "node2": false},
+		},
+		{
+			name: "deadline reached with busy node",
+			inputStates: []map[string]bool{
+				{"node1": true, "node2": false},
+			},
+			inputDeadline:       50 * time.Millisecond,
+			expectedIdle:        []NodeID{{NomadID: "node2"}},
+			expectedEligibility: map[string]bool{"node1": true, "node2": false},
+		},
+		{
+			name: "lookup failing after deadline discards previous state",
+			inputStates: []map[string]bool{
+				{"node1": true, "node2": false},
+				nil,
+			},
+			inputDeadline:       50 * time.Millisecond,
+			expectedError:       true,
+			expectedEligibility: map[string]bool{"node1": true, "node2": true},
+		},
+		{
+			name: "lookup failing with fail closed",
+			inputStates: []map[string]bool{
+				{"node1": true, "node2": false},
+				nil,
+			},
+			inputDeadline:       50 * time.Millisecond,
+			inputFailClosed:     true,
+			expectedEligibility: map[string]bool{"node1": true, "node2": true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &testIdleWaitServer{states: tc.inputStates, eligibility: make(map[string]bool)}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			nomadClient, err := api.NewClient(&api.Config{Address: ts.URL})
+			assert.Nil(t, err)
+			dmsClient, err := NewDmsApiClient(&DmsApiConfig{Address: ts.URL})
+			assert.Nil(t, err)
+
+			si := &ScaleIn{
+				log:   hclog.NewNullLogger(),
+				nomad: nomadClient,
+				busy: newBusyStateChecker(&dmsBusyState{client: dmsClient},
+					&BusyStateConfig{FailClosed: tc.inputFailClosed}, hclog.NewNullLogger()),
+			}
+
+			idle, err := si.waitForIdleNodes(context.Background(), tc.inputDeadline,
+				[]NodeID{{NomadID: "node1"}, {NomadID: "node2"}})
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedIdle, idle)
+			assert.Equal(t, tc.expectedEligibility, srv.eligibility)
+		})
+	}
+}
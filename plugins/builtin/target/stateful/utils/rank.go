@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
+	"github.com/hashicorp/nomad/api"
+)
+
+// rankNodes sorts the nodes in the order they should be selected for removal.
+// Nodes which are idle are always ranked ahead of busy nodes so
+// that busy nodes are only selected once every idle node has been. Within the
+// idle and busy nodes, the order is defined by the strategy.
+func (si *ScaleIn) rankNodes(ctx context.Context, nodes []*api.NodeListStub, strategy NodeIDStrategy) ([]*api.NodeListStub, error) {
+
+	ranked, err := scaleutils.RankNodes(si.nomad, nodes, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := si.busyNodes(ctx, ranked)
+
+	// The sort is stable, and so retains the order of the strategy within the
+	// idle and busy nodes.
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return !busy[ranked[i].ID] && busy[ranked[j].ID]
+	})
+	return ranked, nil
+}
+
+// busyNodes returns the busy state of each node, keyed by the node ID. Failing
+// to look up the busy state does not prevent ranking, since the busy state of
+// the selected nodes is checked again before they are drained.
+func (si *ScaleIn) busyNodes(ctx context.Context, nodes []*api.NodeListStub) map[string]bool {
+
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+
+	status, err := si.busy.busyNodes(ctx, ids)
+	if err != nil {
+		si.log.Warn("failed to look up node busy state, ranking nodes without busy state", "error", err)
+		return nil
+	}
+	return status
+}
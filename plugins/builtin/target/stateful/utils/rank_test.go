@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleIn_rankNodes(t *testing.T) {
+
+	// node1 is the oldest and busy, node2 runs two allocations using most of
+	// its resources, and node3 is the newest and runs a single small
+	// allocation.
+	busy := map[string]bool{"node1": true, "node2": false, "node3": false}
+	allocs := map[string][]*api.Allocation{
+		"node1": {},
+		"node2": {testAlloc(500, 512), testAlloc(500, 512)},
+		"node3": {testAlloc(100, 128), testTerminalAlloc()},
+	}
+
+	dms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(DmsNodes{Nodes: busy})
+	}))
+	defer dms.Close()
+
+	nomad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/node/")
+		if id := strings.TrimSuffix(path, "/allocations"); id != path {
+			_ = json.NewEncoder(w).Encode(allocs[id])
+			return
+		}
+		_ = json.NewEncoder(w).Encode(api.Node{
+			ID: path,
+			NodeResources: &api.NodeResources{
+				Cpu:    api.NodeCpuResources{CpuShares: 1000},
+				Memory: api.NodeMemoryResources{MemoryMB: 1024},
+			},
+		})
+	}))
+	defer nomad.Close()
+
+	nomadClient, err := api.NewClient(&api.Config{Address: nomad.URL})
+	assert.Nil(t, err)
+	dmsClient, err := NewDmsApiClient(&DmsApiConfig{Address: dms.URL})
+	assert.Nil(t, err)
+
+	si := &ScaleIn{
+		log:   hclog.NewNullLogger(),
+		nomad: nomadClient,
+		busy:  newBusyStateChecker(&dmsBusyState{client: dmsClient}, &BusyStateConfig{}, hclog.NewNullLogger()),
+	}
+
+	inputNodes := []*api.NodeListStub{
+		{ID: "node1", CreateIndex: 1},
+		{ID: "node2", CreateIndex: 2},
+		{ID: "node3", CreateIndex: 3},
+	}
+
+	testCases := []struct {
+		inputStrategy  NodeIDStrategy
+		expectedOutput []string
+		expectedError  bool
+	}{
+		{inputStrategy: IDStrategyNewestCreateIndex, expectedOutput: []string{"node3", "node2", "node1"}},
+		{inputStrategy: IDStrategyOldestCreateIndex, expectedOutput: []string{"node2", "node3", "node1"}},
+		{inputStrategy: IDStrategyLeastBusy, expectedOutput: []string{"node3", "node2", "node1"}},
+		{inputStrategy: IDStrategyLeastAllocatedResources, expectedOutput: []string{"node3", "node2", "node1"}},
+		{inputStrategy: IDStrategyEmptyFirst, expectedOutput: []string{"node3", "node2", "node1"}},
+		{inputStrategy: "unknown", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.inputStrategy), func(t *testing.T) {
+			actualOutput, err := si.rankNodes(context.Background(), inputNodes, tc.inputStrategy)
+			if tc.expectedError {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			var ids []string
+			for _, n := range actualOutput {
+				ids = append(ids, n.ID)
+			}
+			assert.Equal(t, tc.expectedOutput, ids)
+		})
+	}
+}
+
+func testAlloc(cpu, mem int64) *api.Allocation {
+	return &api.Allocation{
+		DesiredStatus: api.AllocDesiredStatusRun,
+		ClientStatus:  api.AllocClientStatusRunning,
+		AllocatedResources: &api.AllocatedResources{
+			Tasks: map[string]*api.AllocatedTaskResources{
+				"task": {
+					Cpu:    api.AllocatedCpuResources{CpuShares: cpu},
+					Memory: api.AllocatedMemoryResources{MemoryMB: mem},
+				},
+			},
+		},
+	}
+}
+
+func testTerminalAlloc() *api.Allocation {
+	alloc := testAlloc(900, 900)
+	alloc.ClientStatus = api.AllocClientStatusComplete
+	return alloc
+}
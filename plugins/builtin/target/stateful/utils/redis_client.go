@@ -1,72 +1,237 @@
 package utils
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gomodule/redigo/redis"
 	"github.com/hashicorp/go-hclog"
-	"sync"
-	"time"
 )
 
-var globalRedisPool *redis.Pool
-var globalRedisPoolOnce sync.Once
+const (
+	configKeyRedisAddress          = "redis_address"
+	configKeyRedisPassword         = "redis_password"
+	configKeyRedisDB               = "redis_db"
+	configKeyRedisTLS              = "redis_tls"
+	configKeyRedisCACert           = "redis_ca-cert"
+	configKeyRedisCAPath           = "redis_ca-path"
+	configKeyRedisClientCert       = "redis_client-cert"
+	configKeyRedisClientKey        = "redis_client-key"
+	configKeyRedisTLSServerName    = "redis_tls-server-name"
+	configKeyRedisSkipVerify       = "redis_skip-verify"
+	configKeyRedisMaxIdleConn      = "redis_max_idle_conn"
+	configKeyRedisMaxActiveConn    = "redis_max_active_conn"
+	configKeyRedisIdleTimeout      = "redis_idle_timeout"
+	configKeyRedisSentinelAddress  = "redis_sentinel_address"
+	configKeyRedisSentinelMaster   = "redis_sentinel_master"
+	configKeyRedisSentinelPassword = "redis_sentinel_password"
+	configKeyRedisCluster          = "redis_cluster"
 
-var redisAddress string
-var redisPassword string
-var maxIdleConn int
-var maxActiveConn int
-var logger hclog.Logger
+	// defaultRedisMaxIdleConn, defaultRedisMaxActiveConn and
+	// defaultRedisIdleTimeout size the connection pool when not specified by
+	// an operator.
+	defaultRedisMaxIdleConn   = 5
+	defaultRedisMaxActiveConn = 20
+	defaultRedisIdleTimeout   = 300 * time.Second
 
-func GetRedis() redis.Conn {
-	globalRedisPoolOnce.Do(func() {
-		globalRedisPool = NewRedisPool(logger)
-	})
-	return globalRedisPool.Get()
-}
+	// redisTimeout is the timeout applied when connecting to, reading from
+	// and writing to Redis.
+	redisTimeout = 5 * time.Second
+)
 
+// RedisConfig is used to configure the creation of a RedisClient.
 type RedisConfig struct {
-	Address       string `json:"address"`
-	Password      string `json:"password"`
-	MaxActiveConn int    `json:"max_active_conn"`
-	MaxIdleConn   int    `json:"max_idle_conn"`
+
+	// Addresses are the addresses of the Redis server. Only a single address
+	// is supported unless Cluster is set, in which case the addresses are
+	// used to discover the cluster.
+	Addresses []string
+
+	// Password and DB are used to authenticate and select the database of
+	// each connection.
+	Password string
+	DB       int
+
+	// TLS enables TLS, configured using TLSConfig.
+	TLS       bool
+	TLSConfig *TLSConfig
+
+	// MaxActiveConn, MaxIdleConn and IdleTimeout size the connection pool
+	// of each Redis server.
+	MaxActiveConn int
+	MaxIdleConn   int
+	IdleTimeout   time.Duration
+
+	// SentinelAddresses, when set, are the addresses of the Redis Sentinels
+	// used to discover the current master named by SentinelMaster. The
+	// Addresses param is ignored.
+	SentinelAddresses []string
+	SentinelMaster    string
+	SentinelPassword  string
+
+	// Cluster indicates the Addresses are nodes of a Redis Cluster.
+	Cluster bool
 }
 
-func StartRedisService(redisConfig RedisConfig, log hclog.Logger) {
-	redisAddress = redisConfig.Address
-	redisPassword = redisConfig.Password
-	maxActiveConn = redisConfig.MaxActiveConn
-	maxIdleConn = redisConfig.MaxIdleConn
-	logger = log
+// RedisConfigFromMap converts the map representation of a Redis config to the
+// proper object that can be used to setup a client. If Redis is not
+// configured, nil is returned.
+func RedisConfigFromMap(cfg map[string]string) (*RedisConfig, error) {
+
+	c := &RedisConfig{
+		Addresses:     splitList(cfg[configKeyRedisAddress]),
+		Password:      cfg[configKeyRedisPassword],
+		MaxActiveConn: defaultRedisMaxActiveConn,
+		MaxIdleConn:   defaultRedisMaxIdleConn,
+		IdleTimeout:   defaultRedisIdleTimeout,
+		TLSConfig: &TLSConfig{
+			CACert:        cfg[configKeyRedisCACert],
+			CAPath:        cfg[configKeyRedisCAPath],
+			ClientCert:    cfg[configKeyRedisClientCert],
+			ClientKey:     cfg[configKeyRedisClientKey],
+			TLSServerName: cfg[configKeyRedisTLSServerName],
+		},
+		SentinelAddresses: splitList(cfg[configKeyRedisSentinelAddress]),
+		SentinelMaster:    cfg[configKeyRedisSentinelMaster],
+		SentinelPassword:  cfg[configKeyRedisSentinelPassword],
+	}
+
+	if len(c.Addresses) == 0 && len(c.SentinelAddresses) == 0 {
+		return nil, nil
+	}
+
+	var err error
+
+	if v, ok := cfg[configKeyRedisDB]; ok {
+		if c.DB, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as int: %v", configKeyRedisDB, err)
+		}
+	}
+	if v, ok := cfg[configKeyRedisTLS]; ok {
+		if c.TLS, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as bool: %v", configKeyRedisTLS, err)
+		}
+	}
+	if v, ok := cfg[configKeyRedisSkipVerify]; ok {
+		if c.TLSConfig.Insecure, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as bool: %v", configKeyRedisSkipVerify, err)
+		}
+	}
+	if v, ok := cfg[configKeyRedisMaxActiveConn]; ok {
+		if c.MaxActiveConn, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as int: %v", configKeyRedisMaxActiveConn, err)
+		}
+	}
+	if v, ok := cfg[configKeyRedisMaxIdleConn]; ok {
+		if c.MaxIdleConn, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as int: %v", configKeyRedisMaxIdleConn, err)
+		}
+	}
+	if v, ok := cfg[configKeyRedisIdleTimeout]; ok {
+		if c.IdleTimeout, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as time duration: %v", configKeyRedisIdleTimeout, err)
+		}
+	}
+	if v, ok := cfg[configKeyRedisCluster]; ok {
+		if c.Cluster, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("failed to parse %q as bool: %v", configKeyRedisCluster, err)
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
-// 仅供测试用
-func StartRedisService2(address, password string) {
-	redisAddress = address
-	redisPassword = password
-	maxActiveConn = 1000
-	maxIdleConn = 5000
+// validate is used to ensure that RedisConfig is correctly populated.
+func (c *RedisConfig) validate() error {
+	switch {
+	case c.Cluster && len(c.SentinelAddresses) > 0:
+		return errors.New("redis cluster and sentinel cannot both be configured")
+	case c.Cluster && c.DB != 0:
+		return errors.New("redis cluster only supports database 0")
+	case len(c.SentinelAddresses) > 0 && c.SentinelMaster == "":
+		return fmt.Errorf("%q must be set when using redis sentinel", configKeyRedisSentinelMaster)
+	case !c.Cluster && len(c.SentinelAddresses) == 0 && len(c.Addresses) > 1:
+		return fmt.Errorf("multiple %q values are only supported by redis cluster", configKeyRedisAddress)
+	}
+	return nil
 }
 
-func NewRedisPool(log hclog.Logger) *redis.Pool {
+// splitList splits a comma separated list, ignoring empty elements.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// RedisClient provides pooled connections to a standalone Redis server, the
+// master discovered via Redis Sentinel, or the nodes of a Redis Cluster.
+type RedisClient struct {
+	log hclog.Logger
+
+	// pool is used for standalone and sentinel configurations, with cluster
+	// used otherwise.
+	pool    *redis.Pool
+	cluster *redisCluster
+}
+
+// NewRedisClient returns a new RedisClient. No connections are made until
+// they are required.
+func NewRedisClient(cfg *RedisConfig, log hclog.Logger) (*RedisClient, error) {
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	opts := []redis.DialOption{
+		redis.DialConnectTimeout(redisTimeout),
+		redis.DialReadTimeout(redisTimeout),
+		redis.DialWriteTimeout(redisTimeout),
+		redis.DialPassword(cfg.Password),
+		redis.DialDatabase(cfg.DB),
+	}
+
+	if cfg.TLS {
+		tlsConfig := &tls.Config{}
+		if err := configureTLSConfig(tlsConfig, cfg.TLSConfig); err != nil {
+			return nil, fmt.Errorf("failed to configure redis TLS: %v", err)
+		}
+		opts = append(opts, redis.DialUseTLS(true), redis.DialTLSConfig(tlsConfig))
+	}
+
+	c := &RedisClient{log: log}
+
+	switch {
+	case cfg.Cluster:
+		c.cluster = newRedisCluster(cfg, opts, log)
+	case len(cfg.SentinelAddresses) > 0:
+		c.pool = newRedisPool(cfg, newSentinelDialer(cfg, opts, log).dial)
+		c.pool.TestOnBorrow = testRedisMaster
+	default:
+		addr := cfg.Addresses[0]
+		c.pool = newRedisPool(cfg, func() (redis.Conn, error) { return redis.Dial("tcp", addr, opts...) })
+	}
+
+	return c, nil
+}
+
+// newRedisPool returns a connection pool sized using the config.
+func newRedisPool(cfg *RedisConfig, dial func() (redis.Conn, error)) *redis.Pool {
 	return &redis.Pool{
-		MaxIdle:     maxIdleConn,
-		MaxActive:   maxActiveConn,
-		IdleTimeout: 300 * time.Second,
-		Dial: func() (redis.Conn, error) {
-			c, err := redis.Dial("tcp", redisAddress)
-			if err != nil {
-				log.Trace("redis Dial", "connect redis server fail", "err", err, "address", redisAddress, "password", redisPassword)
-				return nil, err
-			}
-			if len(redisPassword) > 0 {
-				if _, err := c.Do("AUTH", redisPassword); err != nil {
-					c.Close()
-					log.Trace("redis Dial", "connect redis server fail", "err", err, "address", redisAddress, "password", redisPassword)
-					return nil, err
-				}
-			}
-			log.Trace("redis Dial", "connect redis server", "address", redisAddress, "password", redisPassword)
-			return c, err
-		},
+		MaxIdle:     cfg.MaxIdleConn,
+		MaxActive:   cfg.MaxActiveConn,
+		IdleTimeout: cfg.IdleTimeout,
+		Dial:        dial,
 		TestOnBorrow: func(c redis.Conn, t time.Time) error {
 			if time.Since(t) < 2*time.Minute {
 				return nil
@@ -77,6 +242,22 @@ func NewRedisPool(log hclog.Logger) *redis.Pool {
 	}
 }
 
+// Conn returns a connection to Redis which must be closed once finished with.
+func (c *RedisClient) Conn() redis.Conn {
+	if c.cluster != nil {
+		return c.cluster.conn()
+	}
+	return c.pool.Get()
+}
+
+// Close closes all the pooled connections.
+func (c *RedisClient) Close() error {
+	if c.cluster != nil {
+		return c.cluster.close()
+	}
+	return c.pool.Close()
+}
+
 func InnerDo(conn redis.Conn, commandName string, args ...interface{}) (reply interface{}, err error) {
 	reply, err = conn.Do(commandName, args...)
 	return
@@ -114,36 +295,36 @@ func Int64(reply interface{}, err error) (int64, error) {
 	return redis.Int64(reply, err)
 }
 
-func RedisGet(key string) (reply interface{}, err error) {
-	conn := GetRedis()
+func (c *RedisClient) RedisGet(key string) (reply interface{}, err error) {
+	conn := c.Conn()
 	reply, err = InnerDo(conn, "GET", key)
 	conn.Close()
 	return
 }
 
-func RedisSet(key string, value interface{}) (err error) {
-	conn := GetRedis()
+func (c *RedisClient) RedisSet(key string, value interface{}) (err error) {
+	conn := c.Conn()
 	_, err = InnerDo(conn, "SET", key, value)
 	conn.Close()
 	return
 }
 
-func RedisSendSet(key string, value interface{}) (err error) {
-	conn := GetRedis()
+func (c *RedisClient) RedisSendSet(key string, value interface{}) (err error) {
+	conn := c.Conn()
 	err = InnerSend(conn, "SET", key, value)
 	conn.Close()
 	return
 }
 
-func RedisDel(key string) (err error) {
-	conn := GetRedis()
+func (c *RedisClient) RedisDel(key string) (err error) {
+	conn := c.Conn()
 	_, err = InnerDo(conn, "DEL", key)
 	conn.Close()
 	return
 }
 
-func RedisINCR(key string) (incrReply int64, err error) {
-	conn := GetRedis()
+func (c *RedisClient) RedisINCR(key string) (incrReply int64, err error) {
+	conn := c.Conn()
 	reply, err := InnerDo(conn, "INCR", key)
 
 	incrReply, err = Int64(reply, err)
@@ -152,8 +333,8 @@ func RedisINCR(key string) (incrReply int64, err error) {
 	return
 }
 
-func RedisHGETALL(key string) (hashesReply map[string]string, err error) {
-	conn := GetRedis()
+func (c *RedisClient) RedisHGETALL(key string) (hashesReply map[string]string, err error) {
+	conn := c.Conn()
 	reply, err := InnerDo(conn, "HGETALL", key)
 
 	hashesReply, err = redis.StringMap(reply, err)
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisConfigFromMap(t *testing.T) {
+	testCases := []struct {
+		inputConfig         map[string]string
+		expectedOutput      *RedisConfig
+		expectedOutputError error
+		name                string
+	}{
+		{
+			inputConfig:         map[string]string{},
+			expectedOutput:      nil,
+			expectedOutputError: nil,
+			name:                "redis not configured",
+		},
+		{
+			inputConfig: map[string]string{
+				"redis_address":         "10.0.0.1:6379",
+				"redis_password":        "secret",
+				"redis_db":              "2",
+				"redis_tls":             "true",
+				"redis_skip-verify":     "true",
+				"redis_max_active_conn": "50",
+				"redis_idle_timeout":    "1m",
+			},
+			expectedOutput: &RedisConfig{
+				Addresses:     []string{"10.0.0.1:6379"},
+				Password:      "secret",
+				DB:            2,
+				TLS:           true,
+				TLSConfig:     &TLSConfig{Insecure: true},
+				MaxActiveConn: 50,
+				MaxIdleConn:   defaultRedisMaxIdleConn,
+				IdleTimeout:   time.Minute,
+			},
+			expectedOutputError: nil,
+			name:                "standalone",
+		},
+		{
+			inputConfig: map[string]string{
+				"redis_sentinel_address": "10.0.0.1:26379, 10.0.0.2:26379",
+				"redis_sentinel_master":  "game",
+			},
+			expectedOutput: &RedisConfig{
+				TLSConfig:         &TLSConfig{},
+				MaxActiveConn:     defaultRedisMaxActiveConn,
+				MaxIdleConn:       defaultRedisMaxIdleConn,
+				IdleTimeout:       defaultRedisIdleTimeout,
+				SentinelAddresses: []string{"10.0.0.1:26379", "10.0.0.2:26379"},
+				SentinelMaster:    "game",
+			},
+			expectedOutputError: nil,
+			name:                "sentinel",
+		},
+		{
+			inputConfig: map[string]string{
+				"redis_sentinel_address": "10.0.0.1:26379",
+			},
+			expectedOutput:      nil,
+			expectedOutputError: errors.New("\"redis_sentinel_master\" must be set when using redis sentinel"),
+			name:                "sentinel without master",
+		},
+		{
+			inputConfig: map[string]string{
+				"redis_address": "10.0.0.1:6379,10.0.0.2:6379",
+				"redis_cluster": "true",
+			},
+			expectedOutput: &RedisConfig{
+				Addresses:     []string{"10.0.0.1:6379", "10.0.0.2:6379"},
+				TLSConfig:     &TLSConfig{},
+				MaxActiveConn: defaultRedisMaxActiveConn,
+				MaxIdleConn:   defaultRedisMaxIdleConn,
+				IdleTimeout:   defaultRedisIdleTimeout,
+				Cluster:       true,
+			},
+			expectedOutputError: nil,
+			name:                "cluster",
+		},
+		{
+			inputConfig: map[string]string{
+				"redis_address": "10.0.0.1:6379,10.0.0.2:6379",
+			},
+			expectedOutput:      nil,
+			expectedOutputError: errors.New("multiple \"redis_address\" values are only supported by redis cluster"),
+			name:                "multiple addresses without cluster",
+		},
+		{
+			inputConfig: map[string]string{
+				"redis_address": "10.0.0.1:6379",
+				"redis_cluster": "true",
+				"redis_db":      "1",
+			},
+			expectedOutput:      nil,
+			expectedOutputError: errors.New("redis cluster only supports database 0"),
+			name:                "cluster with database",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, actualError := RedisConfigFromMap(tc.inputConfig)
+			assert.Equal(t, tc.expectedOutput, actualOutput, tc.name)
+			assert.Equal(t, tc.expectedOutputError, actualError, tc.name)
+		})
+	}
+}
+
+func Test_keySlot(t *testing.T) {
+	assert.Equal(t, 12182, keySlot("foo"))
+	assert.Equal(t, 12739, keySlot("123456789"))
+	assert.Equal(t, keySlot("user1000"), keySlot("{user1000}.following"))
+	assert.Equal(t, keySlot("{}.following"), keySlot("{}.following"))
+	assert.NotEqual(t, keySlot("{}.following"), keySlot("{}.followers"))
+}
+
+func Test_commandKey(t *testing.T) {
+	key, ok := commandKey("SET", []interface{}{"foo", "bar"})
+	assert.True(t, ok)
+	assert.Equal(t, "foo", key)
+
+	key, ok = commandKey("EVALSHA", []interface{}{"sha", 1, "lock", "owner"})
+	assert.True(t, ok)
+	assert.Equal(t, "lock", key)
+
+	_, ok = commandKey("PING", nil)
+	assert.False(t, ok)
+}
+
+func Test_parseRedirect(t *testing.T) {
+	redirect, ok := parseRedirect(redis.Error("MOVED 3999 127.0.0.1:6381"))
+	assert.True(t, ok)
+	assert.Equal(t, &redisRedirect{kind: "MOVED", slot: 3999, addr: "127.0.0.1:6381"}, redirect)
+
+	_, ok = parseRedirect(redis.Error("ERR unknown command"))
+	assert.False(t, ok)
+
+	_, ok = parseRedirect(errors.New("MOVED 3999 127.0.0.1:6381"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// redisClusterSlots is the number of hash slots in a Redis Cluster.
+	redisClusterSlots = 16384
+
+	// redisClusterMaxRedirects is the maximum number of MOVED or ASK
+	// redirections followed for a single command.
+	redisClusterMaxRedirects = 5
+)
+
+// redisCluster routes commands to the node of a Redis Cluster which serves
+// the hash slot of their key. The slot of each node is learned from the MOVED
+// redirections returned by the cluster, so only commands with a single key
+// are supported.
+type redisCluster struct {
+	cfg  *RedisConfig
+	opts []redis.DialOption
+	log  hclog.Logger
+
+	// lock protects the pools and slots.
+	lock  sync.Mutex
+	pools map[string]*redis.Pool
+	slots [redisClusterSlots]string
+}
+
+func newRedisCluster(cfg *RedisConfig, opts []redis.DialOption, log hclog.Logger) *redisCluster {
+	return &redisCluster{
+		cfg:   cfg,
+		opts:  opts,
+		log:   log,
+		pools: make(map[string]*redis.Pool),
+	}
+}
+
+// conn returns a connection which routes each command to the correct node.
+func (rc *redisCluster) conn() redis.Conn {
+	return &redisClusterConn{cluster: rc}
+}
+
+// close closes the pooled connections of every node.
+func (rc *redisCluster) close() error {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	var mErr *multierror.Error
+	for addr, pool := range rc.pools {
+		if err := pool.Close(); err != nil {
+			mErr = multierror.Append(mErr, err)
+		}
+		delete(rc.pools, addr)
+	}
+	return mErr.ErrorOrNil()
+}
+
+// nodeConn returns a pooled connection to the node.
+func (rc *redisCluster) nodeConn(addr string) redis.Conn {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	pool, ok := rc.pools[addr]
+	if !ok {
+		pool = newRedisPool(rc.cfg, func() (redis.Conn, error) { return redis.Dial("tcp", addr, rc.opts...) })
+		rc.pools[addr] = pool
+	}
+	return pool.Get()
+}
+
+// addrs returns the addresses to try when running a command against the
+// slot; the node known to serve the slot followed by the configured nodes.
+func (rc *redisCluster) addrs(slot int) []string {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if slot >= 0 && rc.slots[slot] != "" {
+		return append([]string{rc.slots[slot]}, rc.cfg.Addresses...)
+	}
+	return rc.cfg.Addresses
+}
+
+func (rc *redisCluster) setSlot(slot int, addr string) {
+	rc.lock.Lock()
+	rc.slots[slot] = addr
+	rc.lock.Unlock()
+}
+
+// do runs the command against the cluster, following redirections.
+func (rc *redisCluster) do(cmd string, args ...interface{}) (interface{}, error) {
+
+	slot := -1
+	if key, ok := commandKey(cmd, args); ok {
+		slot = keySlot(key)
+	}
+
+	// Try each candidate node in turn until one is reachable. Errors
+	// returned by Redis itself are not retried, other than redirections.
+	var mErr *multierror.Error
+
+	for _, addr := range rc.addrs(slot) {
+		reply, err := rc.doRedirect(addr, cmd, args)
+		if _, ok := err.(redis.Error); err == nil || ok {
+			return reply, err
+		}
+		mErr = multierror.Append(mErr, fmt.Errorf("%s: %v", addr, err))
+	}
+	return nil, fmt.Errorf("failed to reach redis cluster: %v", mErr.ErrorOrNil())
+}
+
+// doRedirect runs the command against the node, following any MOVED or ASK
+// redirections to other nodes.
+func (rc *redisCluster) doRedirect(addr, cmd string, args []interface{}) (interface{}, error) {
+
+	asking := false
+
+	for i := 0; i <= redisClusterMaxRedirects; i++ {
+		conn := rc.nodeConn(addr)
+
+		var (
+			reply interface{}
+			err   error
+		)
+		if asking {
+			_, err = conn.Do("ASKING")
+		}
+		if err == nil {
+			reply, err = conn.Do(cmd, args...)
+		}
+		conn.Close()
+
+		redirect, ok := parseRedirect(err)
+		if !ok {
+			return reply, err
+		}
+
+		rc.log.Trace("following redis cluster redirect", "kind", redirect.kind,
+			"slot", redirect.slot, "addr", redirect.addr)
+
+		addr = redirect.addr
+		asking = redirect.kind == "ASK"
+		if !asking {
+			rc.setSlot(redirect.slot, redirect.addr)
+		}
+	}
+	return nil, redis.Error(fmt.Sprintf("too many redis cluster redirections for %s", cmd))
+}
+
+// redisRedirect is a MOVED or ASK redirection returned by a cluster node.
+type redisRedirect struct {
+	kind string
+	slot int
+	addr string
+}
+
+// parseRedirect parses an error of the form "MOVED 3999 127.0.0.1:6381".
+func parseRedirect(err error) (*redisRedirect, bool) {
+	rErr, ok := err.(redis.Error)
+	if !ok {
+		return nil, false
+	}
+
+	parts := strings.Fields(string(rErr))
+	if len(parts) != 3 || (parts[0] != "MOVED" && parts[0] != "ASK") {
+		return nil, false
+	}
+
+	slot, err := strconv.Atoi(parts[1])
+	if err != nil || slot < 0 || slot >= redisClusterSlots {
+		return nil, false
+	}
+	return &redisRedirect{kind: parts[0], slot: slot, addr: parts[2]}, true
+}
+
+// commandKey returns the key of the command, if it has one.
+func commandKey(cmd string, args []interface{}) (string, bool) {
+	switch strings.ToUpper(cmd) {
+	case "PING", "ROLE", "INFO":
+		return "", false
+	case "EVAL", "EVALSHA":
+		if len(args) < 3 {
+			return "", false
+		}
+		if n, err := strconv.Atoi(fmt.Sprint(args[1])); err != nil || n < 1 {
+			return "", false
+		}
+		return fmt.Sprint(args[2]), true
+	default:
+		if len(args) < 1 {
+			return "", false
+		}
+		return fmt.Sprint(args[0]), true
+	}
+}
+
+// keySlot returns the hash slot of the key. If the key contains a non-empty
+// hash tag, such as "{user1000}.following", only the tag is hashed.
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % redisClusterSlots)
+}
+
+// crc16 implements the CRC16-CCITT (XMODEM) checksum used by Redis Cluster.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// redisClusterConn is the redis.Conn returned for a Redis Cluster. Each
+// command is routed independently, so pipelining is not supported.
+type redisClusterConn struct {
+	cluster *redisCluster
+}
+
+var errRedisClusterPipeline = errors.New("pipelining is not supported with redis cluster")
+
+func (c *redisClusterConn) Close() error { return nil }
+func (c *redisClusterConn) Err() error   { return nil }
+
+func (c *redisClusterConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	return c.cluster.do(cmd, args...)
+}
+
+func (c *redisClusterConn) Send(string, ...interface{}) error { return errRedisClusterPipeline }
+func (c *redisClusterConn) Flush() error                      { return errRedisClusterPipeline }
+func (c *redisClusterConn) Receive() (interface{}, error)     { return nil, errRedisClusterPipeline }
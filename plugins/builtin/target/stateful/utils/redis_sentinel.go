@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// sentinelDialer dials the current Redis master, as reported by the Redis
+// Sentinels.
+type sentinelDialer struct {
+	log       hclog.Logger
+	sentinels []string
+	master    string
+
+	// opts are used when dialing the master and sentinelOpts when dialing
+	// the sentinels, which have their own password and no database.
+	opts         []redis.DialOption
+	sentinelOpts []redis.DialOption
+}
+
+func newSentinelDialer(cfg *RedisConfig, opts []redis.DialOption, log hclog.Logger) *sentinelDialer {
+
+	// The sentinel options are the master options with the password and
+	// database replaced; later options take precedence.
+	sentinelOpts := append([]redis.DialOption{}, opts...)
+	sentinelOpts = append(sentinelOpts, redis.DialPassword(cfg.SentinelPassword), redis.DialDatabase(0))
+
+	return &sentinelDialer{
+		log:          log,
+		sentinels:    cfg.SentinelAddresses,
+		master:       cfg.SentinelMaster,
+		opts:         opts,
+		sentinelOpts: sentinelOpts,
+	}
+}
+
+// dial discovers the address of the master and dials it.
+func (sd *sentinelDialer) dial() (redis.Conn, error) {
+
+	addr, err := sd.masterAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := redis.Dial("tcp", addr, sd.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The master reported by the sentinels may be stale if a failover is in
+	// progress, so confirm the role of the server.
+	if err := testRedisMaster(conn, time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// masterAddr asks each sentinel in turn for the address of the master,
+// returning the first answer.
+func (sd *sentinelDialer) masterAddr() (string, error) {
+
+	var mErr *multierror.Error
+
+	for _, sentinel := range sd.sentinels {
+		addr, err := sd.queryMasterAddr(sentinel)
+		if err == nil {
+			return addr, nil
+		}
+		sd.log.Debug("failed to query redis sentinel", "sentinel", sentinel, "error", err)
+		mErr = multierror.Append(mErr, fmt.Errorf("sentinel %s: %v", sentinel, err))
+	}
+	return "", fmt.Errorf("failed to discover redis master %q: %v", sd.master, mErr.ErrorOrNil())
+}
+
+func (sd *sentinelDialer) queryMasterAddr(sentinel string) (string, error) {
+
+	conn, err := redis.Dial("tcp", sentinel, sd.sentinelOpts...)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	resp, err := redis.Strings(conn.Do("SENTINEL", "get-master-addr-by-name", sd.master))
+	if err != nil {
+		return "", err
+	}
+	if len(resp) != 2 {
+		return "", errors.New("master unknown to sentinel")
+	}
+	return net.JoinHostPort(resp[0], resp[1]), nil
+}
+
+// testRedisMaster returns an error if the connection is not to a Redis
+// master. It satisfies the redis.Pool TestOnBorrow function, ensuring pooled
+// connections to a former master are discarded after a failover.
+func testRedisMaster(conn redis.Conn, _ time.Time) error {
+	resp, err := redis.Values(conn.Do("ROLE"))
+	if err != nil {
+		return err
+	}
+	if len(resp) == 0 {
+		return errors.New("empty redis ROLE response")
+	}
+
+	role, err := redis.String(resp[0], nil)
+	if err != nil {
+		return err
+	}
+	if role != "master" {
+		return fmt.Errorf("redis server role is %q, not master", role)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad-autoscaler/helper/scaleutils"
 )
 
 const (
@@ -24,11 +25,48 @@ type ScaleInReq struct {
 	// Nomad Node drain.
 	DrainDeadline time.Duration
 
+	// IdleWaitDeadline is the maximum time to wait for busy nodes to become
+	// idle before draining. When zero, busy nodes are not waited upon and are
+	// instead removed from the selection.
+	IdleWaitDeadline time.Duration
+
+	// IgnoreSystemJobs leaves the allocations of system jobs running while
+	// the nodes are drained, so that jobs such as log shippers keep running
+	// until the nodes are terminated.
+	IgnoreSystemJobs bool
+
+	// IneligibleWait is the time the nodes are marked as ineligible for
+	// scheduling before being drained, allowing allocations to finish without
+	// being migrated. If it is zero, the nodes are drained immediately.
+	IneligibleWait time.Duration
+
+	// NoForce drains the nodes without a deadline, so that allocations are
+	// never forcibly stopped. Nodes which have not drained within the
+	// DrainDeadline have their drain cancelled and are not scaled in.
+	NoForce bool
+
 	PoolIdentifier *PoolIdentifier
 	RemoteProvider RemoteProvider
 	NodeIDStrategy NodeIDStrategy
 }
 
+// ParseDrainConfig sets the optional drain params of the request from the
+// target config, returning an error if any of the params are malformed. The
+// params are parsed by the scaleutils helper, so that they are interpreted
+// identically across targets.
+func (sr *ScaleInReq) ParseDrainConfig(config map[string]string) error {
+
+	var drainReq scaleutils.ScaleInReq
+	if err := drainReq.ParseDrainConfig(config); err != nil {
+		return err
+	}
+
+	sr.IgnoreSystemJobs = drainReq.IgnoreSystemJobs
+	sr.IneligibleWait = drainReq.IneligibleWait
+	sr.NoForce = drainReq.NoForce
+	return nil
+}
+
 // validate is used to ensure that ScaleInReq is correctly populated.
 func (sr *ScaleInReq) validate() error {
 
@@ -42,6 +80,14 @@ func (sr *ScaleInReq) validate() error {
 		err = multierror.Append(errors.New("deadline should be non-zero"), err)
 	}
 
+	if sr.IdleWaitDeadline < 0 {
+		err = multierror.Append(errors.New("idle wait deadline should not be negative"), err)
+	}
+
+	if sr.IneligibleWait < 0 {
+		err = multierror.Append(errors.New("ineligible wait should not be negative"), err)
+	}
+
 	if sr.PoolIdentifier == nil {
 		err = multierror.Append(errors.New("pool identifier should be non-nil"), err)
 	}
@@ -56,3 +102,13 @@ func (sr *ScaleInReq) validate() error {
 
 	return err.ErrorOrNil()
 }
+
+// drainOptions returns the options used to drain the nodes of the request.
+func (sr *ScaleInReq) drainOptions() scaleutils.DrainOptions {
+	return scaleutils.DrainOptions{
+		Deadline:         sr.DrainDeadline,
+		IgnoreSystemJobs: sr.IgnoreSystemJobs,
+		IneligibleWait:   sr.IneligibleWait,
+		NoForce:          sr.NoForce,
+	}
+}
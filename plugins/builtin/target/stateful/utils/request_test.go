@@ -101,6 +101,23 @@ func TestScaleInReq_validate(t *testing.T) {
 			},
 			name: "missing node ID strategy",
 		},
+		{
+			inputScaleInReq: &ScaleInReq{
+				Num:              2,
+				DrainDeadline:    20 * time.Minute,
+				IdleWaitDeadline: -1 * time.Minute,
+				PoolIdentifier: &PoolIdentifier{
+					IdentifierKey: "class",
+					Value:         "myclass",
+				},
+				RemoteProvider: "aws",
+				NodeIDStrategy: "newest_create_index",
+			},
+			expectedOutputError: &multierror.Error{
+				Errors: []error{errors.New("idle wait deadline should not be negative")},
+			},
+			name: "negative idle wait deadline",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	hclog "github.com/hashicorp/go-hclog"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// RetiringKeyPrefix is the prefix of the Redis keys used to mark nodes as
+	// retiring. The full key is the prefix followed by the Nomad node ID, and
+	// game-server allocation logic should not assign new work to a node while
+	// its key exists.
+	RetiringKeyPrefix = "nomad-autoscaler:retiring:"
+
+	// poolLockKeyPrefix is the prefix of the Redis keys used to lock a pool
+	// of nodes, so only one autoscaler instance retires nodes in a pool at a
+	// time.
+	poolLockKeyPrefix = "nomad-autoscaler:lock:"
+
+	// poolLockTTL is the TTL of a pool lock. Held locks are refreshed well
+	// within the TTL, so the TTL only bounds how long a lock outlives an
+	// autoscaler instance which failed to release it.
+	poolLockTTL = 1 * time.Minute
+
+	// retiringMarkerTTLMargin is added to the idle wait and drain deadlines
+	// of a request to give the TTL of the retiring markers, allowing time for
+	// the nodes to be terminated.
+	retiringMarkerTTLMargin = 15 * time.Minute
+)
+
+// errPoolLocked is returned when attempting to lock a pool which is already
+// locked by another autoscaler instance.
+var errPoolLocked = errors.New("pool is locked by another autoscaler instance")
+
+var (
+	// refreshLockScript extends the TTL of a lock only if it is held by the
+	// owner.
+	refreshLockScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseLockScript deletes a lock only if it is held by the owner.
+	releaseLockScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RetiringNodeKey returns the Redis key used to mark the node as retiring.
+func RetiringNodeKey(nodeID string) string {
+	return RetiringKeyPrefix + nodeID
+}
+
+// retirementCoordinator uses Redis to coordinate the retirement of nodes, both
+// with other autoscaler instances and with game-server allocation logic.
+type retirementCoordinator struct {
+	getConn func() redis.Conn
+	log     hclog.Logger
+
+	// owner uniquely identifies this autoscaler instance as the holder of
+	// pool locks.
+	owner string
+
+	// locks contains a channel for each held lock, keyed by the lock key,
+	// which is closed to stop the lock being refreshed. The lock should be
+	// used when accessing the map.
+	locks     map[string]chan struct{}
+	locksLock sync.Mutex
+}
+
+func newRetirementCoordinator(getConn func() redis.Conn, log hclog.Logger) *retirementCoordinator {
+	return &retirementCoordinator{
+		getConn: getConn,
+		log:     log,
+		owner:   lockOwner(),
+		locks:   make(map[string]chan struct{}),
+	}
+}
+
+// lockOwner generates an ID unique to this autoscaler instance.
+func lockOwner() string {
+	host, _ := os.Hostname()
+
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return host + ":" + hex.EncodeToString(b)
+}
+
+// poolLockKey returns the Redis key used to lock the pool.
+func poolLockKey(ident *PoolIdentifier) string {
+	return fmt.Sprintf("%s%s:%s", poolLockKeyPrefix, ident.IdentifierKey, ident.Value)
+}
+
+// lockPool acquires the lock of the pool, returning errPoolLocked if it is
+// held by another autoscaler instance. The lock is refreshed until unlockPool
+// is called.
+func (rc *retirementCoordinator) lockPool(ident *PoolIdentifier) error {
+
+	key := poolLockKey(ident)
+
+	conn := rc.getConn()
+	reply, err := conn.Do("SET", key, rc.owner, "NX", "PX", poolLockTTL.Milliseconds())
+	conn.Close()
+
+	if err != nil {
+		return fmt.Errorf("failed to lock pool: %v", err)
+	}
+	if reply == nil {
+		return errPoolLocked
+	}
+
+	stopCh := make(chan struct{})
+
+	rc.locksLock.Lock()
+	rc.locks[key] = stopCh
+	rc.locksLock.Unlock()
+
+	go rc.refreshLock(key, stopCh)
+	return nil
+}
+
+// refreshLock periodically extends the TTL of the lock until the stop channel
+// is closed.
+func (rc *retirementCoordinator) refreshLock(key string, stopCh chan struct{}) {
+
+	ticker := time.NewTicker(poolLockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		conn := rc.getConn()
+		ok, err := redis.Bool(refreshLockScript.Do(conn, key, rc.owner, poolLockTTL.Milliseconds()))
+		conn.Close()
+
+		switch {
+		case err != nil:
+			rc.log.Warn("failed to refresh pool lock", "key", key, "error", err)
+		case !ok:
+			rc.log.Error("pool lock lost", "key", key)
+			return
+		}
+	}
+}
+
+// unlockPool stops refreshing the lock of the pool and releases it.
+func (rc *retirementCoordinator) unlockPool(ident *PoolIdentifier) {
+
+	key := poolLockKey(ident)
+
+	rc.locksLock.Lock()
+	if stopCh, ok := rc.locks[key]; ok {
+		close(stopCh)
+		delete(rc.locks, key)
+	}
+	rc.locksLock.Unlock()
+
+	conn := rc.getConn()
+	_, err := releaseLockScript.Do(conn, key, rc.owner)
+	conn.Close()
+
+	if err != nil {
+		rc.log.Warn("failed to release pool lock", "key", key, "error", err)
+	}
+}
+
+// markRetiring sets the retiring marker of each node, which expires after the
+// TTL.
+func (rc *retirementCoordinator) markRetiring(nodes []NodeID, ttl time.Duration) error {
+
+	conn := rc.getConn()
+	defer conn.Close()
+
+	for _, node := range nodes {
+		if _, err := conn.Do("SET", RetiringNodeKey(node.NomadID), "1", "PX", ttl.Milliseconds()); err != nil {
+			return fmt.Errorf("failed to mark node %s as retiring: %v", node.NomadID, err)
+		}
+	}
+	return nil
+}
+
+// clearRetiring removes the retiring marker of each node. Failures are logged,
+// as the markers expire regardless.
+func (rc *retirementCoordinator) clearRetiring(nodes []NodeID) {
+
+	conn := rc.getConn()
+	defer conn.Close()
+
+	var mErr *multierror.Error
+
+	for _, node := range nodes {
+		if _, err := conn.Do("DEL", RetiringNodeKey(node.NomadID)); err != nil {
+			mErr = multierror.Append(mErr, err)
+		}
+	}
+
+	if err := mErr.ErrorOrNil(); err != nil {
+		rc.log.Warn("failed to clear node retiring markers", "error", err)
+	}
+}
+
+// ping confirms Redis is reachable.
+func (rc *retirementCoordinator) ping() error {
+	conn := rc.getConn()
+	defer conn.Close()
+
+	_, err := conn.Do("PING")
+	return err
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRedis is an in-memory stand-in for the subset of Redis used by the
+// retirementCoordinator and redisBusyState. Scripts are interpreted based on their arguments,
+// since both only act when the key is held by the owner.
+type testRedis struct {
+	lock   sync.Mutex
+	data   map[string]string
+	hashes map[string]map[string]string
+}
+
+func newTestRedis() *testRedis { return &testRedis{data: make(map[string]string)} }
+
+func (r *testRedis) conn() redis.Conn { return &testRedisConn{r: r} }
+
+type testRedisConn struct{ r *testRedis }
+
+func (c *testRedisConn) Close() error                      { return nil }
+func (c *testRedisConn) Err() error                        { return nil }
+func (c *testRedisConn) Send(string, ...interface{}) error { return nil }
+func (c *testRedisConn) Flush() error                      { return nil }
+func (c *testRedisConn) Receive() (interface{}, error)     { return nil, nil }
+
+func (c *testRedisConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+
+	arg := func(i int) string { return fmt.Sprint(args[i]) }
+
+	switch cmd {
+	case "PING":
+		return "PONG", nil
+	case "SET":
+		if _, ok := c.r.data[arg(0)]; ok && args[2] == "NX" {
+			return nil, nil
+		}
+		c.r.data[arg(0)] = arg(1)
+		return "OK", nil
+	case "HGETALL":
+		var out []interface{}
+		for k, v := range c.r.hashes[arg(0)] {
+			out = append(out, []byte(k), []byte(v))
+		}
+		return out, nil
+	case "DEL":
+		delete(c.r.data, arg(0))
+		return int64(1), nil
+	case "EVALSHA":
+		if c.r.data[arg(2)] != arg(3) {
+			return int64(0), nil
+		}
+		if len(args) == 4 {
+			delete(c.r.data, arg(2))
+		}
+		return int64(1), nil
+	default:
+		return nil, fmt.Errorf("unsupported command %q", cmd)
+	}
+}
+
+func Test_retirementCoordinator_lockPool(t *testing.T) {
+	r := newTestRedis()
+	ident := &PoolIdentifier{IdentifierKey: IdentifierKeyClass, Value: "game-hk"}
+
+	rc1 := newRetirementCoordinator(r.conn, hclog.NewNullLogger())
+	rc2 := newRetirementCoordinator(r.conn, hclog.NewNullLogger())
+
+	// Only one coordinator can hold the lock at a time.
+	assert.Nil(t, rc1.lockPool(ident))
+	assert.Equal(t, errPoolLocked, rc2.lockPool(ident))
+
+	// Releasing a lock held by another coordinator has no effect.
+	rc2.unlockPool(ident)
+	assert.Equal(t, errPoolLocked, rc2.lockPool(ident))
+
+	rc1.unlockPool(ident)
+	assert.Nil(t, rc2.lockPool(ident))
+	rc2.unlockPool(ident)
+	assert.Empty(t, r.data)
+}
+
+func Test_retirementCoordinator_markRetiring(t *testing.T) {
+	r := newTestRedis()
+	rc := newRetirementCoordinator(r.conn, hclog.NewNullLogger())
+
+	nodes := []NodeID{{NomadID: "node1"}, {NomadID: "node2"}}
+
+	assert.Nil(t, rc.markRetiring(nodes, time.Hour))
+	assert.Equal(t, map[string]string{
+		"nomad-autoscaler:retiring:node1": "1",
+		"nomad-autoscaler:retiring:node2": "1",
+	}, r.data)
+
+	rc.clearRetiring(nodes[:1])
+	assert.Equal(t, map[string]string{"nomad-autoscaler:retiring:node2": "1"}, r.data)
+}
+
+func Test_excludeNodes(t *testing.T) {
+	nodes := []NodeID{{NomadID: "node1"}, {NomadID: "node2"}, {NomadID: "node3"}}
+	assert.Equal(t, []NodeID{{NomadID: "node1"}, {NomadID: "node3"}},
+		excludeNodes(nodes, []NodeID{{NomadID: "node2"}}))
+	assert.Nil(t, excludeNodes(nodes, nodes))
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	webhook "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/webhook/plugin"
+)
+
+func main() {
+	plugins.Serve(factory)
+}
+
+// factory returns a new instance of the webhook plugin.
+func factory(log hclog.Logger) interface{} {
+	return webhook.NewWebhookPlugin(log)
+}
@@ -0,0 +1,168 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+const (
+	// pluginName is the unique name of the this plugin amongst Target plugins.
+	pluginName = "webhook"
+
+	// configKeys represents the known configuration parameters required at
+	// varying points throughout the plugins lifecycle. The secret, timeout
+	// and health URL are plugin config params, with the others being target
+	// config params.
+	configKeySecret       = "webhook_secret"
+	configKeyTimeout      = "webhook_timeout"
+	configKeyHealthURL    = "webhook_health_url"
+	configKeyScaleURL     = "webhook_scale_url"
+	configKeyStatusURL    = "webhook_status_url"
+	configKeyBodyTemplate = "webhook_body_template"
+
+	// defaultTimeout is the timeout of each request made to the webhooks when
+	// not specified by an operator.
+	defaultTimeout = 30 * time.Second
+)
+
+var (
+	PluginConfig = &plugins.InternalPluginConfig{
+		Factory: func(l hclog.Logger) interface{} { return NewWebhookPlugin(l) },
+	}
+
+	pluginInfo = &base.PluginInfo{
+		Name:       pluginName,
+		PluginType: plugins.PluginTypeTarget,
+	}
+)
+
+// Assert that TargetPlugin meets the target.ContextTarget and
+// base.HealthChecker interfaces.
+var (
+	_ target.ContextTarget = (*TargetPlugin)(nil)
+	_ base.HealthChecker   = (*TargetPlugin)(nil)
+)
+
+// TargetPlugin is the webhook implementation of the target.Target interface.
+// Scaling actions are sent to an operator supplied URL, with the status of
+// the target read from another.
+type TargetPlugin struct {
+	config    map[string]string
+	logger    hclog.Logger
+	client    *http.Client
+	secret    []byte
+	healthURL string
+}
+
+// NewWebhookPlugin returns the webhook implementation of the target.Target
+// interface.
+func NewWebhookPlugin(log hclog.Logger) *TargetPlugin {
+	return &TargetPlugin{
+		logger: log,
+	}
+}
+
+// SetConfig satisfies the SetConfig function on the base.Plugin interface.
+func (t *TargetPlugin) SetConfig(config map[string]string) error {
+
+	t.config = config
+
+	timeout := defaultTimeout
+
+	if v, ok := config[configKeyTimeout]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as time duration", v)
+		}
+		timeout = d
+	}
+
+	t.client = &http.Client{Timeout: timeout}
+	t.secret = []byte(config[configKeySecret])
+	t.healthURL = config[configKeyHealthURL]
+
+	return nil
+}
+
+// PluginInfo satisfies the PluginInfo function on the base.Plugin interface.
+func (t *TargetPlugin) PluginInfo() (*base.PluginInfo, error) {
+	return pluginInfo, nil
+}
+
+// HealthCheck satisfies the HealthCheck function on the base.HealthChecker
+// interface. If a health URL is configured, it must respond successfully to a
+// GET request.
+func (t *TargetPlugin) HealthCheck(ctx context.Context) error {
+	if t.healthURL == "" {
+		return nil
+	}
+	if err := t.do(ctx, http.MethodGet, t.healthURL, nil, nil); err != nil {
+		return fmt.Errorf("failed to reach health webhook: %v", err)
+	}
+	return nil
+}
+
+// Scale satisfies the Scale function on the target.Target interface.
+func (t *TargetPlugin) Scale(action strategy.Action, config map[string]string) error {
+	return t.ScaleContext(context.Background(), action, config)
+}
+
+// ScaleContext satisfies the ScaleContext function on the target.ContextTarget
+// interface. The action is sent to the scale webhook, which should only
+// respond once the scaling has been accepted.
+func (t *TargetPlugin) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
+
+	// The webhook is not expected to support dry-run like Nomad, so just
+	// exit.
+	if action.Count == strategy.MetaValueDryRunCount {
+		return nil
+	}
+
+	scaleURL, ok := config[configKeyScaleURL]
+	if !ok {
+		return fmt.Errorf("required config param %s not found", configKeyScaleURL)
+	}
+
+	body, err := buildScaleBody(action, config)
+	if err != nil {
+		return fmt.Errorf("failed to build scale webhook body: %v", err)
+	}
+
+	if err := t.do(ctx, http.MethodPost, scaleURL, body, nil); err != nil {
+		return fmt.Errorf("failed to call scale webhook: %v", err)
+	}
+
+	t.logger.Info("successfully called scale webhook", "count", action.Count,
+		"direction", action.Direction)
+	return nil
+}
+
+// Status satisfies the Status function on the target.Target interface.
+func (t *TargetPlugin) Status(config map[string]string) (*target.Status, error) {
+	return t.StatusContext(context.Background(), config)
+}
+
+// StatusContext satisfies the StatusContext function on the
+// target.ContextTarget interface.
+func (t *TargetPlugin) StatusContext(ctx context.Context, config map[string]string) (*target.Status, error) {
+
+	statusURL, ok := config[configKeyStatusURL]
+	if !ok {
+		return nil, fmt.Errorf("required config param %s not found", configKeyStatusURL)
+	}
+
+	var resp target.StatusPayload
+
+	if err := t.do(ctx, http.MethodGet, statusURL, nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to call status webhook: %v", err)
+	}
+	return resp.Status(), nil
+}
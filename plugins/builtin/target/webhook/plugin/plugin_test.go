@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/stretchr/testify/assert"
+)
+
+// testServer is a stand-in for a sizing API, rejecting requests which are not
+// signed with the secret.
+type testServer struct {
+	lock   sync.Mutex
+	secret []byte
+	count  int64
+	bodies []string
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if r.Header.Get(signatureHeader) != sign(s.secret, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/health":
+		return
+	case r.URL.Path == "/scale" && r.Method == http.MethodPost:
+		var req target.ActionPayload
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.count = req.Count
+		s.bodies = append(s.bodies, string(body))
+		return
+	case r.URL.Path == "/status" && r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(target.StatusPayload{
+			Count:     s.count,
+			Ready:     true,
+			LastEvent: 1586765100000000000,
+		})
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func TestTargetPlugin_ScaleContext(t *testing.T) {
+
+	srv := &testServer{secret: []byte("secret"), count: 1}
+
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx := context.Background()
+
+	tp := NewWebhookPlugin(hclog.NewNullLogger())
+	assert.Nil(t, tp.SetConfig(map[string]string{
+		"webhook_secret":     "secret",
+		"webhook_health_url": ts.URL + "/health",
+	}))
+	assert.Nil(t, tp.HealthCheck(ctx))
+
+	config := map[string]string{
+		"webhook_scale_url":  ts.URL + "/scale",
+		"webhook_status_url": ts.URL + "/status",
+	}
+
+	status, err := tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, &target.Status{
+		Ready: true,
+		Count: 1,
+		Meta:  map[string]string{target.MetaKeyLastEvent: "1586765100000000000"},
+	}, status)
+
+	// Scaling sends the action to the scale webhook.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: 4, Direction: strategy.ScaleDirectionUp}, config))
+	assert.Equal(t, int64(4), srv.count)
+
+	status, err = tp.StatusContext(ctx, config)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), status.Count)
+
+	// Dry-run actions are ignored.
+	assert.Nil(t, tp.ScaleContext(ctx, strategy.Action{Count: strategy.MetaValueDryRunCount}, config))
+	assert.Len(t, srv.bodies, 1)
+
+	// Errors returned by the webhook are surfaced.
+	config["webhook_scale_url"] = ts.URL + "/missing"
+	assert.NotNil(t, tp.ScaleContext(ctx, strategy.Action{Count: 2}, config))
+
+	// Requests signed with the wrong secret are rejected.
+	assert.Nil(t, tp.SetConfig(map[string]string{"webhook_secret": "wrong"}))
+	_, err = tp.StatusContext(ctx, config)
+	assert.NotNil(t, err)
+
+	// The URLs must be configured.
+	assert.NotNil(t, tp.ScaleContext(ctx, strategy.Action{Count: 2}, map[string]string{}))
+	_, err = tp.StatusContext(ctx, map[string]string{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+)
+
+// signatureHeader is the header containing the HMAC-SHA256 signature of the
+// request body, in the form "sha256=<hex digest>". It is only set when a
+// secret is configured.
+const signatureHeader = "X-Nomad-Autoscaler-Signature"
+
+// templateData is the data available to an operator supplied body template.
+// The fields of the action payload can be accessed directly, for example
+// {{ .Count }}, with the target config available as {{ .Config }}.
+type templateData struct {
+	target.ActionPayload
+	Config map[string]string
+}
+
+// buildScaleBody returns the body of the scale webhook request for the
+// action. If the target config contains a body template it is rendered,
+// otherwise the action payload is encoded as JSON.
+func buildScaleBody(action strategy.Action, config map[string]string) ([]byte, error) {
+
+	req := target.NewActionPayload(action)
+
+	tmplString, ok := config[configKeyBodyTemplate]
+	if !ok {
+		return json.Marshal(req)
+	}
+
+	tmpl, err := template.New(configKeyBodyTemplate).
+		Funcs(template.FuncMap{"json": toJSON}).
+		Option("missingkey=error").
+		Parse(tmplString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", configKeyBodyTemplate, err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, templateData{ActionPayload: *req, Config: config}); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %v", configKeyBodyTemplate, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// toJSON is the json template function, allowing values to be safely
+// embedded within a JSON body template.
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// sign returns the value of the signature header for the body.
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// do performs the request, sending the body and decoding the response into
+// out if they are not nil. If a secret is configured, the request is signed.
+func (t *TargetPlugin) do(ctx context.Context, method, url string, body []byte, out interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(t.secret) > 0 {
+		req.Header.Set(signatureHeader, sign(t.secret, body))
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
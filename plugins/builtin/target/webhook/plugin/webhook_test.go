@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_buildScaleBody(t *testing.T) {
+	testCases := []struct {
+		inputAction    strategy.Action
+		inputConfig    map[string]string
+		expectedOutput string
+		expectedError  bool
+		name           string
+	}{
+		{
+			inputAction: strategy.Action{
+				Count:     3,
+				Direction: strategy.ScaleDirectionUp,
+				Reason:    "capacity",
+				Meta:      map[string]interface{}{"foo": "bar"},
+			},
+			inputConfig:    map[string]string{},
+			expectedOutput: `{"count":3,"direction":"up","reason":"capacity","error":false,"meta":{"foo":"bar"}}`,
+			name:           "default body",
+		},
+		{
+			inputAction: strategy.Action{
+				Count:     1,
+				Direction: strategy.ScaleDirectionDown,
+				Reason:    `quoted "reason"`,
+			},
+			inputConfig: map[string]string{
+				"webhook_body_template": `{"size":{{ .Count }},"pool":{{ json .Config.pool }},"why":{{ json .Reason }}}`,
+				"pool":                  "battle",
+			},
+			expectedOutput: `{"size":1,"pool":"battle","why":"quoted \"reason\""}`,
+			name:           "templated body",
+		},
+		{
+			inputAction: strategy.Action{Count: 1},
+			inputConfig: map[string]string{
+				"webhook_body_template": `{{ .Count `,
+			},
+			expectedError: true,
+			name:          "invalid template",
+		},
+		{
+			inputAction: strategy.Action{Count: 1},
+			inputConfig: map[string]string{
+				"webhook_body_template": `{{ .Config.missing }}`,
+			},
+			expectedError: true,
+			name:          "missing config key",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualOutput, err := buildScaleBody(tc.inputAction, tc.inputConfig)
+			if tc.expectedError {
+				assert.NotNil(t, err, tc.name)
+				return
+			}
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedOutput, string(actualOutput), tc.name)
+		})
+	}
+}
+
+func Test_sign(t *testing.T) {
+	assert.Equal(t,
+		"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		sign([]byte("key"), []byte("The quick brown fox jumps over the lazy dog")))
+}
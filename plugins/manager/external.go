@@ -5,25 +5,17 @@ import (
 	"strings"
 
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
-	"github.com/hashicorp/nomad-autoscaler/plugins"
 )
 
-// loadExternalPlugin takes the passed plugin and places it into the
-// PluginManager store as a record that it should be launched and dispensed.
-func (pm *PluginManager) loadExternalPlugin(cfg *config.Plugin, pluginType string) {
-
-	info := &pluginInfo{
+// loadExternalPlugin takes the passed plugin and returns the information
+// required to launch and dispense it.
+func (pm *PluginManager) loadExternalPlugin(cfg *config.Plugin) *pluginInfo {
+	return &pluginInfo{
 		args:    cfg.Args,
 		config:  cfg.Config,
 		driver:  cfg.Driver,
 		exePath: filepath.Join(pm.pluginDir, cleanPluginExecutable(cfg.Driver)),
 	}
-
-	// Add the plugin.
-	pm.pluginsLock.Lock()
-	pm.plugins[plugins.PluginID{Name: cfg.Name, PluginType: pluginType}] = info
-	pm.pluginsLock.Unlock()
-
 }
 
 // cleanPluginExecutable is a helper function to remove commonly-found binary
@@ -0,0 +1,144 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+)
+
+const (
+	// pluginHealthCheckInterval is the interval at which the health of all
+	// dispensed plugins is checked.
+	pluginHealthCheckInterval = 30 * time.Second
+
+	// pluginHealthCheckTimeout is the maximum time an individual plugin
+	// health check is allowed to take before the plugin is considered
+	// unhealthy.
+	pluginHealthCheckTimeout = 10 * time.Second
+)
+
+// PluginHealth is the result of the most recent health check of a plugin.
+type PluginHealth struct {
+	Name       string    `json:"name"`
+	PluginType string    `json:"plugin_type"`
+	Healthy    bool      `json:"healthy"`
+	Error      string    `json:"error,omitempty"`
+	LastCheck  time.Time `json:"last_check"`
+}
+
+// Health returns the result of the most recent health check of each plugin,
+// sorted by plugin type and name.
+func (pm *PluginManager) Health() []PluginHealth {
+	pm.healthLock.RLock()
+	defer pm.healthLock.RUnlock()
+
+	out := make([]PluginHealth, 0, len(pm.health))
+	for _, h := range pm.health {
+		out = append(out, *h)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PluginType != out[j].PluginType {
+			return out[i].PluginType < out[j].PluginType
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// CheckHealth returns an error if the most recent health check of the plugin
+// failed. Plugins which have not yet been checked are considered healthy.
+func (pm *PluginManager) CheckHealth(name, pluginType string) error {
+	pm.healthLock.RLock()
+	defer pm.healthLock.RUnlock()
+
+	h, ok := pm.health[plugins.PluginID{Name: name, PluginType: pluginType}]
+	if !ok || h.Healthy {
+		return nil
+	}
+	return fmt.Errorf("plugin %q of type %q is unhealthy: %s", name, pluginType, h.Error)
+}
+
+// runHealthChecks periodically checks the health of all dispensed plugins
+// until the plugins are killed.
+func (pm *PluginManager) runHealthChecks() {
+	ticker := time.NewTicker(pluginHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		pm.checkPluginsHealth()
+
+		select {
+		case <-pm.shutdownCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkPluginsHealth checks the health of all dispensed plugins concurrently
+// and stores the results. Configured plugins which are not dispensed, such as
+// those which failed to launch, are reported as unhealthy.
+func (pm *PluginManager) checkPluginsHealth() {
+
+	pm.pluginsLock.RLock()
+	ids := make([]plugins.PluginID, 0, len(pm.plugins))
+	for pID := range pm.plugins {
+		ids = append(ids, pID)
+	}
+	pm.pluginsLock.RUnlock()
+
+	results := make(map[plugins.PluginID]*PluginHealth, len(ids))
+	var resultsLock sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, pID := range ids {
+		inst, ok := pm.acquirePluginInstance(pID)
+
+		wg.Add(1)
+		go func(pID plugins.PluginID, inst *managedPluginInstance, ok bool) {
+			defer wg.Done()
+			if ok {
+				defer pm.Release(inst)
+			}
+
+			h := &PluginHealth{Name: pID.Name, PluginType: pID.PluginType, Healthy: true}
+
+			var err error
+			switch {
+			case !ok:
+				err = fmt.Errorf("plugin is not running")
+			case inst.Exited():
+				err = fmt.Errorf("plugin process has exited")
+			default:
+				ctx, cancel := context.WithTimeout(context.Background(), pluginHealthCheckTimeout)
+				err = base.HealthCheck(ctx, inst.Plugin())
+				cancel()
+			}
+
+			if err != nil {
+				h.Healthy = false
+				h.Error = err.Error()
+				pm.logger.Warn("plugin health check failed", "plugin_name", pID.Name,
+					"plugin_type", pID.PluginType, "error", err)
+			}
+			h.LastCheck = time.Now()
+
+			resultsLock.Lock()
+			results[pID] = h
+			resultsLock.Unlock()
+		}(pID, inst, ok)
+	}
+	wg.Wait()
+
+	// Replace the stored results, which also removes the results of plugins
+	// which are no longer configured.
+	pm.healthLock.Lock()
+	pm.health = results
+	pm.healthLock.Unlock()
+}
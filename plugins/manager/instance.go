@@ -1,15 +1,26 @@
 package manager
 
-import plugin "github.com/hashicorp/go-plugin"
+import (
+	"io"
+	"sync"
+	"time"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/apm"
+)
 
 // PluginInstance is a wrapper of a plugin and provides a common interface
 // whether the plugin is internal or running externally via a binary.
 type PluginInstance interface {
 
-	// Kill kills the plugin if it is external. It is safe to call on internal
-	// plugins.
+	// Kill kills the plugin if it is external. Internal plugins are closed if
+	// they implement io.Closer.
 	Kill()
 
+	// Exited returns whether the plugin process has exited, either due to a
+	// call to Kill or a crash. Internal plugins never exit.
+	Exited() bool
+
 	// Plugin returns the wrapped plugin instance.
 	Plugin() interface{}
 }
@@ -19,9 +30,17 @@ type internalPluginInstance struct {
 	instance interface{}
 }
 
-func (p *internalPluginInstance) Kill()               {}
+func (p *internalPluginInstance) Exited() bool        { return false }
 func (p *internalPluginInstance) Plugin() interface{} { return p.instance }
 
+// Kill closes the plugin if it implements io.Closer, so that any resources it
+// holds are released.
+func (p *internalPluginInstance) Kill() {
+	if c, ok := p.instance.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
 // externalPluginInstance wraps an external plugin.
 type externalPluginInstance struct {
 	client   *plugin.Client
@@ -29,4 +48,43 @@ type externalPluginInstance struct {
 }
 
 func (p *externalPluginInstance) Kill()               { p.client.Kill() }
+func (p *externalPluginInstance) Exited() bool        { return p.client.Exited() }
 func (p *externalPluginInstance) Plugin() interface{} { return p.instance }
+
+// cachedAPMPluginInstance wraps an APM plugin instance, routing all queries
+// through a query cache.
+type cachedAPMPluginInstance struct {
+	PluginInstance
+	cache *apm.QueryCache
+}
+
+// newCachedAPMPluginInstance wraps the passed APM plugin instance with a query
+// cache using the specified TTL.
+func newCachedAPMPluginInstance(inst PluginInstance, ttl time.Duration) *cachedAPMPluginInstance {
+	return &cachedAPMPluginInstance{
+		PluginInstance: inst,
+		cache:          apm.NewQueryCache(inst.Plugin().(apm.APM), ttl),
+	}
+}
+
+func (p *cachedAPMPluginInstance) Plugin() interface{} { return p.cache }
+
+// Kill cancels any in-flight queries of the cache before killing the plugin.
+func (p *cachedAPMPluginInstance) Kill() {
+	p.cache.Close()
+	p.PluginInstance.Kill()
+}
+
+// managedPluginInstance wraps a plugin instance stored by the plugin manager,
+// tracking the calls in-flight on it.
+type managedPluginInstance struct {
+	PluginInstance
+	inFlight sync.WaitGroup
+}
+
+// killWhenIdle blocks until all calls in-flight on the instance have
+// completed, then kills it. The instance must no longer be dispensed.
+func (p *managedPluginInstance) killWhenIdle() {
+	p.inFlight.Wait()
+	p.Kill()
+}
@@ -10,13 +10,18 @@ import (
 	prometheus "github.com/hashicorp/nomad-autoscaler/plugins/builtin/apm/prometheus/plugin"
 	targetValue "github.com/hashicorp/nomad-autoscaler/plugins/builtin/strategy/target-value/plugin"
 	awsASG "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/aws-asg/plugin"
+	azureVMSS "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/azure-vmss/plugin"
+	execTarget "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/exec/plugin"
+	gceMIG "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/gce-mig/plugin"
 	nomadTarget "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/nomad/plugin"
 	stateful "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/stateful/plugin"
+	webhook "github.com/hashicorp/nomad-autoscaler/plugins/builtin/target/webhook/plugin"
 )
 
 // loadInternalPlugin takes the plugin configuration and attempts to load it
-// from internally to the plugin store.
-func (pm *PluginManager) loadInternalPlugin(cfg *config.Plugin, pluginType string) {
+// from internally, returning the information required to launch it. Nil is
+// returned if the plugin is not supported.
+func (pm *PluginManager) loadInternalPlugin(cfg *config.Plugin) *pluginInfo {
 
 	info := &pluginInfo{config: cfg.Config}
 
@@ -39,15 +44,24 @@ func (pm *PluginManager) loadInternalPlugin(cfg *config.Plugin, pluginType strin
 	case plugins.InternalTargetStateful:
 		info.factory = stateful.PluginConfig.Factory
 		info.driver = "stateful"
+	case plugins.InternalTargetGCEMIG:
+		info.factory = gceMIG.PluginConfig.Factory
+		info.driver = "gce-mig"
+	case plugins.InternalTargetAzureVMSS:
+		info.factory = azureVMSS.PluginConfig.Factory
+		info.driver = "azure-vmss"
+	case plugins.InternalTargetWebhook:
+		info.factory = webhook.PluginConfig.Factory
+		info.driver = "webhook"
+	case plugins.InternalTargetExec:
+		info.factory = execTarget.PluginConfig.Factory
+		info.driver = "exec"
 	default:
 		pm.logger.Error("unsupported internal plugin", "plugin", cfg.Driver)
-		return
+		return nil
 	}
 
-	pm.pluginsLock.Lock()
-	pm.plugins[plugins.PluginID{Name: cfg.Name, PluginType: pluginType}] = info
-	pm.pluginsLock.Unlock()
-
+	return info
 }
 
 // useInternal decides whether we should use the internal implementation of the
@@ -80,7 +94,11 @@ func (pm *PluginManager) useInternal(plugin string) bool {
 		plugins.InternalAPMPrometheus,
 		plugins.InternalStrategyTargetValue,
 		plugins.InternalTargetAWSASG,
-		plugins.InternalTargetStateful:
+		plugins.InternalTargetStateful,
+		plugins.InternalTargetGCEMIG,
+		plugins.InternalTargetAzureVMSS,
+		plugins.InternalTargetWebhook,
+		plugins.InternalTargetExec:
 		return true
 	default:
 		return false
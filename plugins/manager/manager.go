@@ -3,7 +3,9 @@ package manager
 import (
 	"fmt"
 	"os/exec"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
@@ -27,14 +29,28 @@ type PluginManager struct {
 	pluginDir string
 
 	// pluginInstances are our dispensed plugins held as PluginInstance
-	// wrappers.
+	// wrappers, which track the calls in-flight on each instance.
 	pluginInstancesLock sync.RWMutex
-	pluginInstances     map[plugins.PluginID]PluginInstance
+	pluginInstances     map[plugins.PluginID]*managedPluginInstance
 
 	// plugin contains all the information needed to launch and dispense the
 	// Nomad Autoscaler plugins.
 	pluginsLock sync.RWMutex
 	plugins     map[plugins.PluginID]*pluginInfo
+
+	// restarts tracks the relaunch attempts of plugins which have exited or
+	// failed to launch, so that relaunches can be performed with backoff. It
+	// is protected by the pluginsLock.
+	restarts map[plugins.PluginID]*pluginRestart
+
+	// health holds the results of the most recent plugin health checks.
+	healthLock sync.RWMutex
+	health     map[plugins.PluginID]*PluginHealth
+
+	// shutdownCh is closed when the plugins are killed, stopping the plugin
+	// supervisor.
+	shutdownCh   chan struct{}
+	shutdownOnce sync.Once
 }
 
 // pluginInfo contains all the required information to launch an Autoscaler
@@ -53,14 +69,46 @@ type pluginInfo struct {
 	factory plugins.PluginFactory
 }
 
+// requiresRelaunch returns whether a plugin launched using the info must be
+// relaunched in order to use the new info. Changes to the plugin config also
+// require a relaunch, as the config cannot safely be set on a plugin which may
+// be in use.
+func (p *pluginInfo) requiresRelaunch(n *pluginInfo) bool {
+	return p.driver != n.driver ||
+		p.exePath != n.exePath ||
+		!reflect.DeepEqual(p.args, n.args) ||
+		!reflect.DeepEqual(p.config, n.config) ||
+		(p.factory == nil) != (n.factory == nil)
+}
+
+// pluginRestart tracks the relaunch attempts of a single plugin.
+type pluginRestart struct {
+	attempts int
+	next     time.Time
+}
+
+const (
+	// pluginSupervisorInterval is the interval at which the plugin supervisor
+	// checks for exited plugins.
+	pluginSupervisorInterval = 1 * time.Second
+
+	// pluginRestartBackoffBase and pluginRestartBackoffMax control the
+	// exponential backoff applied between attempts to relaunch a plugin.
+	pluginRestartBackoffBase = 1 * time.Second
+	pluginRestartBackoffMax  = 2 * time.Minute
+)
+
 // NewPluginManager sets up a new PluginManager for use.
 func NewPluginManager(log hclog.Logger, dir string, cfg map[string][]*config.Plugin) *PluginManager {
 	return &PluginManager{
 		cfg:             generateConfigMap(cfg),
 		logger:          log.Named("plugin_manager"),
 		pluginDir:       dir,
-		pluginInstances: make(map[plugins.PluginID]PluginInstance),
+		pluginInstances: make(map[plugins.PluginID]*managedPluginInstance),
 		plugins:         make(map[plugins.PluginID]*pluginInfo),
+		restarts:        make(map[plugins.PluginID]*pluginRestart),
+		health:          make(map[plugins.PluginID]*PluginHealth),
+		shutdownCh:      make(chan struct{}),
 	}
 }
 
@@ -68,24 +116,128 @@ func NewPluginManager(log hclog.Logger, dir string, cfg map[string][]*config.Plu
 // use by the Autoscaler agent.
 func (pm *PluginManager) Load() error {
 
-	for t, cfgs := range pm.cfg {
-		for _, cfg := range cfgs {
+	pm.pluginsLock.Lock()
+	pm.plugins = pm.loadPlugins(pm.cfg)
+	pm.pluginsLock.Unlock()
+
+	if err := pm.dispensePlugins(); err != nil {
+		return err
+	}
+
+	// Supervise the plugins so that any external plugin which exits is
+	// relaunched, and periodically check the health of all plugins.
+	go pm.supervise()
+	go pm.runHealthChecks()
+	return nil
+}
+
+// loadPlugins builds the information required to launch each of the plugins
+// within the passed configuration.
+func (pm *PluginManager) loadPlugins(cfg map[string]map[string]*config.Plugin) map[plugins.PluginID]*pluginInfo {
+
+	infos := make(map[plugins.PluginID]*pluginInfo)
+
+	for t, cfgs := range cfg {
+		for _, c := range cfgs {
 
 			// Figure out if the plugin is internal or external, then perform
-			// the loading of the config into the manager store.
-			if pm.useInternal(cfg.Driver) {
-				pm.loadInternalPlugin(cfg, t)
+			// the loading of the config.
+			var info *pluginInfo
+			if pm.useInternal(c.Driver) {
+				info = pm.loadInternalPlugin(c)
 			} else {
-				pm.loadExternalPlugin(cfg, t)
+				info = pm.loadExternalPlugin(c)
+			}
+
+			if info != nil {
+				infos[plugins.PluginID{Name: c.Name, PluginType: t}] = info
+			}
+		}
+	}
+
+	return infos
+}
+
+// Reload updates the running plugins to match the passed configuration.
+// Plugins which are no longer configured are removed, newly configured
+// plugins are launched and plugins whose executable, arguments or config have
+// changed are relaunched. Removed and replaced instances are killed once the
+// calls in-flight on them have completed.
+//
+// Errors are returned for plugins which could not be launched or configured,
+// but do not stop the reload of the other plugins. If a plugin fails to
+// relaunch or rejects its updated config, the existing instance continues to
+// be used with the previous config. Otherwise the supervisor continues to
+// attempt to launch the plugin.
+func (pm *PluginManager) Reload(cfg map[string][]*config.Plugin) error {
+	newCfg := generateConfigMap(cfg)
+
+	pm.pluginsLock.Lock()
+	defer pm.pluginsLock.Unlock()
+
+	err := pm.reloadPlugins(pm.loadPlugins(newCfg))
+	pm.cfg = newCfg
+	return err
+}
+
+// reloadPlugins updates the running plugins to match the passed plugin
+// information. The caller must hold the pluginsLock.
+func (pm *PluginManager) reloadPlugins(newPlugins map[plugins.PluginID]*pluginInfo) error {
+
+	var mErr multierror.Error
+
+	// Remove any plugins which are no longer configured.
+	for pID := range pm.plugins {
+		if _, ok := newPlugins[pID]; !ok {
+			pm.logger.Info("removing plugin", "plugin_name", pID.Name, "plugin_type", pID.PluginType)
+			pm.retirePluginInstance(pID)
+			delete(pm.plugins, pID)
+			delete(pm.restarts, pID)
+		}
+	}
+
+	for pID, newInfo := range newPlugins {
+		curInfo, ok := pm.plugins[pID]
+
+		pm.pluginInstancesLock.RLock()
+		_, running := pm.pluginInstances[pID]
+		pm.pluginInstancesLock.RUnlock()
+
+		if ok && running && !curInfo.requiresRelaunch(newInfo) {
+			continue
+		}
+
+		pm.logger.Info("launching plugin", "plugin_name", pID.Name, "plugin_type", pID.PluginType)
+
+		// Launch the new plugin before replacing any existing instance, so
+		// the existing instance continues to be used if the new one fails to
+		// launch or rejects its config. If there is no existing instance,
+		// store the new info so the supervisor continues to attempt the
+		// launch.
+		newInst, err := pm.launchPlugin(pID, newInfo)
+		if err != nil {
+			if !running {
+				pm.plugins[pID] = newInfo
 			}
+			_ = multierror.Append(&mErr, err)
+			continue
 		}
+		pm.plugins[pID] = newInfo
+		pm.retirePluginInstance(pID)
+		pm.storePluginInstance(pID, newInst)
 	}
 
-	return pm.dispensePlugins()
+	return mErr.ErrorOrNil()
 }
 
-// KillPlugins calls Kill on all plugins currently dispensed.
+// KillPlugins calls Kill on all plugins currently dispensed and stops the
+// plugin supervisor.
 func (pm *PluginManager) KillPlugins() {
+	pm.shutdownOnce.Do(func() { close(pm.shutdownCh) })
+
+	pm.pluginInstancesLock.RLock()
+	defer pm.pluginInstancesLock.RUnlock()
+
 	for id, v := range pm.pluginInstances {
 		pm.logger.Info("shutting down plugin", "plugin_name", id.Name)
 		v.Kill()
@@ -93,24 +245,38 @@ func (pm *PluginManager) KillPlugins() {
 }
 
 // Dispense returns a PluginInstance for use by safely obtaining the
-// PluginInstance from storage if we have it.
+// PluginInstance from storage if we have it. The caller must call Release once
+// it has finished using the instance, so that an instance replaced by a
+// reload is not killed while in use.
 func (pm *PluginManager) Dispense(name, pluginType string) (PluginInstance, error) {
 	pm.pluginInstancesLock.RLock()
 	defer pm.pluginInstancesLock.RUnlock()
 
 	// Attempt to pull our plugin instance from the store and pass this to the
 	// caller.
-	//
-	// TODO(jrasell) if we do not find the instance, we should probably try and
-	//  dispense the plugin. We should also check the plugin instance has not
-	//  exited.
 	inst, ok := pm.pluginInstances[plugins.PluginID{Name: name, PluginType: pluginType}]
 	if !ok {
 		return nil, fmt.Errorf("failed to dispense plugin: %q of type %q is not stored", name, pluginType)
 	}
+
+	// If the plugin process has exited, calls to it will fail. Return an
+	// error until the supervisor has relaunched it.
+	if inst.Exited() {
+		return nil, fmt.Errorf("failed to dispense plugin: %q of type %q has exited", name, pluginType)
+	}
+
+	inst.inFlight.Add(1)
 	return inst, nil
 }
 
+// Release marks the caller as having finished using a PluginInstance returned
+// by Dispense.
+func (pm *PluginManager) Release(inst PluginInstance) {
+	if m, ok := inst.(*managedPluginInstance); ok {
+		m.inFlight.Done()
+	}
+}
+
 // dispensePlugins launches all configured plugins. It is responsible for
 // executing external binaries as well as setting the config on all plugins so
 // they are in a ready state. Any errors from this process will result in the
@@ -121,7 +287,7 @@ func (pm *PluginManager) dispensePlugins() error {
 	// the plugin loading based on the current configuration. Therefore for
 	// future protection blat out our instances map.
 	pm.pluginInstancesLock.Lock()
-	pm.pluginInstances = make(map[plugins.PluginID]PluginInstance)
+	pm.pluginInstances = make(map[plugins.PluginID]*managedPluginInstance)
 	pm.pluginInstancesLock.Unlock()
 
 	var mErr multierror.Error
@@ -131,40 +297,16 @@ func (pm *PluginManager) dispensePlugins() error {
 
 	for pID, pInfo := range pm.plugins {
 
-		var (
-			inst PluginInstance
-			info *base.PluginInfo
-			err  error
-		)
-		if pInfo.factory != nil {
-			inst, info, err = pm.launchInternalPlugin(pID, pInfo)
-		} else {
-			inst, info, err = pm.launchExternalPlugin(pID, pInfo)
-		}
-
 		// If we got an error dispensing the plugin, add this to the muilterror
 		// and continue the loop.
+		inst, err := pm.launchPlugin(pID, pInfo)
 		if err != nil {
-			_ = multierror.Append(&mErr, fmt.Errorf("failed to dispense plugin %s: %v", pID.Name, err))
-			continue
-		}
-
-		// Update our tracking to detail the plugin base information returned
-		// from the plugin itself.
-		pm.plugins[pID].baseInfo = info
-
-		// Perform the SetConfig on the plugin to ensure its state is as the
-		// operator desires.
-		if err := inst.Plugin().(base.Plugin).SetConfig(pInfo.config); err != nil {
-			inst.Kill()
-			_ = multierror.Append(&mErr, fmt.Errorf("failed to set config on plugin %s: %v", pID.Name, err))
+			_ = multierror.Append(&mErr, err)
 			continue
 		}
 
 		// Store our plugin instance.
-		pm.pluginInstancesLock.Lock()
-		pm.pluginInstances[pID] = inst
-		pm.pluginInstancesLock.Unlock()
+		pm.storePluginInstance(pID, inst)
 
 		// When logging to INFO, the plugins do not log anything during startup
 		// therefore log something useful to show the plugin is ready.
@@ -174,6 +316,183 @@ func (pm *PluginManager) dispensePlugins() error {
 	return mErr.ErrorOrNil()
 }
 
+// launchPlugin launches and configures the plugin so that it is ready for
+// use. The caller must hold the pluginsLock.
+func (pm *PluginManager) launchPlugin(pID plugins.PluginID, pInfo *pluginInfo) (PluginInstance, error) {
+
+	var (
+		inst PluginInstance
+		info *base.PluginInfo
+		err  error
+	)
+	if pInfo.factory != nil {
+		inst, info, err = pm.launchInternalPlugin(pID, pInfo)
+	} else {
+		inst, info, err = pm.launchExternalPlugin(pID, pInfo)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispense plugin %s: %v", pID.Name, err)
+	}
+
+	// Update our tracking to detail the plugin base information returned
+	// from the plugin itself.
+	pInfo.baseInfo = info
+
+	return pm.configurePlugin(pID, pInfo, inst)
+}
+
+// configurePlugin sets the config on the plugin and performs any wrapping
+// required by the plugin type. The plugin is killed if this fails.
+func (pm *PluginManager) configurePlugin(pID plugins.PluginID, pInfo *pluginInfo, inst PluginInstance) (PluginInstance, error) {
+
+	var ttl time.Duration
+	if pID.PluginType == plugins.PluginTypeAPM {
+		var err error
+		if ttl, err = parseQueryCacheTTL(pInfo.config); err != nil {
+			inst.Kill()
+			return nil, fmt.Errorf("failed to setup query cache on plugin %s: %v", pID.Name, err)
+		}
+	}
+
+	// Perform the SetConfig on the plugin to ensure its state is as the
+	// operator desires.
+	if err := inst.Plugin().(base.Plugin).SetConfig(pInfo.config); err != nil {
+		inst.Kill()
+		return nil, fmt.Errorf("failed to set config on plugin %s: %v", pID.Name, err)
+	}
+
+	// APM plugins are wrapped with a query cache so that identical queries
+	// issued by different policies and checks share a single result rather
+	// than each calling the APM.
+	if pID.PluginType == plugins.PluginTypeAPM {
+		inst = newCachedAPMPluginInstance(inst, ttl)
+	}
+
+	return inst, nil
+}
+
+// storePluginInstance stores the plugin instance, making it available to be
+// dispensed.
+func (pm *PluginManager) storePluginInstance(pID plugins.PluginID, inst PluginInstance) {
+	pm.pluginInstancesLock.Lock()
+	pm.pluginInstances[pID] = &managedPluginInstance{PluginInstance: inst}
+	pm.pluginInstancesLock.Unlock()
+}
+
+// acquirePluginInstance returns the stored plugin instance, if one exists,
+// marking a call as in-flight on it. The caller must call Release once it has
+// finished using the instance.
+func (pm *PluginManager) acquirePluginInstance(pID plugins.PluginID) (*managedPluginInstance, bool) {
+	pm.pluginInstancesLock.RLock()
+	defer pm.pluginInstancesLock.RUnlock()
+
+	inst, ok := pm.pluginInstances[pID]
+	if ok {
+		inst.inFlight.Add(1)
+	}
+	return inst, ok
+}
+
+// removePluginInstance kills and removes the plugin instance if one exists.
+// It should only be used for instances which have exited, as any calls
+// in-flight on the instance fail.
+func (pm *PluginManager) removePluginInstance(pID plugins.PluginID) {
+	pm.pluginInstancesLock.Lock()
+	defer pm.pluginInstancesLock.Unlock()
+
+	if inst, ok := pm.pluginInstances[pID]; ok {
+		inst.Kill()
+		delete(pm.pluginInstances, pID)
+	}
+}
+
+// retirePluginInstance removes the plugin instance if one exists, so it is no
+// longer dispensed, and kills it once the calls in-flight on it have
+// completed.
+func (pm *PluginManager) retirePluginInstance(pID plugins.PluginID) {
+	pm.pluginInstancesLock.Lock()
+	defer pm.pluginInstancesLock.Unlock()
+
+	if inst, ok := pm.pluginInstances[pID]; ok {
+		delete(pm.pluginInstances, pID)
+		go inst.killWhenIdle()
+	}
+}
+
+// supervise periodically relaunches plugins which have exited or failed to
+// launch until the plugins are killed.
+func (pm *PluginManager) supervise() {
+	ticker := time.NewTicker(pluginSupervisorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pm.shutdownCh:
+			return
+		case <-ticker.C:
+			pm.relaunchPlugins(time.Now())
+		}
+	}
+}
+
+// relaunchPlugins attempts to relaunch all configured plugins which do not
+// have a running instance. Each plugin is relaunched using an exponential
+// backoff, so that a plugin which continually fails does not result in a busy
+// loop.
+func (pm *PluginManager) relaunchPlugins(now time.Time) {
+	pm.pluginsLock.Lock()
+	defer pm.pluginsLock.Unlock()
+
+	for pID, pInfo := range pm.plugins {
+
+		pm.pluginInstancesLock.RLock()
+		inst, ok := pm.pluginInstances[pID]
+		pm.pluginInstancesLock.RUnlock()
+
+		if ok && !inst.Exited() {
+			delete(pm.restarts, pID)
+			continue
+		}
+
+		restart, ok := pm.restarts[pID]
+		if !ok {
+			restart = &pluginRestart{}
+			pm.restarts[pID] = restart
+			pm.logger.Warn("plugin has exited", "plugin_name", pID.Name, "plugin_type", pID.PluginType)
+		}
+		if now.Before(restart.next) {
+			continue
+		}
+
+		newInst, err := pm.launchPlugin(pID, pInfo)
+		if err != nil {
+			restart.attempts++
+			restart.next = now.Add(pluginRestartBackoff(restart.attempts))
+			pm.logger.Error("failed to relaunch plugin", "plugin_name", pID.Name,
+				"plugin_type", pID.PluginType, "attempts", restart.attempts, "next_attempt", restart.next, "error", err)
+			continue
+		}
+
+		pm.removePluginInstance(pID)
+		pm.storePluginInstance(pID, newInst)
+		delete(pm.restarts, pID)
+		pm.logger.Info("successfully relaunched plugin", "plugin_name", pID.Name, "plugin_type", pID.PluginType)
+	}
+}
+
+// pluginRestartBackoff returns the time to wait before the next relaunch
+// attempt, given the number of failed attempts.
+func pluginRestartBackoff(attempts int) time.Duration {
+	backoff := pluginRestartBackoffBase
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= pluginRestartBackoffMax {
+			return pluginRestartBackoffMax
+		}
+	}
+	return backoff
+}
+
 // launchInternalPlugin is used to dispense internal plugins.
 func (pm *PluginManager) launchInternalPlugin(id plugins.PluginID, info *pluginInfo) (PluginInstance, *base.PluginInfo, error) {
 
@@ -195,13 +514,14 @@ func (pm *PluginManager) launchExternalPlugin(id plugins.PluginID, info *pluginI
 	// the command to execute and also the logger to use. The loggers name is
 	// reset to avoid confusion that the log line is from within the agent.
 	client := plugin.NewClient(&plugin.ClientConfig{
-		HandshakeConfig: plugins.Handshake,
-		Plugins:         getPluginMap(id.PluginType),
-		Cmd:             exec.Command(info.exePath, info.args...),
-		Logger:          pm.logger.ResetNamed("external_plugin"),
+		HandshakeConfig:  plugins.Handshake,
+		VersionedPlugins: getVersionedPluginMap(id.PluginType),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC, plugin.ProtocolGRPC},
+		Cmd:              exec.Command(info.exePath, info.args...),
+		Logger:           pm.logger.ResetNamed("external_plugin"),
 	})
 
-	// Connect via RPC.
+	// Connect via RPC, using the protocol negotiated with the plugin.
 	rpcClient, err := client.Client()
 	if err != nil {
 		client.Kill()
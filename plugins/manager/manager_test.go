@@ -1,10 +1,17 @@
 package manager
 
 import (
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
+	"github.com/hashicorp/nomad-autoscaler/plugins"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,3 +111,240 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestPluginManager_Reload(t *testing.T) {
+	logger := hclog.NewNullLogger()
+
+	pm := NewPluginManager(logger, "../test/bin", map[string][]*config.Plugin{
+		"apm": {
+			{Name: "nomad", Driver: "nomad-apm"},
+		},
+		"strategy": {
+			{Name: "target-value", Driver: "target-value"},
+			{Name: "noop", Driver: "noop-strategy"},
+		},
+	})
+	assert.NoError(t, pm.Load())
+	defer pm.KillPlugins()
+
+	apmInst, err := pm.Dispense("nomad", plugins.PluginTypeAPM)
+	assert.NoError(t, err)
+	noopInst, err := pm.Dispense("noop", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+	targetValueInst, err := pm.Dispense("target-value", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+
+	// Update the APM config, remove the noop strategy and add a target.
+	err = pm.Reload(map[string][]*config.Plugin{
+		"apm": {
+			{Name: "nomad", Driver: "nomad-apm", Config: map[string]string{plugins.ConfigKeyQueryCacheTTL: "10s"}},
+		},
+		"strategy": {
+			{Name: "target-value", Driver: "target-value"},
+		},
+		"target": {
+			{Name: "nomad", Driver: "nomad-target"},
+		},
+	})
+	assert.NoError(t, err)
+
+	// The APM should have been relaunched, with a new query cache.
+	newAPMInst, err := pm.Dispense("nomad", plugins.PluginTypeAPM)
+	assert.NoError(t, err)
+	assert.NotSame(t, apmInst, newAPMInst)
+	assert.IsType(t, &cachedAPMPluginInstance{}, newAPMInst.(*managedPluginInstance).PluginInstance)
+	pm.Release(apmInst)
+
+	// The removed plugin should no longer be dispensed, and should be killed
+	// once released.
+	_, err = pm.Dispense("noop", plugins.PluginTypeStrategy)
+	assert.Error(t, err)
+	assert.False(t, noopInst.Exited())
+	pm.Release(noopInst)
+	assert.Eventually(t, noopInst.Exited, time.Second, 10*time.Millisecond)
+
+	// The unchanged plugin should not have been touched.
+	newTargetValueInst, err := pm.Dispense("target-value", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+	assert.Same(t, targetValueInst, newTargetValueInst)
+
+	// The new plugin should have been launched.
+	_, err = pm.Dispense("nomad", plugins.PluginTypeTarget)
+	assert.NoError(t, err)
+
+	// A rejected config should return an error, leaving the running plugin
+	// and its previous config in place.
+	err = pm.Reload(map[string][]*config.Plugin{
+		"apm": {
+			{Name: "nomad", Driver: "nomad-apm", Config: map[string]string{plugins.ConfigKeyQueryCacheTTL: "invalid"}},
+		},
+		"strategy": {
+			{Name: "target-value", Driver: "target-value"},
+		},
+		"target": {
+			{Name: "nomad", Driver: "nomad-target"},
+		},
+	})
+	assert.Error(t, err)
+	rejectedAPMInst, err := pm.Dispense("nomad", plugins.PluginTypeAPM)
+	assert.NoError(t, err)
+	assert.Same(t, newAPMInst, rejectedAPMInst)
+	assert.False(t, rejectedAPMInst.Exited())
+	assert.Equal(t, "10s", pm.plugins[plugins.PluginID{Name: "nomad", PluginType: plugins.PluginTypeAPM}].config[plugins.ConfigKeyQueryCacheTTL])
+
+	// Invalid plugins should return an error without affecting the others.
+	err = pm.Reload(map[string][]*config.Plugin{
+		"strategy": {
+			{Name: "target-value", Driver: "target-value"},
+			{Name: "invalid", Driver: "invalid-binary"},
+		},
+	})
+	assert.Error(t, err)
+	_, err = pm.Dispense("target-value", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+}
+
+// testTarget is a target plugin which sets its config without any
+// synchronisation, like the builtin plugins. Its Scale blocks until released.
+type testTarget struct {
+	config    map[string]string
+	startedCh chan struct{}
+	releaseCh chan struct{}
+	closed    int32
+}
+
+func (t *testTarget) PluginInfo() (*base.PluginInfo, error) {
+	return &base.PluginInfo{Name: "test-target", PluginType: plugins.PluginTypeTarget}, nil
+}
+
+func (t *testTarget) SetConfig(config map[string]string) error {
+	t.config = config
+	return nil
+}
+
+func (t *testTarget) Scale(_ strategy.Action, _ map[string]string) error {
+	value := t.config["value"]
+	t.startedCh <- struct{}{}
+	<-t.releaseCh
+	if t.config["value"] != value {
+		return fmt.Errorf("config changed during scale")
+	}
+	return nil
+}
+
+func (t *testTarget) Status(_ map[string]string) (*target.Status, error) { return nil, nil }
+
+func (t *testTarget) Close() error {
+	atomic.StoreInt32(&t.closed, 1)
+	return nil
+}
+
+func TestPluginManager_reloadPlugins_inFlight(t *testing.T) {
+	startedCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+
+	var targets []*testTarget
+	factory := func(hclog.Logger) interface{} {
+		tt := &testTarget{startedCh: startedCh, releaseCh: releaseCh}
+		targets = append(targets, tt)
+		return tt
+	}
+
+	pID := plugins.PluginID{Name: "test", PluginType: plugins.PluginTypeTarget}
+	infos := func(value string) map[plugins.PluginID]*pluginInfo {
+		return map[plugins.PluginID]*pluginInfo{
+			pID: {driver: "test-target", factory: factory, config: map[string]string{"value": value}},
+		}
+	}
+
+	pm := NewPluginManager(hclog.NewNullLogger(), "", nil)
+	pm.pluginsLock.Lock()
+	assert.NoError(t, pm.reloadPlugins(infos("a")))
+	pm.pluginsLock.Unlock()
+
+	inst, err := pm.Dispense(pID.Name, pID.PluginType)
+	assert.NoError(t, err)
+
+	errCh := make(chan error)
+	go func() { errCh <- inst.Plugin().(target.Target).Scale(strategy.Action{}, nil) }()
+	<-startedCh
+
+	// Reload the plugin with an updated config while Scale is running.
+	pm.pluginsLock.Lock()
+	assert.NoError(t, pm.reloadPlugins(infos("b")))
+	pm.pluginsLock.Unlock()
+
+	newInst, err := pm.Dispense(pID.Name, pID.PluginType)
+	assert.NoError(t, err)
+	assert.Same(t, targets[1], newInst.Plugin())
+	assert.Equal(t, "b", targets[1].config["value"])
+	pm.Release(newInst)
+
+	// The replaced instance should keep its config, and only be closed once
+	// the in-flight Scale has completed and been released.
+	assert.Equal(t, int32(0), atomic.LoadInt32(&targets[0].closed))
+	close(releaseCh)
+	assert.NoError(t, <-errCh)
+	pm.Release(inst)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&targets[0].closed) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&targets[1].closed))
+}
+
+func TestPluginManager_relaunchPlugins(t *testing.T) {
+	logger := hclog.NewNullLogger()
+
+	pm := NewPluginManager(logger, "../test/bin", map[string][]*config.Plugin{
+		"strategy": {
+			{Name: "noop", Driver: "noop-strategy"},
+		},
+	})
+	assert.NoError(t, pm.Load())
+	defer pm.KillPlugins()
+
+	// Simulate the plugin crashing.
+	inst, err := pm.Dispense("noop", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+	inst.Kill()
+
+	_, err = pm.Dispense("noop", plugins.PluginTypeStrategy)
+	assert.Error(t, err)
+
+	// The plugin should be relaunched.
+	pm.relaunchPlugins(time.Now())
+	newInst, err := pm.Dispense("noop", plugins.PluginTypeStrategy)
+	assert.NoError(t, err)
+	assert.False(t, newInst.Exited())
+
+	// Plugins which fail to relaunch should be retried with backoff.
+	now := time.Now()
+	pm.pluginsLock.Lock()
+	pm.plugins[plugins.PluginID{Name: "noop", PluginType: plugins.PluginTypeStrategy}].exePath = "../test/bin/invalid-binary"
+	pm.pluginsLock.Unlock()
+	newInst.Kill()
+
+	pm.relaunchPlugins(now)
+	pm.relaunchPlugins(now.Add(pluginRestartBackoffBase / 2))
+	assert.Equal(t, &pluginRestart{attempts: 1, next: now.Add(pluginRestartBackoffBase)},
+		pm.restarts[plugins.PluginID{Name: "noop", PluginType: plugins.PluginTypeStrategy}])
+
+	pm.relaunchPlugins(now.Add(pluginRestartBackoffBase))
+	assert.Equal(t, 2, pm.restarts[plugins.PluginID{Name: "noop", PluginType: plugins.PluginTypeStrategy}].attempts)
+}
+
+func Test_pluginRestartBackoff(t *testing.T) {
+	testCases := []struct {
+		inputAttempts  int
+		expectedOutput time.Duration
+	}{
+		{inputAttempts: 1, expectedOutput: 1 * time.Second},
+		{inputAttempts: 2, expectedOutput: 2 * time.Second},
+		{inputAttempts: 5, expectedOutput: 16 * time.Second},
+		{inputAttempts: 8, expectedOutput: 2 * time.Minute},
+		{inputAttempts: 100, expectedOutput: 2 * time.Minute},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedOutput, pluginRestartBackoff(tc.inputAttempts))
+	}
+}
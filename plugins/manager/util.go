@@ -1,6 +1,9 @@
 package manager
 
 import (
+	"fmt"
+	"time"
+
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
 	"github.com/hashicorp/nomad-autoscaler/plugins"
@@ -39,3 +42,47 @@ func getPluginMap(pluginType string) map[string]plugin.Plugin {
 	}
 	return m
 }
+
+// getGRPCPluginMap converts the input plugin type to a gRPC plugin map that
+// can be used when setting up a new plugin client.
+func getGRPCPluginMap(pluginType string) map[string]plugin.Plugin {
+	m := make(map[string]plugin.Plugin, 1)
+	switch pluginType {
+	case plugins.PluginTypeAPM:
+		m[pluginType] = &apm.GRPCPlugin{}
+	case plugins.PluginTypeTarget:
+		m[pluginType] = &target.GRPCPlugin{}
+	case plugins.PluginTypeStrategy:
+		m[pluginType] = &strategy.GRPCPlugin{}
+	}
+	return m
+}
+
+// getVersionedPluginMap returns the plugin maps for each supported plugin
+// protocol version, allowing the plugin protocol to be negotiated with
+// external plugins.
+func getVersionedPluginMap(pluginType string) map[int]plugin.PluginSet {
+	return map[int]plugin.PluginSet{
+		plugins.ProtocolVersionNetRPC: getPluginMap(pluginType),
+		plugins.ProtocolVersionGRPC:   getGRPCPluginMap(pluginType),
+	}
+}
+
+// parseQueryCacheTTL reads the APM query cache TTL from the plugin config. If
+// the operator has not configured the TTL, zero is returned which disables
+// result caching but still allows in-flight queries to be coalesced.
+func parseQueryCacheTTL(cfg map[string]string) (time.Duration, error) {
+	val, ok := cfg[plugins.ConfigKeyQueryCacheTTL]
+	if !ok || val == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %q as time duration", val)
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("%s cannot be negative", plugins.ConfigKeyQueryCacheTTL)
+	}
+	return ttl, nil
+}
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"testing"
+	"time"
 
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/agent/config"
@@ -63,3 +64,81 @@ func Test_getPluginMap(t *testing.T) {
 		assert.Equal(t, tc.expectedOutput, getPluginMap(tc.inputPluginType))
 	}
 }
+
+func Test_getVersionedPluginMap(t *testing.T) {
+	testCases := []struct {
+		inputPluginType string
+		expectedOutput  map[int]plugin.PluginSet
+	}{
+		{
+			inputPluginType: plugins.PluginTypeAPM,
+			expectedOutput: map[int]plugin.PluginSet{
+				plugins.ProtocolVersionNetRPC: {plugins.PluginTypeAPM: &apm.Plugin{}},
+				plugins.ProtocolVersionGRPC:   {plugins.PluginTypeAPM: &apm.GRPCPlugin{}},
+			},
+		},
+		{
+			inputPluginType: plugins.PluginTypeTarget,
+			expectedOutput: map[int]plugin.PluginSet{
+				plugins.ProtocolVersionNetRPC: {plugins.PluginTypeTarget: &target.Plugin{}},
+				plugins.ProtocolVersionGRPC:   {plugins.PluginTypeTarget: &target.GRPCPlugin{}},
+			},
+		},
+		{
+			inputPluginType: plugins.PluginTypeStrategy,
+			expectedOutput: map[int]plugin.PluginSet{
+				plugins.ProtocolVersionNetRPC: {plugins.PluginTypeStrategy: &strategy.Plugin{}},
+				plugins.ProtocolVersionGRPC:   {plugins.PluginTypeStrategy: &strategy.GRPCPlugin{}},
+			},
+		},
+		{
+			inputPluginType: "automatic-pizza-delivery",
+			expectedOutput: map[int]plugin.PluginSet{
+				plugins.ProtocolVersionNetRPC: {},
+				plugins.ProtocolVersionGRPC:   {},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expectedOutput, getVersionedPluginMap(tc.inputPluginType))
+	}
+}
+
+func Test_parseQueryCacheTTL(t *testing.T) {
+	testCases := []struct {
+		inputCfg      map[string]string
+		expectedTTL   time.Duration
+		expectedError bool
+		name          string
+	}{
+		{
+			inputCfg:    map[string]string{},
+			expectedTTL: 0,
+			name:        "not configured",
+		},
+		{
+			inputCfg:    map[string]string{"query_cache_ttl": "10s"},
+			expectedTTL: 10 * time.Second,
+			name:        "valid ttl",
+		},
+		{
+			inputCfg:      map[string]string{"query_cache_ttl": "ten seconds"},
+			expectedError: true,
+			name:          "invalid ttl",
+		},
+		{
+			inputCfg:      map[string]string{"query_cache_ttl": "-10s"},
+			expectedError: true,
+			name:          "negative ttl",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ttl, err := parseQueryCacheTTL(tc.inputCfg)
+			assert.Equal(t, tc.expectedTTL, ttl, tc.name)
+			assert.Equal(t, tc.expectedError, err != nil, tc.name)
+		})
+	}
+}
@@ -43,6 +43,20 @@ const (
 	// InternalTargetStateful is the stateful Services AutoScaling Group target
 	// plugin.
 	InternalTargetStateful = "stateful"
+
+	// InternalTargetGCEMIG is the Google Compute Engine Managed Instance Group
+	// target plugin.
+	InternalTargetGCEMIG = "gce-mig"
+
+	// InternalTargetAzureVMSS is the Microsoft Azure Virtual Machine Scale Set
+	// target plugin.
+	InternalTargetAzureVMSS = "azure-vmss"
+
+	// InternalTargetWebhook is the generic HTTP webhook target plugin.
+	InternalTargetWebhook = "webhook"
+
+	// InternalTargetExec is the generic executable target plugin.
+	InternalTargetExec = "exec"
 )
 
 // ConfigKeyNomadConfigInherit is a generic plugin config map key that supports
@@ -51,16 +65,36 @@ const (
 // which plugins can have their Nomad client configured without extra hassle.
 const ConfigKeyNomadConfigInherit = "nomad_config_inherit"
 
+// ConfigKeyQueryCacheTTL is a generic APM plugin config map key that supports
+// a time duration value. It controls how long the results of an individual
+// query are cached and shared between policy checks which issue the same
+// query. Identical queries which are in-flight at the same time are always
+// coalesced into a single APM request, even if this is not set.
+const ConfigKeyQueryCacheTTL = "query_cache_ttl"
+
+const (
+	// ProtocolVersionNetRPC is the plugin protocol version which uses
+	// go-plugin's net/rpc transport. It is supported so that plugins built
+	// against earlier versions of the Autoscaler continue to work.
+	ProtocolVersionNetRPC = 1
+
+	// ProtocolVersionGRPC is the plugin protocol version which uses gRPC. The
+	// protobuf definitions of the plugin services can be found alongside each
+	// plugin type, allowing plugins to be written in languages other than Go.
+	ProtocolVersionGRPC = 2
+)
+
 var (
 	// Handshake is used to do a basic handshake between a plugin and host. If
 	// the handshake fails, a user friendly error is shown. This prevents users
 	// from executing bad plugins or executing a plugin directory. It is a UX
 	// feature, not a security feature.
 	//
-	// Currently the Nomad Autoscaler plugin visioning is performed using the
-	// ProtocolVersion within the Handshake.
+	// The ProtocolVersion within the Handshake is the version used by clients
+	// which do not support versioned plugins. Newer clients negotiate the
+	// highest mutually supported version from the ProtocolVersion* values.
 	Handshake = plugin.HandshakeConfig{
-		ProtocolVersion:  1,
+		ProtocolVersion:  ProtocolVersionNetRPC,
 		MagicCookieKey:   "NOMAD_AUTOSCALER_PLUGIN_MAGIC_COOKIE",
 		MagicCookieValue: "e082fa04d587a6525d683666fa253d6afda00f20c122c54a80a3ed57fec99ff3",
 	}
@@ -105,15 +139,27 @@ func Serve(f PluginFactory) {
 	pCfg := plugin.ServeConfig{
 		HandshakeConfig: Handshake,
 		Logger:          logger,
+		GRPCServer:      plugin.DefaultGRPCServer,
 	}
 
+	// Serve the plugin using both protocols. Clients which support gRPC will
+	// negotiate its use, while older clients fallback to net/rpc.
 	switch pType := p.(type) {
 	case apm.APM:
-		pCfg.Plugins = map[string]plugin.Plugin{PluginTypeAPM: &apm.Plugin{Impl: p.(apm.APM)}}
+		pCfg.VersionedPlugins = map[int]plugin.PluginSet{
+			ProtocolVersionNetRPC: {PluginTypeAPM: &apm.Plugin{Impl: p.(apm.APM)}},
+			ProtocolVersionGRPC:   {PluginTypeAPM: &apm.GRPCPlugin{Impl: p.(apm.APM)}},
+		}
 	case target.Target:
-		pCfg.Plugins = map[string]plugin.Plugin{PluginTypeTarget: &target.Plugin{Impl: p.(target.Target)}}
+		pCfg.VersionedPlugins = map[int]plugin.PluginSet{
+			ProtocolVersionNetRPC: {PluginTypeTarget: &target.Plugin{Impl: p.(target.Target)}},
+			ProtocolVersionGRPC:   {PluginTypeTarget: &target.GRPCPlugin{Impl: p.(target.Target)}},
+		}
 	case strategy.Strategy:
-		pCfg.Plugins = map[string]plugin.Plugin{PluginTypeStrategy: &strategy.Plugin{Impl: p.(strategy.Strategy)}}
+		pCfg.VersionedPlugins = map[int]plugin.PluginSet{
+			ProtocolVersionNetRPC: {PluginTypeStrategy: &strategy.Plugin{Impl: p.(strategy.Strategy)}},
+			ProtocolVersionGRPC:   {PluginTypeStrategy: &strategy.GRPCPlugin{Impl: p.(strategy.Strategy)}},
+		}
 	default:
 		logger.Error("unsupported plugin type %q", pType)
 		return
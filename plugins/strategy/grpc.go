@@ -0,0 +1,216 @@
+package strategy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/jsonpb"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	baseproto "github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy/proto"
+	"google.golang.org/grpc"
+)
+
+// Assert that GRPCClient meets the ContextStrategy and base.HealthChecker
+// interfaces.
+var (
+	_ ContextStrategy    = (*GRPCClient)(nil)
+	_ base.HealthChecker = (*GRPCClient)(nil)
+)
+
+// GRPCPlugin is the plugin.GRPCPlugin used to serve and consume strategy
+// plugins over gRPC.
+type GRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl Strategy
+}
+
+func (p *GRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterStrategyPluginServer(s, &GRPCServer{Impl: p.Impl})
+	return nil
+}
+
+func (GRPCPlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: proto.NewStrategyPluginClient(c)}, nil
+}
+
+// GRPCClient is a plugin implementation that talks over gRPC.
+type GRPCClient struct {
+	client proto.StrategyPluginClient
+}
+
+func (c *GRPCClient) PluginInfo() (*base.PluginInfo, error) {
+	resp, err := c.client.PluginInfo(context.Background(), &baseproto.PluginInfoRequest{})
+	if err != nil {
+		return nil, base.ErrorFromGRPC(err)
+	}
+	return base.PluginInfoFromProto(resp), nil
+}
+
+func (c *GRPCClient) SetConfig(config map[string]string) error {
+	_, err := c.client.SetConfig(context.Background(), &baseproto.SetConfigRequest{Config: config})
+	return base.ErrorFromGRPC(err)
+}
+
+func (c *GRPCClient) Run(req RunRequest) (Action, error) {
+	return c.RunContext(context.Background(), req)
+}
+
+func (c *GRPCClient) RunContext(ctx context.Context, req RunRequest) (Action, error) {
+	resp, err := c.client.Run(ctx, &proto.RunRequest{
+		PolicyId: req.PolicyID,
+		Count:    req.Count,
+		Metric:   req.Metric,
+		Config:   req.Config,
+	})
+	if err != nil {
+		return Action{}, base.ErrorFromGRPC(err)
+	}
+	return ActionFromProto(resp.GetAction())
+}
+
+func (c *GRPCClient) HealthCheck(ctx context.Context) error {
+	_, err := c.client.HealthCheck(ctx, &baseproto.HealthCheckRequest{})
+	return base.HealthCheckFromGRPC(err)
+}
+
+// GRPCServer is the gRPC server.
+type GRPCServer struct {
+	Impl Strategy
+}
+
+func (s *GRPCServer) PluginInfo(_ context.Context, _ *baseproto.PluginInfoRequest) (*baseproto.PluginInfoResponse, error) {
+	info, err := s.Impl.PluginInfo()
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return base.PluginInfoToProto(info), nil
+}
+
+func (s *GRPCServer) SetConfig(_ context.Context, req *baseproto.SetConfigRequest) (*baseproto.SetConfigResponse, error) {
+	if err := s.Impl.SetConfig(req.GetConfig()); err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &baseproto.SetConfigResponse{}, nil
+}
+
+func (s *GRPCServer) Run(ctx context.Context, req *proto.RunRequest) (*proto.RunResponse, error) {
+	a, err := RunWithContext(ctx, s.Impl, RunRequest{
+		PolicyID: req.GetPolicyId(),
+		Count:    req.GetCount(),
+		Metric:   req.GetMetric(),
+		Config:   req.GetConfig(),
+	})
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+
+	pa, err := ActionToProto(a)
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &proto.RunResponse{Action: pa}, nil
+}
+
+// ActionToProto converts the Action to its protobuf representation. The
+// action Meta must be JSON compatible.
+func ActionToProto(a Action) (*proto.Action, error) {
+	meta, err := metaToProto(a.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert action meta: %v", err)
+	}
+
+	pa := &proto.Action{
+		Count:  a.Count,
+		Reason: a.Reason,
+		Error:  a.Error,
+		Meta:   meta,
+	}
+
+	switch a.Direction {
+	case ScaleDirectionUp:
+		pa.Direction = proto.ScaleDirection_SCALE_DIRECTION_UP
+	case ScaleDirectionDown:
+		pa.Direction = proto.ScaleDirection_SCALE_DIRECTION_DOWN
+	default:
+		pa.Direction = proto.ScaleDirection_SCALE_DIRECTION_NONE
+	}
+	return pa, nil
+}
+
+// ActionFromProto converts the protobuf representation of an action to an
+// Action.
+func ActionFromProto(pa *proto.Action) (Action, error) {
+	if pa == nil {
+		return Action{}, nil
+	}
+
+	meta, err := metaFromProto(pa.GetMeta())
+	if err != nil {
+		return Action{}, fmt.Errorf("failed to convert action meta: %v", err)
+	}
+
+	a := Action{
+		Count:  pa.GetCount(),
+		Reason: pa.GetReason(),
+		Error:  pa.GetError(),
+		Meta:   meta,
+	}
+
+	switch pa.GetDirection() {
+	case proto.ScaleDirection_SCALE_DIRECTION_UP:
+		a.Direction = ScaleDirectionUp
+	case proto.ScaleDirection_SCALE_DIRECTION_DOWN:
+		a.Direction = ScaleDirectionDown
+	default:
+		a.Direction = ScaleDirectionNone
+	}
+	return a, nil
+}
+
+// metaToProto converts the action meta to a protobuf struct. JSON is used as
+// the intermediate format which allows any JSON compatible value to be sent,
+// without the type registration required by gob.
+func metaToProto(meta map[string]interface{}) (*structpb.Struct, error) {
+	if meta == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var s structpb.Struct
+	if err := jsonpb.Unmarshal(bytes.NewReader(b), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// metaFromProto converts a protobuf struct to action meta. As the values are
+// decoded from JSON, all numbers are returned as float64.
+func metaFromProto(s *structpb.Struct) (map[string]interface{}, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, s); err != nil {
+		return nil, err
+	}
+
+	var meta map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (s *GRPCServer) HealthCheck(ctx context.Context, _ *baseproto.HealthCheckRequest) (*baseproto.HealthCheckResponse, error) {
+	return base.HealthCheckGRPCServer(ctx, s.Impl)
+}
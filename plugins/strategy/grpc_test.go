@@ -0,0 +1,103 @@
+package strategy
+
+import (
+	"errors"
+	"testing"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStrategy is a simple Strategy implementation which returns the
+// configured action and error.
+type testStrategy struct {
+	action Action
+	err    error
+}
+
+func (t *testStrategy) Run(_ RunRequest) (Action, error)    { return t.action, t.err }
+func (t *testStrategy) SetConfig(_ map[string]string) error { return nil }
+func (t *testStrategy) PluginInfo() (*base.PluginInfo, error) {
+	return &base.PluginInfo{Name: "test", PluginType: "strategy"}, nil
+}
+
+func TestActionProto(t *testing.T) {
+	testCases := []struct {
+		inputAction          Action
+		expectedOutputAction Action
+		name                 string
+	}{
+		{
+			inputAction:          Action{},
+			expectedOutputAction: Action{},
+			name:                 "empty action",
+		},
+		{
+			inputAction: Action{
+				Count:     3,
+				Reason:    "scale up",
+				Direction: ScaleDirectionUp,
+				Meta: map[string]interface{}{
+					metaKeyCountCapped:   true,
+					metaKeyCountOriginal: int64(5),
+					metaKeyReasonHistory: []string{"capped"},
+					"nested":             map[string]interface{}{"key": "value"},
+				},
+			},
+			expectedOutputAction: Action{
+				Count:     3,
+				Reason:    "scale up",
+				Direction: ScaleDirectionUp,
+				Meta: map[string]interface{}{
+					metaKeyCountCapped:   true,
+					metaKeyCountOriginal: float64(5),
+					metaKeyReasonHistory: []interface{}{"capped"},
+					"nested":             map[string]interface{}{"key": "value"},
+				},
+			},
+			name: "action with meta",
+		},
+		{
+			inputAction:          Action{Count: 1, Direction: ScaleDirectionDown, Error: true},
+			expectedOutputAction: Action{Count: 1, Direction: ScaleDirectionDown, Error: true},
+			name:                 "scale down with error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pa, err := ActionToProto(tc.inputAction)
+			assert.Nil(t, err, tc.name)
+
+			actualOutput, err := ActionFromProto(pa)
+			assert.Nil(t, err, tc.name)
+			assert.Equal(t, tc.expectedOutputAction, actualOutput, tc.name)
+		})
+	}
+}
+
+func TestGRPCPlugin(t *testing.T) {
+	impl := &testStrategy{action: Action{Count: 2, Direction: ScaleDirectionUp, Reason: "up"}}
+
+	client, server := plugin.TestPluginGRPCConn(t, map[string]plugin.Plugin{"strategy": &GRPCPlugin{Impl: impl}})
+	defer client.Close()
+	defer server.Stop()
+
+	raw, err := client.Dispense("strategy")
+	assert.Nil(t, err)
+	s := raw.(Strategy)
+
+	info, err := s.PluginInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, &base.PluginInfo{Name: "test", PluginType: "strategy"}, info)
+
+	action, err := s.Run(RunRequest{PolicyID: "policy", Count: 1, Metric: 13})
+	assert.Nil(t, err)
+	assert.Equal(t, impl.action, action)
+
+	// Errors returned by the plugin should be returned to the caller as is.
+	impl.err = errors.New("failed to run strategy")
+	_, err = s.Run(RunRequest{})
+	assert.Equal(t, errors.New("failed to run strategy"), err)
+}
@@ -0,0 +1,478 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugins/strategy/proto/strategy.proto
+
+package proto
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	proto1 "github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// ScaleDirection identifies how the target should scale the resource.
+type ScaleDirection int32
+
+const (
+	ScaleDirection_SCALE_DIRECTION_NONE ScaleDirection = 0
+	ScaleDirection_SCALE_DIRECTION_UP   ScaleDirection = 1
+	ScaleDirection_SCALE_DIRECTION_DOWN ScaleDirection = 2
+)
+
+var ScaleDirection_name = map[int32]string{
+	0: "SCALE_DIRECTION_NONE",
+	1: "SCALE_DIRECTION_UP",
+	2: "SCALE_DIRECTION_DOWN",
+}
+
+var ScaleDirection_value = map[string]int32{
+	"SCALE_DIRECTION_NONE": 0,
+	"SCALE_DIRECTION_UP":   1,
+	"SCALE_DIRECTION_DOWN": 2,
+}
+
+func (x ScaleDirection) String() string {
+	return proto.EnumName(ScaleDirection_name, int32(x))
+}
+
+func (ScaleDirection) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b317edcb3d0ef17, []int{0}
+}
+
+type RunRequest struct {
+	PolicyId             string            `protobuf:"bytes,1,opt,name=policy_id,json=policyId,proto3" json:"policy_id,omitempty"`
+	Count                int64             `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Metric               float64           `protobuf:"fixed64,3,opt,name=metric,proto3" json:"metric,omitempty"`
+	Config               map[string]string `protobuf:"bytes,4,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *RunRequest) Reset()         { *m = RunRequest{} }
+func (m *RunRequest) String() string { return proto.CompactTextString(m) }
+func (*RunRequest) ProtoMessage()    {}
+func (*RunRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b317edcb3d0ef17, []int{0}
+}
+
+func (m *RunRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunRequest.Unmarshal(m, b)
+}
+func (m *RunRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunRequest.Marshal(b, m, deterministic)
+}
+func (m *RunRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunRequest.Merge(m, src)
+}
+func (m *RunRequest) XXX_Size() int {
+	return xxx_messageInfo_RunRequest.Size(m)
+}
+func (m *RunRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunRequest proto.InternalMessageInfo
+
+func (m *RunRequest) GetPolicyId() string {
+	if m != nil {
+		return m.PolicyId
+	}
+	return ""
+}
+
+func (m *RunRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *RunRequest) GetMetric() float64 {
+	if m != nil {
+		return m.Metric
+	}
+	return 0
+}
+
+func (m *RunRequest) GetConfig() map[string]string {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type RunResponse struct {
+	Action               *Action  `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RunResponse) Reset()         { *m = RunResponse{} }
+func (m *RunResponse) String() string { return proto.CompactTextString(m) }
+func (*RunResponse) ProtoMessage()    {}
+func (*RunResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b317edcb3d0ef17, []int{1}
+}
+
+func (m *RunResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RunResponse.Unmarshal(m, b)
+}
+func (m *RunResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RunResponse.Marshal(b, m, deterministic)
+}
+func (m *RunResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RunResponse.Merge(m, src)
+}
+func (m *RunResponse) XXX_Size() int {
+	return xxx_messageInfo_RunResponse.Size(m)
+}
+func (m *RunResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RunResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RunResponse proto.InternalMessageInfo
+
+func (m *RunResponse) GetAction() *Action {
+	if m != nil {
+		return m.Action
+	}
+	return nil
+}
+
+// Action represents a strategy's intention to modify the target.
+type Action struct {
+	Count     int64          `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Reason    string         `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	Error     bool           `protobuf:"varint,3,opt,name=error,proto3" json:"error,omitempty"`
+	Direction ScaleDirection `protobuf:"varint,4,opt,name=direction,proto3,enum=hashicorp.nomad_autoscaler.plugins.strategy.proto.ScaleDirection" json:"direction,omitempty"`
+	// meta holds arbitrary JSON compatible data associated with the action.
+	Meta                 *_struct.Struct `protobuf:"bytes,5,opt,name=meta,proto3" json:"meta,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *Action) Reset()         { *m = Action{} }
+func (m *Action) String() string { return proto.CompactTextString(m) }
+func (*Action) ProtoMessage()    {}
+func (*Action) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b317edcb3d0ef17, []int{2}
+}
+
+func (m *Action) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Action.Unmarshal(m, b)
+}
+func (m *Action) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Action.Marshal(b, m, deterministic)
+}
+func (m *Action) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Action.Merge(m, src)
+}
+func (m *Action) XXX_Size() int {
+	return xxx_messageInfo_Action.Size(m)
+}
+func (m *Action) XXX_DiscardUnknown() {
+	xxx_messageInfo_Action.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Action proto.InternalMessageInfo
+
+func (m *Action) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Action) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *Action) GetError() bool {
+	if m != nil {
+		return m.Error
+	}
+	return false
+}
+
+func (m *Action) GetDirection() ScaleDirection {
+	if m != nil {
+		return m.Direction
+	}
+	return ScaleDirection_SCALE_DIRECTION_NONE
+}
+
+func (m *Action) GetMeta() *_struct.Struct {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("hashicorp.nomad_autoscaler.plugins.strategy.proto.ScaleDirection", ScaleDirection_name, ScaleDirection_value)
+	proto.RegisterType((*RunRequest)(nil), "hashicorp.nomad_autoscaler.plugins.strategy.proto.RunRequest")
+	proto.RegisterMapType((map[string]string)(nil), "hashicorp.nomad_autoscaler.plugins.strategy.proto.RunRequest.ConfigEntry")
+	proto.RegisterType((*RunResponse)(nil), "hashicorp.nomad_autoscaler.plugins.strategy.proto.RunResponse")
+	proto.RegisterType((*Action)(nil), "hashicorp.nomad_autoscaler.plugins.strategy.proto.Action")
+}
+
+func init() {
+	proto.RegisterFile("plugins/strategy/proto/strategy.proto", fileDescriptor_1b317edcb3d0ef17)
+}
+
+var fileDescriptor_1b317edcb3d0ef17 = []byte{
+	// 553 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x94, 0x51, 0x6b, 0x1a, 0x4f,
+	0x10, 0xc0, 0xb3, 0xd1, 0x48, 0x1c, 0x21, 0xc8, 0x12, 0xf2, 0x3f, 0xfc, 0xb7, 0x20, 0x42, 0x41,
+	0x5a, 0xba, 0x52, 0xfb, 0xd2, 0x94, 0x36, 0x8d, 0x51, 0xa1, 0x42, 0xd1, 0x74, 0x6d, 0x29, 0x94,
+	0x82, 0x5d, 0xcf, 0xf5, 0x3c, 0x72, 0xee, 0xda, 0xbd, 0xbd, 0x82, 0x5f, 0xa0, 0xcf, 0x85, 0x42,
+	0x3f, 0x5c, 0xfb, 0x39, 0xfa, 0x5e, 0xdc, 0xdd, 0xf3, 0x92, 0x90, 0x87, 0xaa, 0x4f, 0xee, 0xec,
+	0xec, 0xfc, 0xfc, 0xcd, 0x30, 0x1c, 0x3c, 0x58, 0x44, 0x49, 0x10, 0x8a, 0xb8, 0x11, 0x6b, 0xc5,
+	0x34, 0x0f, 0x96, 0x8d, 0x85, 0x92, 0x5a, 0xae, 0x43, 0x62, 0x42, 0xfc, 0x64, 0xc6, 0xe2, 0x59,
+	0xe8, 0x4b, 0xb5, 0x20, 0x42, 0xce, 0xd9, 0x64, 0xc4, 0x12, 0x2d, 0x63, 0x9f, 0x45, 0x5c, 0x11,
+	0x47, 0x20, 0x37, 0x4b, 0x2a, 0xf7, 0x02, 0x29, 0x83, 0x88, 0x5b, 0xde, 0x38, 0x99, 0xae, 0x90,
+	0x89, 0xaf, 0x5d, 0xf6, 0x7e, 0xfa, 0xbf, 0x63, 0x16, 0xbb, 0x37, 0xe6, 0x68, 0xd3, 0xb5, 0x3f,
+	0x08, 0x80, 0x26, 0x82, 0xf2, 0x2f, 0x09, 0x8f, 0x35, 0xfe, 0x1f, 0x8a, 0x0b, 0x19, 0x85, 0xfe,
+	0x72, 0x14, 0x4e, 0x3c, 0x54, 0x45, 0xf5, 0x22, 0x3d, 0xb4, 0x17, 0xbd, 0x09, 0x3e, 0x86, 0x03,
+	0x5f, 0x26, 0x42, 0x7b, 0xfb, 0x55, 0x54, 0xcf, 0x51, 0x1b, 0xe0, 0x13, 0x28, 0xcc, 0xb9, 0x56,
+	0xa1, 0xef, 0xe5, 0xaa, 0xa8, 0x8e, 0xa8, 0x8b, 0x30, 0x83, 0x82, 0x2f, 0xc5, 0x34, 0x0c, 0xbc,
+	0x7c, 0x35, 0x57, 0x2f, 0x35, 0x7b, 0x64, 0xe3, 0xd6, 0x48, 0x66, 0x46, 0xda, 0x86, 0xd5, 0x15,
+	0x5a, 0x2d, 0xa9, 0x03, 0x57, 0x4e, 0xa1, 0x74, 0xed, 0x1a, 0x97, 0x21, 0x77, 0xc5, 0x97, 0x4e,
+	0x7b, 0x75, 0x5c, 0x19, 0x7f, 0x65, 0x51, 0xc2, 0x8d, 0x71, 0x91, 0xda, 0xe0, 0xf9, 0xfe, 0x33,
+	0x54, 0xfb, 0x0c, 0x25, 0x03, 0x8f, 0x17, 0x52, 0xc4, 0x1c, 0xbf, 0x85, 0x02, 0xf3, 0x75, 0x28,
+	0x85, 0xa9, 0x2e, 0x35, 0x4f, 0xb7, 0x90, 0x6d, 0x19, 0x00, 0x75, 0xa0, 0xda, 0x2f, 0x04, 0x05,
+	0x7b, 0x95, 0x0d, 0x0e, 0xdd, 0x1a, 0x9c, 0xe2, 0x2c, 0x96, 0xc2, 0xd9, 0xb9, 0x68, 0xf5, 0x9a,
+	0x2b, 0x25, 0x95, 0x99, 0xe7, 0x21, 0xb5, 0x01, 0x1e, 0x41, 0x71, 0x12, 0x2a, 0x6e, 0x25, 0xf3,
+	0x55, 0x54, 0x3f, 0x6a, 0xb6, 0xb6, 0x90, 0x1c, 0xae, 0xb2, 0x9d, 0x14, 0x44, 0x33, 0x26, 0x7e,
+	0x04, 0xf9, 0x39, 0xd7, 0xcc, 0x3b, 0x30, 0x03, 0xf8, 0x8f, 0xd8, 0xad, 0x22, 0xe9, 0x56, 0x91,
+	0xa1, 0xd9, 0x2a, 0x6a, 0x1e, 0x3d, 0xfc, 0x04, 0x47, 0x37, 0x49, 0xd8, 0x83, 0xe3, 0x61, 0xbb,
+	0xf5, 0xa6, 0x3b, 0xea, 0xf4, 0x68, 0xb7, 0xfd, 0xae, 0x37, 0xe8, 0x8f, 0xfa, 0x83, 0x7e, 0xb7,
+	0xbc, 0x87, 0x4f, 0x00, 0xdf, 0xce, 0xbc, 0xbf, 0x2c, 0xa3, 0xbb, 0x2a, 0x3a, 0x83, 0x0f, 0xfd,
+	0xf2, 0x7e, 0xf3, 0x77, 0x1e, 0x8e, 0x86, 0xce, 0xfb, 0xd2, 0xf4, 0x81, 0x7f, 0x20, 0x00, 0x7b,
+	0xec, 0x89, 0xa9, 0xc4, 0xe7, 0xff, 0xd2, 0x7a, 0xb6, 0xe6, 0x24, 0x2b, 0x75, 0xfb, 0x54, 0x69,
+	0xed, 0x40, 0xb0, 0x4b, 0x53, 0xdb, 0xc3, 0xdf, 0x11, 0x14, 0x87, 0x5c, 0xdb, 0x25, 0xc4, 0xaf,
+	0x36, 0x44, 0xae, 0x2b, 0x53, 0xa7, 0xf3, 0xed, 0x01, 0x6b, 0xa5, 0x9f, 0x08, 0x4a, 0xaf, 0x39,
+	0x8b, 0xf4, 0xac, 0x3d, 0xe3, 0xfe, 0x15, 0xde, 0xb4, 0xcf, 0x6b, 0xb5, 0xa9, 0xd6, 0xc5, 0x2e,
+	0x88, 0xb5, 0xd8, 0x37, 0x04, 0x39, 0x9a, 0x08, 0xfc, 0x72, 0xa7, 0xef, 0x40, 0xe5, 0x6c, 0xdb,
+	0xf2, 0x54, 0xe4, 0xe2, 0xec, 0xe3, 0x8b, 0x20, 0xd4, 0xb3, 0x64, 0x4c, 0x7c, 0x39, 0x6f, 0xac,
+	0x69, 0x0d, 0x43, 0x7b, 0x9c, 0xd1, 0x1a, 0x77, 0x7f, 0xb1, 0xc7, 0x05, 0xf3, 0xf3, 0xf4, 0xef,
+	0x00, 0x75, 0xab, 0xce, 0x58, 0xd2, 0x05, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// StrategyPluginClient is the client API for StrategyPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StrategyPluginClient interface {
+	PluginInfo(ctx context.Context, in *proto1.PluginInfoRequest, opts ...grpc.CallOption) (*proto1.PluginInfoResponse, error)
+	SetConfig(ctx context.Context, in *proto1.SetConfigRequest, opts ...grpc.CallOption) (*proto1.SetConfigResponse, error)
+	// HealthCheck checks the health of the plugin and its dependencies. Plugins
+	// which do not implement health checks should return UNIMPLEMENTED.
+	HealthCheck(ctx context.Context, in *proto1.HealthCheckRequest, opts ...grpc.CallOption) (*proto1.HealthCheckResponse, error)
+	// Run calculates the desired scaling action based on the current count and
+	// the metric value.
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error)
+}
+
+type strategyPluginClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStrategyPluginClient(cc *grpc.ClientConn) StrategyPluginClient {
+	return &strategyPluginClient{cc}
+}
+
+func (c *strategyPluginClient) PluginInfo(ctx context.Context, in *proto1.PluginInfoRequest, opts ...grpc.CallOption) (*proto1.PluginInfoResponse, error) {
+	out := new(proto1.PluginInfoResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/PluginInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *strategyPluginClient) SetConfig(ctx context.Context, in *proto1.SetConfigRequest, opts ...grpc.CallOption) (*proto1.SetConfigResponse, error) {
+	out := new(proto1.SetConfigResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/SetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *strategyPluginClient) HealthCheck(ctx context.Context, in *proto1.HealthCheckRequest, opts ...grpc.CallOption) (*proto1.HealthCheckResponse, error) {
+	out := new(proto1.HealthCheckResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/HealthCheck", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *strategyPluginClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error) {
+	out := new(RunResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/Run", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StrategyPluginServer is the server API for StrategyPlugin service.
+type StrategyPluginServer interface {
+	PluginInfo(context.Context, *proto1.PluginInfoRequest) (*proto1.PluginInfoResponse, error)
+	SetConfig(context.Context, *proto1.SetConfigRequest) (*proto1.SetConfigResponse, error)
+	// HealthCheck checks the health of the plugin and its dependencies. Plugins
+	// which do not implement health checks should return UNIMPLEMENTED.
+	HealthCheck(context.Context, *proto1.HealthCheckRequest) (*proto1.HealthCheckResponse, error)
+	// Run calculates the desired scaling action based on the current count and
+	// the metric value.
+	Run(context.Context, *RunRequest) (*RunResponse, error)
+}
+
+// UnimplementedStrategyPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedStrategyPluginServer struct {
+}
+
+func (*UnimplementedStrategyPluginServer) PluginInfo(ctx context.Context, req *proto1.PluginInfoRequest) (*proto1.PluginInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PluginInfo not implemented")
+}
+func (*UnimplementedStrategyPluginServer) SetConfig(ctx context.Context, req *proto1.SetConfigRequest) (*proto1.SetConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
+}
+func (*UnimplementedStrategyPluginServer) HealthCheck(ctx context.Context, req *proto1.HealthCheckRequest) (*proto1.HealthCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
+}
+func (*UnimplementedStrategyPluginServer) Run(ctx context.Context, req *RunRequest) (*RunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
+}
+
+func RegisterStrategyPluginServer(s *grpc.Server, srv StrategyPluginServer) {
+	s.RegisterService(&_StrategyPlugin_serviceDesc, srv)
+}
+
+func _StrategyPlugin_PluginInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto1.PluginInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StrategyPluginServer).PluginInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/PluginInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StrategyPluginServer).PluginInfo(ctx, req.(*proto1.PluginInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StrategyPlugin_SetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto1.SetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StrategyPluginServer).SetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/SetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StrategyPluginServer).SetConfig(ctx, req.(*proto1.SetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StrategyPlugin_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto1.HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StrategyPluginServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/HealthCheck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StrategyPluginServer).HealthCheck(ctx, req.(*proto1.HealthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StrategyPlugin_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StrategyPluginServer).Run(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin/Run",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StrategyPluginServer).Run(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _StrategyPlugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "hashicorp.nomad_autoscaler.plugins.strategy.proto.StrategyPlugin",
+	HandlerType: (*StrategyPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PluginInfo",
+			Handler:    _StrategyPlugin_PluginInfo_Handler,
+		},
+		{
+			MethodName: "SetConfig",
+			Handler:    _StrategyPlugin_SetConfig_Handler,
+		},
+		{
+			MethodName: "HealthCheck",
+			Handler:    _StrategyPlugin_HealthCheck_Handler,
+		},
+		{
+			MethodName: "Run",
+			Handler:    _StrategyPlugin_Run_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugins/strategy/proto/strategy.proto",
+}
@@ -1,7 +1,9 @@
 package strategy
 
 import (
+	"context"
 	"net/rpc"
+	"time"
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/plugins/base"
@@ -13,6 +15,41 @@ type Strategy interface {
 	SetConfig(config map[string]string) error
 }
 
+// ContextStrategy is an optional extension of the Strategy interface.
+// Strategy plugins which implement it receive the context of the policy
+// evaluation, allowing them to stop work once the evaluation is cancelled or
+// its timeout is reached.
+type ContextStrategy interface {
+	Strategy
+	RunContext(ctx context.Context, req RunRequest) (Action, error)
+}
+
+// RunWithContext runs the strategy using the passed context. If the strategy
+// does not implement the ContextStrategy interface, the call returns once the
+// context is done without waiting for the strategy to finish.
+func RunWithContext(ctx context.Context, s Strategy, req RunRequest) (Action, error) {
+	if c, ok := s.(ContextStrategy); ok {
+		return c.RunContext(ctx, req)
+	}
+
+	var resp Action
+	err := base.RunWithContext(ctx, func() error {
+		a, err := s.Run(req)
+		resp = a
+		return err
+	})
+	if err != nil {
+		return Action{}, err
+	}
+	return resp, nil
+}
+
+// Assert that RPC meets the ContextStrategy and base.HealthChecker interfaces.
+var (
+	_ ContextStrategy    = (*RPC)(nil)
+	_ base.HealthChecker = (*RPC)(nil)
+)
+
 func (s *RPCServer) PluginInfo(_ interface{}, r *base.PluginInfo) error {
 	resp, err := s.Impl.PluginInfo()
 	if resp != nil {
@@ -60,10 +97,46 @@ func (r *RPC) Run(req RunRequest) (Action, error) {
 	return resp, nil
 }
 
+// RPCRunRequest is the request used when running a strategy with a context
+// via RPC. The context deadline is sent so the plugin can honour it.
+type RPCRunRequest struct {
+	Request  RunRequest
+	Deadline time.Time
+}
+
+func (r *RPC) RunContext(ctx context.Context, req RunRequest) (Action, error) {
+	var resp Action
+	rpcReq := RPCRunRequest{Request: req, Deadline: base.Deadline(ctx)}
+
+	err := base.CallContext(ctx, r.client, "Plugin.RunContext", rpcReq, &resp)
+	if base.IsRPCMethodNotFound(err) {
+
+		// The plugin was built against a version of the interface without
+		// context support, so fallback to the standard run.
+		err = base.RunWithContext(ctx, func() error {
+			a, err := r.Run(req)
+			resp = a
+			return err
+		})
+	}
+	if err != nil {
+		return Action{}, err
+	}
+	return resp, nil
+}
+
+func (r *RPC) HealthCheck(ctx context.Context) error {
+	return base.HealthCheckRPC(ctx, r.client)
+}
+
 type RPCServer struct {
 	Impl Strategy
 }
 
+func (s *RPCServer) HealthCheck(req base.RPCHealthCheckRequest, _ *struct{}) error {
+	return base.HealthCheckRPCServer(req, s.Impl)
+}
+
 func (s *RPCServer) SetConfig(config map[string]string, resp *error) error {
 	err := s.Impl.SetConfig(config)
 	*resp = err
@@ -79,6 +152,18 @@ func (s *RPCServer) Run(req RunRequest, resp *Action) error {
 	return nil
 }
 
+func (s *RPCServer) RunContext(req RPCRunRequest, resp *Action) error {
+	ctx, cancel := base.ContextWithDeadline(req.Deadline)
+	defer cancel()
+
+	r, err := RunWithContext(ctx, s.Impl, req.Request)
+	if err != nil {
+		return err
+	}
+	*resp = r
+	return nil
+}
+
 // Plugin is the plugin.Plugin
 type Plugin struct {
 	Impl Strategy
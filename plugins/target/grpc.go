@@ -0,0 +1,158 @@
+package target
+
+import (
+	"context"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	baseproto "github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/hashicorp/nomad-autoscaler/plugins/target/proto"
+	"google.golang.org/grpc"
+)
+
+// Assert that GRPCClient meets the ContextTarget and base.HealthChecker
+// interfaces.
+var (
+	_ ContextTarget      = (*GRPCClient)(nil)
+	_ base.HealthChecker = (*GRPCClient)(nil)
+)
+
+// GRPCPlugin is the plugin.GRPCPlugin used to serve and consume target
+// plugins over gRPC.
+type GRPCPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl Target
+}
+
+func (p *GRPCPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterTargetPluginServer(s, &GRPCServer{Impl: p.Impl})
+	return nil
+}
+
+func (GRPCPlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return &GRPCClient{client: proto.NewTargetPluginClient(c)}, nil
+}
+
+// GRPCClient is a plugin implementation that talks over gRPC.
+type GRPCClient struct {
+	client proto.TargetPluginClient
+}
+
+func (c *GRPCClient) PluginInfo() (*base.PluginInfo, error) {
+	resp, err := c.client.PluginInfo(context.Background(), &baseproto.PluginInfoRequest{})
+	if err != nil {
+		return nil, base.ErrorFromGRPC(err)
+	}
+	return base.PluginInfoFromProto(resp), nil
+}
+
+func (c *GRPCClient) SetConfig(config map[string]string) error {
+	_, err := c.client.SetConfig(context.Background(), &baseproto.SetConfigRequest{Config: config})
+	return base.ErrorFromGRPC(err)
+}
+
+func (c *GRPCClient) Scale(action strategy.Action, config map[string]string) error {
+	return c.ScaleContext(context.Background(), action, config)
+}
+
+func (c *GRPCClient) Status(config map[string]string) (*Status, error) {
+	return c.StatusContext(context.Background(), config)
+}
+
+func (c *GRPCClient) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
+	pa, err := strategy.ActionToProto(action)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Scale(ctx, &proto.ScaleRequest{Action: pa, Config: config})
+	if err != nil {
+		return base.ErrorFromGRPC(err)
+	}
+	if p := resp.GetPartial(); p != nil {
+		return (&RPCPartialScale{Count: p.GetCount(), Error: p.GetError()}).toError()
+	}
+	return nil
+}
+
+func (c *GRPCClient) StatusContext(ctx context.Context, config map[string]string) (*Status, error) {
+	resp, err := c.client.Status(ctx, &proto.StatusRequest{Config: config})
+	if err != nil {
+		return nil, base.ErrorFromGRPC(err)
+	}
+	return statusFromProto(resp.GetStatus()), nil
+}
+
+func (c *GRPCClient) HealthCheck(ctx context.Context) error {
+	_, err := c.client.HealthCheck(ctx, &baseproto.HealthCheckRequest{})
+	return base.HealthCheckFromGRPC(err)
+}
+
+// GRPCServer is the gRPC server.
+type GRPCServer struct {
+	Impl Target
+}
+
+func (s *GRPCServer) PluginInfo(_ context.Context, _ *baseproto.PluginInfoRequest) (*baseproto.PluginInfoResponse, error) {
+	info, err := s.Impl.PluginInfo()
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return base.PluginInfoToProto(info), nil
+}
+
+func (s *GRPCServer) SetConfig(_ context.Context, req *baseproto.SetConfigRequest) (*baseproto.SetConfigResponse, error) {
+	if err := s.Impl.SetConfig(req.GetConfig()); err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &baseproto.SetConfigResponse{}, nil
+}
+
+func (s *GRPCServer) Scale(ctx context.Context, req *proto.ScaleRequest) (*proto.ScaleResponse, error) {
+	action, err := strategy.ActionFromProto(req.GetAction())
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+
+	// A partial scaling action is reported within the response, so that the
+	// count the target reached is not lost within the error message.
+	err = ScaleWithContext(ctx, s.Impl, action, req.GetConfig())
+	if p := partialScaleFromError(err); p != nil {
+		return &proto.ScaleResponse{Partial: &proto.PartialScale{Count: p.Count, Error: p.Error}}, nil
+	}
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &proto.ScaleResponse{}, nil
+}
+
+func (s *GRPCServer) Status(ctx context.Context, req *proto.StatusRequest) (*proto.StatusResponse, error) {
+	status, err := StatusWithContext(ctx, s.Impl, req.GetConfig())
+	if err != nil {
+		return nil, base.ErrorToGRPC(err)
+	}
+	return &proto.StatusResponse{Status: statusToProto(status)}, nil
+}
+
+// statusToProto converts the Status to its protobuf representation. A nil
+// status, which indicates the target does not exist, is preserved.
+func statusToProto(s *Status) *proto.Status {
+	if s == nil {
+		return nil
+	}
+	return &proto.Status{Ready: s.Ready, Count: s.Count, Meta: s.Meta}
+}
+
+// statusFromProto converts the protobuf representation of the target status
+// to a Status.
+func statusFromProto(s *proto.Status) *Status {
+	if s == nil {
+		return nil
+	}
+	return &Status{Ready: s.GetReady(), Count: s.GetCount(), Meta: s.GetMeta()}
+}
+
+func (s *GRPCServer) HealthCheck(ctx context.Context, _ *baseproto.HealthCheckRequest) (*baseproto.HealthCheckResponse, error) {
+	return base.HealthCheckGRPCServer(ctx, s.Impl)
+}
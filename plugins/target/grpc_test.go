@@ -0,0 +1,75 @@
+package target
+
+import (
+	"errors"
+	"testing"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/base"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTarget is a simple Target implementation which returns the configured
+// status and error.
+type testTarget struct {
+	status *Status
+	err    error
+	action strategy.Action
+}
+
+func (t *testTarget) Scale(action strategy.Action, _ map[string]string) error {
+	t.action = action
+	return t.err
+}
+func (t *testTarget) Status(_ map[string]string) (*Status, error) { return t.status, t.err }
+func (t *testTarget) SetConfig(_ map[string]string) error         { return nil }
+func (t *testTarget) PluginInfo() (*base.PluginInfo, error) {
+	return &base.PluginInfo{Name: "test", PluginType: "target"}, nil
+}
+
+func TestGRPCPlugin(t *testing.T) {
+	impl := &testTarget{status: &Status{Ready: true, Count: 2, Meta: map[string]string{"key": "value"}}}
+
+	client, server := plugin.TestPluginGRPCConn(t, map[string]plugin.Plugin{"target": &GRPCPlugin{Impl: impl}})
+	defer client.Close()
+	defer server.Stop()
+
+	raw, err := client.Dispense("target")
+	assert.Nil(t, err)
+	tgt := raw.(Target)
+
+	testTargetPlugin(t, impl, tgt)
+}
+
+// testTargetPlugin runs the calls shared by the RPC and gRPC plugin tests
+// against the plugin client, which is served by impl.
+func testTargetPlugin(t *testing.T, impl *testTarget, tgt Target) {
+
+	info, err := tgt.PluginInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, &base.PluginInfo{Name: "test", PluginType: "target"}, info)
+
+	status, err := tgt.Status(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, impl.status, status)
+
+	action := strategy.Action{Count: 3, Direction: strategy.ScaleDirectionUp, Reason: "up"}
+	assert.Nil(t, tgt.Scale(action, nil))
+	assert.Equal(t, action.Count, impl.action.Count)
+
+	// Errors returned by the plugin should be returned to the caller.
+	impl.err = errors.New("failed to scale")
+	assert.EqualError(t, tgt.Scale(action, nil), "failed to scale")
+
+	// Partial scaling actions should be returned to the caller along with the
+	// count the target reached, even when the message resembles another.
+	impl.err = &PartialScaleError{Count: 2, Err: errors.New("1 of 2 nodes drained: node failed")}
+	assert.Equal(t, impl.err, tgt.Scale(action, nil))
+
+	impl.err = errors.New("scaling action partially completed, target count is 2: failed")
+	err = tgt.Scale(action, nil)
+	_, ok := err.(*PartialScaleError)
+	assert.False(t, ok)
+	assert.EqualError(t, err, impl.err.Error())
+}
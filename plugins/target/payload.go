@@ -0,0 +1,65 @@
+package target
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+)
+
+// ActionPayload is the scaling action as sent to an external process, such as
+// a webhook or command, which performs the scaling of a target on behalf of
+// the autoscaler.
+type ActionPayload struct {
+	Count     int64                  `json:"count"`
+	Direction string                 `json:"direction"`
+	Reason    string                 `json:"reason"`
+	Error     bool                   `json:"error"`
+	Meta      map[string]interface{} `json:"meta"`
+}
+
+// NewActionPayload returns the payload of the scaling action.
+func NewActionPayload(action strategy.Action) *ActionPayload {
+	return &ActionPayload{
+		Count:     action.Count,
+		Direction: action.Direction.String(),
+		Reason:    action.Reason,
+		Error:     action.Error,
+		Meta:      action.Meta,
+	}
+}
+
+// StatusPayload is the status of a target as reported by an external process,
+// such as a webhook or command, which reads the state of a target on behalf of
+// the autoscaler.
+type StatusPayload struct {
+
+	// Count is the current count of the target.
+	Count int64 `json:"count"`
+
+	// Ready indicates the target is not currently changing and can therefore
+	// be scaled.
+	Ready bool `json:"ready"`
+
+	// LastEvent is the time, in nanoseconds since the Unix epoch, at which
+	// the target last finished changing. It is optional.
+	LastEvent int64 `json:"last_event,omitempty"`
+
+	// Meta is optional additional information about the target.
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Status converts the payload to the status of the target.
+func (s *StatusPayload) Status() *Status {
+	status := Status{
+		Ready: s.Ready,
+		Count: s.Count,
+		Meta:  make(map[string]string),
+	}
+	for k, v := range s.Meta {
+		status.Meta[k] = v
+	}
+	if s.LastEvent > 0 {
+		status.Meta[MetaKeyLastEvent] = strconv.FormatInt(s.LastEvent, 10)
+	}
+	return &status
+}
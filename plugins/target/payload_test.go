@@ -0,0 +1,61 @@
+package target
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewActionPayload(t *testing.T) {
+	action := strategy.Action{
+		Count:     4,
+		Direction: strategy.ScaleDirectionUp,
+		Reason:    "busy",
+		Meta:      map[string]interface{}{"region": "eu"},
+	}
+
+	assert.Equal(t, &ActionPayload{
+		Count:     4,
+		Direction: "up",
+		Reason:    "busy",
+		Meta:      map[string]interface{}{"region": "eu"},
+	}, NewActionPayload(action))
+}
+
+func TestStatusPayload_Status(t *testing.T) {
+	testCases := []struct {
+		inputPayload   StatusPayload
+		expectedStatus *Status
+		name           string
+	}{
+		{
+			inputPayload: StatusPayload{
+				Count:     5,
+				Ready:     true,
+				LastEvent: 1586765100000000000,
+				Meta:      map[string]string{"region": "eu"},
+			},
+			expectedStatus: &Status{
+				Ready: true,
+				Count: 5,
+				Meta: map[string]string{
+					"region":                      "eu",
+					"nomad_autoscaler.last_event": "1586765100000000000",
+				},
+			},
+			name: "full payload",
+		},
+		{
+			inputPayload:   StatusPayload{Count: 2},
+			expectedStatus: &Status{Count: 2, Meta: map[string]string{}},
+			name:           "minimal payload",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedStatus, tc.inputPayload.Status(), tc.name)
+		})
+	}
+}
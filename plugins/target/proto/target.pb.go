@@ -0,0 +1,585 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugins/target/proto/target.proto
+
+package proto
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	proto2 "github.com/hashicorp/nomad-autoscaler/plugins/base/proto"
+	proto1 "github.com/hashicorp/nomad-autoscaler/plugins/strategy/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ScaleRequest struct {
+	Action               *proto1.Action    `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Config               map[string]string `protobuf:"bytes,2,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ScaleRequest) Reset()         { *m = ScaleRequest{} }
+func (m *ScaleRequest) String() string { return proto.CompactTextString(m) }
+func (*ScaleRequest) ProtoMessage()    {}
+func (*ScaleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_24550554512c3a98, []int{0}
+}
+
+func (m *ScaleRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScaleRequest.Unmarshal(m, b)
+}
+func (m *ScaleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScaleRequest.Marshal(b, m, deterministic)
+}
+func (m *ScaleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScaleRequest.Merge(m, src)
+}
+func (m *ScaleRequest) XXX_Size() int {
+	return xxx_messageInfo_ScaleRequest.Size(m)
+}
+func (m *ScaleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScaleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScaleRequest proto.InternalMessageInfo
+
+func (m *ScaleRequest) GetAction() *proto1.Action {
+	if m != nil {
+		return m.Action
+	}
+	return nil
+}
+
+func (m *ScaleRequest) GetConfig() map[string]string {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type ScaleResponse struct {
+	// partial is set when the scaling action only partially completed. The
+	// action is then not returned as an error, so that the count the target
+	// reached can be reported.
+	Partial              *PartialScale `protobuf:"bytes,1,opt,name=partial,proto3" json:"partial,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ScaleResponse) Reset()         { *m = ScaleResponse{} }
+func (m *ScaleResponse) String() string { return proto.CompactTextString(m) }
+func (*ScaleResponse) ProtoMessage()    {}
+func (*ScaleResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_24550554512c3a98, []int{1}
+}
+
+func (m *ScaleResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScaleResponse.Unmarshal(m, b)
+}
+func (m *ScaleResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScaleResponse.Marshal(b, m, deterministic)
+}
+func (m *ScaleResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScaleResponse.Merge(m, src)
+}
+func (m *ScaleResponse) XXX_Size() int {
+	return xxx_messageInfo_ScaleResponse.Size(m)
+}
+func (m *ScaleResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScaleResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScaleResponse proto.InternalMessageInfo
+
+func (m *ScaleResponse) GetPartial() *PartialScale {
+	if m != nil {
+		return m.Partial
+	}
+	return nil
+}
+
+// PartialScale describes a scaling action which only partially completed.
+type PartialScale struct {
+	// count is the count of the target once the action ended.
+	Count int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	// error is the message of the error which ended the action.
+	Error                string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PartialScale) Reset()         { *m = PartialScale{} }
+func (m *PartialScale) String() string { return proto.CompactTextString(m) }
+func (*PartialScale) ProtoMessage()    {}
+func (*PartialScale) Descriptor() ([]byte, []int) {
+	return fileDescriptor_24550554512c3a98, []int{2}
+}
+
+func (m *PartialScale) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PartialScale.Unmarshal(m, b)
+}
+func (m *PartialScale) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PartialScale.Marshal(b, m, deterministic)
+}
+func (m *PartialScale) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PartialScale.Merge(m, src)
+}
+func (m *PartialScale) XXX_Size() int {
+	return xxx_messageInfo_PartialScale.Size(m)
+}
+func (m *PartialScale) XXX_DiscardUnknown() {
+	xxx_messageInfo_PartialScale.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PartialScale proto.InternalMessageInfo
+
+func (m *PartialScale) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *PartialScale) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type StatusRequest struct {
+	Config               map[string]string `protobuf:"bytes,1,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *StatusRequest) Reset()         { *m = StatusRequest{} }
+func (m *StatusRequest) String() string { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()    {}
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_24550554512c3a98, []int{3}
+}
+
+func (m *StatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusRequest.Unmarshal(m, b)
+}
+func (m *StatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusRequest.Marshal(b, m, deterministic)
+}
+func (m *StatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusRequest.Merge(m, src)
+}
+func (m *StatusRequest) XXX_Size() int {
+	return xxx_messageInfo_StatusRequest.Size(m)
+}
+func (m *StatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusRequest proto.InternalMessageInfo
+
+func (m *StatusRequest) GetConfig() map[string]string {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+type StatusResponse struct {
+	// status is the current status of the target. It is not set if the target
+	// no longer exists.
+	Status               *Status  `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusResponse) Reset()         { *m = StatusResponse{} }
+func (m *StatusResponse) String() string { return proto.CompactTextString(m) }
+func (*StatusResponse) ProtoMessage()    {}
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_24550554512c3a98, []int{4}
+}
+
+func (m *StatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusResponse.Unmarshal(m, b)
+}
+func (m *StatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusResponse.Marshal(b, m, deterministic)
+}
+func (m *StatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusResponse.Merge(m, src)
+}
+func (m *StatusResponse) XXX_Size() int {
+	return xxx_messageInfo_StatusResponse.Size(m)
+}
+func (m *StatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusResponse proto.InternalMessageInfo
+
+func (m *StatusResponse) GetStatus() *Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type Status struct {
+	Ready                bool              `protobuf:"varint,1,opt,name=ready,proto3" json:"ready,omitempty"`
+	Count                int64             `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Meta                 map[string]string `protobuf:"bytes,3,rep,name=meta,proto3" json:"meta,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Status) Reset()         { *m = Status{} }
+func (m *Status) String() string { return proto.CompactTextString(m) }
+func (*Status) ProtoMessage()    {}
+func (*Status) Descriptor() ([]byte, []int) {
+	return fileDescriptor_24550554512c3a98, []int{5}
+}
+
+func (m *Status) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Status.Unmarshal(m, b)
+}
+func (m *Status) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Status.Marshal(b, m, deterministic)
+}
+func (m *Status) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Status.Merge(m, src)
+}
+func (m *Status) XXX_Size() int {
+	return xxx_messageInfo_Status.Size(m)
+}
+func (m *Status) XXX_DiscardUnknown() {
+	xxx_messageInfo_Status.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Status proto.InternalMessageInfo
+
+func (m *Status) GetReady() bool {
+	if m != nil {
+		return m.Ready
+	}
+	return false
+}
+
+func (m *Status) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Status) GetMeta() map[string]string {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ScaleRequest)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.ScaleRequest")
+	proto.RegisterMapType((map[string]string)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.ScaleRequest.ConfigEntry")
+	proto.RegisterType((*ScaleResponse)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.ScaleResponse")
+	proto.RegisterType((*PartialScale)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.PartialScale")
+	proto.RegisterType((*StatusRequest)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.StatusRequest")
+	proto.RegisterMapType((map[string]string)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.StatusRequest.ConfigEntry")
+	proto.RegisterType((*StatusResponse)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.StatusResponse")
+	proto.RegisterType((*Status)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.Status")
+	proto.RegisterMapType((map[string]string)(nil), "hashicorp.nomad_autoscaler.plugins.target.proto.Status.MetaEntry")
+}
+
+func init() { proto.RegisterFile("plugins/target/proto/target.proto", fileDescriptor_24550554512c3a98) }
+
+var fileDescriptor_24550554512c3a98 = []byte{
+	// 540 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x95, 0xdf, 0x8a, 0xd3, 0x40,
+	0x14, 0xc6, 0x9d, 0xd6, 0xad, 0xf6, 0xb4, 0x2b, 0x32, 0x78, 0x51, 0x02, 0xc2, 0x1a, 0x10, 0xf6,
+	0xc6, 0x29, 0xd4, 0x8b, 0x75, 0x8b, 0xfb, 0xa7, 0xbb, 0x08, 0xae, 0x20, 0xae, 0xa9, 0x22, 0x78,
+	0x23, 0xd3, 0xec, 0x6c, 0x13, 0x36, 0xcd, 0xc4, 0x99, 0x89, 0xd0, 0x37, 0x10, 0xbc, 0x11, 0x04,
+	0x1f, 0xc3, 0xa7, 0xf0, 0x65, 0x7c, 0x0a, 0x25, 0xf3, 0x27, 0x89, 0xe0, 0xc5, 0x26, 0xf5, 0xaa,
+	0x73, 0xce, 0xe4, 0xfb, 0xcd, 0x37, 0xdf, 0x74, 0x12, 0x78, 0x90, 0x25, 0xf9, 0x32, 0x4e, 0xe5,
+	0x58, 0x51, 0xb1, 0x64, 0x6a, 0x9c, 0x09, 0xae, 0xb8, 0x2d, 0x88, 0x2e, 0xf0, 0x38, 0xa2, 0x32,
+	0x8a, 0x43, 0x2e, 0x32, 0x92, 0xf2, 0x15, 0xbd, 0xf8, 0x40, 0x73, 0xc5, 0x65, 0x48, 0x13, 0x26,
+	0x88, 0x55, 0x93, 0xba, 0xc0, 0xbb, 0xef, 0x98, 0x0b, 0x2a, 0x99, 0x25, 0x16, 0x43, 0x3b, 0xfd,
+	0xd0, 0x4d, 0x4b, 0x25, 0xa8, 0x62, 0xcb, 0xb5, 0x7d, 0xc4, 0x95, 0xe6, 0x31, 0xff, 0x37, 0x82,
+	0xe1, 0xbc, 0x58, 0x25, 0x60, 0x1f, 0x73, 0x26, 0x15, 0x7e, 0x0d, 0x3d, 0x1a, 0xaa, 0x98, 0xa7,
+	0x23, 0xb4, 0x83, 0x76, 0x07, 0x93, 0x7d, 0x72, 0x0d, 0x63, 0x7f, 0x43, 0xc9, 0x4c, 0x03, 0x02,
+	0x0b, 0xc2, 0x14, 0x7a, 0x21, 0x4f, 0x2f, 0xe3, 0xe5, 0xa8, 0xb3, 0xd3, 0xdd, 0x1d, 0x4c, 0xce,
+	0x48, 0xc3, 0xbd, 0x92, 0xba, 0x43, 0x72, 0xaa, 0x59, 0xcf, 0x52, 0x25, 0xd6, 0x81, 0x05, 0x7b,
+	0xfb, 0x30, 0xa8, 0xb5, 0xf1, 0x5d, 0xe8, 0x5e, 0xb1, 0xb5, 0xde, 0x41, 0x3f, 0x28, 0x86, 0xf8,
+	0x1e, 0x6c, 0x7d, 0xa2, 0x49, 0xce, 0x46, 0x1d, 0xdd, 0x33, 0xc5, 0xb4, 0xf3, 0x04, 0xf9, 0x11,
+	0x6c, 0x5b, 0xbc, 0xcc, 0x78, 0x2a, 0x19, 0x7e, 0x07, 0xb7, 0x32, 0x2a, 0x54, 0x4c, 0x13, 0x1b,
+	0xc1, 0x41, 0x63, 0xbf, 0xe7, 0x46, 0x6f, 0xb8, 0x8e, 0xe6, 0x4f, 0x61, 0x58, 0x9f, 0x28, 0x3c,
+	0x85, 0x3c, 0x4f, 0x95, 0x5e, 0xa6, 0x1b, 0x98, 0xa2, 0xe8, 0x32, 0x21, 0xb8, 0x70, 0x4e, 0x75,
+	0xe1, 0xff, 0x40, 0xb0, 0x3d, 0x57, 0x54, 0xe5, 0xd2, 0x1d, 0xd4, 0xa2, 0x4c, 0x15, 0xe9, 0x54,
+	0x5f, 0x34, 0x4f, 0xb5, 0xce, 0xfb, 0xdf, 0xb1, 0x52, 0xb8, 0xe3, 0xf8, 0x36, 0xd7, 0x57, 0xd0,
+	0x93, 0xba, 0x63, 0x63, 0xdd, 0x6b, 0x6b, 0xd8, 0x62, 0xfc, 0x9f, 0x08, 0x7a, 0xa6, 0x55, 0xf8,
+	0x10, 0x8c, 0x5e, 0x18, 0x6f, 0xb7, 0x03, 0x53, 0x54, 0x01, 0x77, 0xea, 0x01, 0xbf, 0x85, 0x9b,
+	0x2b, 0xa6, 0xe8, 0xa8, 0xab, 0x63, 0x9b, 0xb5, 0x74, 0x41, 0x5e, 0x32, 0x45, 0x4d, 0x5a, 0x1a,
+	0xe7, 0xed, 0x41, 0xbf, 0x6c, 0x35, 0x49, 0x6a, 0xf2, 0x6b, 0x0b, 0x86, 0x6f, 0xf4, 0x02, 0xe7,
+	0x7a, 0x39, 0xfc, 0x0d, 0x01, 0x98, 0xe1, 0x59, 0x7a, 0xc9, 0xf1, 0xf1, 0x75, 0x1c, 0x56, 0x37,
+	0x9f, 0x54, 0x52, 0x7b, 0xb4, 0xde, 0x6c, 0x03, 0x82, 0x39, 0x3c, 0xff, 0x06, 0xfe, 0x8a, 0xa0,
+	0x3f, 0x67, 0xca, 0xfc, 0x1f, 0xf0, 0x51, 0x43, 0x64, 0xa9, 0x74, 0x9e, 0x8e, 0xdb, 0x03, 0x4a,
+	0x4b, 0xdf, 0x11, 0x0c, 0x9e, 0x33, 0x9a, 0xa8, 0xe8, 0x34, 0x62, 0xe1, 0x15, 0x6e, 0xba, 0xcf,
+	0x9a, 0xd6, 0xd9, 0x3a, 0xd9, 0x04, 0x51, 0x1a, 0xfb, 0x8c, 0x60, 0xcb, 0xdc, 0xf1, 0x83, 0x8d,
+	0xde, 0x75, 0xde, 0x61, 0x5b, 0x79, 0x69, 0xe5, 0x4b, 0x75, 0x49, 0x0e, 0x37, 0x7b, 0x43, 0x78,
+	0x47, 0xad, 0xf5, 0xce, 0xcd, 0xc9, 0xd3, 0xf7, 0xd3, 0x65, 0xac, 0xa2, 0x7c, 0x41, 0x42, 0xbe,
+	0xaa, 0x3e, 0x79, 0x63, 0x8d, 0x7b, 0x54, 0xe1, 0xc6, 0xff, 0xfa, 0x60, 0x2e, 0x7a, 0xfa, 0xe7,
+	0xf1, 0x9f, 0x01, 0x00, 0xba, 0x30, 0x06, 0x74, 0x4f, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// TargetPluginClient is the client API for TargetPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type TargetPluginClient interface {
+	PluginInfo(ctx context.Context, in *proto2.PluginInfoRequest, opts ...grpc.CallOption) (*proto2.PluginInfoResponse, error)
+	SetConfig(ctx context.Context, in *proto2.SetConfigRequest, opts ...grpc.CallOption) (*proto2.SetConfigResponse, error)
+	// HealthCheck checks the health of the plugin and its dependencies. Plugins
+	// which do not implement health checks should return UNIMPLEMENTED.
+	HealthCheck(ctx context.Context, in *proto2.HealthCheckRequest, opts ...grpc.CallOption) (*proto2.HealthCheckResponse, error)
+	// Scale performs the scaling action against the target.
+	Scale(ctx context.Context, in *ScaleRequest, opts ...grpc.CallOption) (*ScaleResponse, error)
+	// Status returns the current status of the target.
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+}
+
+type targetPluginClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewTargetPluginClient(cc *grpc.ClientConn) TargetPluginClient {
+	return &targetPluginClient{cc}
+}
+
+func (c *targetPluginClient) PluginInfo(ctx context.Context, in *proto2.PluginInfoRequest, opts ...grpc.CallOption) (*proto2.PluginInfoResponse, error) {
+	out := new(proto2.PluginInfoResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/PluginInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *targetPluginClient) SetConfig(ctx context.Context, in *proto2.SetConfigRequest, opts ...grpc.CallOption) (*proto2.SetConfigResponse, error) {
+	out := new(proto2.SetConfigResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/SetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *targetPluginClient) HealthCheck(ctx context.Context, in *proto2.HealthCheckRequest, opts ...grpc.CallOption) (*proto2.HealthCheckResponse, error) {
+	out := new(proto2.HealthCheckResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/HealthCheck", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *targetPluginClient) Scale(ctx context.Context, in *ScaleRequest, opts ...grpc.CallOption) (*ScaleResponse, error) {
+	out := new(ScaleResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/Scale", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *targetPluginClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TargetPluginServer is the server API for TargetPlugin service.
+type TargetPluginServer interface {
+	PluginInfo(context.Context, *proto2.PluginInfoRequest) (*proto2.PluginInfoResponse, error)
+	SetConfig(context.Context, *proto2.SetConfigRequest) (*proto2.SetConfigResponse, error)
+	// HealthCheck checks the health of the plugin and its dependencies. Plugins
+	// which do not implement health checks should return UNIMPLEMENTED.
+	HealthCheck(context.Context, *proto2.HealthCheckRequest) (*proto2.HealthCheckResponse, error)
+	// Scale performs the scaling action against the target.
+	Scale(context.Context, *ScaleRequest) (*ScaleResponse, error)
+	// Status returns the current status of the target.
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+}
+
+// UnimplementedTargetPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedTargetPluginServer struct {
+}
+
+func (*UnimplementedTargetPluginServer) PluginInfo(ctx context.Context, req *proto2.PluginInfoRequest) (*proto2.PluginInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PluginInfo not implemented")
+}
+func (*UnimplementedTargetPluginServer) SetConfig(ctx context.Context, req *proto2.SetConfigRequest) (*proto2.SetConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
+}
+func (*UnimplementedTargetPluginServer) HealthCheck(ctx context.Context, req *proto2.HealthCheckRequest) (*proto2.HealthCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
+}
+func (*UnimplementedTargetPluginServer) Scale(ctx context.Context, req *ScaleRequest) (*ScaleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scale not implemented")
+}
+func (*UnimplementedTargetPluginServer) Status(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+
+func RegisterTargetPluginServer(s *grpc.Server, srv TargetPluginServer) {
+	s.RegisterService(&_TargetPlugin_serviceDesc, srv)
+}
+
+func _TargetPlugin_PluginInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto2.PluginInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TargetPluginServer).PluginInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/PluginInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TargetPluginServer).PluginInfo(ctx, req.(*proto2.PluginInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TargetPlugin_SetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto2.SetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TargetPluginServer).SetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/SetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TargetPluginServer).SetConfig(ctx, req.(*proto2.SetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TargetPlugin_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(proto2.HealthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TargetPluginServer).HealthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/HealthCheck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TargetPluginServer).HealthCheck(ctx, req.(*proto2.HealthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TargetPlugin_Scale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScaleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TargetPluginServer).Scale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/Scale",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TargetPluginServer).Scale(ctx, req.(*ScaleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TargetPlugin_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TargetPluginServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TargetPluginServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _TargetPlugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "hashicorp.nomad_autoscaler.plugins.target.proto.TargetPlugin",
+	HandlerType: (*TargetPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PluginInfo",
+			Handler:    _TargetPlugin_PluginInfo_Handler,
+		},
+		{
+			MethodName: "SetConfig",
+			Handler:    _TargetPlugin_SetConfig_Handler,
+		},
+		{
+			MethodName: "HealthCheck",
+			Handler:    _TargetPlugin_HealthCheck_Handler,
+		},
+		{
+			MethodName: "Scale",
+			Handler:    _TargetPlugin_Scale_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _TargetPlugin_Status_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugins/target/proto/target.proto",
+}
@@ -1,7 +1,11 @@
 package target
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/rpc"
+	"time"
 
 	plugin "github.com/hashicorp/go-plugin"
 	"github.com/hashicorp/nomad-autoscaler/plugins/base"
@@ -15,18 +19,114 @@ type Target interface {
 	SetConfig(config map[string]string) error
 }
 
+// ContextTarget is an optional extension of the Target interface. Target
+// plugins which implement it receive the context of the policy evaluation,
+// allowing them to stop work once the evaluation is cancelled or its timeout
+// is reached. This is particularly important for targets which perform long
+// running actions such as draining nodes.
+type ContextTarget interface {
+	Target
+	ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error
+	StatusContext(ctx context.Context, config map[string]string) (*Status, error)
+}
+
+// ScaleWithContext scales the target using the passed context. If the target
+// does not implement the ContextTarget interface, the call returns once the
+// context is done without waiting for the scaling action to finish.
+func ScaleWithContext(ctx context.Context, t Target, action strategy.Action, config map[string]string) error {
+	if c, ok := t.(ContextTarget); ok {
+		return c.ScaleContext(ctx, action, config)
+	}
+	return base.RunWithContext(ctx, func() error { return t.Scale(action, config) })
+}
+
+// StatusWithContext reads the target status using the passed context. If the
+// target does not implement the ContextTarget interface, the call returns once
+// the context is done without waiting for the status call to finish.
+func StatusWithContext(ctx context.Context, t Target, config map[string]string) (*Status, error) {
+	if c, ok := t.(ContextTarget); ok {
+		return c.StatusContext(ctx, config)
+	}
+
+	var resp *Status
+	err := base.RunWithContext(ctx, func() error {
+		s, err := t.Status(config)
+		resp = s
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Assert that RPC meets the ContextTarget and base.HealthChecker interfaces.
+var (
+	_ ContextTarget      = (*RPC)(nil)
+	_ base.HealthChecker = (*RPC)(nil)
+)
+
 type Status struct {
 	Ready bool
 	Count int64
 	Meta  map[string]string
 }
 
+// PartialScaleError is returned by targets when a scaling action only
+// partially completed, such as when some of the nodes of a cluster failed to
+// drain. Count is the count of the target once the action ended, allowing the
+// autoscaler to account for the part of the action which did complete.
+type PartialScaleError struct {
+	Count int64
+	Err   error
+}
+
+func (e *PartialScaleError) Error() string {
+	return fmt.Sprintf("scaling action partially completed, target count is %d: %v", e.Count, e.Err)
+}
+
+// RPCPartialScale describes a scaling action which only partially completed,
+// so that a PartialScaleError can be sent across the plugin boundary.
+type RPCPartialScale struct {
+	Count int64
+	Error string
+}
+
+// partialScaleFromError returns the RPCPartialScale describing the error if it
+// is a PartialScaleError, otherwise nil.
+func partialScaleFromError(err error) *RPCPartialScale {
+	perr, ok := err.(*PartialScaleError)
+	if !ok {
+		return nil
+	}
+
+	p := RPCPartialScale{Count: perr.Count}
+	if perr.Err != nil {
+		p.Error = perr.Err.Error()
+	}
+	return &p
+}
+
+// toError converts the RPCPartialScale back into a PartialScaleError.
+func (p *RPCPartialScale) toError() error {
+	return &PartialScaleError{Count: p.Count, Err: errors.New(p.Error)}
+}
+
 // MetaKeyLastEvent is an optional meta key that can be added to the status
 // return. The value represents the last scaling event of the target as seen by
 // the remote providers view point. This helps enforce cooldown where
 // out-of-band scaling activities have been triggered.
 const MetaKeyLastEvent = "nomad_autoscaler.last_event"
 
+// MetaKeyMinCount and MetaKeyMaxCount are optional meta keys that can be added
+// to the status return. The values represent limits the remote provider
+// places on the count of the target, such as the min and max of a Nomad job
+// scaling block, and further restrict the limits of the policy.
+const (
+	MetaKeyMinCount = "nomad_autoscaler.min_count"
+	MetaKeyMaxCount = "nomad_autoscaler.max_count"
+)
+
 const (
 	// ConfigKeys are the various target config map keys that can be used to
 	// identify a target.
@@ -34,6 +134,25 @@ const (
 	ConfigKeyTaskGroup     = "Group"
 	ConfigKeyClass         = "node_class"
 	ConfigKeyDrainDeadline = "node_drain_deadline"
+
+	// ConfigKeyDrainIgnoreSystemJobs, ConfigKeyDrainNoForce and
+	// ConfigKeyIneligibleWait are optional target config keys which control
+	// how the nodes of a cluster target are drained when scaling in.
+	ConfigKeyDrainIgnoreSystemJobs = "node_drain_ignore_system_jobs"
+	ConfigKeyDrainNoForce          = "node_drain_no_force"
+	ConfigKeyIneligibleWait        = "node_ineligible_wait"
+
+	// ConfigKeyDatacenter, and keys with the ConfigKeyPrefixNodeMeta and
+	// ConfigKeyPrefixAttribute prefixes, identify the nodes of a cluster
+	// target alongside, or instead of, ConfigKeyClass. A node must match all
+	// of the keys set to form part of the target.
+	ConfigKeyDatacenter      = "datacenter"
+	ConfigKeyPrefixNodeMeta  = "node_meta."
+	ConfigKeyPrefixAttribute = "attribute."
+
+	// ConfigKeyNodeSelectionStrategy is the target config key which sets the
+	// strategy used to select nodes for removal when scaling in a cluster.
+	ConfigKeyNodeSelectionStrategy = "node_selection_strategy"
 )
 
 // RPC is a plugin implementation that talks over net/rpc
@@ -44,6 +163,24 @@ type RPC struct {
 type RPCScaleRequest struct {
 	Action strategy.Action
 	Config map[string]string
+
+	// Deadline is the deadline of the caller's context and is only set when
+	// calling ScaleContext.
+	Deadline time.Time
+}
+
+// RPCScaleResponse is the response of a scaling action via RPC. When the
+// action only partially completed, Partial is set rather than an error being
+// returned, so that the count the target reached is reported.
+type RPCScaleResponse struct {
+	Partial *RPCPartialScale
+}
+
+// RPCStatusRequest is the request used when reading the target status with a
+// context via RPC. The context deadline is sent so the plugin can honour it.
+type RPCStatusRequest struct {
+	Config   map[string]string
+	Deadline time.Time
 }
 
 func (r *RPC) SetConfig(config map[string]string) error {
@@ -68,23 +205,97 @@ func (r *RPC) Status(config map[string]string) (*Status, error) {
 }
 
 func (r *RPC) Scale(action strategy.Action, config map[string]string) error {
+	return r.ScaleContext(context.Background(), action, config)
+}
+
+func (r *RPC) StatusContext(ctx context.Context, config map[string]string) (*Status, error) {
+	var resp Status
+	req := RPCStatusRequest{Config: config, Deadline: base.Deadline(ctx)}
+
+	err := base.CallContext(ctx, r.client, "Plugin.StatusContext", req, &resp)
+	if base.IsRPCMethodNotFound(err) {
+
+		// The plugin was built against a version of the interface without
+		// context support, so fallback to the standard status call.
+		var status *Status
+		err = base.RunWithContext(ctx, func() error {
+			s, err := r.Status(config)
+			status = s
+			return err
+		})
+		return status, err
+	}
+	return &resp, err
+}
+
+func (r *RPC) ScaleContext(ctx context.Context, action strategy.Action, config map[string]string) error {
+	var resp RPCScaleResponse
+	req := RPCScaleRequest{
+		Action:   action,
+		Config:   config,
+		Deadline: base.Deadline(ctx),
+	}
+
+	err := base.CallContext(ctx, r.client, "Plugin.ScaleWithResult", req, &resp)
+	if base.IsRPCMethodNotFound(err) {
+
+		// The plugin was built against a version of the interface which
+		// cannot report partial scaling actions, so fallback to the previous
+		// scale calls.
+		return r.scaleContextLegacy(ctx, action, config)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Partial != nil {
+		return resp.Partial.toError()
+	}
+	return nil
+}
+
+// scaleContextLegacy scales the target using the calls of plugins built
+// before ScaleWithResult was added.
+func (r *RPC) scaleContextLegacy(ctx context.Context, action strategy.Action, config map[string]string) error {
 	var resp error
 	req := RPCScaleRequest{
-		Action: action,
-		Config: config,
+		Action:   action,
+		Config:   config,
+		Deadline: base.Deadline(ctx),
+	}
+
+	err := base.CallContext(ctx, r.client, "Plugin.ScaleContext", req, &resp)
+	if base.IsRPCMethodNotFound(err) {
+
+		// The plugin was built against a version of the interface without
+		// context support, so fallback to the standard scale call.
+		return base.RunWithContext(ctx, func() error {
+			var resp error
+			err := r.client.Call("Plugin.Scale", RPCScaleRequest{Action: action, Config: config}, &resp)
+			if err != nil {
+				return err
+			}
+			return resp
+		})
 	}
-	err := r.client.Call("Plugin.Scale", req, &resp)
 	if err != nil {
 		return err
 	}
 	return resp
 }
 
+func (r *RPC) HealthCheck(ctx context.Context) error {
+	return base.HealthCheckRPC(ctx, r.client)
+}
+
 // RPCServer is the net/rpc server
 type RPCServer struct {
 	Impl Target
 }
 
+func (s *RPCServer) HealthCheck(req base.RPCHealthCheckRequest, _ *struct{}) error {
+	return base.HealthCheckRPCServer(req, s.Impl)
+}
+
 func (s *RPCServer) SetConfig(config map[string]string, resp *error) error {
 	err := s.Impl.SetConfig(config)
 	*resp = err
@@ -112,6 +323,37 @@ func (s *RPCServer) Scale(req RPCScaleRequest, resp *error) error {
 	return err
 }
 
+func (s *RPCServer) StatusContext(req RPCStatusRequest, resp *Status) error {
+	ctx, cancel := base.ContextWithDeadline(req.Deadline)
+	defer cancel()
+
+	status, err := StatusWithContext(ctx, s.Impl, req.Config)
+	if status != nil {
+		*resp = *status
+	}
+	return err
+}
+
+func (s *RPCServer) ScaleContext(req RPCScaleRequest, resp *error) error {
+	ctx, cancel := base.ContextWithDeadline(req.Deadline)
+	defer cancel()
+
+	return ScaleWithContext(ctx, s.Impl, req.Action, req.Config)
+}
+
+// ScaleWithResult scales the target, reporting a partial scaling action
+// within the response rather than as an error.
+func (s *RPCServer) ScaleWithResult(req RPCScaleRequest, resp *RPCScaleResponse) error {
+	ctx, cancel := base.ContextWithDeadline(req.Deadline)
+	defer cancel()
+
+	err := ScaleWithContext(ctx, s.Impl, req.Action, req.Config)
+	if resp.Partial = partialScaleFromError(err); resp.Partial != nil {
+		return nil
+	}
+	return err
+}
+
 // Plugin is the plugin.Plugin
 type Plugin struct {
 	Impl Target
@@ -0,0 +1,61 @@
+package target
+
+import (
+	"errors"
+	"testing"
+
+	plugin "github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad-autoscaler/plugins/strategy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlugin(t *testing.T) {
+	impl := &testTarget{status: &Status{Ready: true, Count: 2, Meta: map[string]string{"key": "value"}}}
+
+	client, _ := plugin.TestPluginRPCConn(t, map[string]plugin.Plugin{"target": &Plugin{Impl: impl}}, nil)
+	defer client.Close()
+
+	raw, err := client.Dispense("target")
+	assert.Nil(t, err)
+	tgt := raw.(Target)
+
+	testTargetPlugin(t, impl, tgt)
+}
+
+// legacyPlugin serves the target without the ScaleWithResult call, as plugins
+// built against a previous version of the interface do.
+type legacyPlugin struct {
+	Plugin
+}
+
+// legacyRPCServer only exposes the scale call of a previous version of the
+// interface.
+type legacyRPCServer struct {
+	server *RPCServer
+}
+
+func (s *legacyRPCServer) ScaleContext(req RPCScaleRequest, resp *error) error {
+	return s.server.ScaleContext(req, resp)
+}
+
+func (p *legacyPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &legacyRPCServer{server: &RPCServer{Impl: p.Impl}}, nil
+}
+
+func TestPlugin_legacyScale(t *testing.T) {
+	impl := &testTarget{}
+
+	client, _ := plugin.TestPluginRPCConn(t, map[string]plugin.Plugin{"target": &legacyPlugin{Plugin{Impl: impl}}}, nil)
+	defer client.Close()
+
+	raw, err := client.Dispense("target")
+	assert.Nil(t, err)
+	tgt := raw.(Target)
+
+	action := strategy.Action{Count: 3}
+	assert.Nil(t, tgt.Scale(action, nil))
+	assert.Equal(t, action.Count, impl.action.Count)
+
+	impl.err = errors.New("failed to scale")
+	assert.EqualError(t, tgt.Scale(action, nil), "failed to scale")
+}
@@ -31,6 +31,22 @@ func decodeFile(file string, p *policy.Policy) error {
 		decodePolicy.Doc.EvaluationInterval = d
 	}
 
+	if decodePolicy.Doc.EvaluationTimeoutHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.EvaluationTimeoutHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.EvaluationTimeout = d
+	}
+
+	if decodePolicy.Doc.ScaleTimeoutHCL != "" {
+		d, err := time.ParseDuration(decodePolicy.Doc.ScaleTimeoutHCL)
+		if err != nil {
+			return err
+		}
+		decodePolicy.Doc.ScaleTimeout = d
+	}
+
 	// Translate from our intermediate struct, to our internal flattened
 	// policy.
 	decodePolicy.Translate(p)
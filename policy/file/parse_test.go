@@ -26,6 +26,8 @@ func Test_decodeFile(t *testing.T) {
 				Max:                100,
 				Cooldown:           10 * time.Minute,
 				EvaluationInterval: 1 * time.Minute,
+				EvaluationTimeout:  2 * time.Minute,
+				ScaleTimeout:       45 * time.Minute,
 				Checks: []*policy.Check{
 					{
 						Name:   "cpu_nomad",
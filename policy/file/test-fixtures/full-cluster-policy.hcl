@@ -6,6 +6,8 @@ policy {
 
   cooldown            = "10m"
   evaluation_interval = "1m"
+  evaluation_timeout  = "2m"
+  scale_timeout       = "45m"
 
   check "cpu_nomad" {
     source    = "nomad_apm"
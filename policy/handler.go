@@ -186,7 +186,7 @@ func (h *Handler) handleTick(ctx context.Context, policy *Policy) (*Evaluation,
 	// consistency.
 	curTime := time.Now().UTC().UnixNano()
 
-	eval, err := h.generateEvaluation(policy)
+	eval, err := h.generateEvaluation(ctx, policy)
 	if err != nil {
 		return nil, err
 	}
@@ -236,7 +236,7 @@ func (h *Handler) handleTick(ctx context.Context, policy *Policy) (*Evaluation,
 
 // generateEvaluation returns an evaluation if the policy needs to be evaluated.
 // Returning an error will stop the handler.
-func (h *Handler) generateEvaluation(policy *Policy) (*Evaluation, error) {
+func (h *Handler) generateEvaluation(ctx context.Context, policy *Policy) (*Evaluation, error) {
 	h.log.Trace("tick")
 
 	if policy == nil {
@@ -256,6 +256,7 @@ func (h *Handler) generateEvaluation(policy *Policy) (*Evaluation, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer h.pluginManager.Release(targetPlugin)
 
 	targetInst, ok := targetPlugin.Plugin().(targetpkg.Target)
 	if !ok {
@@ -266,7 +267,10 @@ func (h *Handler) generateEvaluation(policy *Policy) (*Evaluation, error) {
 	// Get target status.
 	h.log.Trace("getting target status")
 
-	status, err := targetInst.Status(policy.Target.Config)
+	statusCtx, cancel := withTimeout(ctx, policy.EvaluationTimeout)
+	defer cancel()
+
+	status, err := targetpkg.StatusWithContext(statusCtx, targetInst, policy.Target.Config)
 	if err != nil {
 		h.log.Warn("failed to get target status", "error", err)
 		return nil, nil
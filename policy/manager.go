@@ -2,6 +2,7 @@ package policy
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -24,6 +25,20 @@ type Manager struct {
 
 	// keep is used to mark active policies during reconciliation.
 	keep map[PolicyID]bool
+
+	// skipped tracks the policies whose most recent evaluation was skipped
+	// and is synchronized using skippedLock.
+	skippedLock sync.RWMutex
+	skipped     map[PolicyID]SkippedPolicy
+}
+
+// SkippedPolicy describes a policy whose most recent evaluation was skipped
+// and the reason why.
+type SkippedPolicy struct {
+	ID     string    `json:"id"`
+	Target string    `json:"target"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
 }
 
 // NewManager returns a new Manager.
@@ -34,6 +49,7 @@ func NewManager(log hclog.Logger, ps map[SourceName]Source, pm *manager.PluginMa
 		pluginManager: pm,
 		handlers:      make(map[PolicyID]*Handler),
 		keep:          make(map[PolicyID]bool),
+		skipped:       make(map[PolicyID]SkippedPolicy),
 	}
 }
 
@@ -108,6 +124,7 @@ LOOP:
 					m.lock.Lock()
 					delete(m.handlers, ID)
 					m.lock.Unlock()
+					m.clearSkipped(string(ID))
 				}(policyID)
 			}
 
@@ -160,6 +177,42 @@ func (m *Manager) stopHandler(h *Handler) {
 
 	h.Stop()
 	delete(m.handlers, h.policyID)
+	m.clearSkipped(string(h.policyID))
+}
+
+// SkippedPolicies returns the policies whose most recent evaluation was
+// skipped, sorted by ID.
+func (m *Manager) SkippedPolicies() []SkippedPolicy {
+	m.skippedLock.RLock()
+	defer m.skippedLock.RUnlock()
+
+	out := make([]SkippedPolicy, 0, len(m.skipped))
+	for _, s := range m.skipped {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// setSkipped records that the evaluation of the policy was skipped.
+func (m *Manager) setSkipped(p *Policy, reason string) {
+	m.skippedLock.Lock()
+	defer m.skippedLock.Unlock()
+
+	m.skipped[PolicyID(p.ID)] = SkippedPolicy{
+		ID:     p.ID,
+		Target: p.Target.Name,
+		Reason: reason,
+		Time:   time.Now(),
+	}
+}
+
+// clearSkipped removes the skipped status of the policy, if any.
+func (m *Manager) clearSkipped(id string) {
+	m.skippedLock.Lock()
+	defer m.skippedLock.Unlock()
+
+	delete(m.skipped, PolicyID(id))
 }
 
 // EnforceCooldown attempts to enforce cooldown on the policy handler
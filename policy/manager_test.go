@@ -0,0 +1,32 @@
+package policy
+
+import (
+	"testing"
+
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_skippedPolicies(t *testing.T) {
+	m := NewManager(hclog.NewNullLogger(), nil, nil)
+	assert.Empty(t, m.SkippedPolicies())
+
+	m.setSkipped(&Policy{ID: "policy2", Target: &Target{Name: "target"}}, "plugin unhealthy")
+	m.setSkipped(&Policy{ID: "policy1", Target: &Target{Name: "target"}}, "plugin unhealthy")
+
+	actual := m.SkippedPolicies()
+	if assert.Len(t, actual, 2) {
+		assert.Equal(t, "policy1", actual[0].ID)
+		assert.Equal(t, "target", actual[0].Target)
+		assert.Equal(t, "plugin unhealthy", actual[0].Reason)
+		assert.Equal(t, "policy2", actual[1].ID)
+	}
+
+	// A successful evaluation clears the skipped status.
+	m.clearSkipped("policy2")
+	assert.Len(t, m.SkippedPolicies(), 1)
+
+	// A stopped handler clears the skipped status of its policy.
+	m.stopHandler(&Handler{policyID: "policy1"})
+	assert.Empty(t, m.SkippedPolicies())
+}
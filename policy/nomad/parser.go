@@ -46,6 +46,18 @@ func parsePolicy(p *api.ScalingPolicy) policy.Policy {
 		to.Cooldown, _ = time.ParseDuration(cooldown)
 	}
 
+	// Parse evaluation_timeout as time.Duration.
+	// Ignore error since we assume policy has been validated.
+	if evalTimeout, ok := p.Policy[keyEvaluationTimeout].(string); ok {
+		to.EvaluationTimeout, _ = time.ParseDuration(evalTimeout)
+	}
+
+	// Parse scale_timeout as time.Duration.
+	// Ignore error since we assume policy has been validated.
+	if scaleTimeout, ok := p.Policy[keyScaleTimeout].(string); ok {
+		to.ScaleTimeout, _ = time.ParseDuration(scaleTimeout)
+	}
+
 	// Parse target block.
 	var target *policy.Target
 
@@ -23,6 +23,8 @@ const (
 	keyChecks             = "check"
 	keyStrategy           = "strategy"
 	keyCooldown           = "cooldown"
+	keyEvaluationTimeout  = "evaluation_timeout"
+	keyScaleTimeout       = "scale_timeout"
 )
 
 // Ensure NomadSource satisfies the Source interface.
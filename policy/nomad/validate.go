@@ -96,6 +96,22 @@ func validatePolicy(p map[string]interface{}) error {
 		}
 	}
 
+	// Validate EvaluationTimeout, if present.
+	//   1. EvaluationTimeout should be a valid duration.
+	if evalTimeout, ok := p[keyEvaluationTimeout]; ok {
+		if err := validateDuration(evalTimeout, path+"."+keyEvaluationTimeout); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Validate ScaleTimeout, if present.
+	//   1. ScaleTimeout should be a valid duration.
+	if scaleTimeout, ok := p[keyScaleTimeout]; ok {
+		if err := validateDuration(scaleTimeout, path+"."+keyScaleTimeout); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
 	// Validate Target, if present.
 	if targetInterface, ok := p[keyTarget]; ok {
 		err := validateBlocks(targetInterface, path+"."+keyTarget, validateTarget)
@@ -18,6 +18,8 @@ type Policy struct {
 	Enabled            bool
 	Cooldown           time.Duration
 	EvaluationInterval time.Duration
+	EvaluationTimeout  time.Duration
+	ScaleTimeout       time.Duration
 	Checks             []*Check
 	Target             *Target
 }
@@ -54,6 +56,12 @@ func (p *Policy) ApplyDefaults(d *ConfigDefaults) {
 	if p.EvaluationInterval == 0 {
 		p.EvaluationInterval = d.DefaultEvaluationInterval
 	}
+	if p.EvaluationTimeout == 0 {
+		p.EvaluationTimeout = d.DefaultEvaluationTimeout
+	}
+	if p.ScaleTimeout == 0 {
+		p.ScaleTimeout = d.DefaultScaleTimeout
+	}
 }
 
 // Validate performs validation of the policy document returning a list of
@@ -74,6 +82,12 @@ func (p *Policy) Validate() error {
 	if p.Min > p.Max {
 		mErr = multierror.Append(mErr, fmt.Errorf("policy Min must not be greater Max"))
 	}
+	if p.EvaluationTimeout < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy EvaluationTimeout can't be negative"))
+	}
+	if p.ScaleTimeout < 0 {
+		mErr = multierror.Append(mErr, fmt.Errorf("policy ScaleTimeout can't be negative"))
+	}
 
 	return mErr.ErrorOrNil()
 }
@@ -166,7 +180,11 @@ type FileDecodePolicyDoc struct {
 	Cooldown              time.Duration
 	CooldownHCL           string `hcl:"cooldown,optional"`
 	EvaluationInterval    time.Duration
-	EvaluationIntervalHCL string   `hcl:"evaluation_interval,optional"`
+	EvaluationIntervalHCL string `hcl:"evaluation_interval,optional"`
+	EvaluationTimeout     time.Duration
+	EvaluationTimeoutHCL  string `hcl:"evaluation_timeout,optional"`
+	ScaleTimeout          time.Duration
+	ScaleTimeoutHCL       string   `hcl:"scale_timeout,optional"`
 	Checks                []*Check `hcl:"check,block"`
 	Target                *Target  `hcl:"target,block"`
 }
@@ -179,6 +197,8 @@ func (fpd *FileDecodePolicy) Translate(p *Policy) {
 	p.Enabled = fpd.Enabled
 	p.Cooldown = fpd.Doc.Cooldown
 	p.EvaluationInterval = fpd.Doc.EvaluationInterval
+	p.EvaluationTimeout = fpd.Doc.EvaluationTimeout
+	p.ScaleTimeout = fpd.Doc.ScaleTimeout
 	p.Checks = fpd.Doc.Checks
 	p.Target = fpd.Doc.Target
 }
@@ -77,6 +77,22 @@ func TestPolicy_Validate(t *testing.T) {
 			},
 			name: "negative maximum value which is lower than minimum",
 		},
+		{
+			inputPolicy: &Policy{
+				ID:                "ce888afe-3dd2-144c-7227-74644434f708",
+				Min:               1,
+				Max:               10,
+				EvaluationTimeout: -1 * time.Minute,
+				ScaleTimeout:      -1 * time.Minute,
+			},
+			expectedOutput: &multierror.Error{
+				Errors: []error{
+					errors.New("policy EvaluationTimeout can't be negative"),
+					errors.New("policy ScaleTimeout can't be negative"),
+				},
+			},
+			name: "negative timeouts",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -272,6 +288,26 @@ func TestPolicy_ApplyDefaults(t *testing.T) {
 			},
 			name: "neither set to default",
 		},
+		{
+			inputPolicy: &Policy{
+				Cooldown:           10 * time.Minute,
+				EvaluationInterval: 5 * time.Minute,
+				ScaleTimeout:       45 * time.Minute,
+			},
+			inputDefaults: &ConfigDefaults{
+				DefaultEvaluationInterval: 5 * time.Second,
+				DefaultCooldown:           10 * time.Second,
+				DefaultEvaluationTimeout:  5 * time.Minute,
+				DefaultScaleTimeout:       1 * time.Hour,
+			},
+			expectedOutputPolicy: &Policy{
+				Cooldown:           10 * time.Minute,
+				EvaluationInterval: 5 * time.Minute,
+				EvaluationTimeout:  5 * time.Minute,
+				ScaleTimeout:       45 * time.Minute,
+			},
+			name: "evaluation timeout set to default",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -299,6 +335,10 @@ func TestFileDecodePolicy_Translate(t *testing.T) {
 					CooldownHCL:           "10ms",
 					EvaluationInterval:    10 * time.Nanosecond,
 					EvaluationIntervalHCL: "10ns",
+					EvaluationTimeout:     2 * time.Minute,
+					EvaluationTimeoutHCL:  "2m",
+					ScaleTimeout:          30 * time.Minute,
+					ScaleTimeoutHCL:       "30m",
 					Checks: []*Check{
 						{
 							Name:   "approach-speed",
@@ -328,6 +368,8 @@ func TestFileDecodePolicy_Translate(t *testing.T) {
 				Enabled:            true,
 				Cooldown:           10 * time.Millisecond,
 				EvaluationInterval: 10 * time.Nanosecond,
+				EvaluationTimeout:  2 * time.Minute,
+				ScaleTimeout:       30 * time.Minute,
 				Checks: []*Check{
 					{
 						Name:   "approach-speed",
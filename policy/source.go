@@ -9,6 +9,8 @@ import (
 type ConfigDefaults struct {
 	DefaultEvaluationInterval time.Duration
 	DefaultCooldown           time.Duration
+	DefaultEvaluationTimeout  time.Duration
+	DefaultScaleTimeout       time.Duration
 }
 
 type MonitorIDsReq struct {
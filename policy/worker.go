@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
@@ -41,6 +42,16 @@ func (w *Worker) HandlePolicy(ctx context.Context, p *Policy) {
 
 	logger.Info("received policy for evaluation")
 
+	// Skip the evaluation if any of the plugins used by the policy failed its
+	// most recent health check, since the results could not be trusted. The
+	// skip is recorded so it is reported by the agent health endpoint.
+	if err := w.checkPluginsHealth(p); err != nil {
+		logger.Warn("skipping policy evaluation", "reason", "plugin unhealthy", "error", err)
+		w.policyManager.setSkipped(p, err.Error())
+		return
+	}
+	w.policyManager.clearSkipped(p.ID)
+
 	handlersCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -56,9 +67,10 @@ func (w *Worker) HandlePolicy(ctx context.Context, p *Policy) {
 	var winningAction *strategy.Action
 	var winningHandler *checkHandler
 
-	// Initial results should return fairly quickly.
-	// Timeout if it is taking too long.
-	resultsTimeout := time.NewTimer(5 * time.Minute)
+	// Initial results should return fairly quickly. Timeout if it is taking
+	// longer than the policy evaluation timeout. The check handlers use the
+	// same timeout, so this only fires if a plugin fails to honour it.
+	resultsTimeout := time.NewTimer(evaluationTimeout(p))
 
 	// Wait for check results and pick the winner.
 	for check, handler := range checks {
@@ -131,6 +143,26 @@ func (w *Worker) HandlePolicy(ctx context.Context, p *Policy) {
 	logger.Info("policy evaluation complete")
 }
 
+// checkPluginsHealth returns an error if any of the plugins used by the policy
+// is unhealthy.
+func (w *Worker) checkPluginsHealth(p *Policy) error {
+	if err := w.pluginManager.CheckHealth(p.Target.Name, plugins.PluginTypeTarget); err != nil {
+		return err
+	}
+	for _, c := range p.Checks {
+		if err := w.pluginManager.CheckHealth(c.Source, plugins.PluginTypeAPM); err != nil {
+			return err
+		}
+		if c.Strategy == nil {
+			continue
+		}
+		if err := w.pluginManager.CheckHealth(c.Strategy.Name, plugins.PluginTypeStrategy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // checkHandler evaluates one of the checks of a policy.
 type checkHandler struct {
 	logger        hclog.Logger
@@ -193,6 +225,13 @@ func (h *checkHandler) start(ctx context.Context) {
 
 	result := checkHandlerResult{}
 
+	// The evaluation context bounds the time spent gathering the information
+	// required to calculate the scaling action. The scaling action itself is
+	// bounded separately using the scale timeout as it may take significantly
+	// longer.
+	evalCtx, evalCancel := withTimeout(ctx, h.policy.EvaluationTimeout)
+	defer evalCancel()
+
 	var targetInst target.Target
 	var apmInst apm.APM
 	var strategyInst strategy.Strategy
@@ -204,6 +243,7 @@ func (h *checkHandler) start(ctx context.Context) {
 		h.resultCh <- result
 		return
 	}
+	defer h.pluginManager.Release(targetPlugin)
 	targetInst = targetPlugin.Plugin().(target.Target)
 
 	apmPlugin, err := h.pluginManager.Dispense(h.check.Source, plugins.PluginTypeAPM)
@@ -212,6 +252,7 @@ func (h *checkHandler) start(ctx context.Context) {
 		h.resultCh <- result
 		return
 	}
+	defer h.pluginManager.Release(apmPlugin)
 	apmInst = apmPlugin.Plugin().(apm.APM)
 
 	strategyPlugin, err := h.pluginManager.Dispense(h.check.Strategy.Name, plugins.PluginTypeStrategy)
@@ -220,11 +261,12 @@ func (h *checkHandler) start(ctx context.Context) {
 		h.resultCh <- result
 		return
 	}
+	defer h.pluginManager.Release(strategyPlugin)
 	strategyInst = strategyPlugin.Plugin().(strategy.Strategy)
 
 	// Fetch target status.
 	logger.Info("fetching current count")
-	currentStatus, err := targetInst.Status(h.policy.Target.Config)
+	currentStatus, err := target.StatusWithContext(evalCtx, targetInst, h.policy.Target.Config)
 	if err != nil {
 		result.err = fmt.Errorf("failed to fetch current count: %v", err)
 		h.resultCh <- result
@@ -238,7 +280,7 @@ func (h *checkHandler) start(ctx context.Context) {
 
 	// Query check's APM
 	logger.Info("querying source", "query", h.check.Query)
-	value, err := apmInst.Query(h.check.Query)
+	value, err := apm.QueryWithContext(evalCtx, apmInst, h.check.Query)
 	if err != nil {
 		result.err = fmt.Errorf("failed to query source: %v", err)
 		h.resultCh <- result
@@ -253,29 +295,33 @@ func (h *checkHandler) start(ctx context.Context) {
 		Metric:   value,
 		Config:   h.check.Strategy.Config,
 	}
-	action, err := strategyInst.Run(req)
+	action, err := strategy.RunWithContext(evalCtx, strategyInst, req)
 	if err != nil {
 		result.err = fmt.Errorf("failed to execute strategy: %v", err)
 		h.resultCh <- result
 		return
 	}
 
+	// The target may place its own limits on the count, which further
+	// restrict those of the policy.
+	minCount, maxCount := countLimits(h.policy, currentStatus)
+
 	if action.Direction == strategy.ScaleDirectionNone {
 		// Make sure we are currently within [min, max] limits even if there's
 		// no action to execute
 		var minMaxAction *strategy.Action
 
-		if currentStatus.Count < h.policy.Min {
+		if currentStatus.Count < minCount {
 			minMaxAction = &strategy.Action{
-				Count:     h.policy.Min,
+				Count:     minCount,
 				Direction: strategy.ScaleDirectionUp,
-				Reason:    fmt.Sprintf("current count (%d) below limit (%d)", currentStatus.Count, h.policy.Min),
+				Reason:    fmt.Sprintf("current count (%d) below limit (%d)", currentStatus.Count, minCount),
 			}
-		} else if currentStatus.Count > h.policy.Max {
+		} else if currentStatus.Count > maxCount {
 			minMaxAction = &strategy.Action{
-				Count:     h.policy.Max,
+				Count:     maxCount,
 				Direction: strategy.ScaleDirectionDown,
-				Reason:    fmt.Sprintf("current count (%d) above limit (%d)", currentStatus.Count, h.policy.Max),
+				Reason:    fmt.Sprintf("current count (%d) above limit (%d)", currentStatus.Count, maxCount),
 			}
 		}
 
@@ -294,7 +340,7 @@ func (h *checkHandler) start(ctx context.Context) {
 	action.Canonicalize()
 
 	// Make sure new count value is within [min, max] limits
-	action.CapCount(h.policy.Min, h.policy.Max)
+	action.CapCount(minCount, maxCount)
 
 	// Skip action if count doesn't change.
 	if currentStatus.Count == action.Count {
@@ -338,7 +384,20 @@ func (h *checkHandler) start(ctx context.Context) {
 
 	// Scale the target. If we receive an error add this onto the result so the
 	// handler understand what do to.
-	if err = targetInst.Scale(action, h.policy.Target.Config); err != nil {
+	scaleCtx, scaleCancel := withTimeout(ctx, h.policy.ScaleTimeout)
+	defer scaleCancel()
+
+	err = target.ScaleWithContext(scaleCtx, targetInst, action, h.policy.Target.Config)
+
+	// A partially completed action which still changed the target is treated
+	// as successful using the count the target reached. This ensures the
+	// cooldown is enforced. If the count did not change, nothing was scaled
+	// and the action is handled as a failure.
+	if perr, ok := err.(*target.PartialScaleError); ok && perr.Count != currentStatus.Count {
+		logger.Warn("scaling action partially completed",
+			"from", currentStatus.Count, "to", perr.Count, "desired_count", action.Count, "error", perr.Err)
+		action.Count = perr.Count
+	} else if err != nil {
 		result.err = fmt.Errorf("failed to scale target: %v", err)
 		logger.Error("failed to submit scaling action to target", "error", err)
 	} else {
@@ -350,3 +409,44 @@ func (h *checkHandler) start(ctx context.Context) {
 	// leak waiting endlessly for the result it will never receive, poor thing.
 	h.resultCh <- result
 }
+
+// defaultEvaluationTimeout is the evaluation timeout used for policies which do
+// not have one set, such as those created outside of a policy source.
+const defaultEvaluationTimeout = 5 * time.Minute
+
+// evaluationTimeout returns the evaluation timeout of the policy, falling back
+// to the default if it is not set.
+func evaluationTimeout(p *Policy) time.Duration {
+	if p.EvaluationTimeout > 0 {
+		return p.EvaluationTimeout
+	}
+	return defaultEvaluationTimeout
+}
+
+// countLimits returns the min and max count of the policy, restricted by any
+// limits reported by the target in its status meta. Limits which cannot be
+// parsed or which do not overlap with those of the policy are ignored.
+func countLimits(p *Policy, status *target.Status) (int64, int64) {
+	minCount, maxCount := p.Min, p.Max
+
+	if v, err := strconv.ParseInt(status.Meta[target.MetaKeyMinCount], 10, 64); err == nil && v > minCount {
+		minCount = v
+	}
+	if v, err := strconv.ParseInt(status.Meta[target.MetaKeyMaxCount], 10, 64); err == nil && v < maxCount {
+		maxCount = v
+	}
+
+	if minCount > maxCount {
+		return p.Min, p.Max
+	}
+	return minCount, maxCount
+}
+
+// withTimeout returns a context derived from ctx which is cancelled after the
+// timeout. A timeout of zero means no timeout is applied.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
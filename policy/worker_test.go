@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad-autoscaler/plugins/target"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_countLimits(t *testing.T) {
+	testCases := []struct {
+		inputPolicy *Policy
+		inputStatus *target.Status
+		expectedMin int64
+		expectedMax int64
+		name        string
+	}{
+		{
+			inputPolicy: &Policy{Min: 1, Max: 10},
+			inputStatus: &target.Status{},
+			expectedMin: 1,
+			expectedMax: 10,
+			name:        "no target limits",
+		},
+		{
+			inputPolicy: &Policy{Min: 1, Max: 10},
+			inputStatus: &target.Status{Meta: map[string]string{
+				target.MetaKeyMinCount: "2",
+				target.MetaKeyMaxCount: "8",
+			}},
+			expectedMin: 2,
+			expectedMax: 8,
+			name:        "target limits within policy limits",
+		},
+		{
+			inputPolicy: &Policy{Min: 1, Max: 10},
+			inputStatus: &target.Status{Meta: map[string]string{
+				target.MetaKeyMinCount: "0",
+				target.MetaKeyMaxCount: "20",
+			}},
+			expectedMin: 1,
+			expectedMax: 10,
+			name:        "target limits outside policy limits",
+		},
+		{
+			inputPolicy: &Policy{Min: 1, Max: 10},
+			inputStatus: &target.Status{Meta: map[string]string{
+				target.MetaKeyMinCount: "12",
+				target.MetaKeyMaxCount: "20",
+			}},
+			expectedMin: 1,
+			expectedMax: 10,
+			name:        "target limits do not overlap policy limits",
+		},
+		{
+			inputPolicy: &Policy{Min: 1, Max: 10},
+			inputStatus: &target.Status{Meta: map[string]string{
+				target.MetaKeyMaxCount: "not-a-number",
+			}},
+			expectedMin: 1,
+			expectedMax: 10,
+			name:        "invalid target limit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actualMin, actualMax := countLimits(tc.inputPolicy, tc.inputStatus)
+			assert.Equal(t, tc.expectedMin, actualMin, tc.name)
+			assert.Equal(t, tc.expectedMax, actualMax, tc.name)
+		})
+	}
+}